@@ -34,7 +34,32 @@ func NewApp(version string, start time.Time) (app *cli.Command) {
 				Name:    "internal-monitoring-listener-address",
 				Aliases: []string{"m"},
 				Sources: cli.EnvVars("GCPE_INTERNAL_MONITORING_LISTENER_ADDRESS"),
-				Usage:   "internal monitoring listener address",
+				Usage:   "internal monitoring listener address (format: tcp://host:port or unix:///path[?tls=true&tls_cert_file=..&tls_key_file=..&tls_ca_file=..])",
+			},
+			&cli.StringFlag{
+				Name:    "internal-monitoring-listener-tls-cert-file",
+				Sources: cli.EnvVars("GCPE_INTERNAL_MONITORING_LISTENER_TLS_CERT_FILE"),
+				Usage:   "PEM encoded certificate `file` used to serve (or, when monitoring, to authenticate onto) the internal monitoring listener",
+			},
+			&cli.StringFlag{
+				Name:    "internal-monitoring-listener-tls-key-file",
+				Sources: cli.EnvVars("GCPE_INTERNAL_MONITORING_LISTENER_TLS_KEY_FILE"),
+				Usage:   "PEM encoded private key `file` of the internal monitoring listener certificate",
+			},
+			&cli.StringFlag{
+				Name:    "internal-monitoring-listener-tls-ca-file",
+				Sources: cli.EnvVars("GCPE_INTERNAL_MONITORING_LISTENER_TLS_CA_FILE"),
+				Usage:   "PEM encoded CA `file` used to verify client certificates (mTLS) or, when monitoring, the server certificate",
+			},
+			&cli.BoolFlag{
+				Name:    "internal-monitoring-listener-tls-insecure-skip-verify",
+				Sources: cli.EnvVars("GCPE_INTERNAL_MONITORING_LISTENER_TLS_INSECURE_SKIP_VERIFY"),
+				Usage:   "skip the verification of the internal monitoring listener certificate when monitoring",
+			},
+			&cli.StringFlag{
+				Name:    "internal-monitoring-listener-token",
+				Sources: cli.EnvVars("GCPE_INTERNAL_MONITORING_LISTENER_TOKEN"),
+				Usage:   "shared secret `token` used to authenticate requests made onto the internal monitoring listener",
 			},
 		},
 		Commands: []*cli.Command{
@@ -91,8 +116,98 @@ func NewApp(version string, start time.Time) (app *cli.Command) {
 				Usage:  "display information about the currently running exporter",
 				Action: cmd.ExecWrapper(cmd.Monitor),
 			},
+			{
+				Name:   "gc",
+				Usage:  "report what the garbage collections would delete out of the redis store",
+				Action: cmd.ExecWrapper(cmd.GarbageCollect),
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "config",
+						Aliases: []string{"c"},
+						Sources: cli.EnvVars("GCPE_CONFIG"),
+						Usage:   "config `file`",
+						Value:   "./gitlab-ci-pipelines-exporter.yml",
+					},
+					&cli.StringFlag{
+						Name:    "redis-url",
+						Sources: cli.EnvVars("GCPE_REDIS_URL"),
+						Usage:   "redis `url` (format: redis[s]://[:password@]host[:port][/db-number][?option=value]) (overrides config file parameter)",
+					},
+					&cli.StringFlag{
+						Name:    "gitlab-token",
+						Sources: cli.EnvVars("GCPE_GITLAB_TOKEN"),
+						Usage:   "GitLab API access `token` (overrides config file parameter)",
+					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "`format` of the report (text or json)",
+						Value:   "text",
+					},
+					&cli.BoolFlag{
+						Name:    "list",
+						Aliases: []string{"l"},
+						Usage:   "list the entities which would be deleted, not only their count",
+					},
+				},
+			},
+			{
+				Name:  "store",
+				Usage: "export or import the content of the redis store",
+				Commands: []*cli.Command{
+					{
+						Name:   "export",
+						Usage:  "dump the content of the store",
+						Action: cmd.ExecWrapper(cmd.StoreExport),
+						Flags: append(storeFlags(),
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "`file` to write the dump onto",
+								Value:   "-",
+							},
+						),
+					},
+					{
+						Name:   "import",
+						Usage:  "restore a dump onto the store, overwriting the existing entries",
+						Action: cmd.ExecWrapper(cmd.StoreImport),
+						Flags: append(storeFlags(),
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "`file` to read the dump from",
+								Value:   "-",
+							},
+						),
+					},
+				},
+			},
 		},
 	}
 
 	return
 }
+
+func storeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config",
+			Aliases: []string{"c"},
+			Sources: cli.EnvVars("GCPE_CONFIG"),
+			Usage:   "config `file`",
+			Value:   "./gitlab-ci-pipelines-exporter.yml",
+		},
+		&cli.StringFlag{
+			Name:    "redis-url",
+			Sources: cli.EnvVars("GCPE_REDIS_URL"),
+			Usage:   "redis `url` (format: redis[s]://[:password@]host[:port][/db-number][?option=value]) (overrides config file parameter)",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "`format` of the dump (json or msgpack)",
+			Value:   "json",
+		},
+	}
+}
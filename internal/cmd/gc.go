@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/controller"
+)
+
+// gcEntities lists the kinds of entities in the order in which they get garbage collected.
+var gcEntities = []string{"projects", "environments", "refs", "metrics"}
+
+// GarbageCollect reports what the garbage collections would delete out of the store.
+func GarbageCollect(ctx context.Context, cliCmd *cli.Command) (int, error) {
+	cfg, err := configure(cliCmd)
+	if err != nil {
+		return 1, err
+	}
+
+	// Not to mix the logs with the report
+	log.SetOutput(os.Stderr)
+
+	report, err := controller.GarbageCollectDryRun(ctx, cfg, appVersion)
+	if err != nil {
+		return 1, err
+	}
+
+	switch format := cliCmd.String("format"); format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "text":
+		writeGarbageCollectionReport(os.Stdout, report, cliCmd.Bool("list"))
+	default:
+		err = fmt.Errorf("invalid report format '%s', must be either 'text' or 'json'", format)
+	}
+
+	if err != nil {
+		return 1, err
+	}
+
+	for _, r := range report {
+		if r.ThresholdExceeded {
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func writeGarbageCollectionReport(w io.Writer, report controller.GarbageCollectionReport, list bool) {
+	for _, entity := range gcEntities {
+		r, ok := report[entity]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s: %d out of %d would be deleted", entity, r.DeletionsCount(), r.Total)
+
+		if r.ThresholdExceeded {
+			fmt.Fprint(w, ", exceeding the safety threshold")
+		}
+
+		fmt.Fprintln(w)
+
+		reasons := make([]string, 0, len(r.Deletions))
+		for reason := range r.Deletions {
+			reasons = append(reasons, reason)
+		}
+
+		slices.Sort(reasons)
+
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "  %s: %d\n", reason, len(r.Deletions[reason]))
+
+			if list {
+				for _, name := range r.Deletions[reason] {
+					fmt.Fprintf(w, "    %s\n", name)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/controller"
+)
+
+func TestWriteGarbageCollectionReport(t *testing.T) {
+	report := controller.GarbageCollectionReport{
+		"projects": {Total: 2, Deletions: map[string][]string{}},
+		"refs": {
+			Total: 4,
+			Deletions: map[string][]string{
+				"not-expected": {"foo/bar:branch:dev", "foo/bar:branch:feature"},
+				"expired":      {"foo/bar:tag:v1"},
+			},
+			ThresholdExceeded: true,
+		},
+	}
+
+	b := &bytes.Buffer{}
+	writeGarbageCollectionReport(b, report, false)
+	assert.Equal(t, `projects: 0 out of 2 would be deleted
+refs: 3 out of 4 would be deleted, exceeding the safety threshold
+  expired: 1
+  not-expected: 2
+`, b.String())
+
+	b.Reset()
+	writeGarbageCollectionReport(b, report, true)
+	assert.Equal(t, `projects: 0 out of 2 would be deleted
+refs: 3 out of 4 would be deleted, exceeding the safety threshold
+  expired: 1
+    foo/bar:tag:v1
+  not-expected: 2
+    foo/bar:branch:dev
+    foo/bar:branch:feature
+`, b.String())
+}
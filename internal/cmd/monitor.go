@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/urfave/cli/v3"
 
@@ -15,9 +16,13 @@ func Monitor(_ context.Context, cmd *cli.Command) (int, error) {
 		return 1, err
 	}
 
+	if cfg.InternalMonitoringListenerAddress == nil {
+		return 1, fmt.Errorf("'--internal-monitoring-listener-address' must be set")
+	}
+
 	monitorUI.Start(
 		appVersion,
-		cfg.InternalMonitoringListenerAddress,
+		cfg,
 	)
 
 	return 0, nil
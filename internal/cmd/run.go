@@ -14,6 +14,7 @@ import (
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/controller"
 	monitoringServer "github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor/server"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/server"
 )
 
 // Run launches the exporter.
@@ -23,6 +24,8 @@ func Run(ctx context.Context, cliCmd *cli.Command) (int, error) {
 		return 1, err
 	}
 
+	logConfig(cfg)
+
 	ctx, ctxCancel := context.WithCancel(ctx)
 	defer ctxCancel()
 
@@ -38,6 +41,8 @@ func Run(ctx context.Context, cliCmd *cli.Command) (int, error) {
 			c.Config,
 			c.Store,
 			c.TaskController.TaskSchedulingMonitoring,
+			c.TaskController.TaskExecutionMonitoring,
+			c.LeaderElection,
 		)
 		s.Serve()
 	}(&c)
@@ -55,31 +60,87 @@ func Run(ctx context.Context, cliCmd *cli.Command) (int, error) {
 	}
 
 	// health endpoints
+	healthAllowList, err := server.AllowCIDRs(cfg.Server.Health.AllowedCIDRs)
+	if err != nil {
+		return 1, err
+	}
+
 	health := c.HealthCheckHandler(ctx)
-	mux.HandleFunc("/health/live", health.LiveEndpoint)
-	mux.HandleFunc("/health/ready", health.ReadyEndpoint)
+	mux.Handle("/health/live", server.Chain(http.HandlerFunc(health.LiveEndpoint), healthAllowList))
+	mux.Handle("/health/ready", server.Chain(http.HandlerFunc(health.ReadyEndpoint), healthAllowList))
+
+	// When mTLS is enabled, client certificates are only required onto the
+	// metrics and pprof endpoints, GitLab and probes not being able to provide one
+	clientCertificate := server.RequireClientCertificate(cfg.Server.TLS.Enabled && cfg.Server.TLS.ClientCAFile != "")
 
 	// metrics endpoint
 	if cfg.Server.Metrics.Enabled {
-		mux.HandleFunc("/metrics", c.MetricsHandler)
+		metricsAllowList, err := server.AllowCIDRs(cfg.Server.Metrics.AllowedCIDRs)
+		if err != nil {
+			return 1, err
+		}
+
+		mux.Handle("/metrics", server.Chain(
+			http.HandlerFunc(c.MetricsHandler),
+			metricsAllowList,
+			clientCertificate,
+			server.Authenticate(cfg.Server.Metrics.Auth),
+		))
 	}
 
 	// pprof/debug endpoints
 	if cfg.Server.EnablePprof {
-		mux.HandleFunc("/debug/pprof/", pprof.Index)
-		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
-		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
-		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
-		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		pprofAllowList, err := server.AllowCIDRs(cfg.Server.Pprof.AllowedCIDRs)
+		if err != nil {
+			return 1, err
+		}
+
+		for path, handler := range map[string]http.HandlerFunc{
+			"/debug/pprof/":        pprof.Index,
+			"/debug/pprof/cmdline": pprof.Cmdline,
+			"/debug/pprof/profile": pprof.Profile,
+			"/debug/pprof/symbol":  pprof.Symbol,
+			"/debug/pprof/trace":   pprof.Trace,
+		} {
+			mux.Handle(path, server.Chain(
+				handler,
+				pprofAllowList,
+				clientCertificate,
+				server.Authenticate(cfg.Server.Pprof.Auth),
+			))
+		}
 	}
 
 	// webhook endpoints
 	if cfg.Server.Webhook.Enabled {
-		mux.HandleFunc("/webhook", c.WebhookHandler)
+		webhookAllowList, err := server.AllowCIDRs(cfg.Server.Webhook.AllowedCIDRs)
+		if err != nil {
+			return 1, err
+		}
+
+		mux.Handle("/webhook", server.Chain(http.HandlerFunc(c.WebhookHandler), webhookAllowList))
+		mux.Handle("/webhook/replay", server.Chain(http.HandlerFunc(c.WebhookReplayHandler), webhookAllowList))
+	}
+
+	if cfg.Server.TLS.Enabled {
+		certificateReloader, err := server.NewCertificateReloader(cfg.Server.TLS)
+		if err != nil {
+			return 1, err
+		}
+
+		srv.TLSConfig = certificateReloader.TLSConfig()
 	}
 
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if cfg.Server.TLS.Enabled {
+			// Certificates are provided through the TLSConfig
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+
+		if err != nil && err != http.ErrServerClosed {
 			log.WithContext(ctx).
 				WithError(err).
 				Fatal()
@@ -89,6 +150,8 @@ func Run(ctx context.Context, cliCmd *cli.Command) (int, error) {
 	log.WithFields(
 		log.Fields{
 			"listen-address":               cfg.Server.ListenAddress,
+			"tls-enabled":                  cfg.Server.TLS.Enabled,
+			"mtls-enabled":                 cfg.Server.TLS.Enabled && cfg.Server.TLS.ClientCAFile != "",
 			"pprof-endpoint-enabled":       cfg.Server.EnablePprof,
 			"metrics-endpoint-enabled":     cfg.Server.Metrics.Enabled,
 			"webhook-endpoint-enabled":     cfg.Server.Webhook.Enabled,
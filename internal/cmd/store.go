@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/controller"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
+)
+
+// errStoreRedisNotConfigured is returned when attempting to export or import the store without Redis,
+// the local store only living in the memory of the running exporter.
+var errStoreRedisNotConfigured = errors.New("redis is not configured: only the redis store can be exported or imported, " +
+	"the local one lives in the memory of the running exporter and gets rebuilt out of GitLab when it starts")
+
+// StoreExport dumps the content of the store.
+func StoreExport(ctx context.Context, cliCmd *cli.Command) (int, error) {
+	cfg, err := configure(cliCmd)
+	if err != nil {
+		return 1, err
+	}
+
+	if !cfg.Redis.IsEnabled() {
+		return 1, errStoreRedisNotConfigured
+	}
+
+	// Not to mix the logs with the dump when it gets written onto stdout
+	log.SetOutput(os.Stderr)
+
+	s, err := controller.NewRedisStore(ctx, cfg.Redis)
+	if err != nil {
+		return 1, err
+	}
+
+	snap, err := store.Export(ctx, s)
+	if err != nil {
+		return 1, err
+	}
+
+	var w io.Writer = os.Stdout
+
+	if output := cliCmd.String("output"); output != "" && output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return 1, err
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	if err = snap.Encode(w, store.SnapshotFormat(cliCmd.String("format"))); err != nil {
+		return 1, err
+	}
+
+	log.WithFields(snapshotLogFields(snap)).Info("exported the store")
+
+	return 0, nil
+}
+
+// StoreImport restores a dump onto the store.
+func StoreImport(ctx context.Context, cliCmd *cli.Command) (int, error) {
+	cfg, err := configure(cliCmd)
+	if err != nil {
+		return 1, err
+	}
+
+	if !cfg.Redis.IsEnabled() {
+		return 1, errStoreRedisNotConfigured
+	}
+
+	var r io.Reader = os.Stdin
+
+	if input := cliCmd.String("input"); input != "" && input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return 1, err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	snap, err := store.DecodeSnapshot(r, store.SnapshotFormat(cliCmd.String("format")))
+	if err != nil {
+		return 1, err
+	}
+
+	s, err := controller.NewRedisStore(ctx, cfg.Redis)
+	if err != nil {
+		return 1, err
+	}
+
+	if err = store.Import(ctx, s, snap); err != nil {
+		return 1, err
+	}
+
+	log.WithFields(snapshotLogFields(snap)).Info("imported onto the store")
+
+	return 0, nil
+}
+
+func snapshotLogFields(snap store.Snapshot) log.Fields {
+	return log.Fields{
+		"projects-count":           len(snap.Projects),
+		"environments-count":       len(snap.Environments),
+		"refs-count":               len(snap.Refs),
+		"metrics-count":            len(snap.Metrics),
+		"pipelines-count":          len(snap.Pipelines),
+		"pipeline-variables-count": len(snap.PipelineVariables),
+	}
+}
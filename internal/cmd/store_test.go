@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
+)
+
+func TestStoreExportImport(t *testing.T) {
+	src, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer src.Close()
+
+	dst, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer dst.Close()
+
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "config.yml")
+	dumpFile := filepath.Join(dir, "dump.msgpack")
+	assert.NoError(t, os.WriteFile(cfgFile, []byte("gitlab:\n  token: foo\nprojects:\n  - name: foo/bar\n"), 0o600))
+
+	p := schemas.NewProject("foo/bar")
+	assert.NoError(t, store.NewRedisStore(redis.NewClient(&redis.Options{Addr: src.Addr()})).SetProject(context.Background(), p))
+
+	run := func(action func(context.Context, *cli.Command) (int, error), args ...string) (code int, err error) {
+		c := &cli.Command{
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "config"},
+				&cli.StringFlag{Name: "redis-url"},
+				&cli.StringFlag{Name: "format"},
+				&cli.StringFlag{Name: "output"},
+				&cli.StringFlag{Name: "input"},
+			},
+			Action: func(ctx context.Context, cmd *cli.Command) error {
+				code, err = action(ctx, cmd)
+
+				return nil
+			},
+		}
+
+		assert.NoError(t, c.Run(context.Background(), append([]string{"gcpe", "--config", cfgFile}, args...)))
+
+		return
+	}
+
+	code, err := run(StoreExport, "--redis-url", "redis://"+src.Addr(), "--format", "msgpack", "--output", dumpFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+
+	code, err = run(StoreImport, "--redis-url", "redis://"+dst.Addr(), "--format", "msgpack", "--input", dumpFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+
+	projects, err := store.NewRedisStore(redis.NewClient(&redis.Options{Addr: dst.Addr()})).Projects(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Projects{p.Key(): p}, projects)
+
+	// Redis is required
+	code, err = run(StoreExport, "--format", "json", "--output", dumpFile)
+	assert.ErrorIs(t, err, errStoreRedisNotConfigured)
+	assert.Equal(t, 1, code)
+
+	code, err = run(StoreImport, "--format", "json", "--input", dumpFile)
+	assert.ErrorIs(t, err, errStoreRedisNotConfigured)
+	assert.Equal(t, 1, code)
+}
@@ -6,6 +6,7 @@ import (
 	stdlibLog "log"
 	"net/url"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/stdr"
@@ -66,6 +67,11 @@ func configure(cmd *cli.Command) (cfg config.Config, err error) {
 	// This hack is to embed taskq logs with logrus
 	taskq.SetLogger(stdr.New(stdlibLog.New(log.StandardLogger().WriterLevel(log.WarnLevel), "taskq", 0)))
 
+	return
+}
+
+// logConfig outputs a summary of the configuration.
+func logConfig(cfg config.Config) {
 	log.WithFields(
 		log.Fields{
 			"gitlab-endpoint":   cfg.Gitlab.URL,
@@ -76,19 +82,53 @@ func configure(cmd *cli.Command) (cfg config.Config, err error) {
 	log.WithFields(config.SchedulerConfig(cfg.Pull.ProjectsFromWildcards).Log()).Info("pull projects from wildcards")
 	log.WithFields(config.SchedulerConfig(cfg.Pull.EnvironmentsFromProjects).Log()).Info("pull environments from projects")
 	log.WithFields(config.SchedulerConfig(cfg.Pull.RefsFromProjects).Log()).Info("pull refs from projects")
-	log.WithFields(config.SchedulerConfig(cfg.Pull.Metrics).Log()).Info("pull metrics")
+	log.WithFields(cfg.Pull.Metrics.SchedulerConfig().Log()).Info("pull metrics")
 
 	log.WithFields(config.SchedulerConfig(cfg.GarbageCollect.Projects).Log()).Info("garbage collect projects")
 	log.WithFields(config.SchedulerConfig(cfg.GarbageCollect.Environments).Log()).Info("garbage collect environments")
 	log.WithFields(config.SchedulerConfig(cfg.GarbageCollect.Refs).Log()).Info("garbage collect refs")
 	log.WithFields(config.SchedulerConfig(cfg.GarbageCollect.Metrics).Log()).Info("garbage collect metrics")
-
-	return
 }
 
 func parseGlobalFlags(cmd *cli.Command) (cfg config.Global, err error) {
 	if listenerAddr := cmd.String("internal-monitoring-listener-address"); listenerAddr != "" {
 		cfg.InternalMonitoringListenerAddress, err = url.Parse(listenerAddr)
+		if err != nil {
+			return
+		}
+
+		// TLS settings can be provided as query parameters of the listener address
+		q := cfg.InternalMonitoringListenerAddress.Query()
+		cfg.InternalMonitoringListenerTLS.CertFile = q.Get("tls_cert_file")
+		cfg.InternalMonitoringListenerTLS.KeyFile = q.Get("tls_key_file")
+		cfg.InternalMonitoringListenerTLS.CAFile = q.Get("tls_ca_file")
+
+		for k, v := range map[string]*bool{
+			"tls":                      &cfg.InternalMonitoringListenerTLS.Enabled,
+			"tls_insecure_skip_verify": &cfg.InternalMonitoringListenerTLS.InsecureSkipVerify,
+		} {
+			if q.Has(k) {
+				if *v, err = strconv.ParseBool(q.Get(k)); err != nil {
+					return cfg, fmt.Errorf("invalid value for the '%s' parameter of the internal monitoring listener address: %w", k, err)
+				}
+			}
+		}
+	}
+
+	// Flags take precedence over the query parameters
+	for flag, v := range map[string]*string{
+		"internal-monitoring-listener-tls-cert-file": &cfg.InternalMonitoringListenerTLS.CertFile,
+		"internal-monitoring-listener-tls-key-file":  &cfg.InternalMonitoringListenerTLS.KeyFile,
+		"internal-monitoring-listener-tls-ca-file":   &cfg.InternalMonitoringListenerTLS.CAFile,
+		"internal-monitoring-listener-token":         &cfg.InternalMonitoringListenerToken,
+	} {
+		if cmd.String(flag) != "" {
+			*v = cmd.String(flag)
+		}
+	}
+
+	if cmd.Bool("internal-monitoring-listener-tls-insecure-skip-verify") {
+		cfg.InternalMonitoringListenerTLS.InsecureSkipVerify = true
 	}
 
 	return
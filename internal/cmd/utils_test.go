@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli/v3"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
 )
 
 func TestExit(t *testing.T) {
@@ -25,3 +27,48 @@ func TestExecWrapper(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, 0, exitErr.ExitCode())
 }
+
+func TestParseGlobalFlags(t *testing.T) {
+	var (
+		cfg config.Global
+		err error
+	)
+
+	app := &cli.Command{
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "internal-monitoring-listener-address"},
+			&cli.StringFlag{Name: "internal-monitoring-listener-tls-cert-file"},
+			&cli.StringFlag{Name: "internal-monitoring-listener-tls-key-file"},
+			&cli.StringFlag{Name: "internal-monitoring-listener-tls-ca-file"},
+			&cli.BoolFlag{Name: "internal-monitoring-listener-tls-insecure-skip-verify"},
+			&cli.StringFlag{Name: "internal-monitoring-listener-token"},
+		},
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			cfg, err = parseGlobalFlags(cmd)
+
+			return nil
+		},
+	}
+
+	assert.NoError(t, app.Run(context.Background(), []string{
+		"gcpe",
+		"--internal-monitoring-listener-address", "tcp://127.0.0.1:8082?tls_cert_file=/foo.crt&tls_key_file=/foo.key&tls_ca_file=/ca.crt",
+		"--internal-monitoring-listener-tls-ca-file", "/bar.crt",
+		"--internal-monitoring-listener-token", "secret",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8082", cfg.InternalMonitoringListenerAddress.Host)
+	assert.Equal(t, config.InternalMonitoringListenerTLS{
+		CertFile: "/foo.crt",
+		KeyFile:  "/foo.key",
+		CAFile:   "/bar.crt",
+	}, cfg.InternalMonitoringListenerTLS)
+	assert.True(t, cfg.InternalMonitoringListenerTLS.IsEnabled())
+	assert.Equal(t, "secret", cfg.InternalMonitoringListenerToken)
+
+	assert.NoError(t, app.Run(context.Background(), []string{
+		"gcpe",
+		"--internal-monitoring-listener-address", "tcp://127.0.0.1:8082?tls=foo",
+	}))
+	assert.Error(t, err)
+}
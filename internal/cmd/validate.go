@@ -10,12 +10,15 @@ import (
 func Validate(_ context.Context, cliCmd *cli.Command) (int, error) {
 	log.Debug("Validating configuration..")
 
-	if _, err := configure(cliCmd); err != nil {
+	cfg, err := configure(cliCmd)
+	if err != nil {
 		log.WithError(err).Error("Failed to configure")
 
 		return 1, err
 	}
 
+	logConfig(cfg)
+
 	log.Debug("Configuration is valid")
 
 	return 0, nil
@@ -8,6 +8,8 @@ import (
 	"github.com/go-playground/validator/v10"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/cron"
 )
 
 var validate *validator.Validate
@@ -38,6 +40,9 @@ type Config struct {
 	// GarbageCollect configuration
 	GarbageCollect GarbageCollect `yaml:"garbage_collect"`
 
+	// Scheduler configuration
+	Scheduler Scheduler `yaml:"scheduler"`
+
 	// Default parameters which can be overridden at either the Project or Wildcard level
 	ProjectDefaults ProjectParameters `yaml:"project_defaults"`
 
@@ -71,10 +76,80 @@ type Server struct {
 	// [address:port] to make the process listen upon
 	ListenAddress string `default:":8080" yaml:"listen_address"`
 
+	TLS     ServerTLS     `yaml:"tls"`
+	Health  ServerHealth  `yaml:"health"`
+	Pprof   ServerPprof   `yaml:"pprof"`
 	Metrics ServerMetrics `yaml:"metrics"`
 	Webhook ServerWebhook `yaml:"webhook"`
 }
 
+// ServerTLS ..
+type ServerTLS struct {
+	// Serve the endpoints over HTTPS
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Path to the PEM encoded certificate
+	CertFile string `validate:"required_if=Enabled true" yaml:"cert_file"`
+
+	// Path to the PEM encoded private key
+	KeyFile string `validate:"required_if=Enabled true" yaml:"key_file"`
+
+	// Path to a PEM encoded CA bundle, when set clients must present a certificate
+	// signed by it (mTLS) to access the metrics and pprof endpoints
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// Interval at which the files are checked for changes
+	// in order to reload rotated certificates
+	ReloadIntervalSeconds int `default:"60" validate:"gte=1" yaml:"reload_interval_seconds"`
+}
+
+// ServerAuth ..
+type ServerAuth struct {
+	// Basic authentication credentials
+	Basic ServerAuthBasic `yaml:"basic"`
+
+	// Token to provide using the "Authorization: Bearer <token>" header
+	BearerToken string `yaml:"bearer_token"`
+}
+
+// ServerAuthBasic ..
+type ServerAuthBasic struct {
+	Username string `validate:"required_with=Password" yaml:"username"`
+	Password string `validate:"required_with=Username" yaml:"password"`
+}
+
+// Enabled returns whether some authentication is configured or not.
+func (sa ServerAuth) Enabled() bool {
+	return sa.Basic.Username != "" || sa.BearerToken != ""
+}
+
+func (sa ServerAuth) masked() ServerAuth {
+	if sa.Basic.Password != "" {
+		sa.Basic.Password = "*******"
+	}
+
+	if sa.BearerToken != "" {
+		sa.BearerToken = "*******"
+	}
+
+	return sa
+}
+
+// ServerHealth ..
+type ServerHealth struct {
+	// List of CIDRs allowed to query the /health endpoints, all if empty
+	AllowedCIDRs []string `validate:"dive,cidr" yaml:"allowed_cidrs"`
+}
+
+// ServerPprof ..
+type ServerPprof struct {
+	// Authentication required to access the /debug/pprof endpoints
+	Auth ServerAuth `yaml:"auth"`
+
+	// List of CIDRs allowed to query the /debug/pprof endpoints, all if empty
+	AllowedCIDRs []string `validate:"dive,cidr" yaml:"allowed_cidrs"`
+}
+
 // ServerMetrics ..
 type ServerMetrics struct {
 	// Enable /metrics endpoint
@@ -82,6 +157,15 @@ type ServerMetrics struct {
 
 	// Enable OpenMetrics content encoding in prometheus HTTP handler
 	EnableOpenmetricsEncoding bool `default:"false" yaml:"enable_openmetrics_encoding"`
+
+	// Add the numeric ID of the project as a label of the metrics
+	OutputProjectIDLabel bool `default:"false" yaml:"output_project_id_label"`
+
+	// Authentication required to access the /metrics endpoint
+	Auth ServerAuth `yaml:"auth"`
+
+	// List of CIDRs allowed to query the /metrics endpoint, all if empty
+	AllowedCIDRs []string `validate:"dive,cidr" yaml:"allowed_cidrs"`
 }
 
 // ServerWebhook ..
@@ -91,6 +175,60 @@ type ServerWebhook struct {
 
 	// Secret token to authenticate legitimate webhook requests coming from the GitLab server
 	SecretToken string `validate:"required_if=Enabled true" yaml:"secret_token"`
+
+	// List of CIDRs allowed to query the /webhook endpoint, all if empty
+	AllowedCIDRs []string `validate:"dive,cidr" yaml:"allowed_cidrs"`
+
+	// Stop polling the projects from which webhook events are being received
+	SkipPolling ServerWebhookSkipPolling `yaml:"skip_polling"`
+
+	// Automatically register the webhooks onto the GitLab projects or groups
+	AutoRegister ServerWebhookAutoRegister `yaml:"auto_register"`
+
+	// Queue in which the received events are persisted before being processed
+	Queue ServerWebhookQueue `yaml:"queue"`
+}
+
+// ServerWebhookQueue ..
+type ServerWebhookQueue struct {
+	// Maximum amount of events waiting to be processed, further ones get dropped
+	BufferSize int `default:"1000" validate:"gte=1" yaml:"buffer_size"`
+
+	// Maximum amount of events being processed concurrently
+	MaxConcurrency int `default:"5" validate:"gte=1" yaml:"max_concurrency"`
+
+	// Maximum amount of failed events kept in order to be replayed
+	MaxFailedEvents int `default:"1000" validate:"gte=0" yaml:"max_failed_events"`
+}
+
+// ServerWebhookAutoRegister ..
+type ServerWebhookAutoRegister struct {
+	// Create or update the webhooks of the exported projects using the GitLab API
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Publicly reachable URL of the /webhook endpoint, GitLab will send the events onto it
+	URL string `validate:"required_if=Enabled true,omitempty,url" yaml:"url"`
+
+	// Whether to register the hooks at the project or the group level
+	Scope string `default:"project" validate:"oneof=project group" yaml:"scope"`
+
+	// Verify the TLS certificate of the URL when GitLab delivers the events
+	EnableSSLVerification bool `default:"true" yaml:"enable_ssl_verification"`
+}
+
+// ServerWebhookSkipPolling ..
+type ServerWebhookSkipPolling struct {
+	// Do not poll the refs & environments metrics of projects
+	// from which webhook events are being received
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Interval at which the metrics of these refs & environments
+	// still get reconciled using the API
+	ReconciliationIntervalSeconds int `default:"3600" validate:"gte=1" yaml:"reconciliation_interval_seconds"`
+
+	// Polling resumes for a project if no webhook event
+	// has been received from it within this period
+	InactivityTimeoutSeconds int `default:"1800" validate:"gte=1" yaml:"inactivity_timeout_seconds"`
 }
 
 // Gitlab ..
@@ -133,73 +271,292 @@ type Redis struct {
 	// format: redis[s]://[:password@]host[:port][/db-number][?option=value])
 	URL string `yaml:"url"`
 
+	// Username used to authenticate against the Redis ACLs, it overrides the one of the URL
+	Username string `yaml:"username"`
+
+	// Password used to authenticate, it overrides the one of the URL
+	Password string `yaml:"password"`
+
+	// Sentinel configuration, used in place of the URL when a master name is set
+	Sentinel RedisSentinel `yaml:"sentinel"`
+
+	// Cluster configuration, used in place of the URL when addresses are set
+	Cluster RedisCluster `yaml:"cluster"`
+
+	// TLS configuration
+	TLS RedisTLS `yaml:"tls"`
+
+	// Namespace prefixing all the keys and queues, in order to get
+	// several deployments to share the same Redis
+	Namespace string `validate:"excludesall={}" yaml:"namespace"`
+
+	// Move the data stored outside of any namespace into the configured one on startup
+	MigrateToNamespace bool `yaml:"migrate_to_namespace"`
+
 	ProjectTTL time.Duration `default:"168h" yaml:"project_ttl"`
 	RefTTL     time.Duration `default:"1h" yaml:"ref_ttl"`
 	MetricTTL  time.Duration `default:"1h" yaml:"metric_ttl"`
+
+	// LeaderElection configuration
+	LeaderElection RedisLeaderElection `yaml:"leader_election"`
+}
+
+// RedisSentinel holds the configuration to reach a Redis through its sentinels.
+type RedisSentinel struct {
+	// Name of the master monitored by the sentinels
+	MasterName string `validate:"required_with=Addresses" yaml:"master_name"`
+
+	// Addresses (host:port) of the sentinels
+	Addresses []string `validate:"required_with=MasterName,dive,hostname_port" yaml:"addresses"`
+
+	// Credentials used to authenticate against the sentinels, if they differ from the ones of Redis
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// Database to use on the master
+	DB int `validate:"gte=0" yaml:"db"`
+}
+
+// RedisCluster holds the configuration to reach a Redis Cluster.
+type RedisCluster struct {
+	// Addresses (host:port) of the nodes used to discover the cluster
+	Addresses []string `validate:"dive,hostname_port" yaml:"addresses"`
+
+	// HashTag prepended to all the keys in order to get them onto the same slot,
+	// which the transactions and scripts spanning multiple keys require
+	HashTag string `default:"gcpe" validate:"required" yaml:"hash_tag"`
+}
+
+// RedisTLS holds the TLS configuration used to connect onto Redis.
+type RedisTLS struct {
+	// Enable TLS, implicitly true if a certificate or a CA is configured
+	Enabled bool `yaml:"enabled"`
+
+	// Path to a PEM encoded client certificate
+	CertFile string `validate:"required_with=KeyFile" yaml:"cert_file"`
+
+	// Path to the PEM encoded private key of the client certificate
+	KeyFile string `validate:"required_with=CertFile" yaml:"key_file"`
+
+	// Path to a PEM encoded CA bundle used to verify the server certificate
+	CAFile string `yaml:"ca_file"`
+
+	// Skip the verification of the server certificate
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// IsEnabled returns whether Redis is configured or not.
+func (r Redis) IsEnabled() bool {
+	return r.URL != "" || r.Sentinel.MasterName != "" || len(r.Cluster.Addresses) > 0
+}
+
+// IsEnabled returns whether TLS should be used or not.
+func (t RedisTLS) IsEnabled() bool {
+	return t.Enabled || t.CertFile != "" || t.CAFile != "" || t.InsecureSkipVerify
+}
+
+// RedisLeaderElection configures the election of the instance in charge of scheduling
+// the periodic tasks, amongst the ones sharing the same Redis.
+type RedisLeaderElection struct {
+	// Duration after which the leadership gets released if it has not been renewed
+	LeaseDurationSeconds int `default:"10" validate:"gte=2" yaml:"lease_duration_seconds"`
+
+	// Interval at which the leader renews its lease and the other instances attempt to acquire it
+	RenewIntervalSeconds int `default:"2" validate:"gte=1,ltfield=LeaseDurationSeconds" yaml:"renew_interval_seconds"`
 }
 
 // Pull ..
 type Pull struct {
 	// ProjectsFromWildcards configuration
 	ProjectsFromWildcards struct {
-		OnInit          bool `default:"true" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"1800" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"true" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"1800" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"projects_from_wildcards"`
 
 	// EnvironmentsFromProjects configuration
 	EnvironmentsFromProjects struct {
-		OnInit          bool `default:"true" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"1800" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"true" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"1800" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"environments_from_projects"`
 
 	// RefsFromProjects configuration
 	RefsFromProjects struct {
-		OnInit          bool `default:"true" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"300" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"true" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"300" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"refs_from_projects"`
 
 	// Metrics configuration
-	Metrics struct {
-		OnInit          bool `default:"true" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"30" validate:"gte=1" yaml:"interval_seconds"`
-	} `yaml:"metrics"`
+	Metrics PullMetrics `yaml:"metrics"`
+}
+
+// PullMetrics ..
+type PullMetrics struct {
+	OnInit          bool   `default:"true" yaml:"on_init"`
+	Scheduled       bool   `default:"true" yaml:"scheduled"`
+	IntervalSeconds int    `default:"30" validate:"gte=1" yaml:"interval_seconds"`
+	Cron            string `validate:"omitempty,cron" yaml:"cron"`
+
+	// Adapt the pull intervals of the refs to their activity
+	Adaptive PullMetricsAdaptive `yaml:"adaptive"`
+}
+
+// SchedulerConfig ..
+func (pm PullMetrics) SchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		OnInit:          pm.OnInit,
+		Scheduled:       pm.Scheduled,
+		IntervalSeconds: pm.IntervalSeconds,
+		Cron:            pm.Cron,
+	}
+}
+
+// PullMetricsAdaptive ..
+type PullMetricsAdaptive struct {
+	// Enable the adaptive pull intervals
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Interval used for the refs having running or pending pipelines
+	MinIntervalSeconds int `default:"10" validate:"gte=1" yaml:"min_interval_seconds"`
+
+	// Interval up to which the pulls of the idle refs get backed off
+	MaxIntervalSeconds int `default:"3600" validate:"gtefield=MinIntervalSeconds" yaml:"max_interval_seconds"`
 }
 
 // GarbageCollect ..
 type GarbageCollect struct {
+	// Number of entries read out of the store at once when iterating over the refs and metrics
+	BatchSize int `default:"1000" validate:"gte=1" yaml:"batch_size"`
+
+	// Duration after which a garbage collection of the metrics yields, to resume where it
+	// left off as another task. Set to 0 to go through all the metrics at once
+	SliceDurationSeconds int `default:"60" validate:"gte=0" yaml:"slice_duration_seconds"`
+
+	// Only log what would be deleted instead of deleting it
+	DryRun bool `default:"false" yaml:"dry_run"`
+
+	// Abort the garbage collections which would delete more than this percentage of the
+	// stored entities of their kind, before deleting any of them. Set to 0 to disable the safety threshold
+	MaxDeletedPercentage int `default:"0" validate:"gte=0,lte=100" yaml:"max_deleted_percentage"`
+
 	// Projects configuration
 	Projects struct {
-		OnInit          bool `default:"false" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"14400" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"false" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"14400" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"projects"`
 
 	// Environments configuration
 	Environments struct {
-		OnInit          bool `default:"false" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"14400" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"false" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"14400" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"environments"`
 
 	// Refs configuration
 	Refs struct {
-		OnInit          bool `default:"false" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"1800" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"false" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"1800" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"refs"`
 
 	// Metrics configuration
 	Metrics struct {
-		OnInit          bool `default:"false" yaml:"on_init"`
-		Scheduled       bool `default:"true" yaml:"scheduled"`
-		IntervalSeconds int  `default:"600" validate:"gte=1" yaml:"interval_seconds"`
+		OnInit          bool   `default:"false" yaml:"on_init"`
+		Scheduled       bool   `default:"true" yaml:"scheduled"`
+		IntervalSeconds int    `default:"600" validate:"gte=1" yaml:"interval_seconds"`
+		Cron            string `validate:"omitempty,cron" yaml:"cron"`
 	} `yaml:"metrics"`
 }
 
+// BatchSizeOrDefault returns the batch size, or the default one if unset.
+func (gc GarbageCollect) BatchSizeOrDefault() int64 {
+	if gc.BatchSize < 1 {
+		return 1000
+	}
+
+	return int64(gc.BatchSize)
+}
+
+// Scheduler ..
+type Scheduler struct {
+	// Timezone used to evaluate the cron expressions and the quiet hours, defaults to the local one
+	Timezone string `validate:"omitempty,timezone" yaml:"timezone"`
+
+	// Windows during which the scheduled runs of some tasks get deferred
+	QuietHours []QuietHours `validate:"dive" yaml:"quiet_hours"`
+
+	// Queues onto which the tasks get processed
+	Queues SchedulerQueues `yaml:"queues"`
+}
+
+// SchedulerQueues ..
+type SchedulerQueues struct {
+	// Tasks triggered by webhooks, processed ahead of the scheduled ones
+	Priority SchedulerQueue `yaml:"priority"`
+
+	// Discovery of the projects, environments and refs
+	Discovery SchedulerQueue `yaml:"discovery"`
+
+	// Pulls of the metrics
+	Metrics SchedulerQueue `yaml:"metrics"`
+
+	// Garbage collection of the projects, environments, refs and metrics
+	GarbageCollect SchedulerQueue `yaml:"garbage_collect"`
+}
+
+// BufferSize returns the total buffer size of the queues, the unset ones
+// defaulting to the given value.
+func (sq SchedulerQueues) BufferSize(defaultBufferSize int) (bufferSize int) {
+	for _, q := range []SchedulerQueue{sq.Priority, sq.Discovery, sq.Metrics, sq.GarbageCollect} {
+		bufferSize += q.BufferSizeOrDefault(defaultBufferSize)
+	}
+
+	return
+}
+
+// SchedulerQueue ..
+type SchedulerQueue struct {
+	// Maximum amount of tasks to keep in the queue, defaults to gitlab.maximum_jobs_queue_size
+	BufferSize int `default:"0" validate:"gte=0" yaml:"buffer_size"`
+
+	// Maximum amount of tasks processed concurrently, defaults to twice the amount of CPUs
+	MaxConcurrency int `default:"0" validate:"gte=0" yaml:"max_concurrency"`
+}
+
+// BufferSizeOrDefault returns the buffer size of the queue, or the given value if unset.
+func (q SchedulerQueue) BufferSizeOrDefault(defaultBufferSize int) int {
+	if q.BufferSize > 0 {
+		return q.BufferSize
+	}
+
+	return defaultBufferSize
+}
+
+// QuietHours ..
+type QuietHours struct {
+	// Time of the day at which the window starts, formatted as HH:MM
+	Start string `validate:"required,datetime=15:04" yaml:"start"`
+
+	// Time of the day at which the window ends, it can be lower than the start
+	// one for windows spanning over midnight
+	End string `validate:"required,datetime=15:04" yaml:"end"`
+
+	// Days of the week onto which the window starts, all of them if empty
+	Days []string `validate:"dive,oneof=monday tuesday wednesday thursday friday saturday sunday" yaml:"days"`
+
+	// Types of the tasks which get deferred during the window
+	Tasks []string `validate:"min=1,dive,oneof=PullProjectsFromWildcards PullEnvironmentsFromProjects PullRefsFromProjects PullMetrics GarbageCollectProjects GarbageCollectEnvironments GarbageCollectRefs GarbageCollectMetrics" yaml:"tasks"`
+}
+
 // UnmarshalYAML allows us to correctly hydrate our configuration using some custom logic.
 func (c *Config) UnmarshalYAML(v *yaml.Node) (err error) {
 	type localConfig struct {
@@ -210,6 +567,7 @@ func (c *Config) UnmarshalYAML(v *yaml.Node) (err error) {
 		Redis           Redis             `yaml:"redis"`
 		Pull            Pull              `yaml:"pull"`
 		GarbageCollect  GarbageCollect    `yaml:"garbage_collect"`
+		Scheduler       Scheduler         `yaml:"scheduler"`
 		ProjectDefaults ProjectParameters `yaml:"project_defaults"`
 
 		Projects  []yaml.Node `yaml:"projects"`
@@ -230,6 +588,7 @@ func (c *Config) UnmarshalYAML(v *yaml.Node) (err error) {
 	c.Redis = _cfg.Redis
 	c.Pull = _cfg.Pull
 	c.GarbageCollect = _cfg.GarbageCollect
+	c.Scheduler = _cfg.Scheduler
 	c.ProjectDefaults = _cfg.ProjectDefaults
 
 	for _, n := range _cfg.Projects {
@@ -257,6 +616,8 @@ func (c *Config) UnmarshalYAML(v *yaml.Node) (err error) {
 func (c Config) ToYAML() string {
 	c.Global = Global{}
 	c.Server.Webhook.SecretToken = "*******"
+	c.Server.Metrics.Auth = c.Server.Metrics.Auth.masked()
+	c.Server.Pprof.Auth = c.Server.Pprof.Auth.masked()
 	c.Gitlab.Token = "*******"
 
 	b, err := yaml.Marshal(c)
@@ -272,6 +633,7 @@ func (c Config) Validate() error {
 	if validate == nil {
 		validate = validator.New()
 		_ = validate.RegisterValidation("at-least-1-project-or-wildcard", ValidateAtLeastOneProjectOrWildcard)
+		_ = validate.RegisterValidation("cron", ValidateCron)
 	}
 
 	return validate.Struct(c)
@@ -282,6 +644,7 @@ type SchedulerConfig struct {
 	OnInit          bool
 	Scheduled       bool
 	IntervalSeconds int
+	Cron            string
 }
 
 // Log returns some logging fields to showcase the configuration to the enduser.
@@ -293,6 +656,10 @@ func (sc SchedulerConfig) Log() log.Fields {
 
 	if sc.Scheduled {
 		scheduled = fmt.Sprintf("every %vs", sc.IntervalSeconds)
+
+		if len(sc.Cron) > 0 {
+			scheduled = fmt.Sprintf("cron '%s'", sc.Cron)
+		}
 	}
 
 	return log.Fields{
@@ -307,6 +674,14 @@ func ValidateAtLeastOneProjectOrWildcard(v validator.FieldLevel) bool {
 	return v.Parent().FieldByName("Projects").Len() > 0 || v.Parent().FieldByName("Wildcards").Len() > 0
 }
 
+// ValidateCron implements validator.Func
+// assess that the cron expression can be parsed.
+func ValidateCron(v validator.FieldLevel) bool {
+	_, err := cron.Parse(v.Field().String())
+
+	return err == nil
+}
+
 // New returns a new config with the default parameters.
 func New() (c Config) {
 	defaults.MustSet(&c)
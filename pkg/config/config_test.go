@@ -17,7 +17,15 @@ func TestNew(t *testing.T) {
 	c.OpenTelemetry.GRPCEndpoint = ""
 
 	c.Server.ListenAddress = ":8080"
+	c.Server.TLS.ReloadIntervalSeconds = 60
 	c.Server.Metrics.Enabled = true
+	c.Server.Webhook.SkipPolling.ReconciliationIntervalSeconds = 3600
+	c.Server.Webhook.SkipPolling.InactivityTimeoutSeconds = 1800
+	c.Server.Webhook.AutoRegister.Scope = "project"
+	c.Server.Webhook.AutoRegister.EnableSSLVerification = true
+	c.Server.Webhook.Queue.BufferSize = 1000
+	c.Server.Webhook.Queue.MaxConcurrency = 5
+	c.Server.Webhook.Queue.MaxFailedEvents = 1000
 
 	c.Gitlab.URL = "https://gitlab.com"
 	c.Gitlab.HealthURL = "https://gitlab.com/explore"
@@ -42,6 +50,11 @@ func TestNew(t *testing.T) {
 	c.Pull.Metrics.OnInit = true
 	c.Pull.Metrics.Scheduled = true
 	c.Pull.Metrics.IntervalSeconds = 30
+	c.Pull.Metrics.Adaptive.MinIntervalSeconds = 10
+	c.Pull.Metrics.Adaptive.MaxIntervalSeconds = 3600
+
+	c.GarbageCollect.BatchSize = 1000
+	c.GarbageCollect.SliceDurationSeconds = 60
 
 	c.GarbageCollect.Projects.Scheduled = true
 	c.GarbageCollect.Projects.IntervalSeconds = 14400
@@ -72,6 +85,7 @@ func TestNew(t *testing.T) {
 
 	c.ProjectDefaults.Pull.Pipeline.Jobs.FromChildPipelines.Enabled = true
 	c.ProjectDefaults.Pull.Pipeline.Jobs.RunnerDescription.Enabled = true
+	c.ProjectDefaults.Pull.Pipeline.Jobs.FailureReason.Enabled = true
 	c.ProjectDefaults.Pull.Pipeline.Jobs.RunnerDescription.AggregationRegexp = `shared-runners-manager-(\d*)\.gitlab\.com`
 	c.ProjectDefaults.Pull.Pipeline.Variables.Regexp = `.*`
 	c.ProjectDefaults.Pull.Pipeline.PerRef = 1
@@ -79,6 +93,9 @@ func TestNew(t *testing.T) {
 	c.Redis.ProjectTTL = 168 * time.Hour
 	c.Redis.RefTTL = 1 * time.Hour
 	c.Redis.MetricTTL = 1 * time.Hour
+	c.Redis.Cluster.HashTag = "gcpe"
+	c.Redis.LeaderElection.LeaseDurationSeconds = 10
+	c.Redis.LeaderElection.RenewIntervalSeconds = 2
 
 	assert.Equal(t, c, New())
 }
@@ -92,6 +109,87 @@ func TestValidConfig(t *testing.T) {
 	assert.NoError(t, cfg.Validate())
 }
 
+func TestValidateScheduler(t *testing.T) {
+	cfg := New()
+
+	cfg.Gitlab.Token = "foo"
+	cfg.Projects = append(cfg.Projects, NewProject("bar"))
+	cfg.Pull.ProjectsFromWildcards.Cron = "0 */2 * * *"
+	cfg.GarbageCollect.Metrics.Cron = "@daily"
+	cfg.Scheduler.QuietHours = []QuietHours{
+		{
+			Start: "08:00",
+			End:   "19:00",
+			Days:  []string{"monday", "friday"},
+			Tasks: []string{"PullProjectsFromWildcards"},
+		},
+	}
+
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Pull.ProjectsFromWildcards.Cron = "0 */2 * *"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Pull.ProjectsFromWildcards.Cron = ""
+	cfg.Scheduler.QuietHours[0].End = "25:00"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Scheduler.QuietHours[0].End = "19:00"
+	cfg.Scheduler.QuietHours[0].Tasks = []string{"PullProject"}
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidateRedisLeaderElection(t *testing.T) {
+	cfg := New()
+
+	cfg.Gitlab.Token = "foo"
+	cfg.Projects = append(cfg.Projects, NewProject("bar"))
+	assert.NoError(t, cfg.Validate())
+
+	// The lease would expire before being renewed
+	cfg.Redis.LeaderElection.RenewIntervalSeconds = 10
+	assert.Error(t, cfg.Validate())
+}
+
+func TestValidateRedis(t *testing.T) {
+	cfg := New()
+
+	cfg.Gitlab.Token = "foo"
+	cfg.Projects = append(cfg.Projects, NewProject("bar"))
+	assert.False(t, cfg.Redis.IsEnabled())
+
+	// The sentinels are required along with the master name
+	cfg.Redis.Sentinel.MasterName = "mymaster"
+	assert.True(t, cfg.Redis.IsEnabled())
+	assert.Error(t, cfg.Validate())
+
+	cfg.Redis.Sentinel.Addresses = []string{"sentinel:26379"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Redis.Sentinel = RedisSentinel{}
+	cfg.Redis.Cluster.Addresses = []string{"foo"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.Redis.Cluster.Addresses = []string{"node1:6379", "node2:6379"}
+	assert.True(t, cfg.Redis.IsEnabled())
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Redis.TLS.KeyFile = "/tls.key"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Redis.TLS.CertFile = "/tls.crt"
+	assert.True(t, cfg.Redis.TLS.IsEnabled())
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestSchedulerQueuesBufferSize(t *testing.T) {
+	sq := SchedulerQueues{}
+	assert.Equal(t, 4000, sq.BufferSize(1000))
+
+	sq.Metrics.BufferSize = 5000
+	assert.Equal(t, 8000, sq.BufferSize(1000))
+}
+
 func TestSchedulerConfigLog(t *testing.T) {
 	sc := SchedulerConfig{
 		OnInit:          true,
@@ -103,4 +201,7 @@ func TestSchedulerConfigLog(t *testing.T) {
 		"on-init":   "yes",
 		"scheduled": "every 300s",
 	}, sc.Log())
+
+	sc.Cron = "@hourly"
+	assert.Equal(t, "cron '@hourly'", sc.Log()["scheduled"])
 }
@@ -9,4 +9,44 @@ type Global struct {
 	// InternalMonitoringListenerAddress can be used to access
 	// some metrics related to the exporter internals
 	InternalMonitoringListenerAddress *url.URL
+
+	// InternalMonitoringListenerTLS configures TLS (or mTLS) on
+	// the internal monitoring listener and its clients
+	InternalMonitoringListenerTLS InternalMonitoringListenerTLS
+
+	// InternalMonitoringListenerToken is a shared secret which, when set,
+	// must be provided by the clients of the internal monitoring listener
+	InternalMonitoringListenerToken string
+}
+
+// InternalMonitoringListenerTLS holds the TLS configuration of the internal monitoring listener.
+// On the server side, the certificate is used to serve the listener and the CA to verify client
+// certificates (mTLS). On the client side, the certificate is presented to the server and the CA
+// is used to verify the server certificate.
+type InternalMonitoringListenerTLS struct {
+	// Enable TLS, implicitly true if a certificate or a CA is configured
+	Enabled bool
+
+	// Path to a PEM encoded certificate
+	CertFile string `validate:"required_with=KeyFile"`
+
+	// Path to the PEM encoded private key of the certificate
+	KeyFile string `validate:"required_with=CertFile"`
+
+	// Path to a PEM encoded CA bundle
+	CAFile string
+
+	// Skip the verification of the server certificate (client only)
+	InsecureSkipVerify bool
+}
+
+// IsEnabled returns whether the listener should be served over TLS or not.
+func (t InternalMonitoringListenerTLS) IsEnabled() bool {
+	return t.Enabled || t.CertFile != "" || t.CAFile != ""
+}
+
+// IsClientEnabled returns whether the clients should connect to the listener over TLS or not.
+// Skipping the verification of the server certificate only makes sense for the clients.
+func (t InternalMonitoringListenerTLS) IsClientEnabled() bool {
+	return t.IsEnabled() || t.InsecureSkipVerify
 }
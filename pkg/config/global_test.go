@@ -0,0 +1,20 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInternalMonitoringListenerTLSIsEnabled(t *testing.T) {
+	assert.False(t, InternalMonitoringListenerTLS{}.IsEnabled())
+	assert.False(t, InternalMonitoringListenerTLS{}.IsClientEnabled())
+
+	assert.True(t, InternalMonitoringListenerTLS{Enabled: true}.IsEnabled())
+	assert.True(t, InternalMonitoringListenerTLS{CertFile: "/foo.crt"}.IsEnabled())
+	assert.True(t, InternalMonitoringListenerTLS{CAFile: "/ca.crt"}.IsClientEnabled())
+
+	// Skipping the verification of the server certificate must not enable TLS on the server
+	assert.False(t, InternalMonitoringListenerTLS{InsecureSkipVerify: true}.IsEnabled())
+	assert.True(t, InternalMonitoringListenerTLS{InsecureSkipVerify: true}.IsClientEnabled())
+}
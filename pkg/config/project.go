@@ -18,6 +18,10 @@ type ProjectPull struct {
 	Environments ProjectPullEnvironments `yaml:"environments"`
 	Refs         ProjectPullRefs         `yaml:"refs"`
 	Pipeline     ProjectPullPipeline     `yaml:"pipeline"`
+
+	// Interval at which the metrics of the refs and environments of the project
+	// are pulled, overriding pull.metrics.interval_seconds when set.
+	IntervalSeconds int `default:"0" validate:"gte=0" yaml:"interval_seconds"`
 }
 
 // ProjectPullEnvironments ..
@@ -30,6 +34,10 @@ type ProjectPullEnvironments struct {
 
 	// Prevent exporting metrics for stopped environments
 	ExcludeStopped bool `default:"true" yaml:"exclude_stopped"`
+
+	// Interval at which the metrics of the environments are pulled,
+	// overriding the one of the project when set
+	IntervalSeconds int `default:"0" validate:"gte=0" yaml:"interval_seconds"`
 }
 
 // ProjectPullRefs ..
@@ -61,6 +69,10 @@ type ProjectPullRefsBranches struct {
 
 	// Prevent exporting metrics for deleted branches
 	ExcludeDeleted bool `default:"true" yaml:"exclude_deleted"`
+
+	// Interval at which the metrics of the branches are pulled,
+	// overriding the one of the project when set
+	IntervalSeconds int `default:"0" validate:"gte=0" yaml:"interval_seconds"`
 }
 
 // ProjectPullRefsTags ..
@@ -80,6 +92,10 @@ type ProjectPullRefsTags struct {
 
 	// Prevent exporting metrics for deleted tags.
 	ExcludeDeleted bool `default:"true" yaml:"exclude_deleted"`
+
+	// Interval at which the metrics of the tags are pulled,
+	// overriding the one of the project when set.
+	IntervalSeconds int `default:"0" validate:"gte=0" yaml:"interval_seconds"`
 }
 
 // ProjectPullRefsMergeRequests ..
@@ -99,11 +115,16 @@ type ProjectPullRefsMergeRequests struct {
 
 	// Prevent exporting metrics for MRs that are not opened.
 	ExcludeNonOpened bool `default:"false" yaml:"exclude_non_opened"`
+
+	// Interval at which the metrics of the merge requests are pulled,
+	// overriding the one of the project when set.
+	IntervalSeconds int `default:"0" validate:"gte=0" yaml:"interval_seconds"`
 }
 
 // ProjectPullPipeline ..
 type ProjectPullPipeline struct {
 	Jobs        ProjectPullPipelineJobs        `yaml:"jobs"`
+	Bridges     ProjectPullPipelineBridges     `yaml:"bridges"`
 	Variables   ProjectPullPipelineVariables   `yaml:"variables"`
 	TestReports ProjectPullPipelineTestReports `yaml:"test_reports"`
 	PerRef      uint                           `default:"1" yaml:"per_ref"`
@@ -119,6 +140,18 @@ type ProjectPullPipelineJobs struct {
 
 	// Configure the export of the runner description which ran the job.
 	RunnerDescription ProjectPullPipelineJobsRunnerDescription `yaml:"runner_description"`
+
+	// Configure the export of the failure reason of the job.
+	FailureReason ProjectPullPipelineJobsFailureReason `yaml:"failure_reason"`
+
+	// Analyse the critical path of the finished pipelines.
+	CriticalPath ProjectPullPipelineJobsCriticalPath `yaml:"critical_path"`
+
+	// Count the retried jobs.
+	Retries ProjectPullPipelineJobsRetries `yaml:"retries"`
+
+	// Export the jobs waiting on a manual action or a resource group.
+	Waiting ProjectPullPipelineJobsWaiting `yaml:"waiting"`
 }
 
 // ProjectPullPipelineJobsFromChildPipelines ..
@@ -136,6 +169,47 @@ type ProjectPullPipelineJobsRunnerDescription struct {
 	AggregationRegexp string `default:"shared-runners-manager-(\\d*)\\.gitlab\\.com" yaml:"aggregation_regexp"`
 }
 
+// ProjectPullPipelineJobsFailureReason ..
+type ProjectPullPipelineJobsFailureReason struct {
+	// Enabled set to true will export the failure reason of the job as a label of the job metrics, kept empty otherwise.
+	Enabled bool `default:"true" yaml:"enabled"`
+}
+
+// ProjectPullPipelineJobsCriticalPath ..
+type ProjectPullPipelineJobsCriticalPath struct {
+	// Enabled set to true will export the critical path and parallelism related metrics of the finished pipelines.
+	Enabled bool `default:"false" yaml:"enabled"`
+}
+
+// ProjectPullPipelineJobsRetries ..
+type ProjectPullPipelineJobsRetries struct {
+	// Enabled set to true will export the retries related metrics of the jobs.
+	Enabled bool `default:"false" yaml:"enabled"`
+}
+
+// ProjectPullPipelineJobsWaiting ..
+type ProjectPullPipelineJobsWaiting struct {
+	// Enabled set to true will export the metrics of the manual jobs, of the jobs waiting for
+	// a resource group and of the pipelines blocked on a manual job.
+	Enabled bool `default:"false" yaml:"enabled"`
+}
+
+// ProjectPullPipelineBridges ..
+type ProjectPullPipelineBridges struct {
+	// Enabled set to true will pull the metrics of the bridges and of their downstream pipelines.
+	Enabled bool `default:"false" yaml:"enabled"`
+
+	// Track the downstream pipelines of other projects as refs of their own.
+	DownstreamRefs ProjectPullPipelineBridgesDownstreamRefs `yaml:"downstream_refs"`
+}
+
+// ProjectPullPipelineBridgesDownstreamRefs ..
+type ProjectPullPipelineBridgesDownstreamRefs struct {
+	// Enabled set to true will pull the metrics of the refs of the downstream pipelines
+	// which belong to other projects, using the configuration of the upstream project.
+	Enabled bool `default:"false" yaml:"enabled"`
+}
+
 // ProjectPullPipelineVariables ..
 type ProjectPullPipelineVariables struct {
 	// Enabled set to true will attempt to retrieve variables included in the pipeline.
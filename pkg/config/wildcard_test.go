@@ -26,6 +26,7 @@ func TestNewWildcard(t *testing.T) {
 
 	w.Pull.Pipeline.Jobs.FromChildPipelines.Enabled = true
 	w.Pull.Pipeline.Jobs.RunnerDescription.Enabled = true
+	w.Pull.Pipeline.Jobs.FailureReason.Enabled = true
 	w.Pull.Pipeline.Jobs.RunnerDescription.AggregationRegexp = `shared-runners-manager-(\d*)\.gitlab\.com`
 	w.Pull.Pipeline.Variables.Regexp = `.*`
 	w.Pull.Pipeline.PerRef = 1
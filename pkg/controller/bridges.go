@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// PullRefPipelineBridgesMetrics ..
+func (c *Controller) PullRefPipelineBridgesMetrics(ctx context.Context, ref schemas.Ref) error {
+	bridges, err := c.Gitlab.ListRefPipelineBridges(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	for _, bridge := range bridges {
+		c.ProcessBridgeMetrics(ctx, ref, bridge)
+
+		if ref.Project.Pull.Pipeline.Bridges.DownstreamRefs.Enabled &&
+			bridge.Downstream != nil &&
+			bridge.Downstream.CrossProject {
+			if err = c.trackDownstreamRef(ctx, ref, *bridge.Downstream); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ProcessBridgeMetrics ..
+func (c *Controller) ProcessBridgeMetrics(ctx context.Context, ref schemas.Ref, bridge schemas.Bridge) {
+	// Nothing to report as long as the downstream pipeline has not been created
+	if bridge.Downstream == nil {
+		return
+	}
+
+	labels := ref.DefaultLabelsValues()
+	labels["stage"] = bridge.Stage
+	labels["bridge_name"] = bridge.Name
+	labels["downstream_project"] = bridge.Downstream.ProjectName
+
+	log.WithFields(log.Fields{
+		"project-name":           ref.Project.Name,
+		"bridge-name":            bridge.Name,
+		"downstream-project":     bridge.Downstream.ProjectName,
+		"downstream-pipeline-id": bridge.Downstream.Pipeline.ID,
+	}).Trace("processing bridge metrics")
+
+	storeSetMetric(ctx, c.Store, schemas.Metric{
+		Kind:   schemas.MetricKindBridgeDownstreamPipelineID,
+		Labels: labels,
+		Value:  float64(bridge.Downstream.Pipeline.ID),
+	})
+
+	storeSetMetric(ctx, c.Store, schemas.Metric{
+		Kind:   schemas.MetricKindBridgeDownstreamDurationSeconds,
+		Labels: labels,
+		Value:  bridge.Downstream.Pipeline.DurationSeconds,
+	})
+
+	causedFailure := schemas.Metric{
+		Kind:   schemas.MetricKindBridgeCausedFailure,
+		Labels: labels,
+	}
+
+	if bridge.CausedFailure(ref.LatestPipeline.Status) {
+		causedFailure.Value = 1
+	}
+
+	storeSetMetric(ctx, c.Store, causedFailure)
+
+	emitStatusMetric(
+		ctx,
+		c.Store,
+		schemas.MetricKindBridgeDownstreamStatus,
+		labels,
+		statusesList[:],
+		bridge.Downstream.Pipeline.Status,
+		ref.Project.OutputSparseStatusMetrics,
+	)
+}
+
+// trackDownstreamRef starts pulling the metrics of the ref of a downstream pipeline which belongs
+// to another project, unless it is already known. It inherits the configuration of the upstream project.
+func (c *Controller) trackDownstreamRef(ctx context.Context, upstream schemas.Ref, downstream schemas.BridgeDownstream) error {
+	p := schemas.NewProject(downstream.ProjectName)
+	p.ID = downstream.ProjectID
+	p.ProjectParameters = upstream.Project.ProjectParameters
+
+	ref := schemas.NewRef(p, downstream.RefKind, downstream.RefName)
+	ref.UpstreamRefKey = upstream.Key()
+
+	refExists, err := c.Store.RefExists(ctx, ref.Key())
+	if err != nil || refExists {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"project-name":          ref.Project.Name,
+		"ref":                   ref.Name,
+		"ref-kind":              ref.Kind,
+		"upstream-project-name": upstream.Project.Name,
+		"upstream-ref":          upstream.Name,
+	}).Info("discovered new downstream ref")
+
+	if err = c.Store.SetRef(ctx, ref); err != nil {
+		return err
+	}
+
+	c.ScheduleTask(ctx, schemas.TaskTypePullRefMetrics, string(ref.Key()), ref)
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestPullRefPipelineBridgesMetrics(t *testing.T) {
+	ctx, c, mux, srv := newTestController(config.Config{})
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/projects/foo/pipelines/1/bridges",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[
+				{"id":10,"name":"release","stage":"deploy","status":"failed","pipeline":{"id":1,"project_id":1},"downstream_pipeline":{"id":3,"project_id":20,"ref":"main"}},
+				{"id":11,"name":"pending","stage":"deploy","status":"created","pipeline":{"id":1,"project_id":1}}
+			]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":20,"path_with_namespace":"group/release"}`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20/pipelines/3",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":3,"status":"failed","duration":60}`)
+		})
+
+	p := schemas.NewProject("foo")
+	p.Pull.Pipeline.Bridges.Enabled = true
+	p.Pull.Pipeline.Bridges.DownstreamRefs.Enabled = true
+
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	ref.LatestPipeline = schemas.Pipeline{ID: 1, Status: "failed"}
+
+	assert.NoError(t, c.PullRefPipelineBridgesMetrics(ctx, ref))
+
+	labels := ref.DefaultLabelsValues()
+	labels["stage"] = "deploy"
+	labels["bridge_name"] = "release"
+	labels["downstream_project"] = "group/release"
+
+	metrics, _ := c.Store.Metrics(ctx)
+
+	for kind, value := range map[schemas.MetricKind]float64{
+		schemas.MetricKindBridgeDownstreamPipelineID:      3,
+		schemas.MetricKindBridgeDownstreamDurationSeconds: 60,
+		schemas.MetricKindBridgeCausedFailure:             1,
+	} {
+		m := schemas.Metric{Kind: kind, Labels: labels, Value: value}
+		assert.Equal(t, m, metrics[m.Key()])
+	}
+
+	labels["status"] = "failed"
+	status := schemas.Metric{Kind: schemas.MetricKindBridgeDownstreamStatus, Labels: labels, Value: 1}
+	assert.Equal(t, status, metrics[status.Key()])
+
+	// The bridge without downstream pipeline is not reported
+	assert.Len(t, metrics, 4)
+
+	// The downstream ref gets tracked with the configuration of the upstream project
+	downstreamProject := schemas.NewProject("group/release")
+	downstreamProject.ID = 20
+	downstreamProject.ProjectParameters = p.ProjectParameters
+
+	expectedRef := schemas.NewRef(downstreamProject, schemas.RefKindBranch, "main")
+	expectedRef.UpstreamRefKey = ref.Key()
+
+	storedRefs, _ := c.Store.Refs(ctx)
+	assert.Equal(t, schemas.Refs{expectedRef.Key(): expectedRef}, storedRefs)
+}
@@ -5,12 +5,20 @@ import "github.com/prometheus/client_golang/prometheus"
 var (
 	defaultLabels                = []string{"project", "topics", "kind", "ref", "source", "variables"}
 	jobLabels                    = []string{"stage", "job_name", "runner_description", "tag_list", "failure_reason"}
+	stageLabels                  = []string{"stage"}
+	criticalPathJobLabels        = []string{"stage", "job_name"}
+	jobFailureLabels             = []string{"stage", "runner_description", "failure_reason"}
+	jobRetryLabels               = []string{"stage", "job_name", "retry_kind"}
+	bridgeLabels                 = []string{"stage", "bridge_name", "downstream_project"}
 	statusLabels                 = []string{"status"}
 	environmentLabels            = []string{"project", "environment"}
 	environmentInformationLabels = []string{"environment_id", "external_url", "kind", "ref", "latest_commit_short_id", "current_commit_short_id", "available", "username"}
 	testSuiteLabels              = []string{"test_suite_name"}
 	testCaseLabels               = []string{"test_case_name", "test_case_classname"}
+	webhookLabels                = []string{"project", "scope"}
 	statusesList                 = [...]string{"created", "waiting_for_resource", "preparing", "pending", "running", "success", "failed", "canceled", "skipped", "manual", "scheduled", "error", "success_with_warnings"}
+	webhookStatusesList          = [...]string{"executable", "temporarily_disabled", "disabled", "error"}
+	activePipelineStatusesList   = [...]string{"created", "waiting_for_resource", "preparing", "pending", "running"}
 )
 
 // NewInternalCollectorCurrentlyQueuedTasksCount returns a new collector for the gcpe_currently_queued_tasks_count metric.
@@ -79,6 +87,61 @@ func NewInternalCollectorGitLabAPIRequestsLimit() prometheus.Collector {
 	)
 }
 
+// NewInternalCollectorIsLeader returns a new collector for the gcpe_is_leader metric.
+func NewInternalCollectorIsLeader() prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gcpe_is_leader",
+			Help: "Whether the process is the leader in charge of scheduling the periodic tasks",
+		},
+		[]string{},
+	)
+}
+
+// NewInternalCollectorGarbageCollectionDeletedCount returns a new collector for the gitlab_ci_pipelines_exporter_gc_deleted_total metric.
+func NewInternalCollectorGarbageCollectionDeletedCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gitlab_ci_pipelines_exporter_gc_deleted_total",
+			Help: "Number of entities deleted from the store by the garbage collections",
+		},
+		[]string{"entity", "reason"},
+	)
+}
+
+// NewInternalCollectorGarbageCollectionRunsCount returns a new collector for the gcpe_garbage_collection_runs_count metric.
+func NewInternalCollectorGarbageCollectionRunsCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_garbage_collection_runs_count",
+			Help: "Number of garbage collections which went through all the entities of the store",
+		},
+		[]string{"entity"},
+	)
+}
+
+// NewInternalCollectorGarbageCollectionScannedCount returns a new collector for the gcpe_garbage_collection_scanned_count metric.
+func NewInternalCollectorGarbageCollectionScannedCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_garbage_collection_scanned_count",
+			Help: "Number of entities of the store checked by the garbage collections",
+		},
+		[]string{"entity"},
+	)
+}
+
+// NewInternalCollectorGarbageCollectionSlicesCount returns a new collector for the gcpe_garbage_collection_slices_count metric.
+func NewInternalCollectorGarbageCollectionSlicesCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_garbage_collection_slices_count",
+			Help: "Number of time-boxed slices the garbage collections have been executed in",
+		},
+		[]string{"entity"},
+	)
+}
+
 // NewInternalCollectorMetricsCount returns a new collector for the gcpe_metrics_count metric.
 func NewInternalCollectorMetricsCount() prometheus.Collector {
 	return prometheus.NewGaugeVec(
@@ -90,6 +153,29 @@ func NewInternalCollectorMetricsCount() prometheus.Collector {
 	)
 }
 
+// NewInternalCollectorMetricsPullIntervalSeconds returns a new collector for the gcpe_metrics_pull_interval_seconds metric.
+func NewInternalCollectorMetricsPullIntervalSeconds() prometheus.Collector {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gcpe_metrics_pull_interval_seconds",
+			Help:    "Intervals at which the metrics of the refs and environments are currently being pulled",
+			Buckets: []float64{10, 30, 60, 120, 300, 600, 1800, 3600, 7200, 14400, 43200, 86400},
+		},
+		[]string{},
+	)
+}
+
+// NewInternalCollectorOrphanedTasksCount returns a new collector for the gcpe_orphaned_tasks_count metric.
+func NewInternalCollectorOrphanedTasksCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_orphaned_tasks_count",
+			Help: "Number of queued tasks found on startup whose owning process is not alive anymore",
+		},
+		[]string{"task_type"},
+	)
+}
+
 // NewInternalCollectorProjectsCount returns a new collector for the gcpe_projects_count metric.
 func NewInternalCollectorProjectsCount() prometheus.Collector {
 	return prometheus.NewGaugeVec(
@@ -101,6 +187,17 @@ func NewInternalCollectorProjectsCount() prometheus.Collector {
 	)
 }
 
+// NewInternalCollectorRecoveredTasksCount returns a new collector for the gcpe_recovered_tasks_count metric.
+func NewInternalCollectorRecoveredTasksCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_recovered_tasks_count",
+			Help: "Number of orphaned tasks which have been queued back on startup",
+		},
+		[]string{"task_type"},
+	)
+}
+
 // NewInternalCollectorRefsCount returns a new collector for the gcpe_refs_count metric.
 func NewInternalCollectorRefsCount() prometheus.Collector {
 	return prometheus.NewGaugeVec(
@@ -112,398 +209,701 @@ func NewInternalCollectorRefsCount() prometheus.Collector {
 	)
 }
 
+// NewInternalCollectorTaskExecutionDurationSeconds returns a new collector for the gcpe_task_execution_duration_seconds metric.
+func NewInternalCollectorTaskExecutionDurationSeconds() prometheus.Collector {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gcpe_task_execution_duration_seconds",
+			Help:    "Duration of the executions of the tasks",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		},
+		[]string{"task_type"},
+	)
+}
+
+// NewInternalCollectorTaskExecutionsCount returns a new collector for the gcpe_task_executions_count metric.
+func NewInternalCollectorTaskExecutionsCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_task_executions_count",
+			Help: "Number of tasks executed by the process",
+		},
+		[]string{"task_type", "status"},
+	)
+}
+
+// NewInternalCollectorTaskFailuresCount returns a new collector for the gcpe_task_failures_count metric.
+func NewInternalCollectorTaskFailuresCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_task_failures_count",
+			Help: "Number of tasks which failed to be executed by the process",
+		},
+		[]string{"task_type", "reason"},
+	)
+}
+
+// NewInternalCollectorTaskQueueWaitDurationSeconds returns a new collector for the gcpe_task_queue_wait_duration_seconds metric.
+func NewInternalCollectorTaskQueueWaitDurationSeconds() prometheus.Collector {
+	return prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gcpe_task_queue_wait_duration_seconds",
+			Help:    "Duration the tasks spent in the queues before being executed",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+		},
+		[]string{"task_type"},
+	)
+}
+
+// NewInternalCollectorTaskSkipsCount returns a new collector for the gcpe_task_skips_count metric.
+func NewInternalCollectorTaskSkipsCount() prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gcpe_task_skips_count",
+			Help: "Number of tasks which have not been scheduled by the process",
+		},
+		[]string{"task_type", "reason"},
+	)
+}
+
+// NewInternalCollectorWebhookEventsCount returns a new collector for the gcpe_webhook_events_count metric.
+func NewInternalCollectorWebhookEventsCount() prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gcpe_webhook_events_count",
+			Help: "Number of webhook events received, processed, failed or dropped",
+		},
+		[]string{"event_type", "status"},
+	)
+}
+
+// NewInternalCollectorWebhookFailedEventsCount returns a new collector for the gcpe_webhook_failed_events_count metric.
+func NewInternalCollectorWebhookFailedEventsCount() prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gcpe_webhook_failed_events_count",
+			Help: "Number of failed webhook events which can be replayed",
+		},
+		[]string{},
+	)
+}
+
+// NewCollectorAverageParallelism returns a new collector for the gitlab_ci_pipeline_average_parallelism metric.
+func NewCollectorAverageParallelism(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_average_parallelism",
+			Help: "Average number of jobs running at the same time during the most recently finished pipeline",
+		},
+		withLabels(defaultLabels, extraLabels),
+	)
+}
+
+// NewCollectorBlockedDurationSeconds returns a new collector for the gitlab_ci_pipeline_blocked_duration_seconds metric.
+func NewCollectorBlockedDurationSeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_blocked_duration_seconds",
+			Help: "Duration in seconds the most recent pipeline has been blocked on a manual job",
+		},
+		withLabels(defaultLabels, extraLabels),
+	)
+}
+
+// NewCollectorBridgeCausedFailure returns a new collector for the gitlab_ci_pipeline_bridge_caused_failure metric.
+func NewCollectorBridgeCausedFailure(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_bridge_caused_failure",
+			Help: "Whether the most recent pipeline failed because of the downstream pipeline triggered by the bridge",
+		},
+		withLabels(append(defaultLabels, bridgeLabels...), extraLabels),
+	)
+}
+
+// NewCollectorBridgeDownstreamDurationSeconds returns a new collector for the gitlab_ci_pipeline_bridge_downstream_duration_seconds metric.
+func NewCollectorBridgeDownstreamDurationSeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_bridge_downstream_duration_seconds",
+			Help: "Duration in seconds of the downstream pipeline triggered by the bridge of the most recent pipeline",
+		},
+		withLabels(append(defaultLabels, bridgeLabels...), extraLabels),
+	)
+}
+
+// NewCollectorBridgeDownstreamPipelineID returns a new collector for the gitlab_ci_pipeline_bridge_downstream_pipeline_id metric.
+func NewCollectorBridgeDownstreamPipelineID(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_bridge_downstream_pipeline_id",
+			Help: "ID of the downstream pipeline triggered by the bridge of the most recent pipeline",
+		},
+		withLabels(append(defaultLabels, bridgeLabels...), extraLabels),
+	)
+}
+
+// NewCollectorBridgeDownstreamStatus returns a new collector for the gitlab_ci_pipeline_bridge_downstream_status metric.
+func NewCollectorBridgeDownstreamStatus(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_bridge_downstream_status",
+			Help: "Status of the downstream pipeline triggered by the bridge of the most recent pipeline",
+		},
+		withLabels(append(defaultLabels, append(bridgeLabels, statusLabels...)...), extraLabels),
+	)
+}
+
 // NewCollectorCoverage returns a new collector for the gitlab_ci_pipeline_coverage metric.
-func NewCollectorCoverage() prometheus.Collector {
+func NewCollectorCoverage(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_coverage",
 			Help: "Coverage of the most recent pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
+	)
+}
+
+// NewCollectorCriticalPathDurationSeconds returns a new collector for the gitlab_ci_pipeline_critical_path_duration_seconds metric.
+func NewCollectorCriticalPathDurationSeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_critical_path_duration_seconds",
+			Help: "Cumulative duration in seconds of the longest chain of dependent jobs of the most recently finished pipeline",
+		},
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorDurationSeconds returns a new collector for the gitlab_ci_pipeline_duration_seconds metric.
-func NewCollectorDurationSeconds() prometheus.Collector {
+func NewCollectorDurationSeconds(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_duration_seconds",
 			Help: "Duration in seconds of the most recent pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorQueuedDurationSeconds returns a new collector for the gitlab_ci_pipeline_queued_duration_seconds metric.
-func NewCollectorQueuedDurationSeconds() prometheus.Collector {
+func NewCollectorQueuedDurationSeconds(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_queued_duration_seconds",
 			Help: "Duration in seconds the most recent pipeline has been queued before starting",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentBehindCommitsCount returns a new collector for the gitlab_ci_environment_behind_commits_count metric.
-func NewCollectorEnvironmentBehindCommitsCount() prometheus.Collector {
+func NewCollectorEnvironmentBehindCommitsCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_behind_commits_count",
 			Help: "Number of commits the environment is behind given its last deployment",
 		},
-		environmentLabels,
+		withLabels(environmentLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentBehindDurationSeconds returns a new collector for the gitlab_ci_environment_behind_duration_seconds metric.
-func NewCollectorEnvironmentBehindDurationSeconds() prometheus.Collector {
+func NewCollectorEnvironmentBehindDurationSeconds(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_behind_duration_seconds",
 			Help: "Duration in seconds the environment is behind the most recent commit given its last deployment",
 		},
-		environmentLabels,
+		withLabels(environmentLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentDeploymentCount returns a new collector for the gitlab_ci_environment_deployment_count metric.
-func NewCollectorEnvironmentDeploymentCount() prometheus.Collector {
+func NewCollectorEnvironmentDeploymentCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gitlab_ci_environment_deployment_count",
 			Help: "Number of deployments for an environment",
 		},
-		environmentLabels,
+		withLabels(environmentLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentDeploymentDurationSeconds returns a new collector for the gitlab_ci_environment_deployment_duration_seconds metric.
-func NewCollectorEnvironmentDeploymentDurationSeconds() prometheus.Collector {
+func NewCollectorEnvironmentDeploymentDurationSeconds(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_deployment_duration_seconds",
 			Help: "Duration in seconds of the most recent deployment of the environment",
 		},
-		environmentLabels,
+		withLabels(environmentLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentDeploymentJobID returns a new collector for the gitlab_ci_environment_deployment_id metric.
-func NewCollectorEnvironmentDeploymentJobID() prometheus.Collector {
+func NewCollectorEnvironmentDeploymentJobID(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_deployment_job_id",
 			Help: "ID of the most recent deployment job of the environment",
 		},
-		environmentLabels,
+		withLabels(environmentLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentDeploymentStatus returns a new collector for the gitlab_ci_environment_deployment_status metric.
-func NewCollectorEnvironmentDeploymentStatus() prometheus.Collector {
+func NewCollectorEnvironmentDeploymentStatus(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_deployment_status",
 			Help: "Status of the most recent deployment of the environment",
 		},
-		append(environmentLabels, "status"),
+		withLabels(append(environmentLabels, "status"), extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentDeploymentTimestamp returns a new collector for the gitlab_ci_environment_deployment_timestamp metric.
-func NewCollectorEnvironmentDeploymentTimestamp() prometheus.Collector {
+func NewCollectorEnvironmentDeploymentTimestamp(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_deployment_timestamp",
 			Help: "Creation date of the most recent deployment of the environment",
 		},
-		environmentLabels,
+		withLabels(environmentLabels, extraLabels),
 	)
 }
 
 // NewCollectorEnvironmentInformation returns a new collector for the gitlab_ci_environment_information metric.
-func NewCollectorEnvironmentInformation() prometheus.Collector {
+func NewCollectorEnvironmentInformation(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_environment_information",
 			Help: "Information about the environment",
 		},
-		append(environmentLabels, environmentInformationLabels...),
+		withLabels(append(environmentLabels, environmentInformationLabels...), extraLabels),
 	)
 }
 
 // NewCollectorID returns a new collector for the gitlab_ci_pipeline_id metric.
-func NewCollectorID() prometheus.Collector {
+func NewCollectorID(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_id",
 			Help: "ID of the most recent pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorJobArtifactSizeBytes returns a new collector for the gitlab_ci_pipeline_job_artifact_size_bytes metric.
-func NewCollectorJobArtifactSizeBytes() prometheus.Collector {
+func NewCollectorJobArtifactSizeBytes(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_job_artifact_size_bytes",
 			Help: "Artifact size in bytes (sum of all of them) of the most recent job",
 		},
-		append(defaultLabels, jobLabels...),
+		withLabels(append(defaultLabels, jobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobAttemptsToSucceed returns a new collector for the gitlab_ci_pipeline_job_attempts_to_succeed metric.
+func NewCollectorJobAttemptsToSucceed(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_job_attempts_to_succeed",
+			Help: "Number of attempts it took for the job of the most recent pipeline to succeed",
+		},
+		withLabels(append(defaultLabels, criticalPathJobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobCriticalPath returns a new collector for the gitlab_ci_pipeline_job_critical_path metric.
+func NewCollectorJobCriticalPath(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_job_critical_path",
+			Help: "Whether the job is on the critical path of the most recently finished pipeline",
+		},
+		withLabels(append(defaultLabels, criticalPathJobLabels...), extraLabels),
 	)
 }
 
 // NewCollectorJobDurationSeconds returns a new collector for the gitlab_ci_pipeline_job_duration_seconds metric.
-func NewCollectorJobDurationSeconds() prometheus.Collector {
+func NewCollectorJobDurationSeconds(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_job_duration_seconds",
 			Help: "Duration in seconds of the most recent job",
 		},
-		append(defaultLabels, jobLabels...),
+		withLabels(append(defaultLabels, jobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobFailureCount returns a new collector for the gitlab_ci_pipeline_job_failure_count metric.
+func NewCollectorJobFailureCount(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gitlab_ci_pipeline_job_failure_count",
+			Help: "Number of failed jobs",
+		},
+		withLabels(append(defaultLabels, jobFailureLabels...), extraLabels),
 	)
 }
 
 // NewCollectorJobID returns a new collector for the gitlab_ci_pipeline_job_id metric.
-func NewCollectorJobID() prometheus.Collector {
+func NewCollectorJobID(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_job_id",
 			Help: "ID of the most recent job",
 		},
-		append(defaultLabels, jobLabels...),
+		withLabels(append(defaultLabels, jobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobManualPlayDelaySeconds returns a new collector for the gitlab_ci_pipeline_job_manual_play_delay_seconds metric.
+func NewCollectorJobManualPlayDelaySeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_job_manual_play_delay_seconds",
+			Help: "Duration in seconds between the creation of the manual job of the most recent pipeline and the moment it got played",
+		},
+		withLabels(append(defaultLabels, criticalPathJobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobManualWaiting returns a new collector for the gitlab_ci_pipeline_job_manual_waiting metric.
+func NewCollectorJobManualWaiting(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_job_manual_waiting",
+			Help: "Whether the manual job of the most recent pipeline is waiting to be played",
+		},
+		withLabels(append(defaultLabels, criticalPathJobLabels...), extraLabels),
 	)
 }
 
 // NewCollectorJobQueuedDurationSeconds returns a new collector for the gitlab_ci_pipeline_job_queued_duration_seconds metric.
-func NewCollectorJobQueuedDurationSeconds() prometheus.Collector {
+func NewCollectorJobQueuedDurationSeconds(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_job_queued_duration_seconds",
 			Help: "Duration in seconds the most recent job has been queued before starting",
 		},
-		append(defaultLabels, jobLabels...),
+		withLabels(append(defaultLabels, jobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobRetryCount returns a new collector for the gitlab_ci_pipeline_job_retry_count metric.
+func NewCollectorJobRetryCount(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gitlab_ci_pipeline_job_retry_count",
+			Help: "Number of retries of a job",
+		},
+		withLabels(append(defaultLabels, jobRetryLabels...), extraLabels),
 	)
 }
 
 // NewCollectorJobRunCount returns a new collector for the gitlab_ci_pipeline_job_run_count metric.
-func NewCollectorJobRunCount() prometheus.Collector {
+func NewCollectorJobRunCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gitlab_ci_pipeline_job_run_count",
 			Help: "Number of executions of a job",
 		},
-		append(defaultLabels, jobLabels...),
+		withLabels(append(defaultLabels, jobLabels...), extraLabels),
 	)
 }
 
 // NewCollectorJobStatus returns a new collector for the gitlab_ci_pipeline_job_status metric.
-func NewCollectorJobStatus() prometheus.Collector {
+func NewCollectorJobStatus(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_job_status",
 			Help: "Status of the most recent job",
 		},
-		append(defaultLabels, append(jobLabels, statusLabels...)...),
+		withLabels(append(defaultLabels, append(jobLabels, statusLabels...)...), extraLabels),
 	)
 }
 
 // NewCollectorJobTimestamp returns a new collector for the gitlab_ci_pipeline_job_timestamp metric.
-func NewCollectorJobTimestamp() prometheus.Collector {
+func NewCollectorJobTimestamp(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_job_timestamp",
 			Help: "Creation date timestamp of the most recent job",
 		},
-		append(defaultLabels, jobLabels...),
+		withLabels(append(defaultLabels, jobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorJobWaitingForResourceDurationSeconds returns a new collector for the gitlab_ci_pipeline_job_waiting_for_resource_duration_seconds metric.
+func NewCollectorJobWaitingForResourceDurationSeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_job_waiting_for_resource_duration_seconds",
+			Help: "Duration in seconds since the creation of the job of the most recent pipeline which is waiting for its resource group",
+		},
+		withLabels(append(defaultLabels, criticalPathJobLabels...), extraLabels),
+	)
+}
+
+// NewCollectorStageDurationSeconds returns a new collector for the gitlab_ci_pipeline_stage_duration_seconds metric.
+func NewCollectorStageDurationSeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_stage_duration_seconds",
+			Help: "Wall-clock duration in seconds of the stage of the most recent pipeline, from the start of its first job to the end of its last one",
+		},
+		withLabels(append(defaultLabels, stageLabels...), extraLabels),
+	)
+}
+
+// NewCollectorStageJobCount returns a new collector for the gitlab_ci_pipeline_stage_job_count metric.
+func NewCollectorStageJobCount(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_stage_job_count",
+			Help: "Number of jobs of the stage of the most recent pipeline",
+		},
+		withLabels(append(defaultLabels, stageLabels...), extraLabels),
+	)
+}
+
+// NewCollectorStageJobsDurationSeconds returns a new collector for the gitlab_ci_pipeline_stage_jobs_duration_seconds metric.
+func NewCollectorStageJobsDurationSeconds(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_stage_jobs_duration_seconds",
+			Help: "Cumulative duration in seconds of the jobs of the stage of the most recent pipeline",
+		},
+		withLabels(append(defaultLabels, stageLabels...), extraLabels),
+	)
+}
+
+// NewCollectorStageStatus returns a new collector for the gitlab_ci_pipeline_stage_status metric.
+func NewCollectorStageStatus(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_pipeline_stage_status",
+			Help: "Status of the stage of the most recent pipeline, the worst one of its jobs",
+		},
+		withLabels(append(defaultLabels, append(stageLabels, statusLabels...)...), extraLabels),
 	)
 }
 
 // NewCollectorStatus returns a new collector for the gitlab_ci_pipeline_status metric.
-func NewCollectorStatus() prometheus.Collector {
+func NewCollectorStatus(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_status",
 			Help: "Status of the most recent pipeline",
 		},
-		append(defaultLabels, "status"),
+		withLabels(append(defaultLabels, "status"), extraLabels),
 	)
 }
 
 // NewCollectorTimestamp returns a new collector for the gitlab_ci_pipeline_timestamp metric.
-func NewCollectorTimestamp() prometheus.Collector {
+func NewCollectorTimestamp(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_timestamp",
 			Help: "Timestamp of the last update of the most recent pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorRunCount returns a new collector for the gitlab_ci_pipeline_run_count metric.
-func NewCollectorRunCount() prometheus.Collector {
+func NewCollectorRunCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "gitlab_ci_pipeline_run_count",
 			Help: "Number of executions of a pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestReportTotalTime returns a new collector for the gitlab_ci_pipeline_test_report_total_time metric.
-func NewCollectorTestReportTotalTime() prometheus.Collector {
+func NewCollectorTestReportTotalTime(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_report_total_time",
 			Help: "Duration in seconds of all the tests in the most recently finished pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestReportTotalCount returns a new collector for the gitlab_ci_pipeline_test_report_total_count metric.
-func NewCollectorTestReportTotalCount() prometheus.Collector {
+func NewCollectorTestReportTotalCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_report_total_count",
 			Help: "Number of total tests in the most recently finished pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestReportSuccessCount returns a new collector for the gitlab_ci_pipeline_test_report_success_count metric.
-func NewCollectorTestReportSuccessCount() prometheus.Collector {
+func NewCollectorTestReportSuccessCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_report_success_count",
 			Help: "Number of successful tests in the most recently finished pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestReportFailedCount returns a new collector for the gitlab_ci_pipeline_test_report_failed_count metric.
-func NewCollectorTestReportFailedCount() prometheus.Collector {
+func NewCollectorTestReportFailedCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_report_failed_count",
 			Help: "Number of failed tests in the most recently finished pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestReportSkippedCount returns a new collector for the gitlab_ci_pipeline_test_report_skipped_count metric.
-func NewCollectorTestReportSkippedCount() prometheus.Collector {
+func NewCollectorTestReportSkippedCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_report_skipped_count",
 			Help: "Number of skipped tests in the most recently finished pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestReportErrorCount returns a new collector for the gitlab_ci_pipeline_test_report_error_count metric.
-func NewCollectorTestReportErrorCount() prometheus.Collector {
+func NewCollectorTestReportErrorCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_report_error_count",
 			Help: "Number of errored tests in the most recently finished pipeline",
 		},
-		defaultLabels,
+		withLabels(defaultLabels, extraLabels),
 	)
 }
 
 // NewCollectorTestSuiteTotalTime returns a new collector for the gitlab_ci_pipeline_test_suite_total_time metric.
-func NewCollectorTestSuiteTotalTime() prometheus.Collector {
+func NewCollectorTestSuiteTotalTime(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_suite_total_time",
 			Help: "Duration in seconds for the test suite",
 		},
-		append(defaultLabels, testSuiteLabels...),
+		withLabels(append(defaultLabels, testSuiteLabels...), extraLabels),
 	)
 }
 
 // NewCollectorTestSuiteTotalCount returns a new collector for the gitlab_ci_pipeline_test_suite_total_count metric.
-func NewCollectorTestSuiteTotalCount() prometheus.Collector {
+func NewCollectorTestSuiteTotalCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_suite_total_count",
 			Help: "Number of total tests for the test suite",
 		},
-		append(defaultLabels, testSuiteLabels...),
+		withLabels(append(defaultLabels, testSuiteLabels...), extraLabels),
 	)
 }
 
 // NewCollectorTestSuiteSuccessCount returns a new collector for the gitlab_ci_pipeline_test_suite_success_count metric.
-func NewCollectorTestSuiteSuccessCount() prometheus.Collector {
+func NewCollectorTestSuiteSuccessCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_suite_success_count",
 			Help: "Number of successful tests for the test suite",
 		},
-		append(defaultLabels, testSuiteLabels...),
+		withLabels(append(defaultLabels, testSuiteLabels...), extraLabels),
 	)
 }
 
 // NewCollectorTestSuiteFailedCount returns a new collector for the gitlab_ci_pipeline_test_suite_failed_count metric.
-func NewCollectorTestSuiteFailedCount() prometheus.Collector {
+func NewCollectorTestSuiteFailedCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_suite_failed_count",
 			Help: "Number of failed tests for the test suite",
 		},
-		append(defaultLabels, testSuiteLabels...),
+		withLabels(append(defaultLabels, testSuiteLabels...), extraLabels),
 	)
 }
 
 // NewCollectorTestSuiteSkippedCount returns a new collector for the gitlab_ci_pipeline_test_suite_skipped_count metric.
-func NewCollectorTestSuiteSkippedCount() prometheus.Collector {
+func NewCollectorTestSuiteSkippedCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_suite_skipped_count",
 			Help: "Number of skipped tests for the test suite",
 		},
-		append(defaultLabels, testSuiteLabels...),
+		withLabels(append(defaultLabels, testSuiteLabels...), extraLabels),
 	)
 }
 
 // NewCollectorTestSuiteErrorCount returns a new collector for the gitlab_ci_pipeline_test_suite_error_count metric.
-func NewCollectorTestSuiteErrorCount() prometheus.Collector {
+func NewCollectorTestSuiteErrorCount(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_suite_error_count",
 			Help: "Number of errors for the test suite",
 		},
-		append(defaultLabels, testSuiteLabels...),
+		withLabels(append(defaultLabels, testSuiteLabels...), extraLabels),
 	)
 }
 
 // NewCollectorTestCaseExecutionTime returns a new collector for the gitlab_ci_pipeline_test_case_execution_time metric.
-func NewCollectorTestCaseExecutionTime() prometheus.Collector {
+func NewCollectorTestCaseExecutionTime(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_case_execution_time",
 			Help: "Duration in seconds for the test case",
 		},
-		append(defaultLabels, append(testSuiteLabels, testCaseLabels...)...),
+		withLabels(append(defaultLabels, append(testSuiteLabels, testCaseLabels...)...), extraLabels),
 	)
 }
 
 // NewCollectorTestCaseStatus returns a new collector for the gitlab_ci_pipeline_test_case_status metric.
-func NewCollectorTestCaseStatus() prometheus.Collector {
+func NewCollectorTestCaseStatus(extraLabels ...string) prometheus.Collector {
 	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "gitlab_ci_pipeline_test_case_status",
 			Help: "Status of the test case in most recent job",
 		},
-		append(defaultLabels, append(testSuiteLabels, append(testCaseLabels, statusLabels...)...)...),
+		withLabels(append(defaultLabels, append(testSuiteLabels, append(testCaseLabels, statusLabels...)...)...), extraLabels),
+	)
+}
+
+// NewCollectorWebhookDisabledUntilTimestamp returns a new collector for the gitlab_ci_webhook_disabled_until_timestamp metric.
+func NewCollectorWebhookDisabledUntilTimestamp(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_webhook_disabled_until_timestamp",
+			Help: "Timestamp until which GitLab has temporarily disabled the webhook registered by the exporter, 0 if not disabled",
+		},
+		withLabels(webhookLabels, extraLabels),
 	)
 }
+
+// NewCollectorWebhookStatus returns a new collector for the gitlab_ci_webhook_status metric.
+func NewCollectorWebhookStatus(extraLabels ...string) prometheus.Collector {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gitlab_ci_webhook_status",
+			Help: "Status of the webhook registered by the exporter",
+		},
+		withLabels(append(webhookLabels, statusLabels...), extraLabels),
+	)
+}
+
+func withLabels(labels []string, extraLabels []string) []string {
+	return append(append([]string{}, labels...), extraLabels...)
+}
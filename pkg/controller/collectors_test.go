@@ -13,10 +13,27 @@ func TestNewCollectorFunctions(t *testing.T) {
 		NewInternalCollectorEnvironmentsCount,
 		NewInternalCollectorExecutedTasksCount,
 		NewInternalCollectorGitLabAPIRequestsCount,
+		NewInternalCollectorIsLeader,
 		NewInternalCollectorMetricsCount,
 		NewInternalCollectorProjectsCount,
 		NewInternalCollectorRefsCount,
+	} {
+		c := f()
+		assert.NotNil(t, c)
+		assert.IsType(t, &prometheus.GaugeVec{}, c)
+	}
+
+	assert.IsType(t, &prometheus.HistogramVec{}, NewInternalCollectorMetricsPullIntervalSeconds())
+
+	for _, f := range []func(...string) prometheus.Collector{
+		NewCollectorAverageParallelism,
+		NewCollectorBlockedDurationSeconds,
+		NewCollectorBridgeCausedFailure,
+		NewCollectorBridgeDownstreamDurationSeconds,
+		NewCollectorBridgeDownstreamPipelineID,
+		NewCollectorBridgeDownstreamStatus,
 		NewCollectorCoverage,
+		NewCollectorCriticalPathDurationSeconds,
 		NewCollectorDurationSeconds,
 		NewCollectorEnvironmentBehindCommitsCount,
 		NewCollectorEnvironmentBehindDurationSeconds,
@@ -27,12 +44,21 @@ func TestNewCollectorFunctions(t *testing.T) {
 		NewCollectorEnvironmentInformation,
 		NewCollectorID,
 		NewCollectorJobArtifactSizeBytes,
+		NewCollectorJobAttemptsToSucceed,
+		NewCollectorJobCriticalPath,
 		NewCollectorJobDurationSeconds,
 		NewCollectorJobID,
+		NewCollectorJobManualPlayDelaySeconds,
+		NewCollectorJobManualWaiting,
 		NewCollectorJobQueuedDurationSeconds,
 		NewCollectorJobStatus,
 		NewCollectorJobTimestamp,
+		NewCollectorJobWaitingForResourceDurationSeconds,
 		NewCollectorQueuedDurationSeconds,
+		NewCollectorStageDurationSeconds,
+		NewCollectorStageJobCount,
+		NewCollectorStageJobsDurationSeconds,
+		NewCollectorStageStatus,
 		NewCollectorStatus,
 		NewCollectorTimestamp,
 	} {
@@ -41,7 +67,9 @@ func TestNewCollectorFunctions(t *testing.T) {
 		assert.IsType(t, &prometheus.GaugeVec{}, c)
 	}
 
-	for _, f := range []func() prometheus.Collector{
+	for _, f := range []func(...string) prometheus.Collector{
+		NewCollectorJobFailureCount,
+		NewCollectorJobRetryCount,
 		NewCollectorJobRunCount,
 		NewCollectorRunCount,
 		NewCollectorEnvironmentDeploymentCount,
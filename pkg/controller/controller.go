@@ -2,6 +2,10 @@ package controller
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -18,6 +22,7 @@ import (
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/gitlab"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/ratelimit"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
@@ -28,10 +33,13 @@ const tracerName = "gitlab-ci-pipelines-exporter"
 // Controller holds the necessary clients to run the app and handle requests.
 type Controller struct {
 	Config         config.Config
-	Redis          *redis.Client
+	Redis          redis.UniversalClient
 	Gitlab         *gitlab.Client
 	Store          store.Store
 	TaskController TaskController
+	LeaderElection *monitor.LeaderElectionStatus
+
+	GarbageCollectionCollectors GarbageCollectionCollectors
 
 	// UUID is used to identify this controller/process amongst others when
 	// the exporter is running in cluster mode, leveraging Redis.
@@ -51,16 +59,34 @@ func New(ctx context.Context, cfg config.Config, version string) (c Controller,
 		return
 	}
 
-	c.TaskController = NewTaskController(ctx, c.Redis, cfg.Gitlab.MaximumJobsQueueSize)
+	c.TaskController = NewTaskController(
+		ctx,
+		c.Redis,
+		cfg.Redis.Namespace,
+		cfg.Gitlab.MaximumJobsQueueSize,
+		cfg.Scheduler.Queues,
+		cfg.Server.Webhook.Queue,
+	)
 	c.registerTasks()
+	c.GarbageCollectionCollectors = NewGarbageCollectionCollectors()
 
 	var redisStore *store.Redis
 	if c.Redis != nil {
-		redisStore = store.NewRedisStore(c.Redis, store.WithTTLConfig(&store.RedisTTLConfig{
-			Project: cfg.Redis.ProjectTTL,
-			Ref:     cfg.Redis.RefTTL,
-			Metric:  cfg.Redis.MetricTTL,
-		}))
+		redisStore = newRedisStore(c.Redis, cfg.Redis)
+
+		if cfg.Redis.MigrateToNamespace {
+			migrated, err := redisStore.MigrateToNamespace(ctx)
+			if err != nil {
+				return c, errors.Wrap(err, "migrating the redis keys to the namespace")
+			}
+
+			log.WithContext(ctx).
+				WithFields(log.Fields{
+					"namespace":          cfg.Redis.Namespace,
+					"migrated-key-count": migrated,
+				}).
+				Info("migrated the redis keys to the namespace")
+		}
 	}
 
 	c.Store = store.New(ctx, redisStore, c.Config.Projects)
@@ -69,6 +95,33 @@ func New(ctx context.Context, cfg config.Config, version string) (c Controller,
 		return
 	}
 
+	if c.Redis != nil {
+		c.ScheduleRedisSetKeepalive(ctx)
+	}
+
+	// Only the leader schedules the periodic tasks, the other instances process them
+	c.LeaderElection = &monitor.LeaderElectionStatus{}
+	c.campaign(ctx)
+
+	if c.Redis != nil {
+		c.ScheduleLeaderElection(ctx)
+	}
+
+	// Purge the queues when we start, unless other instances are still processing them
+	purged := c.LeaderElection.IsLeader() && c.isSoleInstance(ctx)
+	if purged {
+		c.TaskController.purgeQueues(ctx)
+	}
+
+	// The tasks queued by crashed instances would otherwise not be scheduled again,
+	// they are queued back if they got purged
+	c.RecoverOrphanedTasks(ctx, purged)
+
+	if c.Redis != nil {
+		c.TaskController.startConsumers(ctx)
+		c.ScheduleOrphanedTasksRecovery(ctx)
+	}
+
 	// Start the scheduler
 	c.Schedule(ctx, cfg.Pull, cfg.GarbageCollect)
 
@@ -84,6 +137,7 @@ func (c *Controller) registerTasks() {
 		schemas.TaskTypePullEnvironmentMetrics:       c.TaskHandlerPullEnvironmentMetrics,
 		schemas.TaskTypePullEnvironmentsFromProject:  c.TaskHandlerPullEnvironmentsFromProject,
 		schemas.TaskTypePullEnvironmentsFromProjects: c.TaskHandlerPullEnvironmentsFromProjects,
+		schemas.TaskTypeProcessWebhookEvent:          c.TaskHandlerProcessWebhookEvent,
 		schemas.TaskTypePullMetrics:                  c.TaskHandlerPullMetrics,
 		schemas.TaskTypePullProject:                  c.TaskHandlerPullProject,
 		schemas.TaskTypePullProjectsFromWildcard:     c.TaskHandlerPullProjectsFromWildcard,
@@ -91,18 +145,22 @@ func (c *Controller) registerTasks() {
 		schemas.TaskTypePullRefMetrics:               c.TaskHandlerPullRefMetrics,
 		schemas.TaskTypePullRefsFromProject:          c.TaskHandlerPullRefsFromProject,
 		schemas.TaskTypePullRefsFromProjects:         c.TaskHandlerPullRefsFromProjects,
+		schemas.TaskTypeReconcileMetrics:             c.TaskHandlerReconcileMetrics,
 	} {
 		_, _ = c.TaskController.TaskMap.Register(string(n), &taskq.TaskConfig{
-			Handler:    h,
+			Handler:    c.instrumentTaskHandler(n, h),
 			RetryLimit: 1,
 		})
 	}
 }
 
 func (c *Controller) unqueueTask(ctx context.Context, tt schemas.TaskType, uniqueID string) {
-	if err := c.Store.UnqueueTask(ctx, tt, uniqueID); err != nil {
+	q := taskQueue(ctx, tt)
+
+	if err := c.Store.UnqueueTask(ctx, q, tt, uniqueID); err != nil {
 		log.WithContext(ctx).
 			WithFields(log.Fields{
+				"task_queue":     q,
 				"task_type":      tt,
 				"task_unique_id": uniqueID,
 			}).
@@ -161,7 +219,7 @@ func (c *Controller) configureGitlab(cfg config.Gitlab, version string) (err err
 	var rl ratelimit.Limiter
 
 	if c.Redis != nil {
-		rl = ratelimit.NewRedisLimiter(c.Redis, cfg.MaximumRequestsPerSecond)
+		rl = ratelimit.NewRedisLimiter(c.Redis, c.Config.Redis.Namespace, cfg.MaximumRequestsPerSecond)
 	} else {
 		rl = ratelimit.NewLocalLimiter(cfg.MaximumRequestsPerSecond, cfg.BurstableRequestsPerSecond)
 	}
@@ -182,22 +240,18 @@ func (c *Controller) configureRedis(ctx context.Context, config *config.Redis) (
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:configureRedis")
 	defer span.End()
 
-	if len(config.URL) <= 0 {
-		log.Debug("redis url is not configured, skipping configuration & using local driver")
+	if !config.IsEnabled() {
+		log.Debug("redis is not configured, skipping configuration & using local driver")
 
 		return
 	}
 
-	log.Info("redis url configured, initializing connection..")
-
-	var opt *redis.Options
+	log.Info("redis configured, initializing connection..")
 
-	if opt, err = redis.ParseURL(config.URL); err != nil {
+	if c.Redis, err = newRedisClient(*config); err != nil {
 		return
 	}
 
-	c.Redis = redis.NewClient(opt)
-
 	if err = redisotel.InstrumentTracing(c.Redis); err != nil {
 		return
 	}
@@ -210,3 +264,144 @@ func (c *Controller) configureRedis(ctx context.Context, config *config.Redis) (
 
 	return
 }
+
+// NewRedisStore connects onto the configured Redis and returns the store backed by it,
+// in order to access the state of the exporter from outside of a running process.
+func NewRedisStore(ctx context.Context, cfg config.Redis) (*store.Redis, error) {
+	c := Controller{}
+	if err := c.configureRedis(ctx, &cfg); err != nil {
+		return nil, err
+	}
+
+	if c.Redis == nil {
+		return nil, errors.New("redis is not configured")
+	}
+
+	return newRedisStore(c.Redis, cfg), nil
+}
+
+// GarbageCollectDryRun connects onto the configured Redis and GitLab in order to compute
+// what the garbage collections would delete out of the store of the running exporters.
+func GarbageCollectDryRun(ctx context.Context, cfg config.Config, version string) (GarbageCollectionReport, error) {
+	c := Controller{Config: cfg}
+	if err := c.configureRedis(ctx, &cfg.Redis); err != nil {
+		return nil, err
+	}
+
+	if c.Redis == nil {
+		return nil, errors.New("redis is not configured")
+	}
+
+	c.Store = newRedisStore(c.Redis, cfg.Redis)
+
+	// The GitLab API rate limit gets shared with the running exporters through Redis
+	if err := c.configureGitlab(cfg.Gitlab, version); err != nil {
+		return nil, err
+	}
+
+	return c.GarbageCollectDryRun(ctx)
+}
+
+func newRedisStore(client redis.UniversalClient, cfg config.Redis) *store.Redis {
+	opts := []store.RedisStoreOptions{
+		store.WithTTLConfig(&store.RedisTTLConfig{
+			Project: cfg.ProjectTTL,
+			Ref:     cfg.RefTTL,
+			Metric:  cfg.MetricTTL,
+		}),
+		store.WithNamespace(cfg.Namespace),
+	}
+
+	if _, ok := client.(*redis.ClusterClient); ok {
+		opts = append(opts, store.WithHashTag(cfg.Cluster.HashTag))
+	}
+
+	return store.NewRedisStore(client, opts...)
+}
+
+// newRedisClient returns a client for either a Redis Sentinel, a Redis Cluster
+// or a single Redis node, in this order of precedence.
+func newRedisClient(cfg config.Redis) (redis.UniversalClient, error) {
+	var (
+		tlsConfig *tls.Config
+		err       error
+	)
+
+	if cfg.TLS.IsEnabled() {
+		if tlsConfig, err = newRedisTLSConfig(cfg.TLS); err != nil {
+			return nil, errors.Wrap(err, "configuring redis tls")
+		}
+	}
+
+	// A single node is configured out of its URL, along with all the options it can carry
+	if cfg.Sentinel.MasterName == "" && len(cfg.Cluster.Addresses) == 0 {
+		opts, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Username != "" {
+			opts.Username = cfg.Username
+		}
+
+		if cfg.Password != "" {
+			opts.Password = cfg.Password
+		}
+
+		if tlsConfig != nil {
+			opts.TLSConfig = tlsConfig
+		}
+
+		return redis.NewClient(opts), nil
+	}
+
+	opts := &redis.UniversalOptions{
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+		TLSConfig: tlsConfig,
+	}
+
+	if cfg.Sentinel.MasterName != "" {
+		opts.MasterName = cfg.Sentinel.MasterName
+		opts.Addrs = cfg.Sentinel.Addresses
+		opts.SentinelUsername = cfg.Sentinel.Username
+		opts.SentinelPassword = cfg.Sentinel.Password
+		opts.DB = cfg.Sentinel.DB
+	} else {
+		opts.Addrs = cfg.Cluster.Addresses
+		opts.IsClusterMode = true
+	}
+
+	return redis.NewUniversalClient(opts), nil
+}
+
+// newRedisTLSConfig returns the TLS configuration used to connect onto Redis.
+func newRedisTLSConfig(cfg config.RedisTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading client certificate")
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading CA file")
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificate found in CA file '%s'", cfg.CAFile)
+		}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+const (
+	gcEntityProjects     = "projects"
+	gcEntityEnvironments = "environments"
+	gcEntityRefs         = "refs"
+	gcEntityMetrics      = "metrics"
+)
+
+// GarbageCollectionCollectors holds the collectors reporting the progress of the garbage collections.
+type GarbageCollectionCollectors struct {
+	DeletedCount prometheus.Collector
+	RunsCount    prometheus.Collector
+	ScannedCount prometheus.Collector
+	SlicesCount  prometheus.Collector
+}
+
+// NewGarbageCollectionCollectors ..
+func NewGarbageCollectionCollectors() GarbageCollectionCollectors {
+	return GarbageCollectionCollectors{
+		DeletedCount: NewInternalCollectorGarbageCollectionDeletedCount(),
+		RunsCount:    NewInternalCollectorGarbageCollectionRunsCount(),
+		ScannedCount: NewInternalCollectorGarbageCollectionScannedCount(),
+		SlicesCount:  NewInternalCollectorGarbageCollectionSlicesCount(),
+	}
+}
+
+func (gcc GarbageCollectionCollectors) recordDeletion(entity, reason string) {
+	if gcc.DeletedCount != nil {
+		gcc.DeletedCount.(*prometheus.CounterVec).With(prometheus.Labels{"entity": entity, "reason": reason}).Inc()
+	}
+}
+
+func (gcc GarbageCollectionCollectors) recordRun(entity string) {
+	if gcc.RunsCount != nil {
+		gcc.RunsCount.(*prometheus.CounterVec).With(prometheus.Labels{"entity": entity}).Inc()
+	}
+}
+
+func (gcc GarbageCollectionCollectors) recordScanned(entity string, count int) {
+	if gcc.ScannedCount != nil {
+		gcc.ScannedCount.(*prometheus.CounterVec).With(prometheus.Labels{"entity": entity}).Add(float64(count))
+	}
+}
+
+func (gcc GarbageCollectionCollectors) recordSlice(entity string) {
+	if gcc.SlicesCount != nil {
+		gcc.SlicesCount.(*prometheus.CounterVec).With(prometheus.Labels{"entity": entity}).Inc()
+	}
+}
+
+// GarbageCollectionReport holds, per kind of entity, what the garbage collections would delete.
+type GarbageCollectionReport map[string]*GarbageCollectionEntityReport
+
+// GarbageCollectionEntityReport ..
+type GarbageCollectionEntityReport struct {
+	// Number of entities in the store when the garbage collection started
+	Total int64 `json:"total"`
+
+	// Entities which would be deleted, grouped by reason
+	Deletions map[string][]string `json:"deletions"`
+
+	// Whether the garbage collection would be aborted as deleting too many entities
+	ThresholdExceeded bool `json:"threshold_exceeded"`
+}
+
+// DeletionsCount returns the number of entities which would be deleted.
+func (r GarbageCollectionEntityReport) DeletionsCount() (count int) {
+	for _, names := range r.Deletions {
+		count += len(names)
+	}
+
+	return
+}
+
+// garbageCollection keeps track of the deletions of a garbage collection of a kind of entity.
+type garbageCollection struct {
+	entity     string
+	dryRun     bool
+	threshold  int
+	total      int64
+	deleted    int64
+	collectors GarbageCollectionCollectors
+
+	thresholdExceeded bool
+
+	// For the entities garbage collected in slices, where to resume scanning them from, the number of
+	// deletions found by the pass checking them against the safety threshold and whether it is over
+	cursor  uint64
+	found   int64
+	checked bool
+
+	// Only populated when computing a report
+	report *GarbageCollectionEntityReport
+}
+
+// gcDeletion describes an entity to delete.
+type gcDeletion struct {
+	name   string
+	reason string
+	fields log.Fields
+}
+
+// newGarbageCollection returns a garbage collection of the entity, running dry
+// either if configured to or when computing the report. A garbage collection of the
+// metrics resumes the one left off by the previous time slice, if any, keeping the
+// number of stored metrics it started with for its safety threshold.
+func (c *Controller) newGarbageCollection(ctx context.Context, entity string, report GarbageCollectionReport) (gc *garbageCollection, err error) {
+	gc = &garbageCollection{
+		entity:     entity,
+		dryRun:     c.Config.GarbageCollect.DryRun || report != nil,
+		threshold:  c.Config.GarbageCollect.MaxDeletedPercentage,
+		collectors: c.GarbageCollectionCollectors,
+	}
+
+	if entity == gcEntityMetrics && !gc.dryRun {
+		var cursor schemas.GarbageCollectionCursor
+		if cursor, err = c.Store.GarbageCollectionCursor(ctx, entity); err != nil {
+			return nil, err
+		}
+
+		// The cursors stored by the previous releases do not hold the total, their runs start over
+		if cursor.Total > 0 {
+			gc.cursor = cursor.Position
+			gc.total = cursor.Total
+			gc.found = cursor.Found
+			gc.checked = cursor.Checked
+			gc.deleted = cursor.Deleted
+
+			return gc, nil
+		}
+	}
+
+	switch entity {
+	case gcEntityProjects:
+		gc.total, err = c.Store.ProjectsCount(ctx)
+	case gcEntityEnvironments:
+		gc.total, err = c.Store.EnvironmentsCount(ctx)
+	case gcEntityRefs:
+		gc.total, err = c.Store.RefsCount(ctx)
+	case gcEntityMetrics:
+		gc.total, err = c.Store.MetricsCount(ctx)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if report != nil {
+		gc.report = &GarbageCollectionEntityReport{
+			Total:     gc.total,
+			Deletions: make(map[string][]string),
+		}
+		report[entity] = gc.report
+	}
+
+	return gc, nil
+}
+
+// delete accounts for the deletions and, unless running dry, performs them using del. Nothing
+// gets deleted if it would make the garbage collection exceed the safety threshold, hence the
+// deletions of a run being passed all at once, except for the metrics which get checked by a
+// dedicated pass beforehand.
+func (gc *garbageCollection) delete(deletions []gcDeletion, del func() error) error {
+	if len(deletions) == 0 {
+		return nil
+	}
+
+	if gc.exceedsThreshold(gc.deleted + int64(len(deletions))) {
+		err := gc.thresholdError()
+
+		if !gc.dryRun {
+			return fmt.Errorf("%w, aborting", err)
+		}
+
+		if !gc.thresholdExceeded {
+			gc.thresholdExceeded = true
+
+			log.WithError(err).Warn("garbage collection safety threshold exceeded (dry run)")
+		}
+
+		if gc.report != nil {
+			gc.report.ThresholdExceeded = true
+		}
+	}
+
+	if !gc.dryRun {
+		if err := del(); err != nil {
+			return err
+		}
+	}
+
+	gc.deleted += int64(len(deletions))
+
+	noun := strings.TrimSuffix(gc.entity, "s")
+
+	for _, d := range deletions {
+		if gc.dryRun {
+			if gc.report != nil {
+				gc.report.Deletions[d.reason] = append(gc.report.Deletions[d.reason], d.name)
+
+				continue
+			}
+
+			log.WithFields(d.fields).
+				WithField("reason", d.reason).
+				Info(fmt.Sprintf("%s would have been deleted from the store (dry run)", noun))
+
+			continue
+		}
+
+		gc.collectors.recordDeletion(gc.entity, d.reason)
+
+		log.WithFields(d.fields).
+			WithField("reason", d.reason).
+			Info(fmt.Sprintf("deleted %s from the store", noun))
+	}
+
+	return nil
+}
+
+// checking returns whether the deletions have to be checked against the safety threshold before being
+// performed, which is only required for the entities garbage collected over several slices as the other
+// ones get all deleted at once.
+func (gc *garbageCollection) checking() bool {
+	return !gc.dryRun && gc.threshold > 0 && !gc.checked
+}
+
+// check accounts for the deletions found by the pass checking them against the safety threshold.
+func (gc *garbageCollection) check(count int) error {
+	gc.found += int64(count)
+
+	if gc.exceedsThreshold(gc.found) {
+		return fmt.Errorf("%w, aborting", gc.thresholdError())
+	}
+
+	return nil
+}
+
+func (gc *garbageCollection) exceedsThreshold(deleted int64) bool {
+	return gc.threshold > 0 && gc.total > 0 && deleted*100 > gc.total*int64(gc.threshold)
+}
+
+func (gc *garbageCollection) thresholdError() error {
+	return fmt.Errorf(
+		"the '%s' garbage collection would delete more than %d%% of the %d stored ones",
+		gc.entity,
+		gc.threshold,
+		gc.total,
+	)
+}
+
+// GarbageCollectDryRun computes what each of the garbage collections would delete. As nothing
+// gets deleted, the entities depending on the ones which would be deleted are not reported.
+func (c *Controller) GarbageCollectDryRun(ctx context.Context) (report GarbageCollectionReport, err error) {
+	report = make(GarbageCollectionReport)
+
+	for _, gcFunc := range []struct {
+		entity string
+		run    func(context.Context, *garbageCollection) error
+	}{
+		{gcEntityProjects, c.garbageCollectProjects},
+		{gcEntityEnvironments, c.garbageCollectEnvironments},
+		{gcEntityRefs, c.garbageCollectRefs},
+		{gcEntityMetrics, func(ctx context.Context, gc *garbageCollection) error {
+			_, err := c.garbageCollectMetrics(ctx, gc, time.Time{})
+
+			return err
+		}},
+	} {
+		gc, err := c.newGarbageCollection(ctx, gcFunc.entity, report)
+		if err != nil {
+			return report, err
+		}
+
+		if err = gcFunc.run(ctx, gc); err != nil {
+			return report, fmt.Errorf("computing the '%s' garbage collection: %w", gcFunc.entity, err)
+		}
+	}
+
+	for _, r := range report {
+		for reason := range r.Deletions {
+			slices.Sort(r.Deletions[reason])
+		}
+	}
+
+	return report, nil
+}
+
+// metricName returns a readable identifier of the metric out of its kind and labels.
+func metricName(m schemas.Metric) string {
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, m.Labels[k]))
+	}
+
+	return fmt.Sprintf("metric-kind=%d {%s}", m.Kind, strings.Join(pairs, ","))
+}
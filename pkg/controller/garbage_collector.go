@@ -2,8 +2,12 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"reflect"
 	"regexp"
+	"slices"
+	"time"
 
 	"dario.cat/mergo"
 	log "github.com/sirupsen/logrus"
@@ -14,6 +18,15 @@ import (
 
 // GarbageCollectProjects ..
 func (c *Controller) GarbageCollectProjects(ctx context.Context) error {
+	gc, err := c.newGarbageCollection(ctx, gcEntityProjects, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.garbageCollectProjects(ctx, gc)
+}
+
+func (c *Controller) garbageCollectProjects(ctx context.Context, gc *garbageCollection) error {
 	log.Info("starting 'projects' garbage collection")
 	defer log.Info("ending 'projects' garbage collection")
 
@@ -40,25 +53,37 @@ func (c *Controller) GarbageCollectProjects(ctx context.Context) error {
 		}
 	}
 
-	log.WithFields(log.Fields{
-		"projects-count": len(storedProjects),
-	}).Debug("found projects to garbage collect")
+	deletions := make([]gcDeletion, 0, len(storedProjects))
+	for _, p := range storedProjects {
+		deletions = append(deletions, projectDeletion(p, "not-expected"))
+	}
 
-	for k, p := range storedProjects {
-		if err = c.Store.DelProject(ctx, k); err != nil {
-			return err
-		}
+	return gc.delete(deletions, func() error {
+		for k, p := range storedProjects {
+			if err := c.Store.DelProject(ctx, k); err != nil {
+				return err
+			}
 
-		log.WithFields(log.Fields{
-			"project-name": p.Name,
-		}).Info("deleted project from the store")
-	}
+			if c.isWebhookAutoRegisterEnabled() {
+				c.unregisterWebhook(ctx, p)
+			}
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // GarbageCollectEnvironments ..
 func (c *Controller) GarbageCollectEnvironments(ctx context.Context) error {
+	gc, err := c.newGarbageCollection(ctx, gcEntityEnvironments, nil)
+	if err != nil {
+		return err
+	}
+
+	return c.garbageCollectEnvironments(ctx, gc)
+}
+
+func (c *Controller) garbageCollectEnvironments(ctx context.Context, gc *garbageCollection) error {
 	log.Info("starting 'environments' garbage collection")
 	defer log.Info("ending 'environments' garbage collection")
 
@@ -69,6 +94,17 @@ func (c *Controller) GarbageCollectEnvironments(ctx context.Context) error {
 
 	envProjects := make(map[schemas.Project]bool)
 
+	// The environments only get deleted once all of them have been checked
+	// against the safety threshold
+	var deletions []gcDeletion
+
+	deletedEnvs := make(map[schemas.EnvironmentKey]bool)
+
+	deleteEnvironment := func(env schemas.Environment, reason string) {
+		deletedEnvs[env.Key()] = true
+		deletions = append(deletions, environmentDeletion(env, reason))
+	}
+
 	for _, env := range storedEnvironments {
 		p := schemas.NewProject(env.ProjectName)
 
@@ -79,9 +115,7 @@ func (c *Controller) GarbageCollectEnvironments(ctx context.Context) error {
 
 		// If the project does not exist anymore, delete the environment
 		if !projectExists {
-			if err = deleteEnv(ctx, c.Store, env, "non-existent-project"); err != nil {
-				return err
-			}
+			deleteEnvironment(env, "non-existent-project")
 
 			continue
 		}
@@ -92,9 +126,7 @@ func (c *Controller) GarbageCollectEnvironments(ctx context.Context) error {
 
 		// If the environment is not configured to be pulled anymore, delete it
 		if !p.Pull.Environments.Enabled {
-			if err = deleteEnv(ctx, c.Store, env, "project-pull-environments-disabled"); err != nil {
-				return err
-			}
+			deleteEnvironment(env, "project-pull-environments-disabled")
 
 			continue
 		}
@@ -106,15 +138,13 @@ func (c *Controller) GarbageCollectEnvironments(ctx context.Context) error {
 		// If the environment is not configured to be pulled anymore, delete it
 		re := regexp.MustCompile(p.Pull.Environments.Regexp)
 		if !re.MatchString(env.Name) {
-			if err = deleteEnv(ctx, c.Store, env, "environment-not-in-regexp"); err != nil {
-				return err
-			}
+			deleteEnvironment(env, "environment-not-in-regexp")
 
 			continue
 		}
 
 		// Check if the latest configuration of the project in store matches the environment one
-		if env.OutputSparseStatusMetrics != p.OutputSparseStatusMetrics {
+		if env.OutputSparseStatusMetrics != p.OutputSparseStatusMetrics && !gc.dryRun {
 			env.OutputSparseStatusMetrics = p.OutputSparseStatusMetrics
 
 			if err = c.Store.SetEnvironment(ctx, env); err != nil {
@@ -142,87 +172,70 @@ func (c *Controller) GarbageCollectEnvironments(ctx context.Context) error {
 		}
 	}
 
-	storedEnvironments, err = c.Store.Environments(ctx)
-	if err != nil {
-		return err
+	for k, env := range storedEnvironments {
+		if _, exists := existingEnvs[k]; !exists && !deletedEnvs[k] {
+			deleteEnvironment(env, "non-existent-environment")
+		}
 	}
 
-	for k, env := range storedEnvironments {
-		if _, exists := existingEnvs[k]; !exists {
-			if err = deleteEnv(ctx, c.Store, env, "non-existent-environment"); err != nil {
+	return gc.delete(deletions, func() error {
+		for k := range deletedEnvs {
+			if err := c.Store.DelEnvironment(ctx, k); err != nil {
 				return err
 			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
 // GarbageCollectRefs ..
 func (c *Controller) GarbageCollectRefs(ctx context.Context) error {
-	log.Info("starting 'refs' garbage collection")
-	defer log.Info("ending 'refs' garbage collection")
-
-	storedRefs, err := c.Store.Refs(ctx)
+	gc, err := c.newGarbageCollection(ctx, gcEntityRefs, nil)
 	if err != nil {
 		return err
 	}
 
-	storedRefsLen := len(storedRefs)
-	var i int
-	for _, ref := range storedRefs {
-		i++
-		log.WithFields(log.Fields{"progress": i, "total": storedRefsLen}).Debug("ongoing 'refs' garbage collection")
-		if c.Store.HasRefExpired(ctx, ref.Key()) {
-			if err = deleteRef(ctx, c.Store, ref, "expired"); err != nil {
-				return err
-			}
+	return c.garbageCollectRefs(ctx, gc)
+}
 
-			continue
-		}
+func (c *Controller) garbageCollectRefs(ctx context.Context, gc *garbageCollection) error {
+	log.Info("starting 'refs' garbage collection")
+	defer log.Info("ending 'refs' garbage collection")
 
-		// Check Project Still Exist
-		projectExists, err := c.Store.ProjectExists(ctx, ref.Project.Key())
-		if err != nil {
-			return err
-		}
+	c.GarbageCollectionCollectors.recordSlice(gcEntityRefs)
 
-		// If the project does not exist anymore, delete the ref
-		if !projectExists {
-			if err = deleteRef(ctx, c.Store, ref, "non-existent-project"); err != nil {
-				return err
-			}
-
-			continue
-		}
+	// The refs only get deleted once all of them have been checked against the safety threshold
+	deletedRefs := make(map[schemas.RefKey]bool)
 
-		// If the ref is not configured to be pulled anymore, delete the ref
-		var re *regexp.Regexp
+	// Keep track of whether the refs which are kept track their downstream refs,
+	// not to have to load them all again in order to check the downstream ones
+	downstreamRefsEnabled := make(map[schemas.RefKey]bool)
 
-		if re, err = schemas.GetRefRegexp(ref.Project.Pull.Refs, ref.Kind); err != nil {
-			if err = deleteRef(ctx, c.Store, ref, "invalid-ref-kind"); err != nil {
-				return err
-			}
+	deletions, err := c.scanRefs(ctx, deletedRefs, func(ref schemas.Ref) (string, error) {
+		reason, err := c.refGarbageCollectionReason(ctx, ref)
+		if err != nil || reason != "" || ref.UpstreamRefKey != "" {
+			return reason, err
 		}
 
-		if !re.MatchString(ref.Name) {
-			if err = deleteRef(ctx, c.Store, ref, "ref-not-matching-regexp"); err != nil {
-				return err
-			}
+		if gc.dryRun {
+			downstreamRefsEnabled[ref.Key()] = ref.Project.Pull.Pipeline.Bridges.DownstreamRefs.Enabled
+
+			return "", nil
 		}
 
 		// Check if the latest configuration of the project in store matches the ref one
 		p := ref.Project
 
 		if err = c.Store.GetProject(ctx, &p); err != nil {
-			return err
+			return "", err
 		}
 
 		if !reflect.DeepEqual(ref.Project, p) {
 			ref.Project = p
 
 			if err = c.Store.SetRef(ctx, ref); err != nil {
-				return err
+				return "", err
 			}
 
 			log.WithFields(log.Fields{
@@ -230,6 +243,13 @@ func (c *Controller) GarbageCollectRefs(ctx context.Context) error {
 				"ref":          ref.Name,
 			}).Info("updated ref, associated project configuration was not in sync")
 		}
+
+		downstreamRefsEnabled[ref.Key()] = ref.Project.Pull.Pipeline.Bridges.DownstreamRefs.Enabled
+
+		return "", nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Refresh the refs from the API
@@ -251,168 +271,539 @@ func (c *Controller) GarbageCollectRefs(ctx context.Context) error {
 		}
 	}
 
-	// Refresh the stored refs as we may have already removed some
-	storedRefs, err = c.Store.Refs(ctx)
+	// Go through the stored refs once again to check the downstream ones against their upstream ref
+	moreDeletions, err := c.scanRefs(ctx, deletedRefs, func(ref schemas.Ref) (string, error) {
+		// Downstream refs are kept for as long as their upstream ref tracks them
+		if ref.UpstreamRefKey != "" {
+			if deletedRefs[ref.UpstreamRefKey] {
+				return "non-existent-upstream-ref", nil
+			}
+
+			enabled, scanned := downstreamRefsEnabled[ref.UpstreamRefKey]
+			if !scanned {
+				// The upstream ref may have been created since the first scan, it then gets checked on the next run
+				exists, err := c.Store.RefExists(ctx, ref.UpstreamRefKey)
+				if err != nil || exists {
+					return "", err
+				}
+
+				return "non-existent-upstream-ref", nil
+			}
+
+			if !enabled {
+				return "downstream-refs-disabled-on-upstream-ref", nil
+			}
+
+			return "", nil
+		}
+
+		if _, expected := expectedRefs[ref.Key()]; !expected {
+			return "not-expected", nil
+		}
+
+		return "", nil
+	})
 	if err != nil {
 		return err
 	}
 
-	for k, ref := range storedRefs {
-		if _, expected := expectedRefs[k]; !expected {
-			if err = deleteRef(ctx, c.Store, ref, "not-expected"); err != nil {
+	if err = gc.delete(append(deletions, moreDeletions...), func() error {
+		keys := slices.Collect(maps.Keys(deletedRefs))
+
+		for batch := range slices.Chunk(keys, int(c.Config.GarbageCollect.BatchSizeOrDefault())) {
+			if err := c.Store.DelRefs(ctx, batch); err != nil {
 				return err
 			}
 		}
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
+	c.GarbageCollectionCollectors.recordRun(gcEntityRefs)
+
 	return nil
 }
 
-// GarbageCollectMetrics ..
-func (c *Controller) GarbageCollectMetrics(ctx context.Context) error {
-	log.Info("starting 'metrics' garbage collection")
-	defer log.Info("ending 'metrics' garbage collection")
+// scanRefs iterates over the stored refs in batches, returning the deletions of the ones for which
+// reasonFunc returns a reason, unless they have already been, and adding them to deletedRefs.
+func (c *Controller) scanRefs(
+	ctx context.Context,
+	deletedRefs map[schemas.RefKey]bool,
+	reasonFunc func(schemas.Ref) (string, error),
+) (deletions []gcDeletion, err error) {
+	var cursor uint64
+
+	for {
+		refs, next, err := c.Store.ScanRefs(ctx, cursor, c.Config.GarbageCollect.BatchSizeOrDefault())
+		if err != nil {
+			return deletions, err
+		}
 
-	storedEnvironments, err := c.Store.Environments(ctx)
+		for k, ref := range refs {
+			if deletedRefs[k] {
+				continue
+			}
+
+			reason, err := reasonFunc(ref)
+			if err != nil {
+				return deletions, err
+			}
+
+			if reason != "" {
+				deletedRefs[k] = true
+				deletions = append(deletions, refDeletion(ref, reason))
+			}
+		}
+
+		c.GarbageCollectionCollectors.recordScanned(gcEntityRefs, len(refs))
+
+		if cursor = next; cursor == 0 {
+			return deletions, nil
+		}
+	}
+}
+
+// refGarbageCollectionReason returns the reason why the ref should be deleted, or an empty string if it should be kept.
+func (c *Controller) refGarbageCollectionReason(ctx context.Context, ref schemas.Ref) (string, error) {
+	if c.Store.HasRefExpired(ctx, ref.Key()) {
+		return "expired", nil
+	}
+
+	// Downstream refs get checked against their upstream ref instead
+	if ref.UpstreamRefKey != "" {
+		return "", nil
+	}
+
+	// Check Project Still Exist
+	projectExists, err := c.Store.ProjectExists(ctx, ref.Project.Key())
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	if !projectExists {
+		return "non-existent-project", nil
 	}
 
-	storedRefs, err := c.Store.Refs(ctx)
+	// If the ref is not configured to be pulled anymore, delete the ref
+	re, err := schemas.GetRefRegexp(ref.Project.Pull.Refs, ref.Kind)
 	if err != nil {
-		return err
+		return "invalid-ref-kind", nil
+	}
+
+	if !re.MatchString(ref.Name) {
+		return "ref-not-matching-regexp", nil
 	}
 
-	storedMetrics, err := c.Store.Metrics(ctx)
+	return "", nil
+}
+
+// GarbageCollectMetrics goes through all the metrics which have not been
+// checked yet by the previous time-boxed runs.
+func (c *Controller) GarbageCollectMetrics(ctx context.Context) error {
+	gc, err := c.newGarbageCollection(ctx, gcEntityMetrics, nil)
 	if err != nil {
 		return err
 	}
 
-	storedMetricsLen := len(storedMetrics)
-	var i int
-	for k, m := range storedMetrics {
-		i++
-		log.WithFields(log.Fields{"progress": i, "total": storedMetricsLen}).Debug("ongoing 'metrics' garbage collection")
-		if c.Store.HasMetricExpired(ctx, m.Key()) {
-			if err = deleteMetric(ctx, c.Store, m, "expired"); err != nil {
-				return err
+	_, err = c.garbageCollectMetrics(ctx, gc, time.Time{})
+
+	return err
+}
+
+// garbageCollectMetrics iterates over the metrics in batches, starting from where the previous
+// run left off. It stops once the deadline, if any, is passed and returns whether it
+// went through all of them. When running dry, it goes through all of them at once.
+// With a safety threshold, a first pass checks the deletions against it before a second
+// one performs them, nothing getting deleted by the runs exceeding it.
+func (c *Controller) garbageCollectMetrics(ctx context.Context, gc *garbageCollection, deadline time.Time) (completed bool, err error) {
+	log.Info("starting 'metrics' garbage collection")
+	defer log.Info("ending 'metrics' garbage collection")
+
+	lookups := newGCLookups(c.Store)
+
+	c.GarbageCollectionCollectors.recordSlice(gcEntityMetrics)
+
+	for {
+		metrics, next, err := c.Store.ScanMetrics(ctx, gc.cursor, c.Config.GarbageCollect.BatchSizeOrDefault())
+		if err != nil {
+			return false, err
+		}
+
+		var (
+			keys      []schemas.MetricKey
+			deletions []gcDeletion
+		)
+
+		for k, m := range metrics {
+			reason, err := c.metricGarbageCollectionReason(ctx, m, lookups)
+			if err != nil {
+				return false, err
 			}
 
-			continue
+			if reason != "" {
+				keys = append(keys, k)
+				deletions = append(deletions, metricDeletion(m, reason))
+			}
 		}
-		// In order to save some memory space we chose to have to recompose
-		// the Ref the metric belongs to
-		metricLabelProject, metricLabelProjectExists := m.Labels["project"]
-		metricLabelRef, metricLabelRefExists := m.Labels["ref"]
-		metricLabelEnvironment, metricLabelEnvironmentExists := m.Labels["environment"]
 
-		if !metricLabelProjectExists || (!metricLabelRefExists && !metricLabelEnvironmentExists) {
-			if err = deleteMetric(ctx, c.Store, m, "project-or-ref-and-environment-label-undefined"); err != nil {
-				return err
+		if gc.checking() {
+			err = gc.check(len(deletions))
+		} else {
+			err = gc.delete(deletions, func() error {
+				return c.Store.DelMetrics(ctx, keys)
+			})
+		}
+
+		if err != nil {
+			if !gc.dryRun {
+				// The aborted run starts over next time
+				if resetErr := c.Store.SetGarbageCollectionCursor(ctx, gcEntityMetrics, schemas.GarbageCollectionCursor{}); resetErr != nil {
+					log.WithError(resetErr).Error("resetting the 'metrics' garbage collection cursor")
+				}
 			}
+
+			return false, err
 		}
 
-		if metricLabelRefExists && !metricLabelEnvironmentExists {
-			refKey := schemas.NewRef(
-				schemas.NewProject(metricLabelProject),
-				schemas.RefKind(m.Labels["kind"]),
-				metricLabelRef,
-			).Key()
+		c.GarbageCollectionCollectors.recordScanned(gcEntityMetrics, len(metrics))
 
-			ref, refExists := storedRefs[refKey]
+		if gc.cursor = next; gc.cursor == 0 {
+			if !gc.checking() {
+				break
+			}
 
-			// If the ref does not exist anymore, delete the metric
-			if !refExists {
-				if err = c.Store.DelMetric(ctx, k); err != nil {
-					return err
-				}
+			gc.checked = true
 
-				log.WithFields(log.Fields{
-					"metric-kind":   m.Kind,
-					"metric-labels": m.Labels,
-					"reason":        "non-existent-ref",
-				}).Info("deleted metric from the store")
+			log.WithFields(log.Fields{
+				"found": gc.found,
+				"total": gc.total,
+			}).Info("'metrics' garbage collection within its safety threshold, deleting them")
+		}
 
-				continue
+		if !gc.dryRun && !deadline.IsZero() && time.Now().After(deadline) {
+			log.WithFields(log.Fields{
+				"cursor":  gc.cursor,
+				"checked": gc.checked,
+				"deleted": gc.deleted,
+			}).Info("'metrics' garbage collection time slice exhausted, it will resume from there")
+
+			return false, c.Store.SetGarbageCollectionCursor(ctx, gcEntityMetrics, schemas.GarbageCollectionCursor{
+				Position: gc.cursor,
+				Total:    gc.total,
+				Found:    gc.found,
+				Checked:  gc.checked,
+				Deleted:  gc.deleted,
+			})
+		}
+	}
+
+	c.GarbageCollectionCollectors.recordRun(gcEntityMetrics)
+
+	if gc.dryRun {
+		return true, nil
+	}
+
+	return true, c.Store.SetGarbageCollectionCursor(ctx, gcEntityMetrics, schemas.GarbageCollectionCursor{})
+}
+
+// gcLookups looks up the refs and environments which the metrics belong to,
+// caching them as most of the metrics share the same ones.
+type gcLookups struct {
+	store store.Store
+	refs  map[schemas.RefKey]*schemas.Ref
+	envs  map[schemas.EnvironmentKey]*schemas.Environment
+}
+
+func newGCLookups(s store.Store) *gcLookups {
+	return &gcLookups{
+		store: s,
+		refs:  make(map[schemas.RefKey]*schemas.Ref),
+		envs:  make(map[schemas.EnvironmentKey]*schemas.Environment),
+	}
+}
+
+// ref returns the stored ref and whether it exists.
+func (l *gcLookups) ref(ctx context.Context, ref schemas.Ref) (schemas.Ref, bool, error) {
+	k := ref.Key()
+
+	cached, ok := l.refs[k]
+	if !ok {
+		exists, err := l.store.RefExists(ctx, k)
+		if err != nil {
+			return ref, false, err
+		}
+
+		if exists {
+			if err = l.store.GetRef(ctx, &ref); err != nil {
+				return ref, false, err
 			}
 
-			// Check if the pulling of jobs related metrics has been disabled
-			switch m.Kind {
-			case schemas.MetricKindJobArtifactSizeBytes,
-				schemas.MetricKindJobDurationSeconds,
-				schemas.MetricKindJobID,
-				schemas.MetricKindJobRunCount,
-				schemas.MetricKindJobStatus,
-				schemas.MetricKindJobTimestamp:
-				if !ref.Project.Pull.Pipeline.Jobs.Enabled {
-					if err = c.Store.DelMetric(ctx, k); err != nil {
-						return err
-					}
-
-					log.WithFields(log.Fields{
-						"metric-kind":   m.Kind,
-						"metric-labels": m.Labels,
-						"reason":        "jobs-metrics-disabled-on-ref",
-					}).Info("deleted metric from the store")
-
-					continue
-				}
+			cached = &ref
+		}
+
+		l.refs[k] = cached
+	}
+
+	if cached == nil {
+		return ref, false, nil
+	}
+
+	return *cached, true, nil
+}
+
+// environment returns the stored environment and whether it exists.
+func (l *gcLookups) environment(ctx context.Context, env schemas.Environment) (schemas.Environment, bool, error) {
+	k := env.Key()
+
+	cached, ok := l.envs[k]
+	if !ok {
+		exists, err := l.store.EnvironmentExists(ctx, k)
+		if err != nil {
+			return env, false, err
+		}
 
-			default:
+		if exists {
+			if err = l.store.GetEnvironment(ctx, &env); err != nil {
+				return env, false, err
 			}
 
-			// Check if 'output sparse statuses metrics' has been enabled
-			switch m.Kind {
-			case schemas.MetricKindJobStatus,
-				schemas.MetricKindStatus:
-				if ref.Project.OutputSparseStatusMetrics && m.Value != 1 {
-					if err = c.Store.DelMetric(ctx, k); err != nil {
-						return err
-					}
-
-					log.WithFields(log.Fields{
-						"metric-kind":   m.Kind,
-						"metric-labels": m.Labels,
-						"reason":        "output-sparse-metrics-enabled-on-ref",
-					}).Info("deleted metric from the store")
-
-					continue
-				}
-			default:
+			cached = &env
+		}
+
+		l.envs[k] = cached
+	}
+
+	if cached == nil {
+		return env, false, nil
+	}
+
+	return *cached, true, nil
+}
+
+// metricGarbageCollectionReason returns the reason why the metric should be deleted, or an empty string if it should be kept.
+func (c *Controller) metricGarbageCollectionReason(ctx context.Context, m schemas.Metric, lookups *gcLookups) (string, error) {
+	if c.Store.HasMetricExpired(ctx, m.Key()) {
+		return "expired", nil
+	}
+
+	// In order to save some memory space we chose to have to recompose
+	// the Ref the metric belongs to
+	metricLabelProject, metricLabelProjectExists := m.Labels["project"]
+	metricLabelRef, metricLabelRefExists := m.Labels["ref"]
+	metricLabelEnvironment, metricLabelEnvironmentExists := m.Labels["environment"]
+
+	// Webhooks metrics are only bound to the project
+	switch m.Kind {
+	case schemas.MetricKindWebhookDisabledUntilTimestamp,
+		schemas.MetricKindWebhookStatus:
+		projectExists, err := c.Store.ProjectExists(ctx, schemas.NewProject(metricLabelProject).Key())
+		if err != nil {
+			return "", err
+		}
+
+		if !projectExists || !c.isWebhookAutoRegisterEnabled() {
+			return "non-existent-project-or-webhook-auto-register-disabled", nil
+		}
+
+		return "", nil
+	}
+
+	if !metricLabelProjectExists || (!metricLabelRefExists && !metricLabelEnvironmentExists) {
+		return "project-or-ref-and-environment-label-undefined", nil
+	}
+
+	if metricLabelRefExists && !metricLabelEnvironmentExists {
+		ref, refExists, err := lookups.ref(ctx, schemas.NewRef(
+			schemas.NewProject(metricLabelProject),
+			schemas.RefKind(m.Labels["kind"]),
+			metricLabelRef,
+		))
+		if err != nil {
+			return "", err
+		}
+
+		// If the ref does not exist anymore, delete the metric
+		if !refExists {
+			return "non-existent-ref", nil
+		}
+
+		// Check if the pulling of jobs related metrics has been disabled
+		switch m.Kind {
+		case schemas.MetricKindJobArtifactSizeBytes,
+			schemas.MetricKindJobDurationSeconds,
+			schemas.MetricKindJobFailureCount,
+			schemas.MetricKindJobID,
+			schemas.MetricKindJobRunCount,
+			schemas.MetricKindJobStatus,
+			schemas.MetricKindJobTimestamp,
+			schemas.MetricKindStageDurationSeconds,
+			schemas.MetricKindStageJobCount,
+			schemas.MetricKindStageJobsDurationSeconds,
+			schemas.MetricKindStageStatus,
+			schemas.MetricKindAverageParallelism,
+			schemas.MetricKindCriticalPathDurationSeconds,
+			schemas.MetricKindJobCriticalPath,
+			schemas.MetricKindJobAttemptsToSucceed,
+			schemas.MetricKindJobRetryCount,
+			schemas.MetricKindBlockedDurationSeconds,
+			schemas.MetricKindJobManualPlayDelaySeconds,
+			schemas.MetricKindJobManualWaiting,
+			schemas.MetricKindJobWaitingForResourceDurationSeconds:
+			if !ref.Project.Pull.Pipeline.Jobs.Enabled {
+				return "jobs-metrics-disabled-on-ref", nil
 			}
 		}
 
-		if metricLabelEnvironmentExists {
-			envKey := schemas.Environment{
-				ProjectName: metricLabelProject,
-				Name:        metricLabelEnvironment,
-			}.Key()
+		// Check if the pulling of bridges related metrics has been disabled
+		switch m.Kind {
+		case schemas.MetricKindBridgeCausedFailure,
+			schemas.MetricKindBridgeDownstreamDurationSeconds,
+			schemas.MetricKindBridgeDownstreamPipelineID,
+			schemas.MetricKindBridgeDownstreamStatus:
+			if !ref.Project.Pull.Pipeline.Bridges.Enabled {
+				return "bridges-metrics-disabled-on-ref", nil
+			}
+		}
 
-			env, envExists := storedEnvironments[envKey]
+		// Check if the critical path analysis has been disabled
+		switch m.Kind {
+		case schemas.MetricKindAverageParallelism,
+			schemas.MetricKindCriticalPathDurationSeconds,
+			schemas.MetricKindJobCriticalPath:
+			if !ref.Project.Pull.Pipeline.Jobs.CriticalPath.Enabled {
+				return "critical-path-metrics-disabled-on-ref", nil
+			}
+		}
 
-			// If the ref does not exist anymore, delete the metric
-			if !envExists {
-				if err = deleteMetric(ctx, c.Store, m, "non-existent-environment"); err != nil {
-					return err
-				}
+		// Check if the retries related metrics have been disabled
+		switch m.Kind {
+		case schemas.MetricKindJobAttemptsToSucceed,
+			schemas.MetricKindJobRetryCount:
+			if !ref.Project.Pull.Pipeline.Jobs.Retries.Enabled {
+				return "retries-metrics-disabled-on-ref", nil
+			}
+		}
 
-				continue
+		// Check if the waiting jobs related metrics have been disabled
+		switch m.Kind {
+		case schemas.MetricKindBlockedDurationSeconds,
+			schemas.MetricKindJobManualPlayDelaySeconds,
+			schemas.MetricKindJobManualWaiting,
+			schemas.MetricKindJobWaitingForResourceDurationSeconds:
+			if !ref.Project.Pull.Pipeline.Jobs.Waiting.Enabled {
+				return "waiting-metrics-disabled-on-ref", nil
 			}
+		}
 
-			// Check if 'output sparse statuses metrics' has been enabled
-			switch m.Kind {
-			case schemas.MetricKindEnvironmentDeploymentStatus:
-				if env.OutputSparseStatusMetrics && m.Value != 1 {
-					if err = deleteMetric(ctx, c.Store, m, "output-sparse-metrics-enabled-on-environment"); err != nil {
-						return err
-					}
+		// Check if 'output sparse statuses metrics' has been enabled
+		switch m.Kind {
+		case schemas.MetricKindJobStatus,
+			schemas.MetricKindBridgeDownstreamStatus,
+			schemas.MetricKindStageStatus,
+			schemas.MetricKindStatus:
+			if ref.Project.OutputSparseStatusMetrics && m.Value != 1 {
+				return "output-sparse-metrics-enabled-on-ref", nil
+			}
+		}
+	}
 
-					continue
-				}
+	if metricLabelEnvironmentExists {
+		env, envExists, err := lookups.environment(ctx, schemas.Environment{
+			ProjectName: metricLabelProject,
+			Name:        metricLabelEnvironment,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		// If the environment does not exist anymore, delete the metric
+		if !envExists {
+			return "non-existent-environment", nil
+		}
+
+		// Check if 'output sparse statuses metrics' has been enabled
+		if m.Kind == schemas.MetricKindEnvironmentDeploymentStatus && env.OutputSparseStatusMetrics && m.Value != 1 {
+			return "output-sparse-metrics-enabled-on-environment", nil
+		}
+	}
+
+	return "", nil
+}
+
+// deleteProject removes the project from the store along with all its refs, environments and metrics.
+func (c *Controller) deleteProject(ctx context.Context, p schemas.Project, reason string) error {
+	for cursor := uint64(0); ; {
+		refs, next, err := c.Store.ScanRefs(ctx, cursor, c.Config.GarbageCollect.BatchSizeOrDefault())
+		if err != nil {
+			return err
+		}
+
+		var keys []schemas.RefKey
+
+		for k, ref := range refs {
+			if ref.Project.Name == p.Name {
+				keys = append(keys, k)
+				logDeletedRef(ref, reason)
+			}
+		}
+
+		if err = c.Store.DelRefs(ctx, keys); err != nil {
+			return err
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	envs, err := c.Store.Environments(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, env := range envs {
+		if env.ProjectName == p.Name {
+			if err = deleteEnv(ctx, c.Store, env, reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	for cursor := uint64(0); ; {
+		metrics, next, err := c.Store.ScanMetrics(ctx, cursor, c.Config.GarbageCollect.BatchSizeOrDefault())
+		if err != nil {
+			return err
+		}
+
+		var keys []schemas.MetricKey
+
+		for k, m := range metrics {
+			if m.Labels["project"] == p.Name {
+				keys = append(keys, k)
+				logDeletedMetric(m, reason)
 			}
 		}
+
+		if err = c.Store.DelMetrics(ctx, keys); err != nil {
+			return err
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	if err = c.Store.DelProject(ctx, p.Key()); err != nil {
+		return err
 	}
 
+	log.WithFields(log.Fields{
+		"project-name": p.Name,
+		"reason":       reason,
+	}).Info("deleted project from the store")
+
 	return nil
 }
 
@@ -435,26 +826,68 @@ func deleteRef(ctx context.Context, s store.Store, ref schemas.Ref, reason strin
 		return
 	}
 
+	logDeletedRef(ref, reason)
+
+	return
+}
+
+func logDeletedRef(ref schemas.Ref, reason string) {
 	log.WithFields(log.Fields{
 		"project-name": ref.Project.Name,
 		"ref":          ref.Name,
 		"ref-kind":     ref.Kind,
 		"reason":       reason,
 	}).Info("deleted ref from the store")
-
-	return
 }
 
-func deleteMetric(ctx context.Context, s store.Store, m schemas.Metric, reason string) (err error) {
-	if err = s.DelMetric(ctx, m.Key()); err != nil {
-		return
-	}
-
+func logDeletedMetric(m schemas.Metric, reason string) {
 	log.WithFields(log.Fields{
 		"metric-kind":   m.Kind,
 		"metric-labels": m.Labels,
 		"reason":        reason,
 	}).Info("deleted metric from the store")
+}
 
-	return
+func projectDeletion(p schemas.Project, reason string) gcDeletion {
+	return gcDeletion{
+		name:   p.Name,
+		reason: reason,
+		fields: log.Fields{
+			"project-name": p.Name,
+		},
+	}
+}
+
+func environmentDeletion(env schemas.Environment, reason string) gcDeletion {
+	return gcDeletion{
+		name:   fmt.Sprintf("%s:%s", env.ProjectName, env.Name),
+		reason: reason,
+		fields: log.Fields{
+			"project-name":     env.ProjectName,
+			"environment-name": env.Name,
+		},
+	}
+}
+
+func refDeletion(ref schemas.Ref, reason string) gcDeletion {
+	return gcDeletion{
+		name:   fmt.Sprintf("%s:%s:%s", ref.Project.Name, ref.Kind, ref.Name),
+		reason: reason,
+		fields: log.Fields{
+			"project-name": ref.Project.Name,
+			"ref":          ref.Name,
+			"ref-kind":     ref.Kind,
+		},
+	}
+}
+
+func metricDeletion(m schemas.Metric, reason string) gcDeletion {
+	return gcDeletion{
+		name:   metricName(m),
+		reason: reason,
+		fields: log.Fields{
+			"metric-kind":   m.Kind,
+			"metric-labels": m.Labels,
+		},
+	}
 }
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
@@ -129,6 +131,74 @@ func TestGarbageCollectRefs(t *testing.T) {
 	assert.Equal(t, expectedRefs, storedRefs)
 }
 
+func TestGarbageCollectRefsMaxDeletedPercentage(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		GarbageCollect: config.GarbageCollect{BatchSize: 1, MaxDeletedPercentage: 50},
+	})
+	srv.Close()
+
+	for i := range 4 {
+		_ = c.Store.SetRef(ctx, schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, fmt.Sprintf("%d", i)))
+	}
+
+	// All the deletions get checked against the threshold before any of them gets performed
+	for range 2 {
+		assert.ErrorContains(t, c.GarbageCollectRefs(ctx), "would delete more than 50% of the 4 stored ones")
+
+		count, _ := c.Store.RefsCount(ctx)
+		assert.Equal(t, int64(4), count)
+	}
+}
+
+func TestGarbageCollectDownstreamRefs(t *testing.T) {
+	ctx, c, mux, srv := newTestController(config.Config{})
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/projects/p1/repository/branches",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"name": "main"}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/p1/repository/tags",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[]`)
+		})
+
+	p1 := schemas.NewProject("p1")
+	p1.Pull.Pipeline.Bridges.DownstreamRefs.Enabled = true
+	p1main := schemas.NewRef(p1, schemas.RefKindBranch, "main")
+
+	// Downstream refs of projects which are not tracked
+	downstreamMain := schemas.NewRef(schemas.NewProject("downstream"), schemas.RefKindBranch, "main")
+	downstreamMain.UpstreamRefKey = p1main.Key()
+
+	downstreamOrphan := schemas.NewRef(schemas.NewProject("downstream"), schemas.RefKindTag, "v1.0.0")
+	downstreamOrphan.UpstreamRefKey = schemas.NewRef(p1, schemas.RefKindBranch, "gone").Key()
+
+	_ = c.Store.SetProject(ctx, p1)
+	_ = c.Store.SetRef(ctx, p1main)
+	_ = c.Store.SetRef(ctx, downstreamMain)
+	_ = c.Store.SetRef(ctx, downstreamOrphan)
+
+	assert.NoError(t, c.GarbageCollectRefs(ctx))
+	storedRefs, err := c.Store.Refs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Refs{
+		p1main.Key():         p1main,
+		downstreamMain.Key(): downstreamMain,
+	}, storedRefs)
+
+	// Disabling the tracking on the upstream project removes the downstream refs
+	p1.Pull.Pipeline.Bridges.DownstreamRefs.Enabled = false
+	_ = c.Store.SetProject(ctx, p1)
+
+	assert.NoError(t, c.GarbageCollectRefs(ctx))
+	storedRefs, err = c.Store.Refs(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, storedRefs, 1)
+	assert.Contains(t, storedRefs, p1main.Key())
+}
+
 func TestGarbageCollectMetrics(t *testing.T) {
 	ctx, c, _, srv := newTestController(config.Config{})
 	srv.Close()
@@ -164,3 +234,305 @@ func TestGarbageCollectMetrics(t *testing.T) {
 	}
 	assert.Equal(t, expectedMetrics, storedMetrics)
 }
+
+func TestGarbageCollectWebhookMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(newWebhookAutoRegisterConfig("project"))
+	srv.Close()
+
+	p1 := schemas.NewProject("p1")
+
+	p1m1 := schemas.Metric{Kind: schemas.MetricKindWebhookStatus, Labels: prometheus.Labels{"project": "p1", "scope": "project", "status": "executable"}}
+	p2m1 := schemas.Metric{Kind: schemas.MetricKindWebhookStatus, Labels: prometheus.Labels{"project": "p2", "scope": "project", "status": "executable"}}
+
+	_ = c.Store.SetProject(ctx, p1)
+	_ = c.Store.SetMetric(ctx, p1m1)
+	_ = c.Store.SetMetric(ctx, p2m1)
+
+	assert.NoError(t, c.GarbageCollectMetrics(ctx))
+	storedMetrics, err := c.Store.Metrics(ctx)
+	assert.NoError(t, err)
+
+	expectedMetrics := schemas.Metrics{
+		p1m1.Key(): p1m1,
+	}
+	assert.Equal(t, expectedMetrics, storedMetrics)
+
+	// When the feature gets disabled, all of them get removed
+	c.Config.Server.Webhook.AutoRegister.Enabled = false
+	assert.NoError(t, c.GarbageCollectMetrics(ctx))
+	storedMetrics, err = c.Store.Metrics(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, storedMetrics)
+}
+
+func TestGarbageCollectMetricsSlices(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		GarbageCollect: config.GarbageCollect{BatchSize: 2},
+	})
+	srv.Close()
+
+	for i := range 5 {
+		_ = c.Store.SetMetric(ctx, schemas.Metric{
+			Kind:   schemas.MetricKindRunCount,
+			Labels: prometheus.Labels{"project": "foo", "kind": "branch", "ref": fmt.Sprintf("%d", i)},
+		})
+	}
+
+	// With an already passed deadline, a single batch gets processed per slice
+	expired := time.Now().Add(-time.Second)
+
+	gc, err := c.newGarbageCollection(ctx, gcEntityMetrics, nil)
+	assert.NoError(t, err)
+
+	completed, err := c.garbageCollectMetrics(ctx, gc, expired)
+	assert.NoError(t, err)
+	assert.False(t, completed)
+
+	cursor, err := c.Store.GarbageCollectionCursor(ctx, gcEntityMetrics)
+	assert.NoError(t, err)
+	assert.NotZero(t, cursor.Position)
+
+	count, _ := c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(3), count)
+
+	for !completed {
+		completed, err = c.garbageCollectMetrics(ctx, gc, expired)
+		assert.NoError(t, err)
+	}
+
+	count, _ = c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(0), count)
+
+	cursor, err = c.Store.GarbageCollectionCursor(ctx, gcEntityMetrics)
+	assert.NoError(t, err)
+	assert.Zero(t, cursor)
+
+	scanned := c.GarbageCollectionCollectors.ScannedCount.(*prometheus.CounterVec).WithLabelValues(gcEntityMetrics)
+	runs := c.GarbageCollectionCollectors.RunsCount.(*prometheus.CounterVec).WithLabelValues(gcEntityMetrics)
+	assert.Equal(t, float64(5), testutil.ToFloat64(scanned))
+	assert.Equal(t, float64(1), testutil.ToFloat64(runs))
+}
+
+func TestGarbageCollectMetricsSlicesMaxDeletedPercentage(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		GarbageCollect: config.GarbageCollect{BatchSize: 1, MaxDeletedPercentage: 50},
+	})
+	srv.Close()
+
+	for i := range 4 {
+		_ = c.Store.SetMetric(ctx, schemas.Metric{
+			Kind:   schemas.MetricKindRunCount,
+			Labels: prometheus.Labels{"project": "foo", "kind": "branch", "ref": fmt.Sprintf("%d", i)},
+		})
+	}
+
+	expired := time.Now().Add(-time.Second)
+
+	// Each slice gets its own garbage collection, as when run by the scheduler
+	runSlices := func() error {
+		for {
+			gc, err := c.newGarbageCollection(ctx, gcEntityMetrics, nil)
+			if err != nil {
+				return err
+			}
+
+			completed, err := c.garbageCollectMetrics(ctx, gc, expired)
+			if err != nil || completed {
+				return err
+			}
+		}
+	}
+
+	// The deletions get checked against the threshold of the whole run before any of them
+	// gets performed, the runs exceeding it deleting nothing
+	for range 2 {
+		assert.ErrorContains(t, runSlices(), "would delete more than 50% of the 4 stored ones")
+
+		count, _ := c.Store.MetricsCount(ctx)
+		assert.Equal(t, int64(4), count)
+
+		cursor, err := c.Store.GarbageCollectionCursor(ctx, gcEntityMetrics)
+		assert.NoError(t, err)
+		assert.Zero(t, cursor)
+	}
+
+	// Once checked, the deletions get performed over the following slices
+	p := schemas.NewProject("foo")
+	for i := range 3 {
+		_ = c.Store.SetRef(ctx, schemas.NewRef(p, schemas.RefKindBranch, fmt.Sprintf("%d", i)))
+	}
+
+	gc, err := c.newGarbageCollection(ctx, gcEntityMetrics, nil)
+	assert.NoError(t, err)
+
+	for !gc.checked {
+		_, err = c.garbageCollectMetrics(ctx, gc, expired)
+		assert.NoError(t, err)
+	}
+
+	cursor, err := c.Store.GarbageCollectionCursor(ctx, gcEntityMetrics)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), cursor.Found)
+	assert.True(t, cursor.Checked)
+
+	count, _ := c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(4), count)
+
+	assert.NoError(t, runSlices())
+
+	count, _ = c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(3), count)
+
+	cursor, err = c.Store.GarbageCollectionCursor(ctx, gcEntityMetrics)
+	assert.NoError(t, err)
+	assert.Zero(t, cursor)
+}
+
+func TestGCLookups(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	ref.LatestPipeline.ID = 1
+	env := schemas.Environment{ProjectName: "foo", Name: "prod", OutputSparseStatusMetrics: true}
+
+	_ = c.Store.SetRef(ctx, ref)
+	_ = c.Store.SetEnvironment(ctx, env)
+
+	lookups := newGCLookups(c.Store)
+
+	storedRef, exists, err := lookups.ref(ctx, schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main"))
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, ref, storedRef)
+
+	storedEnv, exists, err := lookups.environment(ctx, schemas.Environment{ProjectName: "foo", Name: "prod"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, env, storedEnv)
+
+	_, exists, err = lookups.ref(ctx, schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "dev"))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// The lookups are cached
+	_ = c.Store.DelRef(ctx, ref.Key())
+	_ = c.Store.DelEnvironment(ctx, env.Key())
+	_ = c.Store.SetRef(ctx, schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "dev"))
+
+	_, exists, _ = lookups.ref(ctx, ref)
+	assert.True(t, exists)
+
+	_, exists, _ = lookups.environment(ctx, env)
+	assert.True(t, exists)
+
+	_, exists, _ = lookups.ref(ctx, schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "dev"))
+	assert.False(t, exists)
+}
+
+func TestDeleteProject(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		GarbageCollect: config.GarbageCollect{BatchSize: 2},
+	})
+	srv.Close()
+
+	for _, name := range []string{"foo", "bar"} {
+		p := schemas.NewProject(name)
+		_ = c.Store.SetProject(ctx, p)
+		_ = c.Store.SetEnvironment(ctx, schemas.Environment{ProjectName: name, Name: "prod"})
+
+		for i := range 5 {
+			ref := schemas.NewRef(p, schemas.RefKindBranch, fmt.Sprintf("%d", i))
+			_ = c.Store.SetRef(ctx, ref)
+			_ = c.Store.SetMetric(ctx, schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: ref.DefaultLabelsValues()})
+		}
+	}
+
+	assert.NoError(t, c.deleteProject(ctx, schemas.NewProject("foo"), "test"))
+
+	projects, _ := c.Store.Projects(ctx)
+	assert.Len(t, projects, 1)
+	assert.Contains(t, projects, schemas.NewProject("bar").Key())
+
+	envs, _ := c.Store.Environments(ctx)
+	assert.Len(t, envs, 1)
+
+	refs, _ := c.Store.Refs(ctx)
+	assert.Len(t, refs, 5)
+
+	for _, ref := range refs {
+		assert.Equal(t, "bar", ref.Project.Name)
+	}
+
+	metrics, _ := c.Store.Metrics(ctx)
+	assert.Len(t, metrics, 5)
+
+	for _, m := range metrics {
+		assert.Equal(t, "bar", m.Labels["project"])
+	}
+}
+
+func TestGarbageCollectDryRun(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	m1 := schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: prometheus.Labels{"project": "foo", "kind": "branch", "ref": "main"}}
+	m2 := schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: prometheus.Labels{"project": "foo"}}
+
+	_ = c.Store.SetMetric(ctx, m1)
+	_ = c.Store.SetMetric(ctx, m2)
+
+	report, err := c.GarbageCollectDryRun(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"non-existent-ref": {fmt.Sprintf(`metric-kind=%d {kind="branch",project="foo",ref="main"}`, schemas.MetricKindRunCount)},
+		"project-or-ref-and-environment-label-undefined": {fmt.Sprintf(`metric-kind=%d {project="foo"}`, schemas.MetricKindRunCount)},
+	}, report[gcEntityMetrics].Deletions)
+	assert.Equal(t, int64(2), report[gcEntityMetrics].Total)
+	assert.Equal(t, 2, report[gcEntityMetrics].DeletionsCount())
+	assert.Empty(t, report[gcEntityProjects].Deletions)
+
+	// Nothing has actually been deleted
+	count, _ := c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(2), count)
+
+	// Same thing when configured to run dry
+	c.Config.GarbageCollect.DryRun = true
+	assert.NoError(t, c.GarbageCollectMetrics(ctx))
+
+	count, _ = c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestGarbageCollectMaxDeletedPercentage(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		GarbageCollect: config.GarbageCollect{MaxDeletedPercentage: 50},
+	})
+	srv.Close()
+
+	for i := range 4 {
+		_ = c.Store.SetMetric(ctx, schemas.Metric{
+			Kind:   schemas.MetricKindRunCount,
+			Labels: prometheus.Labels{"project": "foo", "kind": "branch", "ref": fmt.Sprintf("%d", i)},
+		})
+	}
+
+	// The dry run reports it would be aborted
+	report, err := c.GarbageCollectDryRun(ctx)
+	assert.NoError(t, err)
+	assert.True(t, report[gcEntityMetrics].ThresholdExceeded)
+
+	assert.ErrorContains(t, c.GarbageCollectMetrics(ctx), "would delete more than 50% of the 4 stored ones")
+
+	count, _ := c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(4), count)
+
+	c.Config.GarbageCollect.MaxDeletedPercentage = 0
+	assert.NoError(t, c.GarbageCollectMetrics(ctx))
+
+	count, _ = c.Store.MetricsCount(ctx)
+	assert.Equal(t, int64(0), count)
+
+	deleted := c.GarbageCollectionCollectors.DeletedCount.(*prometheus.CounterVec).WithLabelValues(gcEntityMetrics, "non-existent-ref")
+	assert.Equal(t, float64(4), testutil.ToFloat64(deleted))
+}
@@ -2,10 +2,12 @@ package controller
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"io"
 	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/heptiolabs/healthcheck"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -13,8 +15,16 @@ import (
 	gitlab "gitlab.com/gitlab-org/api/client-go"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 )
 
+// isWebhookTokenValid returns whether the request holds the webhook secret token. The tokens
+// are compared in constant time to prevent the secret from being guessed using timing attacks.
+func (c *Controller) isWebhookTokenValid(r *http.Request) bool {
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(c.Config.Server.Webhook.SecretToken)) == 1
+}
+
 // HealthCheckHandler ..
 func (c *Controller) HealthCheckHandler(ctx context.Context) (h healthcheck.Handler) {
 	h = healthcheck.NewHandler()
@@ -35,7 +45,14 @@ func (c *Controller) MetricsHandler(w http.ResponseWriter, r *http.Request) {
 
 	defer span.End()
 
-	registry := NewRegistry(ctx)
+	var extraLabels []string
+	if c.Config.Server.Metrics.OutputProjectIDLabel {
+		extraLabels = append(extraLabels, "project_id")
+	}
+
+	registry := NewRegistry(ctx, extraLabels...)
+	registry.RegisterTaskExecutionCollectors(c.TaskController.TaskExecutionCollectors)
+	registry.RegisterGarbageCollectionCollectors(c.GarbageCollectionCollectors)
 
 	metrics, err := c.Store.Metrics(ctx)
 	if err != nil {
@@ -44,6 +61,17 @@ func (c *Controller) MetricsHandler(w http.ResponseWriter, r *http.Request) {
 			Error()
 	}
 
+	if c.Config.Server.Metrics.OutputProjectIDLabel {
+		projects, err := c.Store.Projects(ctx)
+		if err != nil {
+			log.WithContext(ctx).
+				WithError(err).
+				Error()
+		}
+
+		metrics = withProjectIDLabel(metrics, projects)
+	}
+
 	if err := registry.ExportInternalMetrics(
 		ctx,
 		c.Gitlab,
@@ -54,6 +82,7 @@ func (c *Controller) MetricsHandler(w http.ResponseWriter, r *http.Request) {
 			Warn()
 	}
 
+	registry.ExportLeaderElectionMetrics(c.LeaderElection)
 	registry.ExportMetrics(metrics)
 
 	otelhttp.NewHandler(
@@ -82,7 +111,7 @@ func (c *Controller) WebhookHandler(w http.ResponseWriter, r *http.Request) {
 
 	logger.Debug("webhook request")
 
-	if r.Header.Get("X-Gitlab-Token") != c.Config.Server.Webhook.SecretToken {
+	if !c.isWebhookTokenValid(r) {
 		logger.Debug("invalid token provided for a webhook request")
 		w.WriteHeader(http.StatusForbidden)
 		_, _ = fmt.Fprint(w, "{\"error\": \"invalid token\"}")
@@ -111,35 +140,125 @@ func (c *Controller) WebhookHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := gitlab.ParseHook(gitlab.HookEventType(r), payload)
+	eventType := gitlab.HookEventType(r)
+	logger = logger.WithField("event-type", eventType)
+	c.incrWebhookEventsCount(ctx, string(eventType), schemas.WebhookEventStatusReceived)
+
+	event, err := gitlab.ParseHook(eventType, payload)
 	if err != nil {
 		logger.
 			WithError(err).
 			Warn("unable to parse body of a received webhook")
 
+		c.incrWebhookEventsCount(ctx, string(eventType), schemas.WebhookEventStatusDropped)
 		w.WriteHeader(http.StatusBadRequest)
 
 		return
 	}
 
-	switch event := event.(type) {
-	case *gitlab.PipelineEvent:
-		go c.processPipelineEvent(ctx, *event)
-	case *gitlab.JobEvent:
-		go c.processJobEvent(ctx, *event)
-	case *gitlab.DeploymentEvent:
-		go c.processDeploymentEvent(ctx, *event)
-	case *gitlab.PushEvent:
-		go c.processPushEvent(ctx, *event)
-	case *gitlab.TagEvent:
-		go c.processTagEvent(ctx, *event)
-	case *gitlab.MergeEvent:
-		go c.processMergeEvent(ctx, *event)
-	default:
+	if !isWebhookEventSupported(event) {
+		c.incrWebhookEventsCount(ctx, string(eventType), schemas.WebhookEventStatusDropped)
+
+		// System hooks send most of their events regardless of the hook configuration,
+		// we acknowledge the ones we do not care about
+		if eventType == gitlab.EventTypeSystemHook {
+			logger.
+				WithField("event-go-type", reflect.TypeOf(event).String()).
+				Debug("ignoring a non supported system hook event")
+
+			return
+		}
+
 		logger.
-			WithField("event-type", reflect.TypeOf(event).String()).
+			WithField("event-go-type", reflect.TypeOf(event).String()).
 			Warn("received a non supported event type as a webhook")
 
 		w.WriteHeader(http.StatusUnprocessableEntity)
+
+		return
+	}
+
+	e := schemas.WebhookEvent{
+		Key:        webhookEventKey(r, eventType, payload),
+		Type:       string(eventType),
+		Payload:    payload,
+		ReceivedAt: time.Now().Unix(),
+	}
+
+	queued, err := c.ScheduleWebhookEvent(ctx, e, true)
+	if err != nil {
+		logger.
+			WithError(err).
+			Warn("unable to queue a received webhook")
+
+		c.incrWebhookEventsCount(ctx, e.Type, schemas.WebhookEventStatusDropped)
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if !queued {
+		logger.
+			WithField("event-key", e.Key).
+			Debug("received a duplicate webhook, ignoring it")
+
+		c.incrWebhookEventsCount(ctx, e.Type, schemas.WebhookEventStatusDropped)
+	}
+}
+
+// WebhookReplayHandler queues back the webhook events which failed to be processed.
+// A single event can be replayed by providing its key using the 'key' query parameter.
+func (c *Controller) WebhookReplayHandler(w http.ResponseWriter, r *http.Request) {
+	span := trace.SpanFromContext(r.Context())
+	defer span.End()
+
+	ctx := trace.ContextWithSpan(context.Background(), span)
+
+	logger := log.
+		WithContext(ctx).
+		WithFields(log.Fields{
+			"ip-address": r.RemoteAddr,
+			"user-agent": r.UserAgent(),
+		})
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
 	}
+
+	if !c.isWebhookTokenValid(r) {
+		logger.Debug("invalid token provided for a webhook replay request")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, "{\"error\": \"invalid token\"}")
+
+		return
+	}
+
+	key := r.URL.Query().Get("key")
+
+	replayed, err := c.ReplayFailedWebhookEvents(ctx, key)
+	if err != nil {
+		logger.
+			WithError(err).
+			Error("replaying failed webhook events")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(w, "{\"error\": %q, \"replayed\": %d}", err.Error(), replayed)
+
+		return
+	}
+
+	if len(key) > 0 && replayed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = fmt.Fprint(w, "{\"error\": \"event not found\"}")
+
+		return
+	}
+
+	logger.
+		WithField("replayed-count", replayed).
+		Info("replayed failed webhook events")
+
+	_, _ = fmt.Fprintf(w, "{\"replayed\": %d}", replayed)
 }
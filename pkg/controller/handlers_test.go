@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 )
 
 func TestWebhookHandler(t *testing.T) {
@@ -72,4 +73,112 @@ func TestWebhookHandler(t *testing.T) {
 	w = httptest.NewRecorder()
 	c.WebhookHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	// Provide a valid system hook event type: project_create
+	req.Body = io.NopCloser(strings.NewReader(`{"event_name": "project_create", "path_with_namespace": "foo/bar"}`))
+	req.Header.Set("X-Gitlab-Event", "System Hook")
+
+	// Test with project_create system hook event, should return a 200
+	w = httptest.NewRecorder()
+	c.WebhookHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	// Provide a non supported system hook event type: user_create
+	req.Body = io.NopCloser(strings.NewReader(`{"event_name": "user_create"}`))
+
+	// Test with a non supported system hook event, should be acknowledged with a 200
+	w = httptest.NewRecorder()
+	c.WebhookHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestWebhookHandlerDeduplication(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		Server: config.Server{
+			Webhook: config.ServerWebhook{
+				Enabled:     true,
+				SecretToken: "secret",
+				Queue: config.ServerWebhookQueue{
+					BufferSize:     10,
+					MaxConcurrency: 1,
+				},
+			},
+		},
+	})
+	srv.Close()
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{"object_kind": "pipeline"}`))
+		req.Header.Set("X-Gitlab-Token", "secret")
+		req.Header.Set("X-Gitlab-Event", "Pipeline Hook")
+		req.Header.Set("Idempotency-Key", "foo")
+
+		w := httptest.NewRecorder()
+		c.WebhookHandler(w, req)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	}
+
+	count, err := c.Store.WebhookEventsCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), count[schemas.WebhookEventsCountKey{Type: "Pipeline Hook", Status: schemas.WebhookEventStatusReceived}])
+	assert.Equal(t, uint64(1), count[schemas.WebhookEventsCountKey{Type: "Pipeline Hook", Status: schemas.WebhookEventStatusDropped}])
+}
+
+func TestWebhookReplayHandler(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{
+		Server: config.Server{
+			Webhook: config.ServerWebhook{
+				Enabled:     true,
+				SecretToken: "secret",
+				Queue: config.ServerWebhookQueue{
+					BufferSize:     10,
+					MaxConcurrency: 1,
+				},
+			},
+		},
+	})
+	srv.Close()
+
+	// Only POST requests are supported
+	w := httptest.NewRecorder()
+	c.WebhookReplayHandler(w, httptest.NewRequest(http.MethodGet, "/webhook/replay", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+
+	// Without auth token, should return a 403
+	w = httptest.NewRecorder()
+	c.WebhookReplayHandler(w, httptest.NewRequest(http.MethodPost, "/webhook/replay", nil))
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+
+	// With a wrong token, should return a 403 as well
+	req := httptest.NewRequest(http.MethodPost, "/webhook/replay", nil)
+	req.Header.Set("X-Gitlab-Token", "secreT")
+
+	w = httptest.NewRecorder()
+	c.WebhookReplayHandler(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+
+	// Unknown event
+	req = httptest.NewRequest(http.MethodPost, "/webhook/replay?key=foo", nil)
+	req.Header.Set("X-Gitlab-Token", "secret")
+
+	w = httptest.NewRecorder()
+	c.WebhookReplayHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+
+	// Replay all the failed events
+	assert.NoError(t, c.Store.SetFailedWebhookEvent(ctx, schemas.WebhookEvent{
+		Key:     "foo",
+		Type:    "Pipeline Hook",
+		Payload: []byte(`{"object_kind": "pipeline"}`),
+	}))
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook/replay", nil)
+	req.Header.Set("X-Gitlab-Token", "secret")
+
+	w = httptest.NewRecorder()
+	c.WebhookReplayHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	body, _ := io.ReadAll(w.Result().Body)
+	assert.Equal(t, `{"replayed": 1}`, string(body))
 }
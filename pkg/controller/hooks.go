@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/gitlab"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func (c *Controller) isWebhookAutoRegisterEnabled() bool {
+	return c.Config.Server.Webhook.Enabled && c.Config.Server.Webhook.AutoRegister.Enabled
+}
+
+// scheduleStaticProjectsPulls schedules the pull of the statically configured projects. As they are
+// not discovered, it is only required to keep their webhooks registered and their statuses up to date.
+func (c *Controller) scheduleStaticProjectsPulls(ctx context.Context) {
+	if !c.isWebhookAutoRegisterEnabled() {
+		return
+	}
+
+	for _, p := range c.Config.Projects {
+		c.ScheduleTask(ctx, schemas.TaskTypePullProject, p.Name, p.Name, p.Pull)
+	}
+}
+
+// webhookGroup returns the group onto which the hook should be registered
+// for the given owner, an empty string meaning that project hooks should be used.
+func (c *Controller) webhookGroup(ownerKind, ownerName string) string {
+	if gitlab.HookScope(c.Config.Server.Webhook.AutoRegister.Scope) == gitlab.HookScopeGroup && ownerKind == "group" {
+		return ownerName
+	}
+
+	return ""
+}
+
+// registerWebhooks creates or updates the hooks sending the events of the given projects
+// to the exporter. If a group is provided, a single group hook is used for all of them.
+func (c *Controller) registerWebhooks(ctx context.Context, projects []schemas.Project, group string) {
+	cfg := gitlab.HookConfig{
+		URL:                   c.Config.Server.Webhook.AutoRegister.URL,
+		SecretToken:           c.Config.Server.Webhook.SecretToken,
+		EnableSSLVerification: c.Config.Server.Webhook.AutoRegister.EnableSSLVerification,
+	}
+
+	if len(group) > 0 {
+		hook, err := c.Gitlab.RegisterGroupHook(ctx, group, cfg)
+		if err != nil {
+			log.WithContext(ctx).
+				WithField("group-name", group).
+				WithError(err).
+				Warn("registering group webhook")
+		}
+
+		for _, p := range projects {
+			c.emitWebhookMetrics(ctx, p, gitlab.HookScopeGroup, hook, err)
+		}
+
+		return
+	}
+
+	for _, p := range projects {
+		hook, err := c.Gitlab.RegisterProjectHook(ctx, p.Name, cfg)
+		if err != nil {
+			log.WithContext(ctx).
+				WithField("project-name", p.Name).
+				WithError(err).
+				Warn("registering project webhook")
+		}
+
+		c.emitWebhookMetrics(ctx, p, gitlab.HookScopeProject, hook, err)
+	}
+}
+
+// unregisterWebhook removes the hook we may have created onto the project. Group hooks
+// are left untouched as they may still be used by other projects.
+func (c *Controller) unregisterWebhook(ctx context.Context, p schemas.Project) {
+	if err := c.Gitlab.UnregisterProjectHook(ctx, p.Name, c.Config.Server.Webhook.AutoRegister.URL); err != nil {
+		log.WithContext(ctx).
+			WithField("project-name", p.Name).
+			WithError(err).
+			Warn("unregistering project webhook")
+
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"project-name": p.Name,
+	}).Info("unregistered project webhook")
+}
+
+func (c *Controller) emitWebhookMetrics(ctx context.Context, p schemas.Project, scope gitlab.HookScope, hook gitlab.Hook, err error) {
+	labels := map[string]string{
+		"project": p.Name,
+		"scope":   string(scope),
+	}
+
+	status := hook.AlertStatus
+	if err != nil {
+		status = "error"
+	} else if len(status) == 0 {
+		// Older GitLab versions do not return this field
+		status = "executable"
+	}
+
+	emitStatusMetric(
+		ctx,
+		c.Store,
+		schemas.MetricKindWebhookStatus,
+		labels,
+		webhookStatusesList[:],
+		status,
+		p.OutputSparseStatusMetrics,
+	)
+
+	disabledUntil := schemas.Metric{
+		Kind:   schemas.MetricKindWebhookDisabledUntilTimestamp,
+		Labels: labels,
+	}
+
+	if hook.DisabledUntil != nil {
+		disabledUntil.Value = float64(hook.DisabledUntil.Unix())
+	}
+
+	storeSetMetric(ctx, c.Store, disabledUntil)
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func newWebhookAutoRegisterConfig(scope string) config.Config {
+	cfg := config.Config{}
+	cfg.Server.Webhook.Enabled = true
+	cfg.Server.Webhook.SecretToken = "secret"
+	cfg.Server.Webhook.AutoRegister.Enabled = true
+	cfg.Server.Webhook.AutoRegister.URL = "https://gcpe.example.net/webhook"
+	cfg.Server.Webhook.AutoRegister.Scope = scope
+
+	return cfg
+}
+
+func TestPullProjectRegistersWebhook(t *testing.T) {
+	ctx, c, mux, srv := newTestController(newWebhookAutoRegisterConfig("project"))
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":1,"path_with_namespace":"foo/bar","namespace":{"kind":"user","full_path":"foo"}}`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				_, _ = fmt.Fprint(w, `{"id":2,"url":"https://gcpe.example.net/webhook","alert_status":"executable"}`)
+
+				return
+			}
+
+			_, _ = fmt.Fprint(w, `[]`)
+		})
+
+	assert.NoError(t, c.PullProject(ctx, "foo/bar", config.ProjectPull{}))
+
+	status := schemas.Metric{
+		Kind: schemas.MetricKindWebhookStatus,
+		Labels: map[string]string{
+			"project": "foo/bar",
+			"scope":   "project",
+			"status":  "executable",
+		},
+	}
+	assert.NoError(t, c.Store.GetMetric(ctx, &status))
+	assert.Equal(t, float64(1), status.Value)
+
+	disabledUntil := schemas.Metric{
+		Kind: schemas.MetricKindWebhookDisabledUntilTimestamp,
+		Labels: map[string]string{
+			"project": "foo/bar",
+		},
+	}
+	metricExists, err := c.Store.MetricExists(ctx, disabledUntil.Key())
+	assert.NoError(t, err)
+	assert.True(t, metricExists)
+}
+
+func TestPullProjectsFromWildcardRegistersGroupWebhook(t *testing.T) {
+	ctx, c, mux, srv := newTestController(newWebhookAutoRegisterConfig("group"))
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/groups/foo/projects",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":1,"path_with_namespace":"foo/bar"},{"id":2,"path_with_namespace":"foo/baz"}]`)
+		})
+
+	var groupHookRegistrations int
+
+	mux.HandleFunc("/api/v4/groups/foo/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				groupHookRegistrations++
+				_, _ = fmt.Fprint(w, `{"id":3,"url":"https://gcpe.example.net/webhook","alert_status":"temporarily_disabled"}`)
+
+				return
+			}
+
+			_, _ = fmt.Fprint(w, `[]`)
+		})
+
+	w := config.NewWildcard()
+	w.Owner.Kind = "group"
+	w.Owner.Name = "foo"
+	assert.NoError(t, c.PullProjectsFromWildcard(ctx, w))
+	assert.Equal(t, 1, groupHookRegistrations)
+
+	for _, projectName := range []string{"foo/bar", "foo/baz"} {
+		status := schemas.Metric{
+			Kind: schemas.MetricKindWebhookStatus,
+			Labels: map[string]string{
+				"project": projectName,
+				"scope":   "group",
+				"status":  "temporarily_disabled",
+			},
+		}
+		assert.NoError(t, c.Store.GetMetric(ctx, &status))
+		assert.Equal(t, float64(1), status.Value)
+	}
+}
+
+func TestGarbageCollectProjectsUnregistersWebhook(t *testing.T) {
+	ctx, c, mux, srv := newTestController(newWebhookAutoRegisterConfig("project"))
+	defer srv.Close()
+
+	var deleted bool
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":2,"url":"https://gcpe.example.net/webhook"}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks/2",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			deleted = true
+		})
+
+	assert.NoError(t, c.Store.SetProject(ctx, schemas.NewProject("foo/bar")))
+	assert.NoError(t, c.GarbageCollectProjects(ctx))
+	assert.True(t, deleted)
+}
+
+func TestPullProjectsFromWildcardsSchedulesStaticProjects(t *testing.T) {
+	cfg := newWebhookAutoRegisterConfig("project")
+	cfg.Projects = []config.Project{config.NewProject("foo/bar")}
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	c.TaskHandlerPullProjectsFromWildcards(ctx)
+
+	// The task being already queued, it cannot be queued again
+	queued, err := c.Store.QueueTask(ctx, schemas.TaskTypePullProject.Queue(), schemas.TaskTypePullProject, "foo/bar", c.UUID.String())
+	assert.NoError(t, err)
+	assert.False(t, queued)
+}
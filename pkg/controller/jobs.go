@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 	"regexp"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -21,9 +22,105 @@ func (c *Controller) PullRefPipelineJobsMetrics(ctx context.Context, ref schemas
 		c.ProcessJobMetrics(ctx, ref, job)
 	}
 
+	c.ProcessStageMetrics(ctx, ref, jobs)
+
 	return nil
 }
 
+// ProcessStageMetrics aggregates the jobs of the most recent pipeline of the ref by stage.
+func (c *Controller) ProcessStageMetrics(ctx context.Context, ref schemas.Ref, jobs []schemas.Job) {
+	for _, stage := range schemas.NewStages(jobs) {
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = stage.Name
+
+		storeSetMetric(ctx, c.Store, schemas.Metric{
+			Kind:   schemas.MetricKindStageDurationSeconds,
+			Labels: labels,
+			Value:  stage.DurationSeconds(),
+		})
+
+		storeSetMetric(ctx, c.Store, schemas.Metric{
+			Kind:   schemas.MetricKindStageJobCount,
+			Labels: labels,
+			Value:  float64(stage.JobCount),
+		})
+
+		storeSetMetric(ctx, c.Store, schemas.Metric{
+			Kind:   schemas.MetricKindStageJobsDurationSeconds,
+			Labels: labels,
+			Value:  stage.JobsDurationSeconds,
+		})
+
+		emitStatusMetric(
+			ctx,
+			c.Store,
+			schemas.MetricKindStageStatus,
+			labels,
+			statusesList[:],
+			stage.Status,
+			ref.Project.OutputSparseStatusMetrics,
+		)
+	}
+}
+
+// PullRefPipelineCriticalPathMetrics ..
+func (c *Controller) PullRefPipelineCriticalPathMetrics(ctx context.Context, ref schemas.Ref) error {
+	g, err := c.Gitlab.GetPipelineGraph(
+		ctx,
+		ref.Project.Name,
+		ref.LatestPipeline.ID,
+		ref.Project.Pull.Pipeline.Jobs.FromChildPipelines.Enabled,
+	)
+	if err != nil {
+		return err
+	}
+
+	c.ProcessCriticalPathMetrics(ctx, ref, g.CriticalPath())
+
+	return nil
+}
+
+// ProcessCriticalPathMetrics ..
+func (c *Controller) ProcessCriticalPathMetrics(ctx context.Context, ref schemas.Ref, cp schemas.CriticalPath) {
+	labels := ref.DefaultLabelsValues()
+
+	storeSetMetric(ctx, c.Store, schemas.Metric{
+		Kind:   schemas.MetricKindCriticalPathDurationSeconds,
+		Labels: labels,
+		Value:  cp.DurationSeconds,
+	})
+
+	storeSetMetric(ctx, c.Store, schemas.Metric{
+		Kind:   schemas.MetricKindAverageParallelism,
+		Labels: labels,
+		Value:  cp.AverageParallelism(ref.LatestPipeline.DurationSeconds),
+	})
+
+	// Child pipelines can have jobs named after the ones of their parent
+	onCriticalPath := make(map[[2]string]bool)
+	for _, job := range cp.Jobs {
+		key := [2]string{job.Stage, job.Name}
+		onCriticalPath[key] = onCriticalPath[key] || job.OnCriticalPath
+	}
+
+	for key, value := range onCriticalPath {
+		jobLabels := ref.DefaultLabelsValues()
+		jobLabels["stage"] = key[0]
+		jobLabels["job_name"] = key[1]
+
+		metric := schemas.Metric{
+			Kind:   schemas.MetricKindJobCriticalPath,
+			Labels: jobLabels,
+		}
+
+		if value {
+			metric.Value = 1
+		}
+
+		storeSetMetric(ctx, c.Store, metric)
+	}
+}
+
 // PullRefMostRecentJobsMetrics ..
 func (c *Controller) PullRefMostRecentJobsMetrics(ctx context.Context, ref schemas.Ref) error {
 	if !ref.Project.Pull.Pipeline.Jobs.Enabled {
@@ -54,7 +151,13 @@ func (c *Controller) ProcessJobMetrics(ctx context.Context, ref schemas.Ref, job
 	labels["stage"] = job.Stage
 	labels["job_name"] = job.Name
 	labels["tag_list"] = job.TagList
-	labels["failure_reason"] = job.FailureReason
+
+	if ref.Project.Pull.Pipeline.Jobs.FailureReason.Enabled {
+		labels["failure_reason"] = job.FailureReason
+	} else {
+		// The label sets of the collectors being shared by all the projects, it is kept empty
+		labels["failure_reason"] = ""
+	}
 
 	if ref.Project.Pull.Pipeline.Jobs.RunnerDescription.Enabled {
 		re, err := regexp.Compile(ref.Project.Pull.Pipeline.Jobs.RunnerDescription.AggregationRegexp)
@@ -167,6 +270,42 @@ func (c *Controller) ProcessJobMetrics(ctx context.Context, ref schemas.Ref, job
 
 	storeSetMetric(ctx, c.Store, jobRunCount)
 
+	// A manual job keeps its ID once played, we can only tell when it happened if we saw it waiting
+	if ref.Project.Pull.Pipeline.Jobs.Waiting.Enabled && lastJob.ID == job.ID && lastJob.Status == "manual" && jobTriggered {
+		playDelayLabels := ref.DefaultLabelsValues()
+		playDelayLabels["stage"] = job.Stage
+		playDelayLabels["job_name"] = job.Name
+
+		storeSetMetric(ctx, c.Store, schemas.Metric{
+			Kind:   schemas.MetricKindJobManualPlayDelaySeconds,
+			Labels: playDelayLabels,
+			Value:  job.PlayedTimestamp(time.Now()) - job.Timestamp,
+		})
+	}
+
+	// We want to increment this counter only once per failed job ID. Jobs which had already
+	// failed before being seen for the first time are not accounted for, to prevent them from
+	// being counted again when restarting the exporter.
+	if job.Status == "failed" {
+		failureLabels := ref.DefaultLabelsValues()
+		failureLabels["stage"] = job.Stage
+		failureLabels["runner_description"] = labels["runner_description"]
+		failureLabels["failure_reason"] = job.FailureReason
+
+		jobFailureCount := schemas.Metric{
+			Kind:   schemas.MetricKindJobFailureCount,
+			Labels: failureLabels,
+		}
+
+		storeGetMetric(ctx, c.Store, &jobFailureCount)
+
+		if lastJobExists && (lastJob.ID != job.ID || lastJob.Status != "failed") {
+			jobFailureCount.Value++
+		}
+
+		storeSetMetric(ctx, c.Store, jobFailureCount)
+	}
+
 	storeSetMetric(ctx, c.Store, schemas.Metric{
 		Kind:   schemas.MetricKindJobArtifactSizeBytes,
 		Labels: labels,
@@ -172,3 +172,173 @@ func TestProcessJobMetrics(t *testing.T) {
 	}
 	assert.Equal(t, status, metrics[status.Key()])
 }
+
+func TestProcessJobFailureCountMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	p := schemas.NewProject("foo")
+	p.Pull.Pipeline.Jobs.FailureReason.Enabled = false
+
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "foo")
+
+	job := schemas.Job{
+		ID:            1,
+		Name:          "unit",
+		Stage:         "test",
+		Status:        "failed",
+		FailureReason: "script_failure",
+		Runner: schemas.Runner{
+			Description: "shared-runners-manager-1.gitlab.com",
+		},
+	}
+
+	failureLabels := ref.DefaultLabelsValues()
+	failureLabels["stage"] = "test"
+	failureLabels["runner_description"] = p.Pull.Pipeline.Jobs.RunnerDescription.AggregationRegexp
+	failureLabels["failure_reason"] = "script_failure"
+
+	failureCount := func() float64 {
+		m := schemas.Metric{Kind: schemas.MetricKindJobFailureCount, Labels: failureLabels}
+		metrics, _ := c.Store.Metrics(ctx)
+
+		return metrics[m.Key()].Value
+	}
+
+	// A job which had already failed when seen for the first time is not accounted for
+	c.ProcessJobMetrics(ctx, ref, job)
+	assert.Equal(t, float64(0), failureCount())
+
+	// The same job is only counted once
+	job.ID = 2
+	job.Status = "running"
+	c.ProcessJobMetrics(ctx, ref, job)
+	job.Status = "failed"
+	c.ProcessJobMetrics(ctx, ref, job)
+	c.ProcessJobMetrics(ctx, ref, job)
+	assert.Equal(t, float64(1), failureCount())
+
+	// Retried job
+	job.ID = 3
+	c.ProcessJobMetrics(ctx, ref, job)
+	assert.Equal(t, float64(2), failureCount())
+
+	// The failure reason is kept empty within the regular job labels when disabled
+	jobLabels := ref.DefaultLabelsValues()
+	jobLabels["stage"] = "test"
+	jobLabels["job_name"] = "unit"
+	jobLabels["tag_list"] = ""
+	jobLabels["runner_description"] = p.Pull.Pipeline.Jobs.RunnerDescription.AggregationRegexp
+	jobLabels["failure_reason"] = ""
+
+	jobID := schemas.Metric{Kind: schemas.MetricKindJobID, Labels: jobLabels, Value: 3}
+	metrics, _ := c.Store.Metrics(ctx)
+	assert.Equal(t, jobID, metrics[jobID.Key()])
+}
+
+func TestProcessJobManualPlayDelayMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	p := schemas.NewProject("foo")
+	p.Pull.Pipeline.Jobs.Waiting.Enabled = true
+
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "foo")
+
+	job := schemas.Job{ID: 1, Name: "deploy", Stage: "deploy", Status: "manual", Timestamp: 100}
+	c.ProcessJobMetrics(ctx, ref, job)
+
+	job.Status = "success"
+	job.StartedTimestamp = 200
+	job.QueuedDurationSeconds = 10
+	c.ProcessJobMetrics(ctx, ref, job)
+
+	labels := ref.DefaultLabelsValues()
+	labels["stage"] = "deploy"
+	labels["job_name"] = "deploy"
+
+	playDelay := schemas.Metric{Kind: schemas.MetricKindJobManualPlayDelaySeconds, Labels: labels, Value: 90}
+	metrics, _ := c.Store.Metrics(ctx)
+	assert.Equal(t, playDelay, metrics[playDelay.Key()])
+}
+
+func TestProcessStageMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	p := schemas.NewProject("foo")
+	p.OutputSparseStatusMetrics = true
+
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "foo")
+
+	c.ProcessStageMetrics(ctx, ref, []schemas.Job{
+		{Name: "unit", Stage: "test", Status: "success", StartedTimestamp: 100, FinishedTimestamp: 160, DurationSeconds: 60},
+		{Name: "lint", Stage: "test", Status: "failed", StartedTimestamp: 110, FinishedTimestamp: 130, DurationSeconds: 20},
+	})
+
+	metrics, _ := c.Store.Metrics(ctx)
+	labels := ref.DefaultLabelsValues()
+	labels["stage"] = "test"
+
+	for kind, value := range map[schemas.MetricKind]float64{
+		schemas.MetricKindStageDurationSeconds:     60,
+		schemas.MetricKindStageJobCount:            2,
+		schemas.MetricKindStageJobsDurationSeconds: 80,
+	} {
+		m := schemas.Metric{Kind: kind, Labels: labels, Value: value}
+		assert.Equal(t, m, metrics[m.Key()])
+	}
+
+	labels["status"] = "failed"
+	status := schemas.Metric{Kind: schemas.MetricKindStageStatus, Labels: labels, Value: 1}
+	assert.Equal(t, status, metrics[status.Key()])
+
+	// Only the current status gets exported with sparse status metrics
+	assert.Len(t, metrics, 4)
+}
+
+func TestProcessCriticalPathMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "foo")
+	ref.LatestPipeline.DurationSeconds = 40
+
+	c.ProcessCriticalPathMetrics(ctx, ref, schemas.CriticalPath{
+		DurationSeconds:     30,
+		JobsDurationSeconds: 50,
+		Jobs: []schemas.CriticalPathJob{
+			{Name: "build", Stage: "build", OnCriticalPath: true},
+			{Name: "lint", Stage: "test"},
+			{Name: "unit", Stage: "test"},
+			// Job of a child pipeline named after one of its parent
+			{Name: "unit", Stage: "test", OnCriticalPath: true},
+		},
+	})
+
+	metrics, _ := c.Store.Metrics(ctx)
+	labels := ref.DefaultLabelsValues()
+
+	for kind, value := range map[schemas.MetricKind]float64{
+		schemas.MetricKindCriticalPathDurationSeconds: 30,
+		schemas.MetricKindAverageParallelism:          1.25,
+	} {
+		m := schemas.Metric{Kind: kind, Labels: labels, Value: value}
+		assert.Equal(t, m, metrics[m.Key()])
+	}
+
+	for job, value := range map[[2]string]float64{
+		{"build", "build"}: 1,
+		{"test", "lint"}:   0,
+		{"test", "unit"}:   1,
+	} {
+		jobLabels := ref.DefaultLabelsValues()
+		jobLabels["stage"] = job[0]
+		jobLabels["job_name"] = job[1]
+
+		m := schemas.Metric{Kind: schemas.MetricKindJobCriticalPath, Labels: jobLabels, Value: value}
+		assert.Equal(t, m, metrics[m.Key()])
+	}
+
+	assert.Len(t, metrics, 5)
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
+)
+
+// campaign attempts to acquire or renew the leadership lease and updates
+// the leader election status accordingly.
+func (c *Controller) campaign(ctx context.Context) {
+	s, ok := c.Store.(*store.Redis)
+	if !ok {
+		// Without Redis, the process is on its own
+		c.LeaderElection.Set(true, c.UUID.String())
+
+		return
+	}
+
+	wasLeader := c.LeaderElection.IsLeader()
+
+	isLeader, err := s.AcquireLeaderLease(
+		ctx,
+		c.UUID.String(),
+		time.Duration(c.Config.Redis.LeaderElection.LeaseDurationSeconds)*time.Second,
+	)
+	if err != nil {
+		// We cannot tell whether our lease is still valid, we step down
+		// in order not to end up with several leaders
+		log.WithContext(ctx).
+			WithError(err).
+			Warn("acquiring leadership lease")
+
+		isLeader = false
+	}
+
+	leader := c.UUID.String()
+	if !isLeader {
+		if leader, err = s.LeaderLease(ctx); err != nil {
+			log.WithContext(ctx).
+				WithError(err).
+				Warn("reading leadership lease")
+		}
+	}
+
+	c.LeaderElection.Set(isLeader, leader)
+
+	switch {
+	case isLeader && !wasLeader:
+		log.WithContext(ctx).
+			WithField("uuid", c.UUID.String()).
+			Info("acquired leadership, scheduling the periodic tasks")
+	case !isLeader && wasLeader:
+		log.WithContext(ctx).
+			WithField("leader-uuid", leader).
+			Warn("lost leadership, only processing tasks")
+	}
+}
+
+// ScheduleLeaderElection periodically campaigns for the leadership whilst the process is running
+// and releases it on shutdown in order to get another instance to take over quickly.
+func (c *Controller) ScheduleLeaderElection(ctx context.Context) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:ScheduleLeaderElection")
+	defer span.End()
+
+	go func(ctx context.Context) {
+		ticker := time.NewTicker(time.Duration(c.Config.Redis.LeaderElection.RenewIntervalSeconds) * time.Second)
+
+		for {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+
+				if c.LeaderElection.IsLeader() {
+					if err := c.Store.(*store.Redis).ReleaseLeaderLease(context.Background(), c.UUID.String()); err != nil {
+						log.WithError(err).
+							Warn("releasing leadership lease")
+					}
+
+					c.LeaderElection.Set(false, "")
+				}
+
+				log.Info("stopped leader election")
+
+				return
+			case <-ticker.C:
+				c.campaign(ctx)
+			}
+		}
+	}(ctx)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
+)
+
+func TestCampaign(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer mr.Close()
+
+	newReplica := func() *Controller {
+		c := &Controller{
+			UUID:           uuid.New(),
+			Store:          store.NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()})),
+			LeaderElection: &monitor.LeaderElectionStatus{},
+		}
+		c.Config.Redis.LeaderElection.LeaseDurationSeconds = 10
+
+		return c
+	}
+
+	c1, c2 := newReplica(), newReplica()
+
+	c1.campaign(context.Background())
+	c2.campaign(context.Background())
+
+	assert.True(t, c1.LeaderElection.IsLeader())
+	assert.False(t, c2.LeaderElection.IsLeader())
+	assert.Equal(t, c1.UUID.String(), c2.LeaderElection.Leader())
+
+	// The leader failing to renew its lease, the other replica takes over
+	mr.FastForward(11 * time.Second)
+	c2.campaign(context.Background())
+	c1.campaign(context.Background())
+
+	assert.False(t, c1.LeaderElection.IsLeader())
+	assert.True(t, c2.LeaderElection.IsLeader())
+	assert.Equal(t, c2.UUID.String(), c1.LeaderElection.Leader())
+}
+
+func TestCampaignWithoutRedis(t *testing.T) {
+	_, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	assert.True(t, c.LeaderElection.IsLeader())
+	assert.Equal(t, c.UUID.String(), c.LeaderElection.Leader())
+}
+
+func TestScheduleDeferrableTaskNotLeader(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	c.LeaderElection.Set(false, "foo")
+	c.scheduleDeferrableTask(ctx, schemas.TaskTypeGarbageCollectMetrics)
+
+	count, err := c.Store.CurrentlyQueuedTasksCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	c.LeaderElection.Set(true, c.UUID.String())
+	c.scheduleDeferrableTask(ctx, schemas.TaskTypeGarbageCollectMetrics)
+
+	count, err = c.Store.CurrentlyQueuedTasksCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
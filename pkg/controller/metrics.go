@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/gitlab"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
 )
@@ -24,9 +26,13 @@ type Registry struct {
 		GitLabAPIRequestsCount     prometheus.Collector
 		GitlabAPIRequestsRemaining prometheus.Collector
 		GitlabAPIRequestsLimit     prometheus.Collector
+		IsLeader                   prometheus.Collector
 		MetricsCount               prometheus.Collector
+		MetricsPullIntervalSeconds prometheus.Collector
 		ProjectsCount              prometheus.Collector
 		RefsCount                  prometheus.Collector
+		WebhookEventsCount         prometheus.Collector
+		WebhookFailedEventsCount   prometheus.Collector
 	}
 
 	Collectors RegistryCollectors
@@ -35,47 +41,68 @@ type Registry struct {
 // RegistryCollectors ..
 type RegistryCollectors map[schemas.MetricKind]prometheus.Collector
 
-// NewRegistry initialize a new registry.
-func NewRegistry(ctx context.Context) *Registry {
+// NewRegistry initialize a new registry, the extra labels are added to all the
+// collectors of the project metrics.
+func NewRegistry(ctx context.Context, extraLabels ...string) *Registry {
 	r := &Registry{
 		Registry: prometheus.NewRegistry(),
 		Collectors: RegistryCollectors{
-			schemas.MetricKindCoverage:                             NewCollectorCoverage(),
-			schemas.MetricKindDurationSeconds:                      NewCollectorDurationSeconds(),
-			schemas.MetricKindEnvironmentBehindCommitsCount:        NewCollectorEnvironmentBehindCommitsCount(),
-			schemas.MetricKindEnvironmentBehindDurationSeconds:     NewCollectorEnvironmentBehindDurationSeconds(),
-			schemas.MetricKindEnvironmentDeploymentCount:           NewCollectorEnvironmentDeploymentCount(),
-			schemas.MetricKindEnvironmentDeploymentDurationSeconds: NewCollectorEnvironmentDeploymentDurationSeconds(),
-			schemas.MetricKindEnvironmentDeploymentJobID:           NewCollectorEnvironmentDeploymentJobID(),
-			schemas.MetricKindEnvironmentDeploymentStatus:          NewCollectorEnvironmentDeploymentStatus(),
-			schemas.MetricKindEnvironmentDeploymentTimestamp:       NewCollectorEnvironmentDeploymentTimestamp(),
-			schemas.MetricKindEnvironmentInformation:               NewCollectorEnvironmentInformation(),
-			schemas.MetricKindID:                                   NewCollectorID(),
-			schemas.MetricKindJobArtifactSizeBytes:                 NewCollectorJobArtifactSizeBytes(),
-			schemas.MetricKindJobDurationSeconds:                   NewCollectorJobDurationSeconds(),
-			schemas.MetricKindJobID:                                NewCollectorJobID(),
-			schemas.MetricKindJobQueuedDurationSeconds:             NewCollectorJobQueuedDurationSeconds(),
-			schemas.MetricKindJobRunCount:                          NewCollectorJobRunCount(),
-			schemas.MetricKindJobStatus:                            NewCollectorJobStatus(),
-			schemas.MetricKindJobTimestamp:                         NewCollectorJobTimestamp(),
-			schemas.MetricKindQueuedDurationSeconds:                NewCollectorQueuedDurationSeconds(),
-			schemas.MetricKindRunCount:                             NewCollectorRunCount(),
-			schemas.MetricKindStatus:                               NewCollectorStatus(),
-			schemas.MetricKindTimestamp:                            NewCollectorTimestamp(),
-			schemas.MetricKindTestReportTotalTime:                  NewCollectorTestReportTotalTime(),
-			schemas.MetricKindTestReportTotalCount:                 NewCollectorTestReportTotalCount(),
-			schemas.MetricKindTestReportSuccessCount:               NewCollectorTestReportSuccessCount(),
-			schemas.MetricKindTestReportFailedCount:                NewCollectorTestReportFailedCount(),
-			schemas.MetricKindTestReportSkippedCount:               NewCollectorTestReportSkippedCount(),
-			schemas.MetricKindTestReportErrorCount:                 NewCollectorTestReportErrorCount(),
-			schemas.MetricKindTestSuiteTotalTime:                   NewCollectorTestSuiteTotalTime(),
-			schemas.MetricKindTestSuiteTotalCount:                  NewCollectorTestSuiteTotalCount(),
-			schemas.MetricKindTestSuiteSuccessCount:                NewCollectorTestSuiteSuccessCount(),
-			schemas.MetricKindTestSuiteFailedCount:                 NewCollectorTestSuiteFailedCount(),
-			schemas.MetricKindTestSuiteSkippedCount:                NewCollectorTestSuiteSkippedCount(),
-			schemas.MetricKindTestSuiteErrorCount:                  NewCollectorTestSuiteErrorCount(),
-			schemas.MetricKindTestCaseExecutionTime:                NewCollectorTestCaseExecutionTime(),
-			schemas.MetricKindTestCaseStatus:                       NewCollectorTestCaseStatus(),
+			schemas.MetricKindAverageParallelism:                   NewCollectorAverageParallelism(extraLabels...),
+			schemas.MetricKindBlockedDurationSeconds:               NewCollectorBlockedDurationSeconds(extraLabels...),
+			schemas.MetricKindBridgeCausedFailure:                  NewCollectorBridgeCausedFailure(extraLabels...),
+			schemas.MetricKindBridgeDownstreamDurationSeconds:      NewCollectorBridgeDownstreamDurationSeconds(extraLabels...),
+			schemas.MetricKindBridgeDownstreamPipelineID:           NewCollectorBridgeDownstreamPipelineID(extraLabels...),
+			schemas.MetricKindBridgeDownstreamStatus:               NewCollectorBridgeDownstreamStatus(extraLabels...),
+			schemas.MetricKindCoverage:                             NewCollectorCoverage(extraLabels...),
+			schemas.MetricKindCriticalPathDurationSeconds:          NewCollectorCriticalPathDurationSeconds(extraLabels...),
+			schemas.MetricKindDurationSeconds:                      NewCollectorDurationSeconds(extraLabels...),
+			schemas.MetricKindEnvironmentBehindCommitsCount:        NewCollectorEnvironmentBehindCommitsCount(extraLabels...),
+			schemas.MetricKindEnvironmentBehindDurationSeconds:     NewCollectorEnvironmentBehindDurationSeconds(extraLabels...),
+			schemas.MetricKindEnvironmentDeploymentCount:           NewCollectorEnvironmentDeploymentCount(extraLabels...),
+			schemas.MetricKindEnvironmentDeploymentDurationSeconds: NewCollectorEnvironmentDeploymentDurationSeconds(extraLabels...),
+			schemas.MetricKindEnvironmentDeploymentJobID:           NewCollectorEnvironmentDeploymentJobID(extraLabels...),
+			schemas.MetricKindEnvironmentDeploymentStatus:          NewCollectorEnvironmentDeploymentStatus(extraLabels...),
+			schemas.MetricKindEnvironmentDeploymentTimestamp:       NewCollectorEnvironmentDeploymentTimestamp(extraLabels...),
+			schemas.MetricKindEnvironmentInformation:               NewCollectorEnvironmentInformation(extraLabels...),
+			schemas.MetricKindID:                                   NewCollectorID(extraLabels...),
+			schemas.MetricKindJobArtifactSizeBytes:                 NewCollectorJobArtifactSizeBytes(extraLabels...),
+			schemas.MetricKindJobAttemptsToSucceed:                 NewCollectorJobAttemptsToSucceed(extraLabels...),
+			schemas.MetricKindJobCriticalPath:                      NewCollectorJobCriticalPath(extraLabels...),
+			schemas.MetricKindJobDurationSeconds:                   NewCollectorJobDurationSeconds(extraLabels...),
+			schemas.MetricKindJobFailureCount:                      NewCollectorJobFailureCount(extraLabels...),
+			schemas.MetricKindJobID:                                NewCollectorJobID(extraLabels...),
+			schemas.MetricKindJobManualPlayDelaySeconds:            NewCollectorJobManualPlayDelaySeconds(extraLabels...),
+			schemas.MetricKindJobManualWaiting:                     NewCollectorJobManualWaiting(extraLabels...),
+			schemas.MetricKindJobQueuedDurationSeconds:             NewCollectorJobQueuedDurationSeconds(extraLabels...),
+			schemas.MetricKindJobRetryCount:                        NewCollectorJobRetryCount(extraLabels...),
+			schemas.MetricKindJobRunCount:                          NewCollectorJobRunCount(extraLabels...),
+			schemas.MetricKindJobStatus:                            NewCollectorJobStatus(extraLabels...),
+			schemas.MetricKindJobTimestamp:                         NewCollectorJobTimestamp(extraLabels...),
+			schemas.MetricKindJobWaitingForResourceDurationSeconds: NewCollectorJobWaitingForResourceDurationSeconds(extraLabels...),
+			schemas.MetricKindQueuedDurationSeconds:                NewCollectorQueuedDurationSeconds(extraLabels...),
+			schemas.MetricKindRunCount:                             NewCollectorRunCount(extraLabels...),
+			schemas.MetricKindStageDurationSeconds:                 NewCollectorStageDurationSeconds(extraLabels...),
+			schemas.MetricKindStageJobCount:                        NewCollectorStageJobCount(extraLabels...),
+			schemas.MetricKindStageJobsDurationSeconds:             NewCollectorStageJobsDurationSeconds(extraLabels...),
+			schemas.MetricKindStageStatus:                          NewCollectorStageStatus(extraLabels...),
+			schemas.MetricKindStatus:                               NewCollectorStatus(extraLabels...),
+			schemas.MetricKindTimestamp:                            NewCollectorTimestamp(extraLabels...),
+			schemas.MetricKindTestReportTotalTime:                  NewCollectorTestReportTotalTime(extraLabels...),
+			schemas.MetricKindTestReportTotalCount:                 NewCollectorTestReportTotalCount(extraLabels...),
+			schemas.MetricKindTestReportSuccessCount:               NewCollectorTestReportSuccessCount(extraLabels...),
+			schemas.MetricKindTestReportFailedCount:                NewCollectorTestReportFailedCount(extraLabels...),
+			schemas.MetricKindTestReportSkippedCount:               NewCollectorTestReportSkippedCount(extraLabels...),
+			schemas.MetricKindTestReportErrorCount:                 NewCollectorTestReportErrorCount(extraLabels...),
+			schemas.MetricKindTestSuiteTotalTime:                   NewCollectorTestSuiteTotalTime(extraLabels...),
+			schemas.MetricKindTestSuiteTotalCount:                  NewCollectorTestSuiteTotalCount(extraLabels...),
+			schemas.MetricKindTestSuiteSuccessCount:                NewCollectorTestSuiteSuccessCount(extraLabels...),
+			schemas.MetricKindTestSuiteFailedCount:                 NewCollectorTestSuiteFailedCount(extraLabels...),
+			schemas.MetricKindTestSuiteSkippedCount:                NewCollectorTestSuiteSkippedCount(extraLabels...),
+			schemas.MetricKindTestSuiteErrorCount:                  NewCollectorTestSuiteErrorCount(extraLabels...),
+			schemas.MetricKindTestCaseExecutionTime:                NewCollectorTestCaseExecutionTime(extraLabels...),
+			schemas.MetricKindTestCaseStatus:                       NewCollectorTestCaseStatus(extraLabels...),
+			schemas.MetricKindWebhookDisabledUntilTimestamp:        NewCollectorWebhookDisabledUntilTimestamp(extraLabels...),
+			schemas.MetricKindWebhookStatus:                        NewCollectorWebhookStatus(extraLabels...),
 		},
 	}
 
@@ -97,9 +124,13 @@ func (r *Registry) RegisterInternalCollectors() {
 	r.InternalCollectors.GitLabAPIRequestsCount = NewInternalCollectorGitLabAPIRequestsCount()
 	r.InternalCollectors.GitlabAPIRequestsRemaining = NewInternalCollectorGitLabAPIRequestsRemaining()
 	r.InternalCollectors.GitlabAPIRequestsLimit = NewInternalCollectorGitLabAPIRequestsLimit()
+	r.InternalCollectors.IsLeader = NewInternalCollectorIsLeader()
 	r.InternalCollectors.MetricsCount = NewInternalCollectorMetricsCount()
+	r.InternalCollectors.MetricsPullIntervalSeconds = NewInternalCollectorMetricsPullIntervalSeconds()
 	r.InternalCollectors.ProjectsCount = NewInternalCollectorProjectsCount()
 	r.InternalCollectors.RefsCount = NewInternalCollectorRefsCount()
+	r.InternalCollectors.WebhookEventsCount = NewInternalCollectorWebhookEventsCount()
+	r.InternalCollectors.WebhookFailedEventsCount = NewInternalCollectorWebhookFailedEventsCount()
 
 	_ = r.Register(r.InternalCollectors.CurrentlyQueuedTasksCount)
 	_ = r.Register(r.InternalCollectors.EnvironmentsCount)
@@ -107,9 +138,44 @@ func (r *Registry) RegisterInternalCollectors() {
 	_ = r.Register(r.InternalCollectors.GitLabAPIRequestsCount)
 	_ = r.Register(r.InternalCollectors.GitlabAPIRequestsRemaining)
 	_ = r.Register(r.InternalCollectors.GitlabAPIRequestsLimit)
+	_ = r.Register(r.InternalCollectors.IsLeader)
 	_ = r.Register(r.InternalCollectors.MetricsCount)
+	_ = r.Register(r.InternalCollectors.MetricsPullIntervalSeconds)
 	_ = r.Register(r.InternalCollectors.ProjectsCount)
 	_ = r.Register(r.InternalCollectors.RefsCount)
+	_ = r.Register(r.InternalCollectors.WebhookEventsCount)
+	_ = r.Register(r.InternalCollectors.WebhookFailedEventsCount)
+}
+
+// RegisterTaskExecutionCollectors declares the collectors of the task execution metrics to the registry.
+func (r *Registry) RegisterTaskExecutionCollectors(tec TaskExecutionCollectors) {
+	for _, c := range []prometheus.Collector{
+		tec.ExecutionDurationSeconds,
+		tec.ExecutionsCount,
+		tec.FailuresCount,
+		tec.OrphanedTasksCount,
+		tec.QueueWaitDurationSeconds,
+		tec.RecoveredTasksCount,
+		tec.SkipsCount,
+	} {
+		if c != nil {
+			_ = r.Register(c)
+		}
+	}
+}
+
+// RegisterGarbageCollectionCollectors declares the collectors of the garbage collection metrics to the registry.
+func (r *Registry) RegisterGarbageCollectionCollectors(gcc GarbageCollectionCollectors) {
+	for _, c := range []prometheus.Collector{
+		gcc.DeletedCount,
+		gcc.RunsCount,
+		gcc.ScannedCount,
+		gcc.SlicesCount,
+	} {
+		if c != nil {
+			_ = r.Register(c)
+		}
+	}
 }
 
 // ExportInternalMetrics ..
@@ -123,8 +189,11 @@ func (r *Registry) ExportInternalMetrics(
 		environmentsCount    int64
 		executedTasksCount   uint64
 		metricsCount         int64
+		metricsPulls         schemas.MetricsPulls
 		projectsCount        int64
 		refsCount            int64
+		webhookEventsCount   schemas.WebhookEventsCount
+		webhookFailedEvents  int64
 	)
 
 	currentlyQueuedTasks, err = s.CurrentlyQueuedTasksCount(ctx)
@@ -157,6 +226,21 @@ func (r *Registry) ExportInternalMetrics(
 		return
 	}
 
+	metricsPulls, err = s.MetricsPulls(ctx)
+	if err != nil {
+		return
+	}
+
+	webhookEventsCount, err = s.WebhookEventsCount(ctx)
+	if err != nil {
+		return
+	}
+
+	webhookFailedEvents, err = s.FailedWebhookEventsCount(ctx)
+	if err != nil {
+		return
+	}
+
 	r.InternalCollectors.CurrentlyQueuedTasksCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(currentlyQueuedTasks))
 	r.InternalCollectors.EnvironmentsCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(environmentsCount))
 	r.InternalCollectors.ExecutedTasksCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(executedTasksCount))
@@ -166,10 +250,32 @@ func (r *Registry) ExportInternalMetrics(
 	r.InternalCollectors.MetricsCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(metricsCount))
 	r.InternalCollectors.ProjectsCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(projectsCount))
 	r.InternalCollectors.RefsCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(refsCount))
+	r.InternalCollectors.WebhookFailedEventsCount.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(float64(webhookFailedEvents))
+
+	for k, v := range webhookEventsCount {
+		r.InternalCollectors.WebhookEventsCount.(*prometheus.GaugeVec).With(prometheus.Labels{
+			"event_type": k.Type,
+			"status":     string(k.Status),
+		}).Set(float64(v))
+	}
+
+	for _, mp := range metricsPulls {
+		r.InternalCollectors.MetricsPullIntervalSeconds.(*prometheus.HistogramVec).With(prometheus.Labels{}).Observe(float64(mp.IntervalSeconds))
+	}
 
 	return
 }
 
+// ExportLeaderElectionMetrics ..
+func (r *Registry) ExportLeaderElectionMetrics(les *monitor.LeaderElectionStatus) {
+	var isLeader float64
+	if les != nil && les.IsLeader() {
+		isLeader = 1
+	}
+
+	r.InternalCollectors.IsLeader.(*prometheus.GaugeVec).With(prometheus.Labels{}).Set(isLeader)
+}
+
 // RegisterCollectors add all our metrics to the registry.
 func (r *Registry) RegisterCollectors() error {
 	for _, c := range r.Collectors {
@@ -200,6 +306,31 @@ func (r *Registry) ExportMetrics(metrics schemas.Metrics) {
 	}
 }
 
+// withProjectIDLabel returns a copy of the metrics with their project_id label set.
+func withProjectIDLabel(metrics schemas.Metrics, projects schemas.Projects) schemas.Metrics {
+	projectIDs := make(map[string]string, len(projects))
+	for _, p := range projects {
+		if p.ID != 0 {
+			projectIDs[p.Name] = strconv.FormatInt(p.ID, 10)
+		}
+	}
+
+	labeledMetrics := make(schemas.Metrics, len(metrics))
+
+	for k, m := range metrics {
+		labels := make(map[string]string, len(m.Labels)+1)
+		for name, value := range m.Labels {
+			labels[name] = value
+		}
+
+		labels["project_id"] = projectIDs[m.Labels["project"]]
+		m.Labels = labels
+		labeledMetrics[k] = m
+	}
+
+	return labeledMetrics
+}
+
 func emitStatusMetric(ctx context.Context, s store.Store, metricKind schemas.MetricKind, labelValues map[string]string, statuses []string, status string, sparseMetrics bool) {
 	// Moved into separate function to reduce cyclomatic complexity
 	// List of available statuses from the API spec
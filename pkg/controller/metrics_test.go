@@ -75,3 +75,35 @@ func TestExportMetrics(_ *testing.T) {
 	// TODO: Assert that we have the correct metrics being rendered by the exporter
 	r.ExportMetrics(metrics)
 }
+
+func TestMetricsHandlerProjectIDLabel(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Server.Metrics.OutputProjectIDLabel = true
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	p := schemas.NewProject("foo")
+	p.ID = 42
+
+	assert.NoError(t, c.Store.SetProject(ctx, p))
+	assert.NoError(t, c.Store.SetMetric(ctx, schemas.Metric{
+		Kind: schemas.MetricKindCoverage,
+		Labels: prometheus.Labels{
+			"project":   "foo",
+			"topics":    "",
+			"ref":       "bar",
+			"kind":      "branch",
+			"source":    "",
+			"variables": "",
+		},
+		Value: 50,
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	c.MetricsHandler(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Contains(t, w.Body.String(), `project_id="42"`)
+}
@@ -80,6 +80,10 @@ func (c *Controller) PullRefMetrics(ctx context.Context, ref schemas.Ref) error
 		}
 	}
 
+	if ref.Project.Pull.Pipeline.Jobs.Enabled && ref.Project.Pull.Pipeline.Jobs.Waiting.Enabled {
+		return c.ProcessWaitingMetrics(ctx, ref)
+	}
+
 	return nil
 }
 
@@ -188,6 +192,24 @@ func (c *Controller) ProcessPipelinesMetrics(ctx context.Context, ref schemas.Re
 			if err := c.PullRefPipelineJobsMetrics(ctx, ref); err != nil {
 				return err
 			}
+
+			if ref.Project.Pull.Pipeline.Jobs.CriticalPath.Enabled && slices.Contains(finishedStatusesList, pipeline.Status) {
+				if err := c.PullRefPipelineCriticalPathMetrics(ctx, ref); err != nil {
+					return err
+				}
+			}
+
+			if ref.Project.Pull.Pipeline.Jobs.Retries.Enabled {
+				if err := c.PullRefPipelineRetriesMetrics(ctx, ref); err != nil {
+					return err
+				}
+			}
+		}
+
+		if ref.Project.Pull.Pipeline.Bridges.Enabled {
+			if err := c.PullRefPipelineBridgesMetrics(ctx, ref); err != nil {
+				return err
+			}
 		}
 	} else {
 		if err := c.PullRefMostRecentJobsMetrics(ctx, ref); err != nil {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
 )
 
 // PullProject ..
@@ -17,9 +18,10 @@ func (c *Controller) PullProject(ctx context.Context, name string, pull config.P
 	}
 
 	p := schemas.NewProject(gp.PathWithNamespace)
+	p.ID = gp.ID
 	p.Pull = pull
 
-	projectExists, err := c.Store.ProjectExists(ctx, p.Key())
+	projectExists, err := c.trackProject(ctx, p, &projectsByID{})
 	if err != nil {
 		return err
 	}
@@ -39,6 +41,15 @@ func (c *Controller) PullProject(ctx context.Context, name string, pull config.P
 		c.ScheduleTask(ctx, schemas.TaskTypePullEnvironmentsFromProject, string(p.Key()), p)
 	}
 
+	if c.isWebhookAutoRegisterEnabled() {
+		var group string
+		if gp.Namespace != nil {
+			group = c.webhookGroup(gp.Namespace.Kind, gp.Namespace.FullPath)
+		}
+
+		c.registerWebhooks(ctx, []schemas.Project{p}, group)
+	}
+
 	return nil
 }
 
@@ -49,8 +60,10 @@ func (c *Controller) PullProjectsFromWildcard(ctx context.Context, w config.Wild
 		return err
 	}
 
+	index := &projectsByID{}
+
 	for _, p := range foundProjects {
-		projectExists, err := c.Store.ProjectExists(ctx, p.Key())
+		projectExists, err := c.trackProject(ctx, p, index)
 		if err != nil {
 			return err
 		}
@@ -76,5 +89,186 @@ func (c *Controller) PullProjectsFromWildcard(ctx context.Context, w config.Wild
 		}
 	}
 
+	if c.isWebhookAutoRegisterEnabled() {
+		c.registerWebhooks(ctx, foundProjects, c.webhookGroup(w.Owner.Kind, w.Owner.Name))
+	}
+
+	return nil
+}
+
+// projectsByID indexes the stored projects using their IDs. It is lazily loaded from the
+// store, at most once per discovery run, as it is only required for the projects we do not
+// know under their current name.
+type projectsByID struct {
+	projects map[int64]schemas.Project
+}
+
+func (index *projectsByID) get(ctx context.Context, st store.Store, id int64) (p schemas.Project, found bool, err error) {
+	if index.projects == nil {
+		var storedProjects schemas.Projects
+
+		if storedProjects, err = st.Projects(ctx); err != nil {
+			return
+		}
+
+		index.projects = make(map[int64]schemas.Project, len(storedProjects))
+
+		for _, sp := range storedProjects {
+			if sp.ID != 0 {
+				index.projects[sp.ID] = sp
+			}
+		}
+	}
+
+	p, found = index.projects[id]
+
+	return
+}
+
+// trackProject returns whether the project is already known, either under its current
+// name or under a former one, in which case it gets migrated to its new name. The projects
+// remain keyed by name, their ID only being used to detect the renames, as the keys of their
+// refs, environments and metrics all derive from the name (see schemas.Project.Key).
+func (c *Controller) trackProject(ctx context.Context, p schemas.Project, index *projectsByID) (bool, error) {
+	projectExists, err := c.Store.ProjectExists(ctx, p.Key())
+	if err != nil {
+		return false, err
+	}
+
+	if projectExists {
+		sp := schemas.NewProject(p.Name)
+		if err = c.Store.GetProject(ctx, &sp); err != nil {
+			return false, err
+		}
+
+		// Projects stored by former versions do not have their ID set
+		if p.ID != 0 && sp.ID != p.ID {
+			sp.ID = p.ID
+
+			if err = c.Store.SetProject(ctx, sp); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	}
+
+	if p.ID == 0 {
+		return false, nil
+	}
+
+	sp, found, err := index.get(ctx, c.Store, p.ID)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err = c.migrateProject(ctx, sp, p); err != nil {
+		return false, err
+	}
+
+	index.projects[p.ID] = p
+
+	return true, nil
+}
+
+// migrateProject moves the project along with its refs, environments and metrics
+// from its former name onto its new one, preserving the values of the metrics.
+func (c *Controller) migrateProject(ctx context.Context, from, to schemas.Project) error {
+	logFields := log.Fields{
+		"project-id":       to.ID,
+		"project-name":     to.Name,
+		"old-project-name": from.Name,
+	}
+
+	if err := c.Store.SetProject(ctx, to); err != nil {
+		return err
+	}
+
+	refs, err := c.Store.Refs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.Project.Name != from.Name {
+			continue
+		}
+
+		if err = c.Store.DelRef(ctx, ref.Key()); err != nil {
+			return err
+		}
+
+		ref.Project = to
+
+		if err = c.Store.SetRef(ctx, ref); err != nil {
+			return err
+		}
+	}
+
+	envs, err := c.Store.Environments(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, env := range envs {
+		if env.ProjectName != from.Name {
+			continue
+		}
+
+		if err = c.Store.DelEnvironment(ctx, env.Key()); err != nil {
+			return err
+		}
+
+		env.ProjectName = to.Name
+
+		if err = c.Store.SetEnvironment(ctx, env); err != nil {
+			return err
+		}
+	}
+
+	metrics, err := c.Store.Metrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range metrics {
+		if m.Labels["project"] != from.Name {
+			continue
+		}
+
+		if err = c.Store.DelMetric(ctx, m.Key()); err != nil {
+			return err
+		}
+
+		labels := make(map[string]string, len(m.Labels))
+		for name, value := range m.Labels {
+			labels[name] = value
+		}
+
+		labels["project"] = to.Name
+		m.Labels = labels
+
+		if err = c.Store.SetMetric(ctx, m); err != nil {
+			return err
+		}
+	}
+
+	lastWebhookEvent, err := c.Store.GetProjectLastWebhookEvent(ctx, from.Key())
+	if err != nil {
+		return err
+	}
+
+	if !lastWebhookEvent.IsZero() {
+		if err = c.Store.SetProjectLastWebhookEvent(ctx, to.Key(), lastWebhookEvent); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Store.DelProject(ctx, from.Key()); err != nil {
+		return err
+	}
+
+	log.WithFields(logFields).Info("project has been moved, migrated it to its new name")
+
 	return nil
 }
@@ -25,9 +25,105 @@ func TestPullProjectsFromWildcard(t *testing.T) {
 
 	projects, _ := c.Store.Projects(ctx)
 	p1 := schemas.NewProject("bar")
+	p1.ID = 2
 
 	expectedProjects := schemas.Projects{
 		p1.Key(): p1,
 	}
 	assert.Equal(t, expectedProjects, projects)
 }
+
+func TestPullProjectsFromWildcardMovedProject(t *testing.T) {
+	ctx, c, mux, srv := newTestController(config.Config{})
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/projects",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":2,"path_with_namespace":"bar","jobs_enabled":true}]`)
+		})
+
+	w := config.NewWildcard()
+
+	// The project used to be named foo
+	p := schemas.NewProject("foo")
+	p.ID = 2
+	p.ProjectParameters = w.ProjectParameters
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	m := schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: map[string]string{"project": "foo", "ref": "main", "kind": "branch"}, Value: 3}
+
+	assert.NoError(t, c.Store.SetProject(ctx, p))
+	assert.NoError(t, c.Store.SetRef(ctx, ref))
+	assert.NoError(t, c.Store.SetMetric(ctx, m))
+
+	assert.NoError(t, c.PullProjectsFromWildcard(ctx, w))
+
+	np := p
+	np.Name = "bar"
+
+	projects, _ := c.Store.Projects(ctx)
+	assert.Equal(t, schemas.Projects{np.Key(): np}, projects)
+
+	nref := schemas.NewRef(np, schemas.RefKindBranch, "main")
+	refs, _ := c.Store.Refs(ctx)
+	assert.Equal(t, schemas.Refs{nref.Key(): nref}, refs)
+
+	// The value of the metric is preserved
+	nm := schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: map[string]string{"project": "bar", "ref": "main", "kind": "branch"}, Value: 3}
+	metrics, _ := c.Store.Metrics(ctx)
+	assert.Equal(t, schemas.Metrics{nm.Key(): nm}, metrics)
+}
+
+func TestPullProjectsFromWildcardMovedProjects(t *testing.T) {
+	ctx, c, mux, srv := newTestController(config.Config{})
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/projects",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":1,"path_with_namespace":"foo/bar"},{"id":2,"path_with_namespace":"foo/baz"},{"id":3,"path_with_namespace":"foo/qux"}]`)
+		})
+
+	w := config.NewWildcard()
+
+	for id, name := range map[int64]string{1: "old/bar", 2: "old/baz"} {
+		p := schemas.NewProject(name)
+		p.ID = id
+		p.ProjectParameters = w.ProjectParameters
+		assert.NoError(t, c.Store.SetProject(ctx, p))
+	}
+
+	assert.NoError(t, c.PullProjectsFromWildcard(ctx, w))
+
+	projects, _ := c.Store.Projects(ctx)
+	assert.Len(t, projects, 3)
+
+	for id, name := range map[int64]string{1: "foo/bar", 2: "foo/baz", 3: "foo/qux"} {
+		p := schemas.NewProject(name)
+		assert.Contains(t, projects, p.Key())
+		assert.Equal(t, id, projects[p.Key()].ID)
+	}
+}
+
+func TestProjectsByID(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	p := schemas.NewProject("foo/bar")
+	p.ID = 1
+	assert.NoError(t, c.Store.SetProject(ctx, p))
+
+	index := &projectsByID{}
+
+	sp, found, err := index.get(ctx, c.Store, 1)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, p, sp)
+
+	// The index is only loaded once from the store
+	p2 := schemas.NewProject("foo/baz")
+	p2.ID = 2
+	assert.NoError(t, c.Store.SetProject(ctx, p2))
+
+	_, found, err = index.get(ctx, c.Store, 2)
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
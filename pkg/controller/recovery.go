@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
+)
+
+// orphanedTasksRecoveryInterval is the interval at which the leader looks up the tasks of the crashed processes.
+const orphanedTasksRecoveryInterval = 30 * time.Second
+
+// isSoleInstance returns whether no other instance is currently
+// sharing the same Redis, and hence processing the queues.
+func (c *Controller) isSoleInstance(ctx context.Context) bool {
+	s, ok := c.Store.(*store.Redis)
+	if !ok {
+		return true
+	}
+
+	uuids, err := s.AliveProcesses(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Warn("listing alive processes")
+
+		return false
+	}
+
+	return !slices.ContainsFunc(uuids, func(uuid string) bool {
+		return uuid != c.UUID.String()
+	})
+}
+
+// RecoverOrphanedTasks reclaims the tasks queued by processes which are not alive anymore, as they would
+// otherwise prevent them from being scheduled again. They only get queued back when requeue is set, which
+// is meant to be done once the queues have been purged. Otherwise the tasks are still queued, and they
+// would be processed twice.
+func (c *Controller) RecoverOrphanedTasks(ctx context.Context, requeue bool) (orphaned, recovered int) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:RecoverOrphanedTasks")
+	defer span.End()
+
+	s, ok := c.Store.(*store.Redis)
+	if !ok {
+		return
+	}
+
+	locks, err := s.TaskLocks(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Warn("listing queued tasks")
+
+		return
+	}
+
+	alive := map[string]bool{c.UUID.String(): true}
+
+	for _, l := range locks {
+		if _, ok := alive[l.ProcessUUID]; !ok {
+			if alive[l.ProcessUUID], err = s.KeepaliveExists(ctx, l.ProcessUUID); err != nil {
+				log.WithContext(ctx).
+					WithError(err).
+					Warn("checking process keepalive")
+
+				return
+			}
+		}
+
+		if alive[l.ProcessUUID] {
+			continue
+		}
+
+		// Another instance may be recovering it concurrently
+		released, err := s.ReleaseTaskLock(ctx, l.Queue, l.Type, l.UniqueID, l.ProcessUUID)
+		if err != nil {
+			log.WithContext(ctx).
+				WithError(err).
+				Warn("releasing orphaned task")
+
+			continue
+		}
+
+		if !released {
+			continue
+		}
+
+		orphaned++
+		c.TaskController.recordTaskRecovery(c.TaskController.TaskExecutionCollectors.OrphanedTasksCount, l.Type)
+
+		if requeue && c.rescheduleTask(ctx, l) {
+			recovered++
+			c.TaskController.recordTaskRecovery(c.TaskController.TaskExecutionCollectors.RecoveredTasksCount, l.Type)
+		}
+	}
+
+	if orphaned > 0 {
+		log.WithContext(ctx).
+			WithFields(log.Fields{
+				"orphaned-tasks-count":  orphaned,
+				"recovered-tasks-count": recovered,
+			}).
+			Info("recovered orphaned tasks")
+	}
+
+	return
+}
+
+// ScheduleOrphanedTasksRecovery periodically reclaims the tasks of the processes which
+// crashed in the meantime, it is only done by the leader.
+func (c *Controller) ScheduleOrphanedTasksRecovery(ctx context.Context) {
+	go func(ctx context.Context) {
+		ticker := time.NewTicker(orphanedTasksRecoveryInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if c.LeaderElection.IsLeader() {
+					c.RecoverOrphanedTasks(ctx, false)
+				}
+			}
+		}
+	}(ctx)
+}
+
+// rescheduleTask queues back a task onto the queue of its lock, it returns false if the
+// arguments of the task could not be found anymore.
+func (c *Controller) rescheduleTask(ctx context.Context, l schemas.TaskLock) bool {
+	logger := log.WithContext(ctx).
+		WithFields(log.Fields{
+			"task_queue":     l.Queue,
+			"task_type":      l.Type,
+			"task_unique_id": l.UniqueID,
+		})
+
+	switch l.Type {
+	case schemas.TaskTypePullProjectsFromWildcards,
+		schemas.TaskTypePullEnvironmentsFromProjects,
+		schemas.TaskTypePullRefsFromProjects,
+		schemas.TaskTypePullMetrics,
+		schemas.TaskTypeReconcileMetrics,
+		schemas.TaskTypeGarbageCollectProjects,
+		schemas.TaskTypeGarbageCollectEnvironments,
+		schemas.TaskTypeGarbageCollectRefs,
+		schemas.TaskTypeGarbageCollectMetrics:
+		c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID)
+
+		return true
+
+	case schemas.TaskTypePullProjectsFromWildcard:
+		id, err := strconv.Atoi(l.UniqueID)
+		if err != nil || id < 0 || id >= len(c.Config.Wildcards) {
+			break
+		}
+
+		c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID, l.UniqueID, c.Config.Wildcards[id])
+
+		return true
+
+	case schemas.TaskTypePullProject:
+		for _, p := range c.Config.Projects {
+			if p.Name == l.UniqueID {
+				c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID, p.Name, p.Pull)
+
+				return true
+			}
+		}
+
+		p := schemas.NewProject(l.UniqueID)
+
+		exists, err := c.Store.ProjectExists(ctx, p.Key())
+		if err != nil {
+			logger.WithError(err).Warn("reading project")
+
+			break
+		}
+
+		if exists {
+			if err = c.Store.GetProject(ctx, &p); err != nil {
+				logger.WithError(err).Warn("reading project")
+
+				break
+			}
+
+			c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID, p.Name, p.Pull)
+
+			return true
+		}
+
+	case schemas.TaskTypePullEnvironmentsFromProject,
+		schemas.TaskTypePullRefsFromProject:
+		projects, err := c.Store.ProjectsByKeys(ctx, []schemas.ProjectKey{schemas.ProjectKey(l.UniqueID)})
+		if err != nil {
+			logger.WithError(err).Warn("reading project")
+
+			break
+		}
+
+		if p, ok := projects[schemas.ProjectKey(l.UniqueID)]; ok {
+			c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID, p)
+
+			return true
+		}
+
+	case schemas.TaskTypePullEnvironmentMetrics:
+		envs, err := c.Store.EnvironmentsByKeys(ctx, []schemas.EnvironmentKey{schemas.EnvironmentKey(l.UniqueID)})
+		if err != nil {
+			logger.WithError(err).Warn("reading environment")
+
+			break
+		}
+
+		if env, ok := envs[schemas.EnvironmentKey(l.UniqueID)]; ok {
+			c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID, env)
+
+			return true
+		}
+
+	case schemas.TaskTypePullRefMetrics:
+		refs, err := c.Store.RefsByKeys(ctx, []schemas.RefKey{schemas.RefKey(l.UniqueID)})
+		if err != nil {
+			logger.WithError(err).Warn("reading ref")
+
+			break
+		}
+
+		if ref, ok := refs[schemas.RefKey(l.UniqueID)]; ok {
+			c.scheduleTask(ctx, l.Queue, l.Type, l.UniqueID, ref)
+
+			return true
+		}
+	}
+
+	logger.Debug("orphaned task could not be queued back")
+
+	return false
+}
+
+func (tc *TaskController) recordTaskRecovery(collector prometheus.Collector, tt schemas.TaskType) {
+	if collector == nil {
+		return
+	}
+
+	collector.(*prometheus.CounterVec).With(prometheus.Labels{
+		"task_type": string(tt),
+	}).Inc()
+}
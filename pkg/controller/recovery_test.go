@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
+)
+
+func newTestRedisController(t *testing.T) (ctx context.Context, c Controller, s *store.Redis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+
+	t.Cleanup(mr.Close)
+
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	s = store.NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	c.Store = s
+
+	return
+}
+
+func TestIsSoleInstance(t *testing.T) {
+	ctx, c, s := newTestRedisController(t)
+
+	_, _ = s.SetKeepalive(ctx, c.UUID.String(), 10*time.Second)
+	assert.True(t, c.isSoleInstance(ctx))
+
+	_, _ = s.SetKeepalive(ctx, "controller2", 10*time.Second)
+	assert.False(t, c.isSoleInstance(ctx))
+}
+
+func TestRecoverOrphanedTasks(t *testing.T) {
+	ctx, c, s := newTestRedisController(t)
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	_ = s.SetRef(ctx, ref)
+
+	_, _ = s.SetKeepalive(ctx, "alive", 10*time.Second)
+
+	_, _ = s.QueueTask(ctx, schemas.TaskTypeGarbageCollectMetrics.Queue(), schemas.TaskTypeGarbageCollectMetrics, "_", "alive")
+	_, _ = s.QueueTask(ctx, schemas.TaskTypeGarbageCollectRefs.Queue(), schemas.TaskTypeGarbageCollectRefs, "_", "crashed")
+	_, _ = s.QueueTask(ctx, schemas.TaskTypePullRefMetrics.Queue(), schemas.TaskTypePullRefMetrics, string(ref.Key()), "crashed")
+	_, _ = s.QueueTask(ctx, schemas.TaskTypePullRefMetrics.Queue(), schemas.TaskTypePullRefMetrics, "unknown", "crashed")
+
+	orphaned, recovered := c.RecoverOrphanedTasks(ctx, true)
+	assert.Equal(t, 3, orphaned)
+	assert.Equal(t, 2, recovered)
+
+	orphanedRefMetrics := c.TaskController.TaskExecutionCollectors.OrphanedTasksCount.(*prometheus.CounterVec).WithLabelValues(string(schemas.TaskTypePullRefMetrics))
+	recoveredRefMetrics := c.TaskController.TaskExecutionCollectors.RecoveredTasksCount.(*prometheus.CounterVec).WithLabelValues(string(schemas.TaskTypePullRefMetrics))
+	assert.Equal(t, float64(2), testutil.ToFloat64(orphanedRefMetrics))
+	assert.Equal(t, float64(1), testutil.ToFloat64(recoveredRefMetrics))
+
+	// The tasks of the processes still alive are left untouched
+	locks, err := s.TaskLocks(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, locks, schemas.TaskLock{Queue: schemas.TaskTypeGarbageCollectMetrics.Queue(), Type: schemas.TaskTypeGarbageCollectMetrics, UniqueID: "_", ProcessUUID: "alive"})
+	assert.NotContains(t, locks, schemas.TaskLock{Queue: schemas.TaskTypePullRefMetrics.Queue(), Type: schemas.TaskTypePullRefMetrics, UniqueID: "unknown", ProcessUUID: "crashed"})
+
+	// Nothing left to recover
+	orphaned, recovered = c.RecoverOrphanedTasks(ctx, true)
+	assert.Equal(t, 0, orphaned)
+	assert.Equal(t, 0, recovered)
+}
+
+func TestRecoverOrphanedTasksWithoutRequeue(t *testing.T) {
+	ctx, c, s := newTestRedisController(t)
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	_ = s.SetRef(ctx, ref)
+
+	_, _ = s.QueueTask(ctx, schemas.TaskTypePullRefMetrics.Queue(), schemas.TaskTypePullRefMetrics, string(ref.Key()), "crashed")
+
+	// The task still being queued, it only gets its lock released
+	orphaned, recovered := c.RecoverOrphanedTasks(ctx, false)
+	assert.Equal(t, 1, orphaned)
+	assert.Equal(t, 0, recovered)
+
+	locks, err := s.TaskLocks(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, locks)
+
+	qlen, err := c.TaskController.Queues[schemas.TaskQueueMetrics].Len(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, qlen)
+}
+
+func TestRescheduleTask(t *testing.T) {
+	ctx, c, s := newTestRedisController(t)
+
+	p := schemas.NewProject("foo")
+	env := schemas.Environment{ProjectName: p.Name, Name: "prod"}
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "main")
+
+	_ = s.SetProject(ctx, p)
+	_ = s.SetEnvironment(ctx, env)
+	_ = s.SetRef(ctx, ref)
+
+	for _, tc := range []struct {
+		lock        schemas.TaskLock
+		rescheduled bool
+	}{
+		{schemas.TaskLock{Type: schemas.TaskTypePullProject, UniqueID: p.Name}, true},
+		{schemas.TaskLock{Type: schemas.TaskTypePullProject, UniqueID: "bar"}, false},
+		{schemas.TaskLock{Type: schemas.TaskTypePullRefsFromProject, UniqueID: string(p.Key())}, true},
+		{schemas.TaskLock{Type: schemas.TaskTypePullRefsFromProject, UniqueID: "unknown"}, false},
+		{schemas.TaskLock{Type: schemas.TaskTypePullEnvironmentMetrics, UniqueID: string(env.Key())}, true},
+		{schemas.TaskLock{Type: schemas.TaskTypePullEnvironmentMetrics, UniqueID: "unknown"}, false},
+		{schemas.TaskLock{Type: schemas.TaskTypePullRefMetrics, UniqueID: string(ref.Key())}, true},
+		{schemas.TaskLock{Type: schemas.TaskTypePullRefMetrics, UniqueID: "unknown"}, false},
+	} {
+		tc.lock.Queue = tc.lock.Type.Queue()
+		assert.Equal(t, tc.rescheduled, c.rescheduleTask(ctx, tc.lock), "%s %s", tc.lock.Type, tc.lock.UniqueID)
+	}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// PullRefPipelineRetriesMetrics ..
+func (c *Controller) PullRefPipelineRetriesMetrics(ctx context.Context, ref schemas.Ref) error {
+	attempts, err := c.Gitlab.ListRefPipelineJobAttempts(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	return c.ProcessRetriesMetrics(ctx, ref, attempts)
+}
+
+// ProcessRetriesMetrics ..
+func (c *Controller) ProcessRetriesMetrics(ctx context.Context, ref schemas.Ref, attempts schemas.JobAttempts) error {
+	// Refresh ref state from the store
+	if err := c.Store.GetRef(ctx, &ref); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"project-name":   ref.Project.Name,
+		"ref":            ref.Name,
+		"pipeline-id":    ref.LatestPipeline.ID,
+		"attempts-count": len(attempts),
+	}).Trace("processing retries metrics")
+
+	// We want to increment the counters only once per retried job ID. As several pipelines of the ref
+	// get processed in turn when pulling more than one per ref, the counted IDs are kept by pipeline.
+	// The retries are not accounted for until the ref has been processed once, to prevent them from
+	// being counted again when restarting the exporter.
+	counted := ref.LatestRetriedJobs
+	firstProcessing := len(counted) == 0
+
+	var retriedJobIDs []int64
+
+	for _, retry := range attempts.Retries() {
+		retriedJobIDs = append(retriedJobIDs, retry.ID)
+
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = retry.Stage
+		labels["job_name"] = retry.Name
+		labels["retry_kind"] = string(retry.Kind)
+
+		retryCount := schemas.Metric{
+			Kind:   schemas.MetricKindJobRetryCount,
+			Labels: labels,
+		}
+
+		storeGetMetric(ctx, c.Store, &retryCount)
+
+		if !firstProcessing && !counted.Contains(ref.LatestPipeline.ID, retry.ID) {
+			retryCount.Value++
+		}
+
+		storeSetMetric(ctx, c.Store, retryCount)
+	}
+
+	for key, count := range attempts.AttemptsToSucceed() {
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = key[0]
+		labels["job_name"] = key[1]
+
+		storeSetMetric(ctx, c.Store, schemas.Metric{
+			Kind:   schemas.MetricKindJobAttemptsToSucceed,
+			Labels: labels,
+			Value:  float64(count),
+		})
+	}
+
+	ref.LatestRetriedJobs = counted.With(ref.LatestPipeline.ID, retriedJobIDs, int(ref.Project.Pull.Pipeline.PerRef))
+
+	return c.Store.SetRef(ctx, ref)
+}
@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestPullRefPipelineRetriesMetrics(t *testing.T) {
+	ctx, c, mux, srv := newTestController(config.Config{})
+	defer srv.Close()
+
+	mux.HandleFunc("/api/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{"jobs":{
+				"pageInfo":{"hasNextPage":false},
+				"nodes":[
+					{"id":"gid://gitlab/Ci::Build/10","name":"unit","status":"FAILED","retried":true,"stage":{"name":"test"}},
+					{"id":"gid://gitlab/Ci::Build/11","name":"unit","status":"SUCCESS","retried":false,"stage":{"name":"test"}}
+				]
+			}}}}}`)
+		})
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	ref.LatestPipeline.ID = 1
+
+	assert.NoError(t, c.PullRefPipelineRetriesMetrics(ctx, ref))
+
+	labels := ref.DefaultLabelsValues()
+	labels["stage"] = "test"
+	labels["job_name"] = "unit"
+
+	attemptsToSucceed := schemas.Metric{Kind: schemas.MetricKindJobAttemptsToSucceed, Labels: labels, Value: 2}
+	metrics, _ := c.Store.Metrics(ctx)
+	assert.Equal(t, attemptsToSucceed, metrics[attemptsToSucceed.Key()])
+
+	srv.Close()
+	assert.Error(t, c.PullRefPipelineRetriesMetrics(ctx, ref))
+}
+
+func TestProcessRetriesMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	ref.LatestPipeline.ID = 1
+
+	attempts := schemas.JobAttempts{
+		{ID: 10, PipelineID: 1, Name: "unit", Stage: "test", Status: "failed", Retried: true, FinishedTimestamp: 100},
+		{ID: 11, PipelineID: 1, Name: "unit", Stage: "test", Status: "failed", CreatedTimestamp: 101, FinishedTimestamp: 200},
+	}
+
+	retryCount := func(kind schemas.JobRetryKind) float64 {
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = "test"
+		labels["job_name"] = "unit"
+		labels["retry_kind"] = string(kind)
+
+		m := schemas.Metric{Kind: schemas.MetricKindJobRetryCount, Labels: labels}
+		metrics, _ := c.Store.Metrics(ctx)
+
+		return metrics[m.Key()].Value
+	}
+
+	// The retries are not accounted for when processing the ref for the first time
+	assert.NoError(t, c.ProcessRetriesMetrics(ctx, ref, attempts))
+	assert.Equal(t, float64(0), retryCount(schemas.JobRetryKindAutomatic))
+
+	// The same retries are only counted once
+	attempts[1].Retried = true
+	attempts = append(attempts, schemas.JobAttempt{ID: 12, PipelineID: 1, Name: "unit", Stage: "test", Status: "success", CreatedTimestamp: 900})
+
+	assert.NoError(t, c.ProcessRetriesMetrics(ctx, ref, attempts))
+	assert.NoError(t, c.ProcessRetriesMetrics(ctx, ref, attempts))
+	assert.Equal(t, float64(0), retryCount(schemas.JobRetryKindAutomatic))
+	assert.Equal(t, float64(1), retryCount(schemas.JobRetryKindManual))
+
+	// Retries of a new pipeline
+	assert.NoError(t, c.Store.GetRef(ctx, &ref))
+	ref.LatestPipeline.ID = 2
+	assert.NoError(t, c.Store.SetRef(ctx, ref))
+	assert.NoError(t, c.ProcessRetriesMetrics(ctx, ref, schemas.JobAttempts{
+		{ID: 20, PipelineID: 2, Name: "unit", Stage: "test", Status: "failed", Retried: true, FinishedTimestamp: 1000},
+		{ID: 21, PipelineID: 2, Name: "unit", Stage: "test", Status: "running", CreatedTimestamp: 1001},
+	}))
+	assert.Equal(t, float64(1), retryCount(schemas.JobRetryKindAutomatic))
+	assert.Equal(t, float64(1), retryCount(schemas.JobRetryKindManual))
+
+	storedRefs, _ := c.Store.Refs(ctx)
+	assert.Equal(t, schemas.RetriedJobs{2: {20}}, storedRefs[ref.Key()].LatestRetriedJobs)
+}
+
+func TestProcessRetriesMetricsSeveralPipelinesPerRef(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	ref.Project.Pull.Pipeline.PerRef = 2
+
+	attempts := map[int64]schemas.JobAttempts{
+		1: {
+			{ID: 10, PipelineID: 1, Name: "unit", Stage: "test", Status: "failed", Retried: true, FinishedTimestamp: 100},
+			{ID: 11, PipelineID: 1, Name: "unit", Stage: "test", Status: "success", CreatedTimestamp: 1000},
+		},
+		2: {
+			{ID: 20, PipelineID: 2, Name: "unit", Stage: "test", Status: "failed", Retried: true, FinishedTimestamp: 100},
+			{ID: 21, PipelineID: 2, Name: "unit", Stage: "test", Status: "success", CreatedTimestamp: 1000},
+		},
+	}
+
+	process := func(pipelineID int64) {
+		assert.NoError(t, c.Store.GetRef(ctx, &ref))
+		ref.LatestPipeline.ID = pipelineID
+		assert.NoError(t, c.Store.SetRef(ctx, ref))
+		assert.NoError(t, c.ProcessRetriesMetrics(ctx, ref, attempts[pipelineID]))
+	}
+
+	retryCount := func() float64 {
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = "test"
+		labels["job_name"] = "unit"
+		labels["retry_kind"] = string(schemas.JobRetryKindManual)
+
+		m := schemas.Metric{Kind: schemas.MetricKindJobRetryCount, Labels: labels}
+		metrics, _ := c.Store.Metrics(ctx)
+
+		return metrics[m.Key()].Value
+	}
+
+	// The retries of the first pipeline are not accounted for, the ones of the new one are
+	process(1)
+	process(2)
+	assert.Equal(t, float64(1), retryCount())
+
+	// Processing the pipelines of the ref again does not count their retries once more
+	process(1)
+	process(2)
+	process(1)
+	assert.Equal(t, float64(1), retryCount())
+
+	storedRefs, _ := c.Store.Refs(ctx)
+	assert.Equal(t, schemas.RetriedJobs{1: {10}, 2: {20}}, storedRefs[ref.Key()].LatestRetriedJobs)
+}
@@ -2,7 +2,10 @@ package controller
 
 import (
 	"context"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -14,6 +17,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/cron"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/store"
@@ -22,55 +26,143 @@ import (
 // TaskController holds task related clients.
 type TaskController struct {
 	Factory                  taskq.Factory
-	Queue                    taskq.Queue
+	Queues                   map[schemas.TaskQueue]taskq.Queue
+	WebhooksQueue            taskq.Queue
 	TaskMap                  *taskq.TaskMap
 	TaskSchedulingMonitoring map[schemas.TaskType]*monitor.TaskSchedulingStatus
+	TaskExecutionMonitoring  *monitor.TaskExecutionMonitoring
+	TaskExecutionCollectors  TaskExecutionCollectors
+
+	// Tasks currently deferred until the end of some quiet hours
+	deferredTasks *sync.Map
 }
 
 // NewTaskController initializes and returns a new TaskController object.
-func NewTaskController(ctx context.Context, r *redis.Client, maximumJobsQueueSize int) (t TaskController) {
+func NewTaskController(
+	ctx context.Context,
+	r redis.UniversalClient,
+	namespace string,
+	maximumJobsQueueSize int,
+	queues config.SchedulerQueues,
+	webhooksQueue config.ServerWebhookQueue,
+) (t TaskController) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:NewTaskController")
 	defer span.End()
 
 	t.TaskMap = &taskq.TaskMap{}
 
-	queueOptions := &taskq.QueueConfig{
-		Name:                 "default",
-		PauseErrorsThreshold: 3,
-		Handler:              t.TaskMap,
-		BufferSize:           maximumJobsQueueSize,
-	}
+	var queuesRedis taskq.Redis
 
 	if r != nil {
 		t.Factory = redisq.NewFactory()
-		queueOptions.Redis = r
+		queuesRedis = r
 	} else {
 		t.Factory = memqueue.NewFactory()
 	}
 
-	t.Queue = t.Factory.RegisterQueue(queueOptions)
+	// Each class of tasks gets its own queue in order not to get the discovery
+	// and garbage collection starved by the metrics pulls
+	t.Queues = make(map[schemas.TaskQueue]taskq.Queue)
 
-	// Purge the queue when we start
-	// I am only partially convinced this will not cause issues in HA fashion
-	if err := t.Queue.Purge(ctx); err != nil {
-		log.WithContext(ctx).
-			WithError(err).
-			Error("purging the pulling queue")
+	for q, qc := range map[schemas.TaskQueue]config.SchedulerQueue{
+		schemas.TaskQueuePriority:       queues.Priority,
+		schemas.TaskQueueDiscovery:      queues.Discovery,
+		schemas.TaskQueueMetrics:        queues.Metrics,
+		schemas.TaskQueueGarbageCollect: queues.GarbageCollect,
+	} {
+		t.Queues[q] = t.Factory.RegisterQueue(&taskq.QueueConfig{
+			Name:                 queueName(namespace, string(q)),
+			PauseErrorsThreshold: 3,
+			Handler:              taskQueueHandler{queue: q, tasks: t.TaskMap},
+			BufferSize:           qc.BufferSizeOrDefault(maximumJobsQueueSize),
+			NumWorker:            qc.MaxConcurrency,
+			Redis:                queuesRedis,
+		})
 	}
 
-	if r != nil {
-		if err := t.Factory.StartConsumers(context.TODO()); err != nil {
-			log.WithContext(ctx).
-				WithError(err).
-				Fatal("starting consuming the task queue")
-		}
+	// Webhook events get their own queue in order to bound their processing concurrency
+	// without being slowed down by the pulls. It is not purged in order not to lose
+	// the events received but not processed yet by a previous run.
+	webhooksQueueOptions := &taskq.QueueConfig{
+		Name:                 queueName(namespace, "webhooks"),
+		PauseErrorsThreshold: 3,
+		Handler:              t.TaskMap,
+		BufferSize:           webhooksQueue.BufferSize,
+		NumWorker:            webhooksQueue.MaxConcurrency,
+		Redis:                queuesRedis,
+	}
+
+	// Used to deduplicate the events, it defaults to Redis
+	if r == nil {
+		webhooksQueueOptions.Storage = taskq.NewLocalStorage()
 	}
 
+	t.WebhooksQueue = t.Factory.RegisterQueue(webhooksQueueOptions)
+
 	t.TaskSchedulingMonitoring = make(map[schemas.TaskType]*monitor.TaskSchedulingStatus)
+	t.TaskExecutionMonitoring = monitor.NewTaskExecutionMonitoring()
+	t.TaskExecutionCollectors = NewTaskExecutionCollectors()
+	t.deferredTasks = &sync.Map{}
 
 	return
 }
 
+type taskQueueContextKey struct{}
+
+// taskQueueHandler passes the queue onto which the tasks get processed to their handlers,
+// in order for them to release the lock they have been queued with.
+type taskQueueHandler struct {
+	queue schemas.TaskQueue
+	tasks *taskq.TaskMap
+}
+
+// HandleJob ..
+func (h taskQueueHandler) HandleJob(ctx context.Context, job *taskq.Job) error {
+	return h.tasks.HandleJob(context.WithValue(ctx, taskQueueContextKey{}, h.queue), job)
+}
+
+// taskQueue returns the queue onto which the task is being processed,
+// it defaults to the one of its type.
+func taskQueue(ctx context.Context, tt schemas.TaskType) schemas.TaskQueue {
+	if q, ok := ctx.Value(taskQueueContextKey{}).(schemas.TaskQueue); ok {
+		return q
+	}
+
+	return tt.Queue()
+}
+
+// queueName prefixes the name of the queue with the namespace, if any.
+func queueName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+
+	return namespace + ":" + name
+}
+
+// purgeQueues drops the tasks left in the queues by a previous run. It is only
+// meant to be done by the leader, not to wipe the work of the other instances.
+func (tc *TaskController) purgeQueues(ctx context.Context) {
+	for q, queue := range tc.Queues {
+		if err := queue.Purge(ctx); err != nil {
+			log.WithContext(ctx).
+				WithField("queue", q).
+				WithError(err).
+				Error("purging the tasks queue")
+		}
+	}
+}
+
+// startConsumers starts processing the tasks of the Redis backed queues,
+// the in-memory ones get started as soon as tasks are added onto them.
+func (tc *TaskController) startConsumers(ctx context.Context) {
+	if err := tc.Factory.StartConsumers(context.TODO()); err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Fatal("starting consuming the task queue")
+	}
+}
+
 // TaskHandlerPullProject ..
 func (c *Controller) TaskHandlerPullProject(ctx context.Context, name string, pull config.ProjectPull) error {
 	defer c.unqueueTask(ctx, schemas.TaskTypePullProject, name)
@@ -98,6 +190,8 @@ func (c *Controller) TaskHandlerPullEnvironmentsFromProject(ctx context.Context,
 				}).
 				WithError(err).
 				Warn("pulling environments from project")
+
+			setTaskExecutionError(ctx, err)
 		}
 	}
 }
@@ -116,6 +210,8 @@ func (c *Controller) TaskHandlerPullEnvironmentMetrics(ctx context.Context, env
 			}).
 			WithError(err).
 			Warn("pulling environment metrics")
+
+		setTaskExecutionError(ctx, err)
 	}
 }
 
@@ -131,6 +227,8 @@ func (c *Controller) TaskHandlerPullRefsFromProject(ctx context.Context, p schem
 			}).
 			WithError(err).
 			Warn("pulling refs from project")
+
+		setTaskExecutionError(ctx, err)
 	}
 }
 
@@ -147,6 +245,8 @@ func (c *Controller) TaskHandlerPullRefMetrics(ctx context.Context, ref schemas.
 			}).
 			WithError(err).
 			Warn("pulling ref metrics")
+
+		setTaskExecutionError(ctx, err)
 	}
 }
 
@@ -164,6 +264,8 @@ func (c *Controller) TaskHandlerPullProjectsFromWildcards(ctx context.Context) {
 	for id, w := range c.Config.Wildcards {
 		c.ScheduleTask(ctx, schemas.TaskTypePullProjectsFromWildcard, strconv.Itoa(id), strconv.Itoa(id), w)
 	}
+
+	c.scheduleStaticProjectsPulls(ctx)
 }
 
 // TaskHandlerPullEnvironmentsFromProjects ..
@@ -231,6 +333,29 @@ func (c *Controller) TaskHandlerPullMetrics(ctx context.Context) {
 	defer c.unqueueTask(ctx, schemas.TaskTypePullMetrics, "_")
 	defer c.TaskController.monitorLastTaskScheduling(schemas.TaskTypePullMetrics)
 
+	c.scheduleMetricsPull(ctx, false)
+}
+
+// TaskHandlerProcessWebhookEvent ..
+func (c *Controller) TaskHandlerProcessWebhookEvent(ctx context.Context, e schemas.WebhookEvent) {
+	// Errors are not returned in order not to pause the queue,
+	// failed events are kept in the store to be replayed instead
+	c.processWebhookEvent(ctx, e)
+}
+
+// TaskHandlerReconcileMetrics ..
+func (c *Controller) TaskHandlerReconcileMetrics(ctx context.Context) {
+	defer c.unqueueTask(ctx, schemas.TaskTypeReconcileMetrics, "_")
+	defer c.TaskController.monitorLastTaskScheduling(schemas.TaskTypeReconcileMetrics)
+
+	c.scheduleMetricsPull(ctx, true)
+}
+
+// scheduleMetricsPull schedules the pull of the metrics of the environments and refs
+// which are currently being kept up to date by webhook events (reconciliation) or not (regular polling).
+// Regular polling only goes through the pulls which are due, the whole store only being scanned
+// to seed the pulls of the environments and refs which have never been scheduled.
+func (c *Controller) scheduleMetricsPull(ctx context.Context, reconciliation bool) {
 	refsCount, err := c.Store.RefsCount(ctx)
 	if err != nil {
 		log.WithContext(ctx).
@@ -249,11 +374,194 @@ func (c *Controller) TaskHandlerPullMetrics(ctx context.Context) {
 		log.Fields{
 			"environments-count": envsCount,
 			"refs-count":         refsCount,
+			"reconciliation":     reconciliation,
 		},
 	).Info("scheduling metrics pull")
 
-	// ENVIRONMENTS
-	envs, err := c.Store.Environments(ctx)
+	run := &metricsPullRun{
+		c:               c,
+		reconciliation:  reconciliation,
+		now:             time.Now(),
+		coveredProjects: make(map[string]bool),
+		projects:        make(map[string]schemas.Project),
+	}
+
+	if reconciliation {
+		run.reconcile(ctx)
+	} else {
+		run.pullDue(ctx)
+		run.seed(ctx, refsCount+envsCount)
+	}
+
+	if run.skipped > 0 {
+		log.WithFields(
+			log.Fields{
+				"skipped-count":  run.skipped,
+				"reconciliation": reconciliation,
+			},
+		).Debug("skipped the metrics pull of environments and refs handled by the other schedule")
+	}
+}
+
+// metricsPullRun holds the state of a scheduling run of the metrics pulls.
+type metricsPullRun struct {
+	c              *Controller
+	reconciliation bool
+	now            time.Time
+	skipped        int
+
+	// Caches of the webhook coverage and of the stored projects for the duration of the run
+	coveredProjects map[string]bool
+	projects        map[string]schemas.Project
+}
+
+// pullDue schedules the pulls which are due, forgetting the ones of the environments
+// and refs which do not exist anymore.
+func (run *metricsPullRun) pullDue(ctx context.Context) {
+	due, err := run.c.Store.DueMetricsPulls(ctx, run.now)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	var (
+		envKeys []schemas.EnvironmentKey
+		refKeys []schemas.RefKey
+	)
+
+	for k := range due {
+		if ek, ok := k.EnvironmentKey(); ok {
+			envKeys = append(envKeys, ek)
+		} else if rk, ok := k.RefKey(); ok {
+			refKeys = append(refKeys, rk)
+		} else {
+			run.forget(ctx, k)
+		}
+	}
+
+	envs, err := run.c.Store.EnvironmentsByKeys(ctx, envKeys)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	refs, err := run.c.Store.RefsByKeys(ctx, refKeys)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	for _, ek := range envKeys {
+		if env, ok := envs[ek]; ok {
+			run.scheduleEnvironment(ctx, env)
+		} else {
+			run.forget(ctx, ek.MetricsPullKey())
+		}
+	}
+
+	for _, rk := range refKeys {
+		if ref, ok := refs[rk]; ok {
+			run.scheduleRef(ctx, ref, due[rk.MetricsPullKey()])
+		} else {
+			run.forget(ctx, rk.MetricsPullKey())
+		}
+	}
+}
+
+// seed schedules the environments and refs which have never been. The whole store only gets
+// scanned when the number of pulls does not match the one of the environments and refs.
+func (run *metricsPullRun) seed(ctx context.Context, entitiesCount int64) {
+	pullsCount, err := run.c.Store.MetricsPullsCount(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	if pullsCount == entitiesCount {
+		return
+	}
+
+	pulls, err := run.c.Store.MetricsPulls(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	envs, err := run.c.Store.Environments(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	refs, err := run.c.Store.Refs(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Error()
+
+		return
+	}
+
+	var seeded int
+
+	for k, env := range envs {
+		if _, ok := pulls[k.MetricsPullKey()]; ok {
+			delete(pulls, k.MetricsPullKey())
+
+			continue
+		}
+
+		seeded++
+
+		run.scheduleEnvironment(ctx, env)
+	}
+
+	for k, ref := range refs {
+		if _, ok := pulls[k.MetricsPullKey()]; ok {
+			delete(pulls, k.MetricsPullKey())
+
+			continue
+		}
+
+		seeded++
+
+		run.scheduleRef(ctx, ref, schemas.MetricsPull{})
+	}
+
+	// The remaining ones belong to environments and refs which do not exist anymore
+	for k := range pulls {
+		run.forget(ctx, k)
+	}
+
+	log.WithFields(
+		log.Fields{
+			"seeded-count":    seeded,
+			"forgotten-count": len(pulls),
+		},
+	).Debug("seeded the metrics pulls of the environments and refs which had never been scheduled")
+}
+
+// reconcile schedules the pull of all the environments and refs covered by webhooks.
+func (run *metricsPullRun) reconcile(ctx context.Context) {
+	envs, err := run.c.Store.Environments(ctx)
 	if err != nil {
 		log.WithContext(ctx).
 			WithError(err).
@@ -261,11 +569,10 @@ func (c *Controller) TaskHandlerPullMetrics(ctx context.Context) {
 	}
 
 	for _, env := range envs {
-		c.ScheduleTask(ctx, schemas.TaskTypePullEnvironmentMetrics, string(env.Key()), env)
+		run.scheduleEnvironment(ctx, env)
 	}
 
-	// REFS
-	refs, err := c.Store.Refs(ctx)
+	refs, err := run.c.Store.Refs(ctx)
 	if err != nil {
 		log.WithContext(ctx).
 			WithError(err).
@@ -273,10 +580,226 @@ func (c *Controller) TaskHandlerPullMetrics(ctx context.Context) {
 	}
 
 	for _, ref := range refs {
-		c.ScheduleTask(ctx, schemas.TaskTypePullRefMetrics, string(ref.Key()), ref)
+		run.scheduleRef(ctx, ref, schemas.MetricsPull{})
+	}
+}
+
+// scheduleEnvironment ..
+func (run *metricsPullRun) scheduleEnvironment(ctx context.Context, env schemas.Environment) {
+	run.schedule(ctx, env.Key().MetricsPullKey(), env.ProjectName, func() int {
+		p := run.project(ctx, env.ProjectName)
+
+		return metricsPullIntervalSeconds(
+			run.c.Config.Pull.Metrics.IntervalSeconds,
+			p.Pull.IntervalSeconds,
+			p.Pull.Environments.IntervalSeconds,
+		)
+	}, func() bool {
+		return run.c.ScheduleTask(ctx, schemas.TaskTypePullEnvironmentMetrics, string(env.Key()), env)
+	})
+}
+
+// scheduleRef ..
+func (run *metricsPullRun) scheduleRef(ctx context.Context, ref schemas.Ref, previous schemas.MetricsPull) {
+	run.schedule(ctx, ref.Key().MetricsPullKey(), ref.Project.Name, func() int {
+		intervalSeconds := run.c.refMetricsPullIntervalSeconds(ref)
+		if run.c.Config.Pull.Metrics.Adaptive.Enabled {
+			intervalSeconds = run.c.adaptiveRefMetricsPullIntervalSeconds(ref, previous, intervalSeconds, run.now)
+		}
+
+		return intervalSeconds
+	}, func() bool {
+		return run.c.ScheduleTask(ctx, schemas.TaskTypePullRefMetrics, string(ref.Key()), ref)
+	})
+}
+
+// schedule schedules the pull and, outside of the reconciliations, queues the next one once it got
+// queued. Otherwise it remains due and gets scheduled again on the next run instead of being skipped
+// for a whole interval. The pulls of the projects covered by webhooks get postponed by an interval.
+func (run *metricsPullRun) schedule(ctx context.Context, k schemas.MetricsPullKey, projectName string, intervalSeconds func() int, scheduleTask func() bool) {
+	if run.isCovered(ctx, projectName) != run.reconciliation {
+		run.skipped++
+
+		if !run.reconciliation {
+			run.setNext(ctx, k, intervalSeconds())
+		}
+
+		return
+	}
+
+	if queued := scheduleTask(); queued && !run.reconciliation {
+		run.setNext(ctx, k, intervalSeconds())
+	}
+}
+
+func (run *metricsPullRun) setNext(ctx context.Context, k schemas.MetricsPullKey, intervalSeconds int) {
+	if err := run.c.Store.SetMetricsPull(ctx, k, schemas.MetricsPull{
+		DueTime:         run.now.Add(time.Duration(intervalSeconds) * time.Second),
+		IntervalSeconds: intervalSeconds,
+	}); err != nil {
+		log.WithContext(ctx).
+			WithField("metrics-pull-key", k).
+			WithError(err).
+			Warn("queueing next metrics pull")
+	}
+}
+
+func (run *metricsPullRun) forget(ctx context.Context, k schemas.MetricsPullKey) {
+	if err := run.c.Store.DelMetricsPull(ctx, k); err != nil {
+		log.WithContext(ctx).
+			WithField("metrics-pull-key", k).
+			WithError(err).
+			Warn("deleting the metrics pull of a deleted environment or ref")
+	}
+}
+
+func (run *metricsPullRun) isCovered(ctx context.Context, projectName string) bool {
+	if _, ok := run.coveredProjects[projectName]; !ok {
+		run.coveredProjects[projectName] = run.c.isProjectCoveredByWebhooks(ctx, projectName)
+	}
+
+	return run.coveredProjects[projectName]
+}
+
+// project returns the stored project, the zero value if it does not exist.
+func (run *metricsPullRun) project(ctx context.Context, name string) schemas.Project {
+	if p, ok := run.projects[name]; ok {
+		return p
+	}
+
+	var p schemas.Project
+
+	sp := schemas.NewProject(name)
+	if exists, err := run.c.Store.ProjectExists(ctx, sp.Key()); err != nil {
+		log.WithContext(ctx).
+			WithField("project-name", name).
+			WithError(err).
+			Warn("reading project from the store")
+	} else if exists {
+		if err = run.c.Store.GetProject(ctx, &sp); err != nil {
+			log.WithContext(ctx).
+				WithField("project-name", name).
+				WithError(err).
+				Warn("reading project from the store")
+		} else {
+			p = sp
+		}
+	}
+
+	run.projects[name] = p
+
+	return p
+}
+
+// refMetricsPullIntervalSeconds returns the interval at which the metrics of the ref should be pulled.
+func (c *Controller) refMetricsPullIntervalSeconds(ref schemas.Ref) int {
+	var kindIntervalSeconds int
+
+	switch ref.Kind {
+	case schemas.RefKindBranch:
+		kindIntervalSeconds = ref.Project.Pull.Refs.Branches.IntervalSeconds
+	case schemas.RefKindTag:
+		kindIntervalSeconds = ref.Project.Pull.Refs.Tags.IntervalSeconds
+	case schemas.RefKindMergeRequest:
+		kindIntervalSeconds = ref.Project.Pull.Refs.MergeRequests.IntervalSeconds
+	}
+
+	return metricsPullIntervalSeconds(
+		c.Config.Pull.Metrics.IntervalSeconds,
+		ref.Project.Pull.IntervalSeconds,
+		kindIntervalSeconds,
+	)
+}
+
+// adaptiveRefMetricsPullIntervalSeconds adapts the pull interval of the ref to its activity. Refs having running
+// or pending pipelines get pulled at the minimum interval, the idle ones get exponentially backed off from their
+// configured interval up to the maximum one.
+func (c *Controller) adaptiveRefMetricsPullIntervalSeconds(ref schemas.Ref, previous schemas.MetricsPull, intervalSeconds int, now time.Time) int {
+	cfg := c.Config.Pull.Metrics.Adaptive
+
+	if slices.Contains(activePipelineStatusesList[:], ref.LatestPipeline.Status) {
+		return cfg.MinIntervalSeconds
+	}
+
+	// Back off only if nothing happened on the ref since its previous pull
+	lastActivity := time.Unix(int64(ref.LatestPipeline.Timestamp), 0)
+	if previous.IntervalSeconds > 0 && lastActivity.Before(now.Add(-time.Duration(previous.IntervalSeconds)*time.Second)) {
+		intervalSeconds = max(intervalSeconds, previous.IntervalSeconds*2)
+	}
+
+	return min(max(intervalSeconds, cfg.MinIntervalSeconds), cfg.MaxIntervalSeconds)
+}
+
+// resetRefMetricsPull brings the ref back to the fast pull cadence, if the adaptive pull intervals are enabled.
+func (c *Controller) resetRefMetricsPull(ctx context.Context, ref schemas.Ref) {
+	cfg := c.Config.Pull.Metrics.Adaptive
+	if !cfg.Enabled {
+		return
+	}
+
+	if err := c.Store.SetMetricsPull(ctx, ref.Key().MetricsPullKey(), schemas.MetricsPull{
+		DueTime:         time.Now().Add(time.Duration(cfg.MinIntervalSeconds) * time.Second),
+		IntervalSeconds: cfg.MinIntervalSeconds,
+	}); err != nil {
+		log.WithContext(ctx).
+			WithField("metrics-pull-key", ref.Key().MetricsPullKey()).
+			WithError(err).
+			Warn("resetting the metrics pull interval of the ref")
 	}
 }
 
+// metricsPullSchedulingIntervalSeconds returns how often the due metrics pulls have to be looked up
+// for, which is the shortest of the configured intervals, the adaptive minimum one included.
+func (c *Controller) metricsPullSchedulingIntervalSeconds() int {
+	intervalSeconds := c.Config.Pull.Metrics.IntervalSeconds
+	if intervalSeconds <= 0 {
+		return intervalSeconds
+	}
+
+	if adaptive := c.Config.Pull.Metrics.Adaptive; adaptive.Enabled && adaptive.MinIntervalSeconds > 0 {
+		intervalSeconds = min(intervalSeconds, adaptive.MinIntervalSeconds)
+	}
+
+	pulls := make([]config.ProjectPull, 0, len(c.Config.Projects)+len(c.Config.Wildcards)+1)
+	pulls = append(pulls, c.Config.ProjectDefaults.Pull)
+
+	for _, p := range c.Config.Projects {
+		pulls = append(pulls, p.Pull)
+	}
+
+	for _, w := range c.Config.Wildcards {
+		pulls = append(pulls, w.Pull)
+	}
+
+	for _, pull := range pulls {
+		for _, i := range []int{
+			pull.IntervalSeconds,
+			pull.Environments.IntervalSeconds,
+			pull.Refs.Branches.IntervalSeconds,
+			pull.Refs.Tags.IntervalSeconds,
+			pull.Refs.MergeRequests.IntervalSeconds,
+		} {
+			if i > 0 && i < intervalSeconds {
+				intervalSeconds = i
+			}
+		}
+	}
+
+	return intervalSeconds
+}
+
+// metricsPullIntervalSeconds returns the most specific of the intervals which has been set,
+// from the global one to the one of the kind of ref or environment.
+func metricsPullIntervalSeconds(intervalsSeconds ...int) (intervalSeconds int) {
+	for _, i := range intervalsSeconds {
+		if i > 0 {
+			intervalSeconds = i
+		}
+	}
+
+	return
+}
+
 // TaskHandlerGarbageCollectProjects ..
 func (c *Controller) TaskHandlerGarbageCollectProjects(ctx context.Context) error {
 	defer c.unqueueTask(ctx, schemas.TaskTypeGarbageCollectProjects, "_")
@@ -303,10 +826,28 @@ func (c *Controller) TaskHandlerGarbageCollectRefs(ctx context.Context) error {
 
 // TaskHandlerGarbageCollectMetrics ..
 func (c *Controller) TaskHandlerGarbageCollectMetrics(ctx context.Context) error {
-	defer c.unqueueTask(ctx, schemas.TaskTypeGarbageCollectMetrics, "_")
 	defer c.TaskController.monitorLastTaskScheduling(schemas.TaskTypeGarbageCollectMetrics)
 
-	return c.GarbageCollectMetrics(ctx)
+	var deadline time.Time
+	if d := c.Config.GarbageCollect.SliceDurationSeconds; d > 0 {
+		deadline = time.Now().Add(time.Duration(d) * time.Second)
+	}
+
+	var completed bool
+
+	gc, err := c.newGarbageCollection(ctx, gcEntityMetrics, nil)
+	if err == nil {
+		completed, err = c.garbageCollectMetrics(ctx, gc, deadline)
+	}
+
+	// The task has to be unqueued for its next slice to be queued
+	c.unqueueTask(ctx, schemas.TaskTypeGarbageCollectMetrics, "_")
+
+	if err == nil && !completed {
+		c.scheduleTaskOutsideQuietHours(ctx, schemas.TaskTypeGarbageCollectMetrics)
+	}
+
+	return err
 }
 
 // Schedule ..
@@ -322,23 +863,39 @@ func (c *Controller) Schedule(ctx context.Context, pull config.Pull, gc config.G
 		schemas.TaskTypePullProjectsFromWildcards:    config.SchedulerConfig(pull.ProjectsFromWildcards),
 		schemas.TaskTypePullEnvironmentsFromProjects: config.SchedulerConfig(pull.EnvironmentsFromProjects),
 		schemas.TaskTypePullRefsFromProjects:         config.SchedulerConfig(pull.RefsFromProjects),
-		schemas.TaskTypePullMetrics:                  config.SchedulerConfig(pull.Metrics),
+		schemas.TaskTypePullMetrics:                  pull.Metrics.SchedulerConfig(),
 		schemas.TaskTypeGarbageCollectProjects:       config.SchedulerConfig(gc.Projects),
 		schemas.TaskTypeGarbageCollectEnvironments:   config.SchedulerConfig(gc.Environments),
 		schemas.TaskTypeGarbageCollectRefs:           config.SchedulerConfig(gc.Refs),
 		schemas.TaskTypeGarbageCollectMetrics:        config.SchedulerConfig(gc.Metrics),
 	} {
+		// The metrics pulls are queued by due time, we need to look them up
+		// as often as the shortest interval configured
+		if tt == schemas.TaskTypePullMetrics {
+			cfg.IntervalSeconds = c.metricsPullSchedulingIntervalSeconds()
+		}
+
 		if cfg.OnInit {
-			c.ScheduleTask(ctx, tt, "_")
+			c.scheduleDeferrableTask(ctx, tt)
 		}
 
+		// Every instance runs the schedulers in order to be able to take
+		// over the leadership, only the leader actually schedules the tasks
 		if cfg.Scheduled {
-			c.ScheduleTaskWithTicker(ctx, tt, cfg.IntervalSeconds)
+			if len(cfg.Cron) > 0 {
+				c.ScheduleTaskWithCron(ctx, tt, cfg.Cron)
+			} else {
+				c.ScheduleTaskWithTicker(ctx, tt, cfg.IntervalSeconds)
+			}
 		}
+	}
 
-		if c.Redis != nil {
-			c.ScheduleRedisSetKeepalive(ctx)
-		}
+	if c.Config.Server.Webhook.Enabled && c.Config.Server.Webhook.SkipPolling.Enabled {
+		c.ScheduleTaskWithTicker(ctx, schemas.TaskTypeReconcileMetrics, c.Config.Server.Webhook.SkipPolling.ReconciliationIntervalSeconds)
+	}
+
+	if c.LeaderElection.IsLeader() {
+		c.scheduleStaticProjectsPulls(ctx)
 	}
 }
 
@@ -349,6 +906,13 @@ func (c *Controller) ScheduleRedisSetKeepalive(ctx context.Context) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:ScheduleRedisSetKeepalive")
 	defer span.End()
 
+	// Let the other instances know about us right away
+	if _, err := c.Store.(*store.Redis).SetKeepalive(ctx, c.UUID.String(), time.Duration(10)*time.Second); err != nil {
+		log.WithContext(ctx).
+			WithError(err).
+			Fatal("setting keepalive")
+	}
+
 	go func(ctx context.Context) {
 		ticker := time.NewTicker(time.Duration(5) * time.Second)
 
@@ -369,61 +933,81 @@ func (c *Controller) ScheduleRedisSetKeepalive(ctx context.Context) {
 	}(ctx)
 }
 
-// ScheduleTask ..
-func (c *Controller) ScheduleTask(ctx context.Context, tt schemas.TaskType, uniqueID string, args ...interface{}) {
+// ScheduleTask schedules the task and returns whether it is queued, it may already have been queued earlier on.
+func (c *Controller) ScheduleTask(ctx context.Context, tt schemas.TaskType, uniqueID string, args ...interface{}) bool {
+	return c.scheduleTask(ctx, tt.Queue(), tt, uniqueID, args...)
+}
+
+// SchedulePriorityTask schedules the task ahead of the ones which got scheduled using ScheduleTask.
+// The tasks are deduplicated per queue, hence it still gets scheduled if it is only
+// waiting in the queue of its type, but not if it is already queued with priority.
+func (c *Controller) SchedulePriorityTask(ctx context.Context, tt schemas.TaskType, uniqueID string, args ...interface{}) bool {
+	return c.scheduleTask(ctx, schemas.TaskQueuePriority, tt, uniqueID, args...)
+}
+
+func (c *Controller) scheduleTask(ctx context.Context, q schemas.TaskQueue, tt schemas.TaskType, uniqueID string, args ...interface{}) bool {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:ScheduleTask")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("task_type", string(tt)))
 	span.SetAttributes(attribute.String("task_unique_id", uniqueID))
+	span.SetAttributes(attribute.String("task_queue", string(q)))
 
 	logFields := log.Fields{
 		"task_type":      tt,
 		"task_unique_id": uniqueID,
+		"task_queue":     q,
 	}
 	task := c.TaskController.TaskMap.Get(string(tt))
-	msg := task.NewJob(args...)
+	msg := task.NewJob(newTaskJobArgs(args...)...)
+	queue := c.TaskController.Queues[q]
 
-	qlen, err := c.TaskController.Queue.Len(ctx)
+	qlen, err := queue.Len(ctx)
 	if err != nil {
 		log.WithContext(ctx).
 			WithFields(logFields).
 			Warn("unable to read task queue length, skipping scheduling of task..")
 
-		return
+		return false
 	}
 
-	if qlen >= c.TaskController.Queue.Options().BufferSize {
+	if qlen >= queue.Options().BufferSize {
 		log.WithContext(ctx).
 			WithFields(logFields).
 			Warn("queue buffer size exhausted, skipping scheduling of task..")
 
-		return
+		c.TaskController.recordTaskSkip(tt, TaskSkipReasonBufferFull)
+
+		return false
 	}
 
-	queued, err := c.Store.QueueTask(ctx, tt, uniqueID, c.UUID.String())
+	queued, err := c.Store.QueueTask(ctx, q, tt, uniqueID, c.UUID.String())
 	if err != nil {
 		log.WithContext(ctx).
 			WithFields(logFields).
 			Warn("unable to declare the queueing, skipping scheduling of task..")
 
-		return
+		return false
 	}
 
 	if !queued {
 		log.WithFields(logFields).
 			Debug("task already queued, skipping scheduling of task..")
 
-		return
+		c.TaskController.recordTaskSkip(tt, TaskSkipReasonAlreadyQueued)
+
+		return true
 	}
 
 	go func(job *taskq.Job) {
-		if err := c.TaskController.Queue.AddJob(ctx, job); err != nil {
+		if err := queue.AddJob(ctx, job); err != nil {
 			log.WithContext(ctx).
 				WithError(err).
 				Warn("scheduling task")
 		}
 	}(msg)
+
+	return true
 }
 
 // ScheduleTaskWithTicker ..
@@ -446,7 +1030,7 @@ func (c *Controller) ScheduleTaskWithTicker(ctx context.Context, tt schemas.Task
 		"interval_seconds": intervalSeconds,
 	}).Debug("task scheduled")
 
-	c.TaskController.monitorNextTaskScheduling(tt, intervalSeconds)
+	c.TaskController.monitorNextTaskScheduling(tt, time.Now().Add(time.Duration(intervalSeconds)*time.Second))
 
 	go func(ctx context.Context) {
 		ticker := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
@@ -458,19 +1042,177 @@ func (c *Controller) ScheduleTaskWithTicker(ctx context.Context, tt schemas.Task
 
 				return
 			case <-ticker.C:
-				c.ScheduleTask(ctx, tt, "_")
-				c.TaskController.monitorNextTaskScheduling(tt, intervalSeconds)
+				c.scheduleDeferrableTask(ctx, tt)
+				c.TaskController.monitorNextTaskScheduling(tt, time.Now().Add(time.Duration(intervalSeconds)*time.Second))
 			}
 		}
 	}(ctx)
 }
 
-func (tc *TaskController) monitorNextTaskScheduling(tt schemas.TaskType, duration int) {
+// ScheduleTaskWithCron ..
+func (c *Controller) ScheduleTaskWithCron(ctx context.Context, tt schemas.TaskType, expr string) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:ScheduleTaskWithCron")
+	defer span.End()
+	span.SetAttributes(attribute.String("task_type", string(tt)))
+	span.SetAttributes(attribute.String("cron", expr))
+
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		log.WithContext(ctx).
+			WithField("task", tt).
+			WithError(err).
+			Warn("task scheduling misconfigured, currently disabled")
+
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"task": tt,
+		"cron": expr,
+	}).Debug("task scheduled")
+
+	loc := c.schedulerLocation()
+
+	go func(ctx context.Context) {
+		for {
+			next := schedule.Next(time.Now().In(loc))
+			if next.IsZero() {
+				log.WithFields(log.Fields{
+					"task": tt,
+					"cron": expr,
+				}).Warn("cron expression never matches, task scheduling stopped")
+
+				return
+			}
+
+			c.TaskController.monitorNextTaskScheduling(tt, next)
+
+			timer := time.NewTimer(time.Until(next))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				log.WithField("task", tt).Info("scheduling of task stopped")
+
+				return
+			case <-timer.C:
+				c.scheduleDeferrableTask(ctx, tt)
+			}
+		}
+	}(ctx)
+}
+
+// scheduleDeferrableTask schedules the task unless we are within quiet hours configured for it,
+// in which case it gets scheduled once they are over. Only the leader schedules them.
+func (c *Controller) scheduleDeferrableTask(ctx context.Context, tt schemas.TaskType) {
+	if !c.LeaderElection.IsLeader() {
+		log.WithField("task", tt).
+			Debug("not the leader, skipping scheduling of task..")
+
+		return
+	}
+
+	c.scheduleTaskOutsideQuietHours(ctx, tt)
+}
+
+// scheduleTaskOutsideQuietHours schedules the task right away or, if we are within quiet hours configured
+// for it, once they are over. Unlike scheduleDeferrableTask, it can be used by any instance, eg: to queue
+// the next slice of a task being processed.
+func (c *Controller) scheduleTaskOutsideQuietHours(ctx context.Context, tt schemas.TaskType) {
+	end := quietHoursEnd(c.Config.Scheduler.QuietHours, tt, time.Now().In(c.schedulerLocation()))
+	if end.IsZero() {
+		c.ScheduleTask(ctx, tt, "_")
+
+		return
+	}
+
+	// Only keep a single deferred run of the task
+	if _, alreadyDeferred := c.TaskController.deferredTasks.LoadOrStore(tt, end); alreadyDeferred {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"task":           tt,
+		"deferred-until": end,
+	}).Info("within quiet hours, deferring the task")
+
+	time.AfterFunc(time.Until(end), func() {
+		c.TaskController.deferredTasks.Delete(tt)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Quiet hours may be chained
+		c.scheduleTaskOutsideQuietHours(ctx, tt)
+	})
+}
+
+// schedulerLocation returns the location in which the cron expressions and quiet hours are evaluated.
+func (c *Controller) schedulerLocation() *time.Location {
+	if len(c.Config.Scheduler.Timezone) == 0 {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(c.Config.Scheduler.Timezone)
+	if err != nil {
+		log.WithField("timezone", c.Config.Scheduler.Timezone).
+			WithError(err).
+			Warn("loading scheduler timezone, using the local one")
+
+		return time.Local
+	}
+
+	return loc
+}
+
+// quietHoursEnd returns when the quiet hours currently deferring the task end,
+// a zero time meaning that the task is not deferred.
+func quietHoursEnd(windows []config.QuietHours, tt schemas.TaskType, now time.Time) (end time.Time) {
+	for _, w := range windows {
+		if !slices.Contains(w.Tasks, string(tt)) {
+			continue
+		}
+
+		start, err := time.Parse("15:04", w.Start)
+		if err != nil {
+			continue
+		}
+
+		stop, err := time.Parse("15:04", w.End)
+		if err != nil {
+			continue
+		}
+
+		// Windows spanning over midnight may have started the day before
+		for _, offset := range []int{0, -1} {
+			day := now.AddDate(0, 0, offset)
+			if len(w.Days) > 0 && !slices.Contains(w.Days, strings.ToLower(day.Weekday().String())) {
+				continue
+			}
+
+			windowStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, now.Location())
+			windowEnd := time.Date(day.Year(), day.Month(), day.Day(), stop.Hour(), stop.Minute(), 0, 0, now.Location())
+
+			if !windowEnd.After(windowStart) {
+				windowEnd = windowEnd.AddDate(0, 0, 1)
+			}
+
+			if !now.Before(windowStart) && now.Before(windowEnd) && windowEnd.After(end) {
+				end = windowEnd
+			}
+		}
+	}
+
+	return
+}
+
+func (tc *TaskController) monitorNextTaskScheduling(tt schemas.TaskType, next time.Time) {
 	if _, ok := tc.TaskSchedulingMonitoring[tt]; !ok {
 		tc.TaskSchedulingMonitoring[tt] = &monitor.TaskSchedulingStatus{}
 	}
 
-	tc.TaskSchedulingMonitoring[tt].Next = time.Now().Add(time.Duration(duration) * time.Second)
+	tc.TaskSchedulingMonitoring[tt].Next = next
 }
 
 func (tc *TaskController) monitorLastTaskScheduling(tt schemas.TaskType) {
@@ -1,3 +1,484 @@
 package controller
 
-// TODO
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/taskq/v4"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestMetricsPullIntervalSeconds(t *testing.T) {
+	assert.Equal(t, 30, metricsPullIntervalSeconds(30, 0, 0))
+	assert.Equal(t, 60, metricsPullIntervalSeconds(30, 60, 0))
+	assert.Equal(t, 600, metricsPullIntervalSeconds(30, 60, 600))
+	assert.Equal(t, 600, metricsPullIntervalSeconds(30, 0, 600))
+}
+
+func TestRefMetricsPullIntervalSeconds(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.IntervalSeconds = 30
+
+	_, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	p := schemas.NewProject("foo/bar")
+	p.Pull.IntervalSeconds = 60
+	p.Pull.Refs.MergeRequests.IntervalSeconds = 3600
+
+	assert.Equal(t, 60, c.refMetricsPullIntervalSeconds(schemas.NewRef(p, schemas.RefKindBranch, "main")))
+	assert.Equal(t, 3600, c.refMetricsPullIntervalSeconds(schemas.NewRef(p, schemas.RefKindMergeRequest, "1")))
+	assert.Equal(t, 30, c.refMetricsPullIntervalSeconds(schemas.NewRef(schemas.NewProject("foo/baz"), schemas.RefKindTag, "v1")))
+}
+
+func TestMetricsPullSchedulingIntervalSeconds(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.IntervalSeconds = 30
+
+	p := config.NewProject("foo/bar")
+	p.Pull.Refs.Branches.IntervalSeconds = 10
+	cfg.Projects = []config.Project{p}
+
+	_, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	assert.Equal(t, 10, c.metricsPullSchedulingIntervalSeconds())
+
+	// Refs with active pipelines get pulled at the adaptive minimum interval
+	c.Config.Pull.Metrics.Adaptive.Enabled = true
+	c.Config.Pull.Metrics.Adaptive.MinIntervalSeconds = 5
+	assert.Equal(t, 5, c.metricsPullSchedulingIntervalSeconds())
+
+	c.Config.Pull.Metrics.Adaptive.MinIntervalSeconds = 15
+	assert.Equal(t, 10, c.metricsPullSchedulingIntervalSeconds())
+
+	// Disabled scheduling remains disabled
+	c.Config.Pull.Metrics.IntervalSeconds = 0
+	assert.Equal(t, 0, c.metricsPullSchedulingIntervalSeconds())
+}
+
+func TestScheduleMetricsPulls(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.IntervalSeconds = 30
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	p := schemas.NewProject("foo/bar")
+	p.Pull.Refs.MergeRequests.IntervalSeconds = 3600
+
+	ref1 := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	ref2 := schemas.NewRef(p, schemas.RefKindMergeRequest, "1")
+
+	assert.NoError(t, c.Store.SetProject(ctx, p))
+	assert.NoError(t, c.Store.SetRef(ctx, ref1))
+	assert.NoError(t, c.Store.SetRef(ctx, ref2))
+
+	before := time.Now()
+	c.scheduleMetricsPull(ctx, false)
+
+	mps, err := c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, mps, 2)
+	assert.WithinDuration(t, before.Add(30*time.Second), mps[ref1.Key().MetricsPullKey()].DueTime, 2*time.Second)
+	assert.WithinDuration(t, before.Add(time.Hour), mps[ref2.Key().MetricsPullKey()].DueTime, 2*time.Second)
+	assert.Equal(t, 3600, mps[ref2.Key().MetricsPullKey()].IntervalSeconds)
+
+	// Pulls which are not due yet do not get rescheduled
+	assert.NoError(t, c.Store.SetMetricsPull(ctx, ref1.Key().MetricsPullKey(), schemas.MetricsPull{
+		DueTime:         before.Add(-time.Second),
+		IntervalSeconds: 30,
+	}))
+	c.scheduleMetricsPull(ctx, false)
+
+	newMps, err := c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.True(t, newMps[ref1.Key().MetricsPullKey()].DueTime.After(before))
+	assert.Equal(t, mps[ref2.Key().MetricsPullKey()], newMps[ref2.Key().MetricsPullKey()])
+}
+
+func TestScheduleMetricsPullsDueAndSeeded(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.IntervalSeconds = 30
+	cfg.Server.Webhook.Enabled = true
+	cfg.Server.Webhook.SkipPolling.Enabled = true
+	cfg.Server.Webhook.SkipPolling.InactivityTimeoutSeconds = 60
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	p := schemas.NewProject("foo/bar")
+	covered := schemas.NewProject("foo/baz")
+
+	notDue := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	unscheduled := schemas.NewRef(p, schemas.RefKindBranch, "dev")
+	coveredRef := schemas.NewRef(covered, schemas.RefKindBranch, "main")
+	deleted := schemas.NewRef(p, schemas.RefKindBranch, "deleted")
+
+	assert.NoError(t, c.Store.SetRef(ctx, notDue))
+	assert.NoError(t, c.Store.SetRef(ctx, unscheduled))
+	assert.NoError(t, c.Store.SetRef(ctx, coveredRef))
+	assert.NoError(t, c.Store.SetProjectLastWebhookEvent(ctx, covered.Key(), time.Now()))
+
+	before := time.Now()
+	notDuePull := schemas.MetricsPull{DueTime: time.Unix(before.Add(time.Hour).Unix(), 0), IntervalSeconds: 30}
+
+	assert.NoError(t, c.Store.SetMetricsPull(ctx, notDue.Key().MetricsPullKey(), notDuePull))
+	assert.NoError(t, c.Store.SetMetricsPull(ctx, deleted.Key().MetricsPullKey(), schemas.MetricsPull{
+		DueTime:         before.Add(-time.Second),
+		IntervalSeconds: 30,
+	}))
+
+	c.scheduleMetricsPull(ctx, false)
+
+	mps, err := c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, mps, 3)
+
+	// The pull which is not due is left untouched, the one of the deleted ref gets forgotten
+	assert.Equal(t, notDuePull, mps[notDue.Key().MetricsPullKey()])
+	assert.NotContains(t, mps, deleted.Key().MetricsPullKey())
+
+	// The pulls which had never been scheduled get seeded, the ones covered by webhooks being postponed
+	assert.WithinDuration(t, before.Add(30*time.Second), mps[unscheduled.Key().MetricsPullKey()].DueTime, 2*time.Second)
+	assert.WithinDuration(t, before.Add(30*time.Second), mps[coveredRef.Key().MetricsPullKey()].DueTime, 2*time.Second)
+
+	queued, err := c.Store.CurrentlyQueuedTasksCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), queued)
+}
+
+func TestAdaptiveRefMetricsPullIntervalSeconds(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.Adaptive.Enabled = true
+	cfg.Pull.Metrics.Adaptive.MinIntervalSeconds = 10
+	cfg.Pull.Metrics.Adaptive.MaxIntervalSeconds = 3600
+
+	_, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	now := time.Now()
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	ref.LatestPipeline.Timestamp = float64(now.Add(-24 * time.Hour).Unix())
+
+	// Never pulled before
+	assert.Equal(t, 30, c.adaptiveRefMetricsPullIntervalSeconds(ref, schemas.MetricsPull{}, 30, now))
+
+	// Idle refs get backed off, up to the maximum interval
+	assert.Equal(t, 60, c.adaptiveRefMetricsPullIntervalSeconds(ref, schemas.MetricsPull{IntervalSeconds: 30}, 30, now))
+	assert.Equal(t, 3600, c.adaptiveRefMetricsPullIntervalSeconds(ref, schemas.MetricsPull{IntervalSeconds: 2400}, 30, now))
+
+	// Refs which got reset are backed off from their configured interval
+	assert.Equal(t, 30, c.adaptiveRefMetricsPullIntervalSeconds(ref, schemas.MetricsPull{IntervalSeconds: 10}, 30, now))
+
+	// Refs which had some activity since their previous pull get back to their configured interval
+	ref.LatestPipeline.Timestamp = float64(now.Add(-time.Minute).Unix())
+	assert.Equal(t, 30, c.adaptiveRefMetricsPullIntervalSeconds(ref, schemas.MetricsPull{IntervalSeconds: 1200}, 30, now))
+
+	// Refs having running or pending pipelines get pulled at the minimum interval
+	for _, status := range []string{"running", "pending"} {
+		ref.LatestPipeline.Status = status
+		assert.Equal(t, 10, c.adaptiveRefMetricsPullIntervalSeconds(ref, schemas.MetricsPull{IntervalSeconds: 1200}, 30, now))
+	}
+}
+
+func TestScheduleMetricsPullAdaptive(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.IntervalSeconds = 30
+	cfg.Pull.Metrics.Adaptive.Enabled = true
+	cfg.Pull.Metrics.Adaptive.MinIntervalSeconds = 10
+	cfg.Pull.Metrics.Adaptive.MaxIntervalSeconds = 3600
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	ref.LatestPipeline.Status = "success"
+
+	assert.NoError(t, c.Store.SetRef(ctx, ref))
+	assert.NoError(t, c.Store.SetMetricsPull(ctx, ref.Key().MetricsPullKey(), schemas.MetricsPull{
+		DueTime:         time.Now().Add(-time.Second),
+		IntervalSeconds: 120,
+	}))
+
+	c.scheduleMetricsPull(ctx, false)
+
+	mps, err := c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 240, mps[ref.Key().MetricsPullKey()].IntervalSeconds)
+}
+
+func TestResetRefMetricsPull(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.Adaptive.MinIntervalSeconds = 10
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	idle := schemas.MetricsPull{
+		DueTime:         time.Unix(time.Now().Add(time.Hour).Unix(), 0),
+		IntervalSeconds: 3600,
+	}
+
+	// Nothing happens when the feature is disabled
+	assert.NoError(t, c.Store.SetMetricsPull(ctx, ref.Key().MetricsPullKey(), idle))
+	c.resetRefMetricsPull(ctx, ref)
+
+	mps, err := c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, idle, mps[ref.Key().MetricsPullKey()])
+
+	c.Config.Pull.Metrics.Adaptive.Enabled = true
+	c.resetRefMetricsPull(ctx, ref)
+
+	mps, err = c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, mps[ref.Key().MetricsPullKey()].IntervalSeconds)
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), mps[ref.Key().MetricsPullKey()].DueTime, 2*time.Second)
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	windows := []config.QuietHours{
+		{
+			Start: "08:00",
+			End:   "19:00",
+			Days:  []string{"monday", "tuesday", "wednesday", "thursday", "friday"},
+			Tasks: []string{"PullProjectsFromWildcards", "GarbageCollectMetrics"},
+		},
+		{
+			Start: "22:00",
+			End:   "02:00",
+			Tasks: []string{"GarbageCollectMetrics"},
+		},
+	}
+
+	// Wednesday
+	day := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 15, hour, minute, 0, 0, time.UTC)
+	}
+
+	assert.Equal(t, day(19, 0), quietHoursEnd(windows, schemas.TaskTypePullProjectsFromWildcards, day(10, 30)))
+	assert.Equal(t, day(19, 0), quietHoursEnd(windows, schemas.TaskTypePullProjectsFromWildcards, day(8, 0)))
+	assert.True(t, quietHoursEnd(windows, schemas.TaskTypePullProjectsFromWildcards, day(19, 0)).IsZero())
+	assert.True(t, quietHoursEnd(windows, schemas.TaskTypePullProjectsFromWildcards, day(7, 59)).IsZero())
+	assert.True(t, quietHoursEnd(windows, schemas.TaskTypePullMetrics, day(10, 30)).IsZero())
+
+	// Saturday
+	assert.True(t, quietHoursEnd(windows, schemas.TaskTypePullProjectsFromWildcards, day(10, 30).AddDate(0, 0, 3)).IsZero())
+
+	// Windows spanning over midnight
+	assert.Equal(t, day(2, 0).AddDate(0, 0, 1), quietHoursEnd(windows, schemas.TaskTypeGarbageCollectMetrics, day(23, 0)))
+	assert.Equal(t, day(2, 0), quietHoursEnd(windows, schemas.TaskTypeGarbageCollectMetrics, day(1, 0)))
+	assert.True(t, quietHoursEnd(windows, schemas.TaskTypeGarbageCollectMetrics, day(3, 0)).IsZero())
+}
+
+func TestScheduleDeferrableTask(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Scheduler.Timezone = "UTC"
+	cfg.Scheduler.QuietHours = []config.QuietHours{
+		{
+			Start: "00:00",
+			End:   "00:00",
+			Tasks: []string{"GarbageCollectMetrics"},
+		},
+	}
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	c.scheduleDeferrableTask(ctx, schemas.TaskTypeGarbageCollectMetrics)
+	c.scheduleDeferrableTask(ctx, schemas.TaskTypeGarbageCollectMetrics)
+
+	end, deferred := c.TaskController.deferredTasks.Load(schemas.TaskTypeGarbageCollectMetrics)
+	assert.True(t, deferred)
+	assert.True(t, end.(time.Time).After(time.Now()))
+
+	_, deferred = c.TaskController.deferredTasks.Load(schemas.TaskTypePullMetrics)
+	assert.False(t, deferred)
+}
+
+func TestScheduleOnInitTaskWithinQuietHours(t *testing.T) {
+	cfg := config.Config{}
+	cfg.GarbageCollect.Metrics.OnInit = true
+	cfg.Scheduler.Timezone = "UTC"
+	cfg.Scheduler.QuietHours = []config.QuietHours{
+		{
+			Start: "00:00",
+			End:   "00:00",
+			Tasks: []string{"GarbageCollectMetrics"},
+		},
+	}
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.Schedule(ctx, c.Config.Pull, c.Config.GarbageCollect)
+
+	_, deferred := c.TaskController.deferredTasks.Load(schemas.TaskTypeGarbageCollectMetrics)
+	assert.True(t, deferred)
+
+	count, err := c.Store.CurrentlyQueuedTasksCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), count)
+}
+
+func TestScheduleTaskOutsideQuietHoursNotLeader(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Scheduler.Timezone = "UTC"
+	cfg.Scheduler.QuietHours = []config.QuietHours{
+		{
+			Start: "00:00",
+			End:   "00:00",
+			Tasks: []string{"GarbageCollectMetrics"},
+		},
+	}
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	// The next slices of a task can be queued by any instance, within quiet hours they get deferred as well
+	c.LeaderElection.Set(false, "foo")
+	c.scheduleTaskOutsideQuietHours(ctx, schemas.TaskTypeGarbageCollectMetrics)
+
+	_, deferred := c.TaskController.deferredTasks.Load(schemas.TaskTypeGarbageCollectMetrics)
+	assert.True(t, deferred)
+
+	c.Config.Scheduler.QuietHours = nil
+	c.scheduleTaskOutsideQuietHours(ctx, schemas.TaskTypeGarbageCollectMetrics)
+
+	count, err := c.Store.CurrentlyQueuedTasksCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestNewTaskControllerQueues(t *testing.T) {
+	tc := NewTaskController(
+		context.Background(),
+		nil,
+		"foo",
+		1000,
+		config.SchedulerQueues{
+			Metrics: config.SchedulerQueue{
+				BufferSize:     5000,
+				MaxConcurrency: 4,
+			},
+		},
+		config.ServerWebhookQueue{BufferSize: 10},
+	)
+
+	assert.Len(t, tc.Queues, 4)
+	assert.Equal(t, "foo:metrics", tc.Queues[schemas.TaskQueueMetrics].Options().Name)
+	assert.Equal(t, "foo:webhooks", tc.WebhooksQueue.Options().Name)
+	assert.Equal(t, 5000, tc.Queues[schemas.TaskQueueMetrics].Options().BufferSize)
+	assert.Equal(t, 4, tc.Queues[schemas.TaskQueueMetrics].Options().NumWorker)
+
+	for _, q := range []schemas.TaskQueue{
+		schemas.TaskQueuePriority,
+		schemas.TaskQueueDiscovery,
+		schemas.TaskQueueGarbageCollect,
+	} {
+		assert.Equal(t, 1000, tc.Queues[q].Options().BufferSize)
+	}
+}
+
+func TestSchedulePriorityTaskQueuedOntoItsQueue(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+
+	// The pull waiting onto the metrics queue does not prevent it from being processed with priority
+	queued, err := c.Store.QueueTask(ctx, schemas.TaskQueueMetrics, schemas.TaskTypePullRefMetrics, string(ref.Key()), c.UUID.String())
+	assert.NoError(t, err)
+	assert.True(t, queued)
+
+	assert.True(t, c.SchedulePriorityTask(ctx, schemas.TaskTypePullRefMetrics, string(ref.Key()), ref))
+
+	queued, err = c.Store.QueueTask(ctx, schemas.TaskQueuePriority, schemas.TaskTypePullRefMetrics, string(ref.Key()), c.UUID.String())
+	assert.NoError(t, err)
+	assert.False(t, queued)
+
+	// Processing it with priority releases its own lock only
+	c.unqueueTask(context.WithValue(ctx, taskQueueContextKey{}, schemas.TaskQueuePriority), schemas.TaskTypePullRefMetrics, string(ref.Key()))
+
+	queued, err = c.Store.QueueTask(ctx, schemas.TaskQueuePriority, schemas.TaskTypePullRefMetrics, string(ref.Key()), c.UUID.String())
+	assert.NoError(t, err)
+	assert.True(t, queued)
+
+	queued, err = c.Store.QueueTask(ctx, schemas.TaskQueueMetrics, schemas.TaskTypePullRefMetrics, string(ref.Key()), c.UUID.String())
+	assert.NoError(t, err)
+	assert.False(t, queued)
+}
+
+func TestTaskQueueHandler(t *testing.T) {
+	tasks := &taskq.TaskMap{}
+
+	var q schemas.TaskQueue
+
+	task, err := tasks.Register("foo", &taskq.TaskConfig{
+		Handler: func(ctx context.Context) {
+			q = taskQueue(ctx, schemas.TaskTypePullRefMetrics)
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, schemas.TaskQueueMetrics, taskQueue(context.Background(), schemas.TaskTypePullRefMetrics))
+	assert.NoError(t, taskQueueHandler{queue: schemas.TaskQueuePriority, tasks: tasks}.HandleJob(context.Background(), task.NewJob()))
+	assert.Equal(t, schemas.TaskQueuePriority, q)
+}
+
+func TestScheduleMetricsPullSkippedTask(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.IntervalSeconds = 30
+	cfg.Scheduler.Queues.Metrics.BufferSize = 1
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	assert.NoError(t, c.Store.SetRef(ctx, ref))
+
+	// Exhaust the buffer of the metrics queue
+	queue := c.TaskController.Queues[schemas.TaskQueueMetrics]
+	assert.NoError(t, queue.AddJob(ctx, c.TaskController.TaskMap.Get(string(schemas.TaskTypePullMetrics)).NewJob()))
+
+	qlen, err := queue.Len(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, qlen)
+
+	// The task being skipped, the pull remains due
+	c.scheduleMetricsPull(ctx, false)
+
+	mps, err := c.Store.MetricsPulls(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, mps)
+}
+
+func TestScheduleMetricsPullTickInterval(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Pull.Metrics.Scheduled = true
+	cfg.Pull.Metrics.IntervalSeconds = 30
+	cfg.Pull.Metrics.Adaptive.Enabled = true
+	cfg.Pull.Metrics.Adaptive.MinIntervalSeconds = 10
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	before := time.Now()
+	c.Schedule(ctx, c.Config.Pull, c.Config.GarbageCollect)
+
+	require.Contains(t, c.TaskController.TaskSchedulingMonitoring, schemas.TaskTypePullMetrics)
+	assert.WithinDuration(t, before.Add(10*time.Second), c.TaskController.TaskSchedulingMonitoring[schemas.TaskTypePullMetrics].Next, time.Second)
+}
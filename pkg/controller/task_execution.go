@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"github.com/vmihailenco/taskq/v4"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// TaskSkipReason ..
+type TaskSkipReason string
+
+const (
+	// TaskSkipReasonAlreadyQueued ..
+	TaskSkipReasonAlreadyQueued TaskSkipReason = "already_queued"
+
+	// TaskSkipReasonBufferFull ..
+	TaskSkipReasonBufferFull TaskSkipReason = "buffer_full"
+)
+
+// TaskExecutionCollectors holds the collectors of the task execution metrics. Unlike the
+// other internal ones, they are computed by each process rather than read from the store.
+type TaskExecutionCollectors struct {
+	ExecutionDurationSeconds prometheus.Collector
+	ExecutionsCount          prometheus.Collector
+	FailuresCount            prometheus.Collector
+	OrphanedTasksCount       prometheus.Collector
+	QueueWaitDurationSeconds prometheus.Collector
+	RecoveredTasksCount      prometheus.Collector
+	SkipsCount               prometheus.Collector
+}
+
+// NewTaskExecutionCollectors ..
+func NewTaskExecutionCollectors() TaskExecutionCollectors {
+	return TaskExecutionCollectors{
+		ExecutionDurationSeconds: NewInternalCollectorTaskExecutionDurationSeconds(),
+		ExecutionsCount:          NewInternalCollectorTaskExecutionsCount(),
+		FailuresCount:            NewInternalCollectorTaskFailuresCount(),
+		OrphanedTasksCount:       NewInternalCollectorOrphanedTasksCount(),
+		QueueWaitDurationSeconds: NewInternalCollectorTaskQueueWaitDurationSeconds(),
+		RecoveredTasksCount:      NewInternalCollectorRecoveredTasksCount(),
+		SkipsCount:               NewInternalCollectorTaskSkipsCount(),
+	}
+}
+
+type taskExecutionContextKey struct{}
+
+// taskExecution holds the outcome of a task handler which does not return its errors.
+type taskExecution struct {
+	err error
+}
+
+// setTaskExecutionError flags the task being executed as failed, it is meant to be used
+// by the handlers which do not return their errors in order not to pause the queues.
+func setTaskExecutionError(ctx context.Context, err error) {
+	if te, ok := ctx.Value(taskExecutionContextKey{}).(*taskExecution); ok {
+		te.err = err
+	}
+}
+
+// taskJobEnvelopeVersion is the current version of the envelope prepended to the arguments of the tasks.
+const taskJobEnvelopeVersion = 1
+
+// taskJobEnvelope holds the metadata of a task, prepended to its arguments. It is versioned in
+// order for the handlers to tell it apart from the arguments of the tasks queued by previous releases.
+type taskJobEnvelope struct {
+	Version int `msgpack:"version"`
+
+	// Time at which the task got queued, according to the clock of the process which queued it
+	QueuedAt int64 `msgpack:"queued_at"`
+}
+
+// newTaskJobArgs prepends the envelope of the task to its arguments.
+func newTaskJobArgs(args ...interface{}) []interface{} {
+	return append([]interface{}{taskJobEnvelope{
+		Version:  taskJobEnvelopeVersion,
+		QueuedAt: time.Now().UnixNano(),
+	}}, args...)
+}
+
+// instrumentedTaskHandler dispatches the tasks onto the handler matching the shape of their arguments,
+// in order to process the ones left in the queues by previous releases as well. These were either not
+// prepended anything, or only the time at which they got queued.
+type instrumentedTaskHandler struct {
+	argsCount   int
+	enveloped   taskq.Handler
+	timestamped taskq.Handler
+	plain       taskq.Handler
+}
+
+// HandleJob ..
+func (h instrumentedTaskHandler) HandleJob(ctx context.Context, job *taskq.Job) error {
+	// The arguments are only available decoded when the job has not gone through Redis
+	if job.Args != nil {
+		if len(job.Args) == h.argsCount+1 {
+			switch job.Args[0].(type) {
+			case taskJobEnvelope:
+				return h.enveloped.HandleJob(ctx, job)
+			case int64:
+				return h.timestamped.HandleJob(ctx, job)
+			}
+		}
+
+		return h.plain.HandleJob(ctx, job)
+	}
+
+	b, err := job.MarshalArgs()
+	if err != nil {
+		return err
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return err
+	}
+
+	if n != h.argsCount+1 {
+		return h.plain.HandleJob(ctx, job)
+	}
+
+	code, err := dec.PeekCode()
+	if err != nil {
+		return err
+	}
+
+	if msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32 {
+		return h.enveloped.HandleJob(ctx, job)
+	}
+
+	return h.timestamped.HandleJob(ctx, job)
+}
+
+// instrumentTaskHandler wraps a task handler in order to monitor its executions. The returned
+// handler expects the envelope of the task as its first argument after the context.
+func (c *Controller) instrumentTaskHandler(tt schemas.TaskType, handler interface{}) taskq.Handler {
+	hv := reflect.ValueOf(handler)
+	ht := hv.Type()
+
+	out := make([]reflect.Type, ht.NumOut())
+	for i := range out {
+		out[i] = ht.Out(i)
+	}
+
+	call := func(ctx context.Context, queuedAt time.Time, args []reflect.Value) []reflect.Value {
+		te := &taskExecution{}
+		ctx = context.WithValue(ctx, taskExecutionContextKey{}, te)
+		start := time.Now()
+
+		results := hv.Call(append([]reflect.Value{reflect.ValueOf(ctx)}, args...))
+
+		err := te.err
+		if len(results) > 0 {
+			if e, ok := results[len(results)-1].Interface().(error); ok && e != nil {
+				err = e
+			}
+		}
+
+		// The time at which the task got queued is unknown for the ones queued by previous releases
+		queueWait := time.Duration(-1)
+		if !queuedAt.IsZero() {
+			// It can be ahead of ours if the clocks of the processes are not in sync
+			queueWait = max(start.Sub(queuedAt), 0)
+		}
+
+		c.TaskController.recordTaskExecution(tt, queueWait, time.Since(start), err)
+
+		return results
+	}
+
+	// withQueuedAt returns a handler expecting an argument of the provided type
+	// ahead of the ones of the task, out of which the queueing time is read.
+	withQueuedAt := func(t reflect.Type, queuedAt func(reflect.Value) time.Time) taskq.Handler {
+		in := []reflect.Type{ht.In(0), t}
+		for i := 1; i < ht.NumIn(); i++ {
+			in = append(in, ht.In(i))
+		}
+
+		return taskq.NewHandler(reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+			return call(args[0].Interface().(context.Context), queuedAt(args[1]), args[2:])
+		}).Interface())
+	}
+
+	return instrumentedTaskHandler{
+		argsCount: ht.NumIn() - 1,
+		enveloped: withQueuedAt(reflect.TypeOf(taskJobEnvelope{}), func(v reflect.Value) time.Time {
+			return time.Unix(0, v.Interface().(taskJobEnvelope).QueuedAt)
+		}),
+		timestamped: withQueuedAt(reflect.TypeOf(int64(0)), func(v reflect.Value) time.Time {
+			return time.Unix(0, v.Int())
+		}),
+		plain: taskq.NewHandler(reflect.MakeFunc(ht, func(args []reflect.Value) []reflect.Value {
+			return call(args[0].Interface().(context.Context), time.Time{}, args[1:])
+		}).Interface()),
+	}
+}
+
+// recordTaskExecution records the execution of a task, its queue wait is negative when unknown.
+func (tc *TaskController) recordTaskExecution(tt schemas.TaskType, queueWait, duration time.Duration, err error) {
+	if tc.TaskExecutionMonitoring != nil {
+		tc.TaskExecutionMonitoring.RecordExecution(tt, max(queueWait, 0), duration, err == nil)
+	}
+
+	if tc.TaskExecutionCollectors.ExecutionsCount == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil {
+		status = "failure"
+
+		tc.TaskExecutionCollectors.FailuresCount.(*prometheus.CounterVec).With(prometheus.Labels{
+			"task_type": string(tt),
+			"reason":    taskFailureReason(err),
+		}).Inc()
+	}
+
+	tc.TaskExecutionCollectors.ExecutionsCount.(*prometheus.CounterVec).With(prometheus.Labels{
+		"task_type": string(tt),
+		"status":    status,
+	}).Inc()
+
+	tc.TaskExecutionCollectors.ExecutionDurationSeconds.(*prometheus.HistogramVec).With(prometheus.Labels{
+		"task_type": string(tt),
+	}).Observe(duration.Seconds())
+
+	if queueWait >= 0 {
+		tc.TaskExecutionCollectors.QueueWaitDurationSeconds.(*prometheus.HistogramVec).With(prometheus.Labels{
+			"task_type": string(tt),
+		}).Observe(queueWait.Seconds())
+	}
+}
+
+func (tc *TaskController) recordTaskSkip(tt schemas.TaskType, reason TaskSkipReason) {
+	if tc.TaskExecutionMonitoring != nil {
+		tc.TaskExecutionMonitoring.RecordSkip(tt)
+	}
+
+	if tc.TaskExecutionCollectors.SkipsCount == nil {
+		return
+	}
+
+	tc.TaskExecutionCollectors.SkipsCount.(*prometheus.CounterVec).With(prometheus.Labels{
+		"task_type": string(tt),
+		"reason":    string(reason),
+	}).Inc()
+}
+
+// taskFailureReason returns a class of the error which made a task fail,
+// bounded in order to be used as a label value.
+func taskFailureReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return "timeout"
+	}
+
+	var errResp *goGitlab.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil {
+		switch code := errResp.Response.StatusCode; {
+		case code == http.StatusTooManyRequests:
+			return "rate_limited"
+		case code == http.StatusNotFound:
+			return "not_found"
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return "unauthorized"
+		case code >= http.StatusInternalServerError:
+			return "gitlab_server_error"
+		default:
+			return "gitlab_client_error"
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+
+	return "other"
+}
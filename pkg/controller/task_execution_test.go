@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/taskq/v4"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestInstrumentTaskHandler(t *testing.T) {
+	_, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	notFound := &goGitlab.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+	// Handlers returning their errors
+	h := c.instrumentTaskHandler(schemas.TaskTypePullProject, func(_ context.Context, name string) error {
+		if name == "fail" {
+			return notFound
+		}
+
+		return nil
+	})
+
+	queuedAt := time.Now().Add(-time.Minute)
+	assert.NoError(t, h.HandleJob(context.Background(), &taskq.Job{Args: []interface{}{taskJobEnvelope{Version: taskJobEnvelopeVersion, QueuedAt: queuedAt.UnixNano()}, "foo"}}))
+	assert.Equal(t, notFound, h.HandleJob(context.Background(), &taskq.Job{Args: []interface{}{taskJobEnvelope{Version: taskJobEnvelopeVersion, QueuedAt: queuedAt.UnixNano()}, "fail"}}))
+
+	// Handlers flagging their errors
+	v := c.instrumentTaskHandler(schemas.TaskTypePullRefMetrics, func(ctx context.Context) {
+		setTaskExecutionError(ctx, fmt.Errorf("foo"))
+	})
+
+	assert.NoError(t, v.HandleJob(context.Background(), &taskq.Job{Args: newTaskJobArgs()}))
+
+	stats := c.TaskController.TaskExecutionMonitoring.Stats()
+	assert.Equal(t, uint64(1), stats[schemas.TaskTypePullProject].SuccessCount)
+	assert.Equal(t, uint64(1), stats[schemas.TaskTypePullProject].FailureCount)
+	assert.GreaterOrEqual(t, stats[schemas.TaskTypePullProject].QueueWaitSecondsSum, float64(120))
+	assert.Equal(t, uint64(0), stats[schemas.TaskTypePullRefMetrics].SuccessCount)
+	assert.Equal(t, uint64(1), stats[schemas.TaskTypePullRefMetrics].FailureCount)
+
+	failures := c.TaskController.TaskExecutionCollectors.FailuresCount
+	assert.Equal(t, 2, testutil.CollectAndCount(failures))
+	assert.Equal(t, 2, testutil.CollectAndCount(c.TaskController.TaskExecutionCollectors.ExecutionDurationSeconds, "gcpe_task_execution_duration_seconds"))
+}
+
+func TestInstrumentTaskHandlerArgsShapes(t *testing.T) {
+	_, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	var refs []schemas.Ref
+
+	h := c.instrumentTaskHandler(schemas.TaskTypePullRefMetrics, func(_ context.Context, ref schemas.Ref) {
+		refs = append(refs, ref)
+	})
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	queuedAt := time.Now().Add(-time.Minute).UnixNano()
+
+	// Jobs read out of Redis only have their encoded arguments
+	encoded := func(args ...interface{}) *taskq.Job {
+		b, err := (&taskq.Job{Args: args}).MarshalArgs()
+		require.NoError(t, err)
+
+		return &taskq.Job{ArgsBin: b}
+	}
+
+	for _, tc := range []struct {
+		name string
+		job  *taskq.Job
+	}{
+		{"plain", &taskq.Job{Args: []interface{}{ref}}},
+		{"plain_encoded", encoded(ref)},
+		{"timestamped", &taskq.Job{Args: []interface{}{queuedAt, ref}}},
+		{"timestamped_encoded", encoded(queuedAt, ref)},
+		{"enveloped", &taskq.Job{Args: newTaskJobArgs(ref)}},
+		{"enveloped_encoded", encoded(newTaskJobArgs(ref)...)},
+	} {
+		refs = nil
+
+		assert.NoError(t, h.HandleJob(context.Background(), tc.job), tc.name)
+		assert.Equal(t, []schemas.Ref{ref}, refs, tc.name)
+
+		// The queue wait of the jobs queued without their queueing time is unknown, hence not observed
+		if tc.name == "plain_encoded" {
+			assert.Equal(t, 0, testutil.CollectAndCount(c.TaskController.TaskExecutionCollectors.QueueWaitDurationSeconds))
+		}
+	}
+
+	stats := c.TaskController.TaskExecutionMonitoring.Stats()
+	assert.Equal(t, uint64(6), stats[schemas.TaskTypePullRefMetrics].SuccessCount)
+	assert.GreaterOrEqual(t, stats[schemas.TaskTypePullRefMetrics].QueueWaitSecondsSum, float64(120))
+	assert.Equal(t, 1, testutil.CollectAndCount(c.TaskController.TaskExecutionCollectors.QueueWaitDurationSeconds))
+}
+
+func TestInstrumentTaskHandlerClockSkew(t *testing.T) {
+	_, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	h := c.instrumentTaskHandler(schemas.TaskTypePullMetrics, func(_ context.Context) {})
+
+	// Queued by a process whose clock is ahead of ours
+	queuedAt := time.Now().Add(time.Minute).UnixNano()
+	assert.NoError(t, h.HandleJob(context.Background(), &taskq.Job{Args: []interface{}{taskJobEnvelope{Version: taskJobEnvelopeVersion, QueuedAt: queuedAt}}}))
+	assert.Equal(t, float64(0), c.TaskController.TaskExecutionMonitoring.Stats()[schemas.TaskTypePullMetrics].QueueWaitSecondsSum)
+}
+
+func TestScheduleTaskRecordsSkips(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	queued, err := c.Store.QueueTask(ctx, schemas.TaskTypePullProject.Queue(), schemas.TaskTypePullProject, "foo", "")
+	assert.NoError(t, err)
+	assert.True(t, queued)
+
+	c.ScheduleTask(ctx, schemas.TaskTypePullProject, "foo", "foo", config.ProjectPull{})
+
+	assert.Equal(t, uint64(1), c.TaskController.TaskExecutionMonitoring.Stats()[schemas.TaskTypePullProject].SkippedCount)
+	assert.Equal(t, 1, testutil.CollectAndCount(c.TaskController.TaskExecutionCollectors.SkipsCount))
+}
+
+func TestTaskFailureReason(t *testing.T) {
+	errorResponse := func(code int) error {
+		return fmt.Errorf("wrapped: %w", &goGitlab.ErrorResponse{Response: &http.Response{StatusCode: code}})
+	}
+
+	assert.Equal(t, "timeout", taskFailureReason(context.DeadlineExceeded))
+	assert.Equal(t, "rate_limited", taskFailureReason(errorResponse(http.StatusTooManyRequests)))
+	assert.Equal(t, "not_found", taskFailureReason(errorResponse(http.StatusNotFound)))
+	assert.Equal(t, "unauthorized", taskFailureReason(errorResponse(http.StatusForbidden)))
+	assert.Equal(t, "gitlab_server_error", taskFailureReason(errorResponse(http.StatusBadGateway)))
+	assert.Equal(t, "gitlab_client_error", taskFailureReason(errorResponse(http.StatusBadRequest)))
+	assert.Equal(t, "network", taskFailureReason(&net.OpError{Op: "dial", Err: fmt.Errorf("refused")}))
+	assert.Equal(t, "other", taskFailureReason(fmt.Errorf("foo")))
+}
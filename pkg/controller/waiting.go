@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// ProcessWaitingMetrics exports the metrics of the most recent pipeline of the ref being blocked on a manual job
+// and of its jobs waiting to be played or for their resource group. As they depend on the current time,
+// they are computed out of the state of the ref on each pull, whether its pipeline changed or not.
+func (c *Controller) ProcessWaitingMetrics(ctx context.Context, ref schemas.Ref) error {
+	// Refresh ref state from the store
+	if err := c.Store.GetRef(ctx, &ref); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"project-name": ref.Project.Name,
+		"ref":          ref.Name,
+		"pipeline-id":  ref.LatestPipeline.ID,
+	}).Trace("processing waiting metrics")
+
+	now := float64(time.Now().Unix())
+
+	blockedDuration := schemas.Metric{
+		Kind:   schemas.MetricKindBlockedDurationSeconds,
+		Labels: ref.DefaultLabelsValues(),
+	}
+
+	// A blocked pipeline does not get updated until its manual job gets played
+	if ref.LatestPipeline.Status == "manual" && ref.LatestPipeline.Timestamp > 0 {
+		blockedDuration.Value = now - ref.LatestPipeline.Timestamp
+	}
+
+	storeSetMetric(ctx, c.Store, blockedDuration)
+
+	for _, job := range ref.LatestJobs {
+		// The jobs which are not part of the most recent pipeline anymore are not waiting
+		latest := job.PipelineID == ref.LatestPipeline.ID
+
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = job.Stage
+		labels["job_name"] = job.Name
+
+		manualWaiting := schemas.Metric{
+			Kind:   schemas.MetricKindJobManualWaiting,
+			Labels: labels,
+		}
+
+		if latest && job.Status == "manual" {
+			manualWaiting.Value = 1
+		}
+
+		waitingForResource := schemas.Metric{
+			Kind:   schemas.MetricKindJobWaitingForResourceDurationSeconds,
+			Labels: labels,
+		}
+
+		if latest && job.Status == "waiting_for_resource" && job.Timestamp > 0 {
+			waitingForResource.Value = now - job.Timestamp
+		}
+
+		// Only the jobs which have been waiting at some point get exported
+		for _, m := range []schemas.Metric{manualWaiting, waitingForResource} {
+			exists, err := c.Store.MetricExists(ctx, m.Key())
+			if err != nil {
+				return err
+			}
+
+			if m.Value > 0 || exists {
+				storeSetMetric(ctx, c.Store, m)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestProcessWaitingMetrics(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+	ref.LatestPipeline = schemas.Pipeline{ID: 2, Status: "manual", Timestamp: 1}
+	ref.LatestJobs = schemas.Jobs{
+		"deploy":  {ID: 20, PipelineID: 2, Name: "deploy", Stage: "deploy", Status: "manual", Timestamp: 1},
+		"release": {ID: 21, PipelineID: 2, Name: "release", Stage: "deploy", Status: "waiting_for_resource", Timestamp: 1},
+		"build":   {ID: 19, PipelineID: 2, Name: "build", Stage: "build", Status: "success", Timestamp: 1},
+		// Manual job of a former pipeline
+		"cleanup": {ID: 10, PipelineID: 1, Name: "cleanup", Stage: "deploy", Status: "manual", Timestamp: 1},
+	}
+
+	assert.NoError(t, c.Store.SetRef(ctx, ref))
+	assert.NoError(t, c.ProcessWaitingMetrics(ctx, ref))
+
+	jobLabels := func(stage, name string) map[string]string {
+		labels := ref.DefaultLabelsValues()
+		labels["stage"] = stage
+		labels["job_name"] = name
+
+		return labels
+	}
+
+	metrics, _ := c.Store.Metrics(ctx)
+
+	blocked := schemas.Metric{Kind: schemas.MetricKindBlockedDurationSeconds, Labels: ref.DefaultLabelsValues()}
+	assert.Greater(t, metrics[blocked.Key()].Value, float64(0))
+
+	manualWaiting := schemas.Metric{Kind: schemas.MetricKindJobManualWaiting, Labels: jobLabels("deploy", "deploy"), Value: 1}
+	assert.Equal(t, manualWaiting, metrics[manualWaiting.Key()])
+
+	waitingForResource := schemas.Metric{Kind: schemas.MetricKindJobWaitingForResourceDurationSeconds, Labels: jobLabels("deploy", "release")}
+	assert.Greater(t, metrics[waitingForResource.Key()].Value, float64(0))
+
+	// Only the jobs which are waiting get exported
+	assert.Len(t, metrics, 3)
+
+	// Once played, the metrics are kept with a value of 0
+	ref.LatestPipeline.Status = "running"
+	ref.LatestJobs["deploy"] = schemas.Job{ID: 20, PipelineID: 2, Name: "deploy", Stage: "deploy", Status: "running", Timestamp: 1}
+	ref.LatestJobs["release"] = schemas.Job{ID: 21, PipelineID: 2, Name: "release", Stage: "deploy", Status: "running", Timestamp: 1}
+
+	assert.NoError(t, c.Store.SetRef(ctx, ref))
+	assert.NoError(t, c.ProcessWaitingMetrics(ctx, ref))
+
+	metrics, _ = c.Store.Metrics(ctx)
+	assert.Len(t, metrics, 3)
+
+	for _, m := range metrics {
+		assert.Equal(t, float64(0), m.Value)
+	}
+}
@@ -3,18 +3,223 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/vmihailenco/taskq/v4"
 	goGitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 )
 
-func (c *Controller) processPipelineEvent(ctx context.Context, e goGitlab.PipelineEvent) {
+// ScheduleWebhookEvent persists the webhook event in the queue for it to be processed
+// asynchronously. It returns false if the event has been deduplicated.
+func (c *Controller) ScheduleWebhookEvent(ctx context.Context, e schemas.WebhookEvent, deduplicate bool) (bool, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "controller:ScheduleWebhookEvent")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("event_key", e.Key))
+	span.SetAttributes(attribute.String("event_type", e.Type))
+
+	qlen, err := c.TaskController.WebhooksQueue.Len(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "reading webhooks queue length")
+	}
+
+	if qlen >= c.TaskController.WebhooksQueue.Options().BufferSize {
+		c.TaskController.recordTaskSkip(schemas.TaskTypeProcessWebhookEvent, TaskSkipReasonBufferFull)
+
+		return false, fmt.Errorf("webhooks queue buffer size exhausted")
+	}
+
+	job := c.TaskController.TaskMap.Get(string(schemas.TaskTypeProcessWebhookEvent)).NewJob(newTaskJobArgs(e)...)
+
+	// Jobs with a name get deduplicated by the queue
+	if deduplicate {
+		job.Name = e.Key
+	}
+
+	if err = c.TaskController.WebhooksQueue.AddJob(ctx, job); err != nil {
+		return false, errors.Wrap(err, "adding webhook event to the queue")
+	}
+
+	if job.Err == taskq.ErrDuplicate {
+		c.TaskController.recordTaskSkip(schemas.TaskTypeProcessWebhookEvent, TaskSkipReasonAlreadyQueued)
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// ReplayFailedWebhookEvents queues back the webhook events which failed to be processed,
+// all of them if no key is provided.
+func (c *Controller) ReplayFailedWebhookEvents(ctx context.Context, key string) (replayed int, err error) {
+	events, err := c.Store.FailedWebhookEvents(ctx)
+	if err != nil {
+		return
+	}
+
+	for k, e := range events {
+		if len(key) > 0 && k != key {
+			continue
+		}
+
+		// We remove it first in case it would fail again before we are done
+		if err = c.Store.DelFailedWebhookEvent(ctx, k); err != nil {
+			return
+		}
+
+		if _, err = c.ScheduleWebhookEvent(ctx, e, false); err != nil {
+			// Put it back for a later attempt
+			_ = c.Store.SetFailedWebhookEvent(ctx, e)
+
+			return
+		}
+
+		replayed++
+	}
+
+	return
+}
+
+func (c *Controller) processWebhookEvent(ctx context.Context, e schemas.WebhookEvent) {
+	logFields := log.Fields{
+		"event-key":  e.Key,
+		"event-type": e.Type,
+	}
+
+	event, err := goGitlab.ParseHook(goGitlab.EventType(e.Type), e.Payload)
+	if err == nil {
+		switch event := event.(type) {
+		case *goGitlab.PipelineEvent:
+			err = c.processPipelineEvent(ctx, *event)
+		case *goGitlab.JobEvent:
+			err = c.processJobEvent(ctx, *event)
+		case *goGitlab.DeploymentEvent:
+			err = c.processDeploymentEvent(ctx, *event)
+		case *goGitlab.PushEvent:
+			err = c.processPushEvent(ctx, *event)
+		case *goGitlab.TagEvent:
+			err = c.processTagEvent(ctx, *event)
+		case *goGitlab.MergeEvent:
+			err = c.processMergeEvent(ctx, *event)
+		case *goGitlab.ProjectSystemEvent:
+			err = c.processProjectLifecycleEvent(ctx, event.EventName, event.ProjectID, event.PathWithNamespace, event.OldPathWithNamespace)
+		case *goGitlab.ProjectWebhookEvent:
+			err = c.processProjectLifecycleEvent(ctx, event.EventName, event.ProjectID, event.PathWithNamespace, event.OldPathWithNamespace)
+		case *goGitlab.RepositoryUpdateSystemEvent:
+			err = c.processRepositoryUpdateEvent(ctx, *event)
+		default:
+			err = fmt.Errorf("non supported event type %s", reflect.TypeOf(event).String())
+		}
+	}
+
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(logFields).
+			WithError(err).
+			Warn("processing webhook event")
+
+		c.incrWebhookEventsCount(ctx, e.Type, schemas.WebhookEventStatusFailed)
+		setTaskExecutionError(ctx, err)
+
+		e.Error = err.Error()
+		c.storeFailedWebhookEvent(ctx, e)
+
+		return
+	}
+
+	c.incrWebhookEventsCount(ctx, e.Type, schemas.WebhookEventStatusProcessed)
+}
+
+func (c *Controller) storeFailedWebhookEvent(ctx context.Context, e schemas.WebhookEvent) {
+	logFields := log.Fields{
+		"event-key":  e.Key,
+		"event-type": e.Type,
+	}
+
+	count, err := c.Store.FailedWebhookEventsCount(ctx)
+	if err != nil {
+		log.WithContext(ctx).
+			WithFields(logFields).
+			WithError(err).
+			Error("reading failed webhook events count from the store")
+
+		return
+	}
+
+	if count >= int64(c.Config.Server.Webhook.Queue.MaxFailedEvents) {
+		log.WithContext(ctx).
+			WithFields(logFields).
+			Warn("maximum amount of failed webhook events reached, it will not be possible to replay this one")
+
+		return
+	}
+
+	if err = c.Store.SetFailedWebhookEvent(ctx, e); err != nil {
+		log.WithContext(ctx).
+			WithFields(logFields).
+			WithError(err).
+			Error("writing failed webhook event in the store")
+	}
+}
+
+func (c *Controller) incrWebhookEventsCount(ctx context.Context, eventType string, status schemas.WebhookEventStatus) {
+	if err := c.Store.IncrWebhookEventsCount(ctx, eventType, status); err != nil {
+		log.WithContext(ctx).
+			WithFields(log.Fields{
+				"event-type":   eventType,
+				"event-status": status,
+			}).
+			WithError(err).
+			Warn("incrementing webhook events count")
+	}
+}
+
+func isWebhookEventSupported(event interface{}) bool {
+	switch event.(type) {
+	case *goGitlab.PipelineEvent,
+		*goGitlab.JobEvent,
+		*goGitlab.DeploymentEvent,
+		*goGitlab.PushEvent,
+		*goGitlab.TagEvent,
+		*goGitlab.MergeEvent,
+		*goGitlab.ProjectSystemEvent,
+		*goGitlab.ProjectWebhookEvent,
+		*goGitlab.RepositoryUpdateSystemEvent:
+		return true
+	}
+
+	return false
+}
+
+// webhookEventKey returns the idempotency key of the event, GitLab keeps it
+// identical across the delivery attempts of the same event.
+func webhookEventKey(r *http.Request, eventType goGitlab.EventType, payload []byte) string {
+	if key := r.Header.Get("Idempotency-Key"); len(key) > 0 {
+		return key
+	}
+
+	if key := r.Header.Get("X-Gitlab-Event-UUID"); len(key) > 0 {
+		return key
+	}
+
+	return schemas.NewWebhookEventKey(string(eventType), payload)
+}
+
+func (c *Controller) processPipelineEvent(ctx context.Context, e goGitlab.PipelineEvent) error {
+	c.recordWebhookEvent(ctx, e.Project.PathWithNamespace)
+
 	var (
 		refKind schemas.RefKind
 		refName = e.ObjectAttributes.Ref
@@ -31,14 +236,14 @@ func (c *Controller) processPipelineEvent(ctx context.Context, e goGitlab.Pipeli
 		refKind = schemas.RefKindBranch
 	}
 
-	c.triggerRefMetricsPull(ctx, schemas.NewRef(
+	return c.triggerRefMetricsPull(ctx, schemas.NewRef(
 		schemas.NewProject(e.Project.PathWithNamespace),
 		refKind,
 		refName,
 	))
 }
 
-func (c *Controller) processJobEvent(ctx context.Context, e goGitlab.JobEvent) {
+func (c *Controller) processJobEvent(ctx context.Context, e goGitlab.JobEvent) error {
 	var (
 		refKind schemas.RefKind
 		refName = e.Ref
@@ -52,21 +257,21 @@ func (c *Controller) processJobEvent(ctx context.Context, e goGitlab.JobEvent) {
 
 	project, _, err := c.Gitlab.Projects.GetProject(e.ProjectID, nil)
 	if err != nil {
-		log.WithContext(ctx).
-			WithError(err).
-			Error("reading project from GitLab")
-
-		return
+		return errors.Wrap(err, "reading project from GitLab")
 	}
 
-	c.triggerRefMetricsPull(ctx, schemas.NewRef(
+	c.recordWebhookEvent(ctx, project.PathWithNamespace)
+
+	return c.triggerRefMetricsPull(ctx, schemas.NewRef(
 		schemas.NewProject(project.PathWithNamespace),
 		refKind,
 		refName,
 	))
 }
 
-func (c *Controller) processPushEvent(ctx context.Context, e goGitlab.PushEvent) {
+func (c *Controller) processPushEvent(ctx context.Context, e goGitlab.PushEvent) error {
+	c.recordWebhookEvent(ctx, e.Project.PathWithNamespace)
+
 	if e.CheckoutSHA == "" {
 		var (
 			refKind = schemas.RefKindBranch
@@ -77,25 +282,22 @@ func (c *Controller) processPushEvent(ctx context.Context, e goGitlab.PushEvent)
 		if branch, found := strings.CutPrefix(e.Ref, "refs/heads/"); found {
 			refName = branch
 		} else {
-			log.WithContext(ctx).
-				WithFields(log.Fields{
-					"project-name": e.Project.Name,
-					"ref":          e.Ref,
-				}).
-				Error("extracting branch name from ref")
-
-			return
+			return fmt.Errorf("extracting branch name from ref '%s' of project '%s'", e.Ref, e.Project.Name)
 		}
 
-		_ = deleteRef(ctx, c.Store, schemas.NewRef(
+		return deleteRef(ctx, c.Store, schemas.NewRef(
 			schemas.NewProject(e.Project.PathWithNamespace),
 			refKind,
 			refName,
 		), "received branch deletion push event from webhook")
 	}
+
+	return nil
 }
 
-func (c *Controller) processTagEvent(ctx context.Context, e goGitlab.TagEvent) {
+func (c *Controller) processTagEvent(ctx context.Context, e goGitlab.TagEvent) error {
+	c.recordWebhookEvent(ctx, e.Project.PathWithNamespace)
+
 	if e.CheckoutSHA == "" {
 		var (
 			refKind = schemas.RefKindTag
@@ -106,25 +308,22 @@ func (c *Controller) processTagEvent(ctx context.Context, e goGitlab.TagEvent) {
 		if tag, found := strings.CutPrefix(e.Ref, "refs/tags/"); found {
 			refName = tag
 		} else {
-			log.WithContext(ctx).
-				WithFields(log.Fields{
-					"project-name": e.Project.Name,
-					"ref":          e.Ref,
-				}).
-				Error("extracting tag name from ref")
-
-			return
+			return fmt.Errorf("extracting tag name from ref '%s' of project '%s'", e.Ref, e.Project.Name)
 		}
 
-		_ = deleteRef(ctx, c.Store, schemas.NewRef(
+		return deleteRef(ctx, c.Store, schemas.NewRef(
 			schemas.NewProject(e.Project.PathWithNamespace),
 			refKind,
 			refName,
 		), "received tag deletion tag event from webhook")
 	}
+
+	return nil
 }
 
-func (c *Controller) processMergeEvent(ctx context.Context, e goGitlab.MergeEvent) {
+func (c *Controller) processMergeEvent(ctx context.Context, e goGitlab.MergeEvent) error {
+	c.recordWebhookEvent(ctx, e.Project.PathWithNamespace)
+
 	ref := schemas.NewRef(
 		schemas.NewProject(e.Project.PathWithNamespace),
 		schemas.RefKindMergeRequest,
@@ -133,29 +332,202 @@ func (c *Controller) processMergeEvent(ctx context.Context, e goGitlab.MergeEven
 
 	switch e.ObjectAttributes.Action {
 	case "close":
-		c.triggerRefDeletion(ctx, ref)
+		return c.triggerRefDeletion(ctx, ref)
 	case "merge":
-		c.triggerRefDeletion(ctx, ref)
+		return c.triggerRefDeletion(ctx, ref)
 	default:
 		log.
 			WithField("merge-request-event-type", e.ObjectAttributes.Action).
 			Debug("received a non supported merge-request event type as a webhook")
 	}
+
+	return nil
+}
+
+// processProjectLifecycleEvent handles the project events sent by system hooks and group hooks.
+func (c *Controller) processProjectLifecycleEvent(ctx context.Context, eventName string, projectID int64, projectName, oldProjectName string) error {
+	logFields := log.Fields{
+		"project-name": projectName,
+		"event-name":   eventName,
+	}
+
+	switch eventName {
+	case "project_create":
+		return c.triggerProjectPullIfMatchingWildcards(ctx, projectName)
+	case "project_destroy":
+		return c.triggerProjectDeletion(ctx, projectName, "received project destroy event from webhook")
+	case "project_rename", "project_transfer":
+		logFields["old-project-name"] = oldProjectName
+
+		p := schemas.NewProject(oldProjectName)
+
+		projectExists, err := c.Store.ProjectExists(ctx, p.Key())
+		if err != nil {
+			return errors.Wrap(err, "reading project from the store")
+		}
+
+		// We were not exporting it under its former name, it may be matching a wildcard now
+		if !projectExists {
+			return c.triggerProjectPullIfMatchingWildcards(ctx, projectName)
+		}
+
+		if err = c.Store.GetProject(ctx, &p); err != nil {
+			return errors.Wrap(err, "reading project from the store")
+		}
+
+		for _, cp := range c.Config.Projects {
+			if cp.Name == oldProjectName {
+				log.WithFields(logFields).Warn("statically configured project has been moved, its name should be updated in the configuration")
+			}
+		}
+
+		// Keep exporting it under its new name, using the same configuration
+		np := p
+		np.Name = projectName
+
+		if projectID != 0 {
+			np.ID = projectID
+		}
+
+		return errors.Wrapf(
+			c.migrateProject(ctx, p, np),
+			"migrating project '%s' to '%s'", oldProjectName, projectName,
+		)
+	default:
+		log.WithFields(logFields).Debug("received a non supported project event type as a webhook")
+	}
+
+	return nil
 }
 
-func (c *Controller) triggerRefDeletion(ctx context.Context, ref schemas.Ref) {
-	err := c.Store.DelRef(ctx, ref.Key())
+// processRepositoryUpdateEvent adds or removes the refs which have been created or deleted
+// through a repository update system event.
+func (c *Controller) processRepositoryUpdateEvent(ctx context.Context, e goGitlab.RepositoryUpdateSystemEvent) error {
+	c.recordWebhookEvent(ctx, e.Project.PathWithNamespace)
+
+	p := schemas.NewProject(e.Project.PathWithNamespace)
+
+	for _, change := range e.Changes {
+		var (
+			refKind schemas.RefKind
+			refName string
+			found   bool
+		)
+
+		if refName, found = strings.CutPrefix(change.Ref, "refs/heads/"); found {
+			refKind = schemas.RefKindBranch
+		} else if refName, found = strings.CutPrefix(change.Ref, "refs/tags/"); found {
+			refKind = schemas.RefKindTag
+		} else {
+			log.WithFields(log.Fields{
+				"project-name": p.Name,
+				"ref":          change.Ref,
+			}).Debug("received a repository update event for a non supported ref, skipping..")
+
+			continue
+		}
+
+		ref := schemas.NewRef(p, refKind, refName)
+
+		if isDeletedRevision(change.After) {
+			if err := deleteRef(ctx, c.Store, ref, "received ref deletion repository update event from webhook"); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		refExists, err := c.Store.RefExists(ctx, ref.Key())
+		if err != nil {
+			return errors.Wrap(err, "reading ref from the store")
+		}
+
+		// Existing refs will get refreshed by their pipeline events
+		if refExists {
+			continue
+		}
+
+		if err = c.triggerRefMetricsPull(ctx, ref); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// triggerProjectPullIfMatchingWildcards schedules a pull of the project if it could
+// be discovered through one of the configured wildcards.
+func (c *Controller) triggerProjectPullIfMatchingWildcards(ctx context.Context, projectName string) error {
+	logFields := log.Fields{
+		"project-name": projectName,
+	}
+
+	p := schemas.NewProject(projectName)
+
+	projectExists, err := c.Store.ProjectExists(ctx, p.Key())
 	if err != nil {
-		log.WithContext(ctx).
-			WithFields(log.Fields{
-				"project-name": ref.Project.Name,
-				"ref":          ref.Name,
-			}).
-			Error("failed deleting ref")
+		return errors.Wrap(err, "reading project from the store")
+	}
+
+	if projectExists {
+		return nil
 	}
+
+	// The project details are only fetched once we know it could belong to one of the wildcards
+	var gp *goGitlab.Project
+
+	for _, w := range c.Config.Wildcards {
+		if !isProjectMatchingWildcardOwner(w, projectName) {
+			continue
+		}
+
+		if gp == nil {
+			if gp, err = c.Gitlab.GetProject(ctx, projectName); err != nil {
+				return errors.Wrap(err, "fetching project")
+			}
+		}
+
+		if !isProjectMatchingWildcard(w, gp) {
+			continue
+		}
+
+		log.WithFields(logFields).Info("project not currently exported but matches a wildcard, triggering a pull of the project")
+		c.SchedulePriorityTask(ctx, schemas.TaskTypePullProject, projectName, projectName, w.Pull)
+
+		return nil
+	}
+
+	log.WithFields(logFields).Debug("project not matching any wildcard, skipping..")
+
+	return nil
+}
+
+func (c *Controller) triggerProjectDeletion(ctx context.Context, projectName, reason string) error {
+	p := schemas.NewProject(projectName)
+
+	projectExists, err := c.Store.ProjectExists(ctx, p.Key())
+	if err != nil {
+		return errors.Wrap(err, "reading project from the store")
+	}
+
+	if !projectExists {
+		return nil
+	}
+
+	return errors.Wrapf(
+		c.deleteProject(ctx, p, reason),
+		"deleting project '%s'", projectName,
+	)
 }
 
-func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref) {
+func (c *Controller) triggerRefDeletion(ctx context.Context, ref schemas.Ref) error {
+	return errors.Wrapf(
+		c.Store.DelRef(ctx, ref.Key()),
+		"deleting ref '%s' of project '%s'", ref.Name, ref.Project.Name,
+	)
+}
+
+func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref) error {
 	logFields := log.Fields{
 		"project-name": ref.Project.Name,
 		"ref":          ref.Name,
@@ -164,12 +536,7 @@ func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref)
 
 	refExists, err := c.Store.RefExists(ctx, ref.Key())
 	if err != nil {
-		log.WithContext(ctx).
-			WithFields(logFields).
-			WithError(err).
-			Error("reading ref from the store")
-
-		return
+		return errors.Wrap(err, "reading ref from the store")
 	}
 
 	// Let's try to see if the project is configured to export this ref
@@ -178,12 +545,7 @@ func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref)
 
 		projectExists, err := c.Store.ProjectExists(ctx, p.Key())
 		if err != nil {
-			log.WithContext(ctx).
-				WithFields(logFields).
-				WithError(err).
-				Error("reading project from the store")
-
-			return
+			return errors.Wrap(err, "reading project from the store")
 		}
 
 		// Perhaps the project is discoverable through a wildcard
@@ -201,7 +563,7 @@ func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref)
 				}
 
 				if matches {
-					c.ScheduleTask(context.TODO(), schemas.TaskTypePullProject, ref.Project.Name, ref.Project.Name, w.Pull)
+					c.SchedulePriorityTask(context.TODO(), schemas.TaskTypePullProject, ref.Project.Name, ref.Project.Name, w.Pull)
 					log.WithFields(logFields).Info("project ref not currently exported but its configuration matches a wildcard, triggering a pull of the project")
 				} else {
 					log.WithFields(logFields).Debug("project ref not matching wildcard, skipping..")
@@ -210,39 +572,25 @@ func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref)
 
 			log.WithFields(logFields).Info("done looking up for wildcards matching the project ref")
 
-			return
+			return nil
 		}
 
 		if projectExists {
 			// If the project exists, we check that the ref matches it's configuration
 			if err := c.Store.GetProject(ctx, &p); err != nil {
-				log.WithContext(ctx).
-					WithFields(logFields).
-					WithError(err).
-					Error("reading project from the store")
-
-				return
+				return errors.Wrap(err, "reading project from the store")
 			}
 
 			matches, err := isRefMatchingProjectPullRefs(p.Pull.Refs, ref)
 			if err != nil {
-				log.WithContext(ctx).
-					WithError(err).
-					Error("checking if the ref matches the project config")
-
-				return
+				return errors.Wrap(err, "checking if the ref matches the project config")
 			}
 
 			if matches {
 				ref.Project = p
 
 				if err = c.Store.SetRef(ctx, ref); err != nil {
-					log.WithContext(ctx).
-						WithFields(logFields).
-						WithError(err).
-						Error("writing ref in the store")
-
-					return
+					return errors.Wrap(err, "writing ref in the store")
 				}
 
 				goto schedulePull
@@ -251,18 +599,23 @@ func (c *Controller) triggerRefMetricsPull(ctx context.Context, ref schemas.Ref)
 
 		log.WithFields(logFields).Info("ref not configured in the exporter, ignoring pipeline webhook")
 
-		return
+		return nil
 	}
 
 schedulePull:
 	log.WithFields(logFields).Info("received a pipeline webhook from GitLab for a ref, triggering metrics pull")
 	// TODO: When all the metrics will be sent over the webhook, we might be able to avoid redoing a pull
 	// eg: 'coverage' is not in the pipeline payload yet, neither is 'artifacts' in the job one
-	c.ScheduleTask(context.TODO(), schemas.TaskTypePullRefMetrics, string(ref.Key()), ref)
+	c.SchedulePriorityTask(context.TODO(), schemas.TaskTypePullRefMetrics, string(ref.Key()), ref)
+	c.resetRefMetricsPull(ctx, ref)
+
+	return nil
 }
 
-func (c *Controller) processDeploymentEvent(ctx context.Context, e goGitlab.DeploymentEvent) {
-	c.triggerEnvironmentMetricsPull(
+func (c *Controller) processDeploymentEvent(ctx context.Context, e goGitlab.DeploymentEvent) error {
+	c.recordWebhookEvent(ctx, e.Project.PathWithNamespace)
+
+	return c.triggerEnvironmentMetricsPull(
 		ctx,
 		schemas.Environment{
 			ProjectName: e.Project.PathWithNamespace,
@@ -271,7 +624,7 @@ func (c *Controller) processDeploymentEvent(ctx context.Context, e goGitlab.Depl
 	)
 }
 
-func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env schemas.Environment) {
+func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env schemas.Environment) error {
 	logFields := log.Fields{
 		"project-name":     env.ProjectName,
 		"environment-name": env.Name,
@@ -279,12 +632,7 @@ func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env sche
 
 	envExists, err := c.Store.EnvironmentExists(ctx, env.Key())
 	if err != nil {
-		log.WithContext(ctx).
-			WithFields(logFields).
-			WithError(err).
-			Error("reading environment from the store")
-
-		return
+		return errors.Wrap(err, "reading environment from the store")
 	}
 
 	if !envExists {
@@ -292,12 +640,7 @@ func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env sche
 
 		projectExists, err := c.Store.ProjectExists(ctx, p.Key())
 		if err != nil {
-			log.WithContext(ctx).
-				WithFields(logFields).
-				WithError(err).
-				Error("reading project from the store")
-
-			return
+			return errors.Wrap(err, "reading project from the store")
 		}
 
 		// Perhaps the project is discoverable through a wildcard
@@ -315,7 +658,7 @@ func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env sche
 				}
 
 				if matches {
-					c.ScheduleTask(context.TODO(), schemas.TaskTypePullProject, env.ProjectName, env.ProjectName, w.Pull)
+					c.SchedulePriorityTask(context.TODO(), schemas.TaskTypePullProject, env.ProjectName, env.ProjectName, w.Pull)
 					log.WithFields(logFields).Info("project environment not currently exported but its configuration matches a wildcard, triggering a pull of the project")
 				} else {
 					log.WithFields(logFields).Debug("project ref not matching wildcard, skipping..")
@@ -324,35 +667,23 @@ func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env sche
 
 			log.WithFields(logFields).Info("done looking up for wildcards matching the project ref")
 
-			return
+			return nil
 		}
 
 		if projectExists {
 			if err := c.Store.GetProject(ctx, &p); err != nil {
-				log.WithContext(ctx).
-					WithFields(logFields).
-					WithError(err).
-					Error("reading project from the store")
+				return errors.Wrap(err, "reading project from the store")
 			}
 
 			matches, err := isEnvMatchingProjectPullEnvironments(p.Pull.Environments, env)
 			if err != nil {
-				log.WithContext(ctx).
-					WithError(err).
-					Error("checking if the env matches the project config")
-
-				return
+				return errors.Wrap(err, "checking if the env matches the project config")
 			}
 
 			if matches {
 				// As we do not get the environment ID within the deployment event, we need to query it back..
 				if err = c.UpdateEnvironment(ctx, &env); err != nil {
-					log.WithContext(ctx).
-						WithFields(logFields).
-						WithError(err).
-						Error("updating event from GitLab API")
-
-					return
+					return errors.Wrap(err, "updating event from GitLab API")
 				}
 
 				goto schedulePull
@@ -362,22 +693,72 @@ func (c *Controller) triggerEnvironmentMetricsPull(ctx context.Context, env sche
 		log.WithFields(logFields).
 			Info("environment not configured in the exporter, ignoring deployment webhook")
 
-		return
+		return nil
 	}
 
 	// Need to refresh the env from the store in order to get at least it's ID
 	if env.ID == 0 {
 		if err = c.Store.GetEnvironment(ctx, &env); err != nil {
-			log.WithContext(ctx).
-				WithFields(logFields).
-				WithError(err).
-				Error("reading environment from the store")
+			return errors.Wrap(err, "reading environment from the store")
 		}
 	}
 
 schedulePull:
 	log.WithFields(logFields).Info("received a deployment webhook from GitLab for an environment, triggering metrics pull")
-	c.ScheduleTask(ctx, schemas.TaskTypePullEnvironmentMetrics, string(env.Key()), env)
+	c.SchedulePriorityTask(ctx, schemas.TaskTypePullEnvironmentMetrics, string(env.Key()), env)
+
+	return nil
+}
+
+// recordWebhookEvent keeps track of the last time we received a webhook event for a project
+// we are currently exporting metrics for.
+func (c *Controller) recordWebhookEvent(ctx context.Context, projectName string) {
+	p := schemas.NewProject(projectName)
+
+	projectExists, err := c.Store.ProjectExists(ctx, p.Key())
+	if err != nil {
+		log.WithContext(ctx).
+			WithField("project-name", projectName).
+			WithError(err).
+			Error("reading project from the store")
+
+		return
+	}
+
+	if !projectExists {
+		return
+	}
+
+	if err = c.Store.SetProjectLastWebhookEvent(ctx, p.Key(), time.Now()); err != nil {
+		log.WithContext(ctx).
+			WithField("project-name", projectName).
+			WithError(err).
+			Error("writing project last webhook event in the store")
+	}
+}
+
+// isProjectCoveredByWebhooks returns whether the project metrics are currently being kept
+// up to date by webhook events, in which case it does not need to be polled.
+func (c *Controller) isProjectCoveredByWebhooks(ctx context.Context, projectName string) bool {
+	if !c.Config.Server.Webhook.Enabled || !c.Config.Server.Webhook.SkipPolling.Enabled {
+		return false
+	}
+
+	lastEvent, err := c.Store.GetProjectLastWebhookEvent(ctx, schemas.NewProject(projectName).Key())
+	if err != nil {
+		log.WithContext(ctx).
+			WithField("project-name", projectName).
+			WithError(err).
+			Warn("reading project last webhook event from the store, assuming it is not covered by webhooks")
+
+		return false
+	}
+
+	if lastEvent.IsZero() {
+		return false
+	}
+
+	return time.Since(lastEvent) < time.Duration(c.Config.Server.Webhook.SkipPolling.InactivityTimeoutSeconds)*time.Second
 }
 
 func isRefMatchingProjectPullRefs(pprs config.ProjectPullRefs, ref schemas.Ref) (matches bool, err error) {
@@ -435,6 +816,49 @@ func isRefMatchingWilcard(w config.Wildcard, ref schemas.Ref) (matches bool, err
 	return isRefMatchingProjectPullRefs(w.Pull.Refs, ref)
 }
 
+// isProjectMatchingWildcardOwner returns whether the project belongs to the owner of the wildcard, if any.
+// GitLab paths being case insensitive, so is the comparison.
+func isProjectMatchingWildcardOwner(w config.Wildcard, projectName string) bool {
+	if w.Owner.Kind == "" {
+		return true
+	}
+
+	prefix := w.Owner.Name + "/"
+	if len(projectName) <= len(prefix) || !strings.EqualFold(projectName[:len(prefix)], prefix) {
+		return false
+	}
+
+	return w.Owner.IncludeSubgroups || !strings.Contains(projectName[len(prefix):], "/")
+}
+
+// isProjectMatchingWildcard returns whether the project would get listed using the wildcard, applying
+// the same filters as the GitLab API does: the search is a case insensitive match of the name, path
+// or description of the project and archived projects are only listed by archived wildcards.
+func isProjectMatchingWildcard(w config.Wildcard, gp *goGitlab.Project) bool {
+	if !isProjectMatchingWildcardOwner(w, gp.PathWithNamespace) || gp.Archived != w.Archived {
+		return false
+	}
+
+	if len(w.Search) == 0 {
+		return true
+	}
+
+	search := strings.ToLower(w.Search)
+
+	for _, field := range []string{gp.Name, gp.Path, gp.Description} {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDeletedRevision returns whether the revision is the blank one GitLab uses for deleted refs.
+func isDeletedRevision(rev string) bool {
+	return len(rev) > 0 && strings.Trim(rev, "0") == ""
+}
+
 func isEnvMatchingWilcard(w config.Wildcard, env schemas.Environment) (matches bool, err error) {
 	// Then we check if the owner matches the ref or is global
 	if w.Owner.Kind != "" && !strings.Contains(env.ProjectName, w.Owner.Name) {
@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
@@ -55,3 +59,254 @@ func TestTriggerEnvironmentMetricsPull(t *testing.T) {
 	c.triggerEnvironmentMetricsPull(ctx, env1)
 	c.triggerEnvironmentMetricsPull(ctx, env2)
 }
+
+func TestIsProjectCoveredByWebhooks(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Server.Webhook.Enabled = true
+	cfg.Server.Webhook.SkipPolling.Enabled = true
+	cfg.Server.Webhook.SkipPolling.InactivityTimeoutSeconds = 60
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	p := schemas.NewProject("foo/bar")
+	assert.NoError(t, c.Store.SetProject(ctx, p))
+
+	// No event received yet
+	assert.False(t, c.isProjectCoveredByWebhooks(ctx, p.Name))
+
+	// Recent event
+	c.recordWebhookEvent(ctx, p.Name)
+	assert.True(t, c.isProjectCoveredByWebhooks(ctx, p.Name))
+
+	// Outdated event
+	assert.NoError(t, c.Store.SetProjectLastWebhookEvent(ctx, p.Key(), time.Now().Add(-2*time.Minute)))
+	assert.False(t, c.isProjectCoveredByWebhooks(ctx, p.Name))
+
+	// Events for projects we do not export are not recorded
+	c.recordWebhookEvent(ctx, "foo/baz")
+	lastEvent, err := c.Store.GetProjectLastWebhookEvent(ctx, schemas.NewProject("foo/baz").Key())
+	assert.NoError(t, err)
+	assert.True(t, lastEvent.IsZero())
+
+	// Skip polling disabled
+	c.Config.Server.Webhook.SkipPolling.Enabled = false
+	c.recordWebhookEvent(ctx, p.Name)
+	assert.False(t, c.isProjectCoveredByWebhooks(ctx, p.Name))
+}
+
+func TestProcessWebhookEvent(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Server.Webhook.Queue.MaxFailedEvents = 1
+
+	ctx, c, _, srv := newTestController(cfg)
+	srv.Close()
+
+	// Valid event
+	c.processWebhookEvent(ctx, schemas.WebhookEvent{
+		Key:     "foo",
+		Type:    "Pipeline Hook",
+		Payload: []byte(`{"object_kind": "pipeline"}`),
+	})
+
+	// Unsupported events are not processed
+	for _, key := range []string{"bar", "baz"} {
+		c.processWebhookEvent(ctx, schemas.WebhookEvent{
+			Key:     key,
+			Type:    "Wiki Page Hook",
+			Payload: []byte(`{"object_kind": "wiki_page"}`),
+		})
+	}
+
+	count, err := c.Store.WebhookEventsCount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.WebhookEventsCount{
+		{Type: "Pipeline Hook", Status: schemas.WebhookEventStatusProcessed}: 1,
+		{Type: "Wiki Page Hook", Status: schemas.WebhookEventStatusFailed}:   2,
+	}, count)
+
+	// Only one of them is kept for replay
+	failedEvents, err := c.Store.FailedWebhookEvents(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, failedEvents, 1)
+	assert.Contains(t, failedEvents, "bar")
+	assert.NotEmpty(t, failedEvents["bar"].Error)
+}
+
+func TestProcessProjectLifecycleEvent(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	p1 := schemas.NewProject("foo/bar")
+	p1.ID = 1
+	ref1 := schemas.NewRef(p1, schemas.RefKindBranch, "main")
+	env1 := schemas.Environment{ProjectName: p1.Name, Name: "prod"}
+	m1 := schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: map[string]string{"project": p1.Name, "ref": "main", "kind": "branch"}, Value: 42}
+
+	p2 := schemas.NewProject("foo/baz")
+	ref2 := schemas.NewRef(p2, schemas.RefKindBranch, "main")
+
+	assert.NoError(t, c.Store.SetProject(ctx, p1))
+	assert.NoError(t, c.Store.SetRef(ctx, ref1))
+	assert.NoError(t, c.Store.SetEnvironment(ctx, env1))
+	assert.NoError(t, c.Store.SetMetric(ctx, m1))
+	assert.NoError(t, c.Store.SetProject(ctx, p2))
+	assert.NoError(t, c.Store.SetRef(ctx, ref2))
+
+	// Renamed projects get migrated to their new name straight away
+	assert.NoError(t, c.processProjectLifecycleEvent(ctx, "project_rename", 1, "foo/qux", p1.Name))
+
+	np1 := p1
+	np1.Name = "foo/qux"
+
+	projects, err := c.Store.Projects(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Projects{np1.Key(): np1, p2.Key(): p2}, projects)
+
+	nref1 := schemas.NewRef(np1, schemas.RefKindBranch, "main")
+
+	refs, err := c.Store.Refs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Refs{nref1.Key(): nref1, ref2.Key(): ref2}, refs)
+
+	envs, err := c.Store.Environments(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, envs, 1)
+	assert.Contains(t, envs, schemas.Environment{ProjectName: np1.Name, Name: "prod"}.Key())
+
+	nm1 := schemas.Metric{Kind: schemas.MetricKindRunCount, Labels: map[string]string{"project": np1.Name, "ref": "main", "kind": "branch"}, Value: 42}
+
+	metrics, err := c.Store.Metrics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Metrics{nm1.Key(): nm1}, metrics)
+
+	// Destroyed ones get removed
+	assert.NoError(t, c.processProjectLifecycleEvent(ctx, "project_destroy", 0, p2.Name, ""))
+
+	projects, err = c.Store.Projects(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Projects{np1.Key(): np1}, projects)
+
+	refs, err = c.Store.Refs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Refs{nref1.Key(): nref1}, refs)
+
+	// Unknown projects are left aside
+	assert.NoError(t, c.processProjectLifecycleEvent(ctx, "project_destroy", 0, "foo/unknown", ""))
+}
+
+func TestProcessRepositoryUpdateEvent(t *testing.T) {
+	ctx, c, _, srv := newTestController(config.Config{})
+	srv.Close()
+
+	p := schemas.NewProject("foo/bar")
+	ref1 := schemas.NewRef(p, schemas.RefKindBranch, "dev")
+	ref2 := schemas.NewRef(p, schemas.RefKindTag, "v1.0.0")
+
+	assert.NoError(t, c.Store.SetProject(ctx, p))
+	assert.NoError(t, c.Store.SetRef(ctx, ref1))
+	assert.NoError(t, c.Store.SetRef(ctx, ref2))
+
+	e := goGitlab.RepositoryUpdateSystemEvent{
+		Project: goGitlab.RepositoryUpdateSystemEventProject{PathWithNamespace: p.Name},
+		Changes: []goGitlab.RepositoryUpdateSystemEventChange{
+			{Ref: "refs/heads/dev", Before: "8205ea8d81ce0c6b90fbe8280d118cc9fdad6130", After: "0000000000000000000000000000000000000000"},
+			{Ref: "refs/tags/v1.0.0", Before: "8205ea8d81ce0c6b90fbe8280d118cc9fdad6130", After: "4a2b3c8d81ce0c6b90fbe8280d118cc9fdad6130"},
+			{Ref: "refs/merge-requests/1/head", After: "0000000000000000000000000000000000000000"},
+		},
+	}
+
+	assert.NoError(t, c.processRepositoryUpdateEvent(ctx, e))
+
+	refs, err := c.Store.Refs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.Refs{ref2.Key(): ref2}, refs)
+}
+
+func TestIsProjectMatchingWildcardOwner(t *testing.T) {
+	w := config.Wildcard{}
+	assert.True(t, isProjectMatchingWildcardOwner(w, "foo/bar"))
+
+	w.Owner = config.WildcardOwner{Kind: "group", Name: "foo"}
+	assert.True(t, isProjectMatchingWildcardOwner(w, "foo/bar"))
+	assert.True(t, isProjectMatchingWildcardOwner(w, "Foo/bar"))
+	assert.False(t, isProjectMatchingWildcardOwner(w, "foo/baz/bar"))
+	assert.False(t, isProjectMatchingWildcardOwner(w, "foobar/baz"))
+	assert.False(t, isProjectMatchingWildcardOwner(w, "foo/"))
+
+	w.Owner.IncludeSubgroups = true
+	assert.True(t, isProjectMatchingWildcardOwner(w, "foo/baz/bar"))
+}
+
+func TestIsProjectMatchingWildcard(t *testing.T) {
+	gp := &goGitlab.Project{
+		Name:              "Bar",
+		Path:              "bar",
+		PathWithNamespace: "foo/bar",
+		Description:       "the baz service",
+	}
+
+	// Global wildcard
+	w := config.Wildcard{}
+	assert.True(t, isProjectMatchingWildcard(w, gp))
+
+	w.Owner = config.WildcardOwner{Kind: "group", Name: "FOO"}
+	assert.True(t, isProjectMatchingWildcard(w, gp))
+
+	w.Owner = config.WildcardOwner{Kind: "group", Name: "qux"}
+	assert.False(t, isProjectMatchingWildcard(w, gp))
+
+	// Search
+	w.Owner = config.WildcardOwner{}
+	w.Search = "BA"
+	assert.True(t, isProjectMatchingWildcard(w, gp))
+
+	w.Search = "baz"
+	assert.True(t, isProjectMatchingWildcard(w, gp))
+
+	w.Search = "qux"
+	assert.False(t, isProjectMatchingWildcard(w, gp))
+
+	// Archived projects are only listed by archived wildcards
+	w.Search = ""
+	gp.Archived = true
+	assert.False(t, isProjectMatchingWildcard(w, gp))
+
+	w.Archived = true
+	assert.True(t, isProjectMatchingWildcard(w, gp))
+}
+
+func TestTriggerProjectPullIfMatchingWildcards(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Wildcards = config.Wildcards{
+		{Search: "bar", Owner: config.WildcardOwner{Kind: "group", Name: "foo"}},
+		{Search: "qux"},
+	}
+
+	ctx, c, mux, srv := newTestController(cfg)
+	defer srv.Close()
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbaz",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":1,"name":"baz","path":"baz","path_with_namespace":"foo/baz"}`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fqux",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":2,"name":"qux","path":"qux","path_with_namespace":"foo/qux"}`)
+		})
+
+	// Not matching the search of any wildcard, no pull should be scheduled
+	assert.NoError(t, c.processProjectLifecycleEvent(ctx, "project_create", 1, "foo/baz", ""))
+
+	queued, err := c.Store.QueueTask(ctx, schemas.TaskQueuePriority, schemas.TaskTypePullProject, "foo/baz", c.UUID.String())
+	assert.NoError(t, err)
+	assert.True(t, queued)
+
+	// Matching the global wildcard
+	assert.NoError(t, c.processProjectLifecycleEvent(ctx, "project_create", 2, "foo/qux", ""))
+
+	queued, err = c.Store.QueueTask(ctx, schemas.TaskQueuePriority, schemas.TaskTypePullProject, "foo/qux", c.UUID.String())
+	assert.NoError(t, err)
+	assert.False(t, queued)
+}
@@ -0,0 +1,215 @@
+// Package cron parses standard 5 fields cron expressions and computes their activation times.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule holds the parsed fields of a cron expression, as bitsets of the values they match.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// Whether the day fields are unrestricted, as when both of them are restricted,
+	// a day matches if either of them does
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is also accepted for sunday
+	dowBounds = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	macros = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Parse returns the Schedule of a cron expression made of the minute, hour, day of month,
+// month and day of week fields. The @yearly, @monthly, @weekly, @daily and @hourly
+// macros are supported as well.
+func Parse(expr string) (s Schedule, err error) {
+	if m, ok := macros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = m
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return s, fmt.Errorf("invalid cron expression '%s': expected 5 fields, got %d", expr, len(fields))
+	}
+
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return s, fmt.Errorf("invalid minute field: %w", err)
+	}
+
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return s, fmt.Errorf("invalid hour field: %w", err)
+	}
+
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return s, fmt.Errorf("invalid day of month field: %w", err)
+	}
+
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return s, fmt.Errorf("invalid month field: %w", err)
+	}
+
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return s, fmt.Errorf("invalid day of week field: %w", err)
+	}
+
+	// Sunday can be referred to as either 0 or 7
+	if s.dow&(1<<7) > 0 {
+		s.dow |= 1
+	}
+
+	s.domStar = isStar(fields[2])
+	s.dowStar = isStar(fields[4])
+
+	return s, nil
+}
+
+// Next returns the first activation time of the schedule after the given one, in its location.
+// A zero time is returned if the schedule never matches, eg: on February 30th.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// Schedules matching at least once are bound to do so within a leap years cycle
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+
+			continue
+		}
+
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			// Adding an hour rather than building the date handles the DST transitions
+			t = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s Schedule) matchesDay(t time.Time) bool {
+	domMatches := s.dom&(1<<uint(t.Day())) > 0
+	dowMatches := s.dow&(1<<uint(t.Weekday())) > 0
+
+	if s.domStar || s.dowStar {
+		return domMatches && dowMatches
+	}
+
+	return domMatches || dowMatches
+}
+
+func isStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+// parseField returns the bitset of the values matched by a comma separated list of
+// values, ranges and steps, eg: '1,5-10,*/15'.
+func parseField(field string, b bounds) (bits uint64, err error) {
+	for _, expr := range strings.Split(field, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+		step := 1
+		if hasStep {
+			if step, err = strconv.Atoi(stepExpr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s'", stepExpr)
+			}
+		}
+
+		var start, end int
+
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			start, end = b.min, b.max
+		case strings.Contains(rangeExpr, "-"):
+			startExpr, endExpr, _ := strings.Cut(rangeExpr, "-")
+			if start, err = parseValue(startExpr, b); err != nil {
+				return 0, err
+			}
+
+			if end, err = parseValue(endExpr, b); err != nil {
+				return 0, err
+			}
+		default:
+			if start, err = parseValue(rangeExpr, b); err != nil {
+				return 0, err
+			}
+
+			end = start
+
+			// 'n/step' stands for 'n-max/step'
+			if hasStep {
+				end = b.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range '%s'", rangeExpr)
+		}
+
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(expr string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", expr)
+	}
+
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value '%d' out of the [%d-%d] bounds", v, b.min, b.max)
+	}
+
+	return v, nil
+}
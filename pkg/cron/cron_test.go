@@ -0,0 +1,65 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"*/15 0-6,22-23 * * mon-fri",
+		"0 12 1,15 jan,jul *",
+		"30 4 * * 7",
+		"@daily",
+		"@HOURLY",
+	} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"foo * * * *",
+		"@foo",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 5, 15, 10, 42, 30, 0, time.UTC)
+
+	for expr, expected := range map[string]time.Time{
+		"* * * * *":         time.Date(2024, 5, 15, 10, 43, 0, 0, time.UTC),
+		"*/15 * * * *":      time.Date(2024, 5, 15, 10, 45, 0, 0, time.UTC),
+		"0 */6 * * *":       time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC),
+		"30 2 * * *":        time.Date(2024, 5, 16, 2, 30, 0, 0, time.UTC),
+		"0 0 * * sun":       time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC),
+		"0 0 * * 7":         time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC),
+		"0 0 1 * *":         time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":        time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		"0 0 20 * mon":      time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC),
+		"0 0 31 * fri":      time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC),
+		"@yearly":           time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		"0 22-23,0-6 * * *": time.Date(2024, 5, 15, 22, 0, 0, 0, time.UTC),
+		"0 0 30 2 *":        {},
+	} {
+		s, err := Parse(expr)
+		assert.NoError(t, err, expr)
+		assert.Equal(t, expected, s.Next(now), expr)
+	}
+}
@@ -41,6 +41,13 @@ type Client struct {
 
 	version GitLabVersion
 	mutex   sync.RWMutex
+
+	// Digests of the secret tokens we set onto the hooks, GitLab not returning them
+	hookTokens sync.Map
+
+	// Paths of the projects looked up by their IDs, the bridges only referencing
+	// their downstream projects by ID
+	projectPaths sync.Map
 }
 
 // ClientConfig ..
@@ -0,0 +1,189 @@
+package gitlab
+
+import (
+	"context"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// The REST API does not expose the needs of the jobs, we have to use GraphQL for it
+const pipelineJobsNeedsQuery = `query($fullPath: ID!, $pipelineID: CiPipelineID!, $after: String) {
+  project(fullPath: $fullPath) {
+    pipeline(id: $pipelineID) {
+      stages {
+        nodes {
+          name
+        }
+      }
+      jobs(retried: false, after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          name
+          schedulingType
+          needs {
+            nodes {
+              name
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type pipelineJobsNeeds struct {
+	Stages struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"stages"`
+	Jobs struct {
+		PageInfo graphQLPageInfo `json:"pageInfo"`
+		Nodes    []struct {
+			Name           string `json:"name"`
+			SchedulingType string `json:"schedulingType"`
+			Needs          struct {
+				Nodes []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"needs"`
+		} `json:"nodes"`
+	} `json:"jobs"`
+}
+
+// PipelineJobsNeeds holds the stages of a pipeline along with the needs of its jobs.
+type PipelineJobsNeeds struct {
+	Stages []string
+
+	// Needs of the jobs using the `needs:` keyword, indexed by job name
+	Needs map[string][]string
+}
+
+// GetPipelineJobsNeeds ..
+func (c *Client) GetPipelineJobsNeeds(ctx context.Context, projectFullPath string, pipelineID int64) (jn PipelineJobsNeeds, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:GetPipelineJobsNeeds")
+	defer span.End()
+	span.SetAttributes(attribute.String("project_full_path", projectFullPath))
+	span.SetAttributes(attribute.Int64("pipeline_id", pipelineID))
+
+	jn.Needs = make(map[string][]string)
+
+	err = queryPipelineJobs(ctx, c, pipelineJobsNeedsQuery, projectFullPath, pipelineID, func(pipeline *pipelineJobsNeeds) (graphQLPageInfo, error) {
+		if jn.Stages == nil {
+			for _, stage := range pipeline.Stages.Nodes {
+				jn.Stages = append(jn.Stages, stage.Name)
+			}
+		}
+
+		for _, job := range pipeline.Jobs.Nodes {
+			if job.SchedulingType != "dag" {
+				continue
+			}
+
+			needs := []string{}
+			for _, need := range job.Needs.Nodes {
+				needs = append(needs, need.Name)
+			}
+
+			jn.Needs[job.Name] = needs
+		}
+
+		return pipeline.Jobs.PageInfo, nil
+	})
+	if err != nil {
+		return
+	}
+
+	log.WithFields(
+		log.Fields{
+			"project-full-path": projectFullPath,
+			"pipeline-id":       pipelineID,
+			"dag-jobs-count":    len(jn.Needs),
+		},
+	).Debug("found pipeline jobs needs")
+
+	return
+}
+
+// GetPipelineGraph returns the jobs and bridges of the pipeline along with their dependencies,
+// recursing into the downstream pipelines when fromChildPipelines is set.
+func (c *Client) GetPipelineGraph(ctx context.Context, projectNameOrID string, pipelineID int64, fromChildPipelines bool) (g schemas.PipelineGraph, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:GetPipelineGraph")
+	defer span.End()
+	span.SetAttributes(attribute.String("project_name_or_id", projectNameOrID))
+	span.SetAttributes(attribute.Int64("pipeline_id", pipelineID))
+
+	projectFullPath, err := c.projectFullPath(ctx, projectNameOrID)
+	if err != nil {
+		return
+	}
+
+	jobsNeeds, err := c.GetPipelineJobsNeeds(ctx, projectFullPath, pipelineID)
+	if err != nil {
+		return
+	}
+
+	g.Stages = jobsNeeds.Stages
+
+	jobs, err := c.ListPipelineJobs(ctx, projectNameOrID, pipelineID)
+	if err != nil {
+		return
+	}
+
+	for _, job := range jobs {
+		needs, dag := jobsNeeds.Needs[job.Name]
+
+		g.Jobs = append(g.Jobs, schemas.PipelineGraphJob{
+			Name:            job.Name,
+			Stage:           job.Stage,
+			DurationSeconds: job.DurationSeconds,
+			DAG:             dag,
+			Needs:           needs,
+		})
+	}
+
+	bridges, err := c.ListPipelineBridges(ctx, projectNameOrID, pipelineID)
+	if err != nil {
+		return
+	}
+
+	for _, bridge := range bridges {
+		needs, dag := jobsNeeds.Needs[bridge.Name]
+
+		job := schemas.PipelineGraphJob{
+			Name:            bridge.Name,
+			Stage:           bridge.Stage,
+			DurationSeconds: bridge.Duration,
+			DAG:             dag,
+			Needs:           needs,
+			Bridge:          true,
+		}
+
+		if fromChildPipelines && bridge.DownstreamPipeline != nil {
+			var downstream schemas.PipelineGraph
+
+			downstreamProject := projectNameOrID
+			if bridge.DownstreamPipeline.ProjectID != bridge.Pipeline.ProjectID {
+				downstreamProject = strconv.FormatInt(bridge.DownstreamPipeline.ProjectID, 10)
+			}
+
+			if downstream, err = c.GetPipelineGraph(ctx, downstreamProject, bridge.DownstreamPipeline.ID, fromChildPipelines); err != nil {
+				return
+			}
+
+			job.Downstream = &downstream
+		}
+
+		g.Jobs = append(g.Jobs, job)
+	}
+
+	return
+}
@@ -0,0 +1,153 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestGetPipelineJobsNeeds(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	mux.HandleFunc("/api/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+
+			var query goGitlab.GraphQLQuery
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+			assert.Equal(t, "gid://gitlab/Ci::Pipeline/1", query.Variables["pipelineID"])
+
+			switch query.Variables["fullPath"] {
+			case "bar":
+				_, _ = fmt.Fprint(w, `{"data":{"project":null}}`)
+
+				return
+			case "baz":
+				_, _ = fmt.Fprint(w, `{"data":null,"errors":[{"message":"boom"}]}`)
+
+				return
+			}
+
+			if query.Variables["after"] == nil {
+				_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{
+					"stages":{"nodes":[{"name":"build"},{"name":"test"}]},
+					"jobs":{"pageInfo":{"hasNextPage":true,"endCursor":"abc"},"nodes":[
+						{"name":"build","schedulingType":"stage","needs":{"nodes":[]}},
+						{"name":"lint","schedulingType":"dag","needs":{"nodes":[]}}
+					]}
+				}}}}`)
+
+				return
+			}
+
+			assert.Equal(t, "abc", query.Variables["after"])
+			_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{
+				"stages":{"nodes":[{"name":"build"},{"name":"test"}]},
+				"jobs":{"pageInfo":{"hasNextPage":false,"endCursor":"def"},"nodes":[
+					{"name":"unit","schedulingType":"dag","needs":{"nodes":[{"name":"build"}]}}
+				]}
+			}}}}`)
+		})
+
+	jn, err := c.GetPipelineJobsNeeds(ctx, "foo", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, PipelineJobsNeeds{
+		Stages: []string{"build", "test"},
+		Needs: map[string][]string{
+			"lint": {},
+			"unit": {"build"},
+		},
+	}, jn)
+
+	// Test not found pipeline
+	_, err = c.GetPipelineJobsNeeds(ctx, "bar", 1)
+	assert.Error(t, err)
+
+	// Test errored query
+	_, err = c.GetPipelineJobsNeeds(ctx, "baz", 1)
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestGetPipelineGraph(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	mux.HandleFunc("/api/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			var query goGitlab.GraphQLQuery
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+
+			switch query.Variables["fullPath"] {
+			case "foo":
+				_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{
+					"stages":{"nodes":[{"name":"build"},{"name":"trigger"}]},
+					"jobs":{"nodes":[{"name":"trigger","schedulingType":"dag","needs":{"nodes":[{"name":"build"}]}}]}
+				}}}}`)
+			case "group/downstream":
+				_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{
+					"stages":{"nodes":[{"name":"test"}]},
+					"jobs":{"nodes":[]}
+				}}}}`)
+			}
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo/pipelines/1/jobs",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":10,"name":"build","stage":"build","duration":30}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo/pipelines/1/bridges",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":11,"name":"trigger","stage":"trigger","duration":60,"pipeline":{"id":1,"project_id":10},"downstream_pipeline":{"id":2,"project_id":20}}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":20,"path_with_namespace":"group/downstream"}`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20/pipelines/2/jobs",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":20,"name":"unit","stage":"test","duration":50}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20/pipelines/2/bridges",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[]`)
+		})
+
+	downstream := &schemas.PipelineGraph{
+		Stages: []string{"test"},
+		Jobs: []schemas.PipelineGraphJob{
+			{Name: "unit", Stage: "test", DurationSeconds: 50},
+		},
+	}
+
+	expectedGraph := schemas.PipelineGraph{
+		Stages: []string{"build", "trigger"},
+		Jobs: []schemas.PipelineGraphJob{
+			{Name: "build", Stage: "build", DurationSeconds: 30},
+			{Name: "trigger", Stage: "trigger", DurationSeconds: 60, DAG: true, Needs: []string{"build"}, Bridge: true, Downstream: downstream},
+		},
+	}
+
+	g, err := c.GetPipelineGraph(ctx, "foo", 1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedGraph, g)
+
+	// Without the downstream pipelines
+	expectedGraph.Jobs[1].Downstream = nil
+
+	g, err = c.GetPipelineGraph(ctx, "foo", 1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedGraph, g)
+}
@@ -0,0 +1,94 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// graphQLPageInfo ..
+type graphQLPageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// pipelineGraphQLResponse is the response of a query on the pipeline of a project, P holding its fields.
+type pipelineGraphQLResponse[P any] struct {
+	Data struct {
+		Project *struct {
+			Pipeline *P `json:"pipeline"`
+		} `json:"project"`
+	} `json:"data"`
+
+	goGitlab.GenericGraphQLErrors
+}
+
+// queryPipelineJobs runs the GraphQL query on the pipeline of the project, going through all the pages of
+// its jobs. handlePage gets called with the fields of the pipeline of each of the pages and returns the
+// pagination info of the jobs. The query must take the $fullPath, $pipelineID and $after variables.
+func queryPipelineJobs[P any](
+	ctx context.Context,
+	c *Client,
+	query string,
+	projectFullPath string,
+	pipelineID int64,
+	handlePage func(*P) (graphQLPageInfo, error),
+) error {
+	q := goGitlab.GraphQLQuery{
+		Query: query,
+		Variables: map[string]any{
+			"fullPath":   projectFullPath,
+			"pipelineID": fmt.Sprintf("gid://gitlab/Ci::Pipeline/%d", pipelineID),
+		},
+	}
+
+	for {
+		var response pipelineGraphQLResponse[P]
+
+		c.rateLimit(ctx)
+
+		resp, err := c.GraphQL.Do(q, &response, goGitlab.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+
+		c.requestsRemaining(resp)
+
+		if len(response.Errors) > 0 {
+			messages := make([]string, 0, len(response.Errors))
+			for _, e := range response.Errors {
+				messages = append(messages, e.Message)
+			}
+
+			return fmt.Errorf("querying pipeline %d of project '%s': %s", pipelineID, projectFullPath, strings.Join(messages, ", "))
+		}
+
+		if response.Data.Project == nil || response.Data.Project.Pipeline == nil {
+			return fmt.Errorf("pipeline %d of project '%s' not found", pipelineID, projectFullPath)
+		}
+
+		pageInfo, err := handlePage(response.Data.Project.Pipeline)
+		if err != nil {
+			return err
+		}
+
+		if !pageInfo.HasNextPage {
+			return nil
+		}
+
+		q.Variables["after"] = pageInfo.EndCursor
+	}
+}
+
+// projectFullPath returns the full path of the project, GraphQL not identifying projects by their IDs.
+func (c *Client) projectFullPath(ctx context.Context, projectNameOrID string) (string, error) {
+	id, err := strconv.ParseInt(projectNameOrID, 10, 64)
+	if err != nil {
+		return projectNameOrID, nil
+	}
+
+	return c.GetProjectPath(ctx, id)
+}
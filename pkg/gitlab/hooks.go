@@ -0,0 +1,344 @@
+package gitlab
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.openly.dev/pointy"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const hookName = "gitlab-ci-pipelines-exporter"
+
+// HookScope ..
+type HookScope string
+
+const (
+	// HookScopeProject ..
+	HookScopeProject HookScope = "project"
+
+	// HookScopeGroup ..
+	HookScopeGroup HookScope = "group"
+)
+
+// HookConfig holds the settings of the hooks we register onto GitLab.
+type HookConfig struct {
+	URL                   string
+	SecretToken           string
+	EnableSSLVerification bool
+}
+
+// Hook ..
+type Hook struct {
+	ID            int64
+	Scope         HookScope
+	AlertStatus   string
+	DisabledUntil *time.Time
+}
+
+// RegisterProjectHook creates or updates the hook of the exporter onto the project.
+// Existing hooks are matched using their URL.
+func (c *Client) RegisterProjectHook(ctx context.Context, projectName string, cfg HookConfig) (hook Hook, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:RegisterProjectHook")
+	defer span.End()
+	span.SetAttributes(attribute.String("project_name", projectName))
+
+	hook.Scope = HookScopeProject
+
+	var existingHook *goGitlab.ProjectHook
+
+	if existingHook, err = c.findProjectHook(ctx, projectName, cfg.URL); err != nil {
+		return
+	}
+
+	if existingHook != nil &&
+		projectHookMatches(existingHook, cfg) &&
+		c.isHookTokenUpToDate(hook.Scope, projectName, existingHook.ID, cfg.SecretToken) {
+		log.WithFields(log.Fields{
+			"project-name": projectName,
+			"hook-id":      existingHook.ID,
+		}).Debug("project hook is up to date")
+
+		hook.ID = existingHook.ID
+		hook.AlertStatus = existingHook.AlertStatus
+		hook.DisabledUntil = existingHook.DisabledUntil
+
+		return
+	}
+
+	var (
+		gh   *goGitlab.ProjectHook
+		resp *goGitlab.Response
+	)
+
+	c.rateLimit(ctx)
+
+	if existingHook == nil {
+		log.WithFields(log.Fields{
+			"project-name": projectName,
+		}).Debug("creating project hook")
+
+		gh, resp, err = c.Projects.AddProjectHook(projectName, &goGitlab.AddProjectHookOptions{
+			Name:                  pointy.String(hookName),
+			URL:                   pointy.String(cfg.URL),
+			Token:                 pointy.String(cfg.SecretToken),
+			EnableSSLVerification: pointy.Bool(cfg.EnableSSLVerification),
+			DeploymentEvents:      pointy.Bool(true),
+			JobEvents:             pointy.Bool(true),
+			MergeRequestsEvents:   pointy.Bool(true),
+			PipelineEvents:        pointy.Bool(true),
+			PushEvents:            pointy.Bool(true),
+			TagPushEvents:         pointy.Bool(true),
+		}, goGitlab.WithContext(ctx))
+	} else {
+		log.WithFields(log.Fields{
+			"project-name": projectName,
+			"hook-id":      existingHook.ID,
+		}).Debug("updating project hook")
+
+		gh, resp, err = c.Projects.EditProjectHook(projectName, existingHook.ID, &goGitlab.EditProjectHookOptions{
+			Name:                  pointy.String(hookName),
+			URL:                   pointy.String(cfg.URL),
+			Token:                 pointy.String(cfg.SecretToken),
+			EnableSSLVerification: pointy.Bool(cfg.EnableSSLVerification),
+			DeploymentEvents:      pointy.Bool(true),
+			JobEvents:             pointy.Bool(true),
+			MergeRequestsEvents:   pointy.Bool(true),
+			PipelineEvents:        pointy.Bool(true),
+			PushEvents:            pointy.Bool(true),
+			TagPushEvents:         pointy.Bool(true),
+		}, goGitlab.WithContext(ctx))
+	}
+
+	c.requestsRemaining(resp)
+
+	if err != nil {
+		return
+	}
+
+	hook.ID = gh.ID
+	hook.AlertStatus = gh.AlertStatus
+	hook.DisabledUntil = gh.DisabledUntil
+	c.setHookToken(hook.Scope, projectName, gh.ID, cfg.SecretToken)
+
+	return
+}
+
+// UnregisterProjectHook deletes the hook of the exporter from the project, if any.
+func (c *Client) UnregisterProjectHook(ctx context.Context, projectName string, url string) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:UnregisterProjectHook")
+	defer span.End()
+	span.SetAttributes(attribute.String("project_name", projectName))
+
+	existingHook, err := c.findProjectHook(ctx, projectName, url)
+	if err != nil || existingHook == nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"project-name": projectName,
+		"hook-id":      existingHook.ID,
+	}).Debug("deleting project hook")
+
+	c.rateLimit(ctx)
+	resp, err := c.Projects.DeleteProjectHook(projectName, existingHook.ID, goGitlab.WithContext(ctx))
+	c.requestsRemaining(resp)
+
+	return err
+}
+
+// RegisterGroupHook creates or updates the hook of the exporter onto the group.
+// Existing hooks are matched using their URL.
+func (c *Client) RegisterGroupHook(ctx context.Context, groupName string, cfg HookConfig) (hook Hook, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:RegisterGroupHook")
+	defer span.End()
+	span.SetAttributes(attribute.String("group_name", groupName))
+
+	hook.Scope = HookScopeGroup
+
+	var existingHook *goGitlab.GroupHook
+
+	if existingHook, err = c.findGroupHook(ctx, groupName, cfg.URL); err != nil {
+		return
+	}
+
+	if existingHook != nil &&
+		groupHookMatches(existingHook, cfg) &&
+		c.isHookTokenUpToDate(hook.Scope, groupName, existingHook.ID, cfg.SecretToken) {
+		log.WithFields(log.Fields{
+			"group-name": groupName,
+			"hook-id":    existingHook.ID,
+		}).Debug("group hook is up to date")
+
+		hook.ID = existingHook.ID
+		hook.AlertStatus = existingHook.AlertStatus
+
+		return
+	}
+
+	var (
+		gh   *goGitlab.GroupHook
+		resp *goGitlab.Response
+	)
+
+	c.rateLimit(ctx)
+
+	if existingHook == nil {
+		log.WithFields(log.Fields{
+			"group-name": groupName,
+		}).Debug("creating group hook")
+
+		gh, resp, err = c.Groups.AddGroupHook(groupName, &goGitlab.AddGroupHookOptions{
+			Name:                  pointy.String(hookName),
+			URL:                   pointy.String(cfg.URL),
+			Token:                 pointy.String(cfg.SecretToken),
+			EnableSSLVerification: pointy.Bool(cfg.EnableSSLVerification),
+			DeploymentEvents:      pointy.Bool(true),
+			JobEvents:             pointy.Bool(true),
+			MergeRequestsEvents:   pointy.Bool(true),
+			PipelineEvents:        pointy.Bool(true),
+			ProjectEvents:         pointy.Bool(true),
+			PushEvents:            pointy.Bool(true),
+			TagPushEvents:         pointy.Bool(true),
+		}, goGitlab.WithContext(ctx))
+	} else {
+		log.WithFields(log.Fields{
+			"group-name": groupName,
+			"hook-id":    existingHook.ID,
+		}).Debug("updating group hook")
+
+		gh, resp, err = c.Groups.EditGroupHook(groupName, existingHook.ID, &goGitlab.EditGroupHookOptions{
+			Name:                  pointy.String(hookName),
+			URL:                   pointy.String(cfg.URL),
+			Token:                 pointy.String(cfg.SecretToken),
+			EnableSSLVerification: pointy.Bool(cfg.EnableSSLVerification),
+			DeploymentEvents:      pointy.Bool(true),
+			JobEvents:             pointy.Bool(true),
+			MergeRequestsEvents:   pointy.Bool(true),
+			PipelineEvents:        pointy.Bool(true),
+			ProjectEvents:         pointy.Bool(true),
+			PushEvents:            pointy.Bool(true),
+			TagPushEvents:         pointy.Bool(true),
+		}, goGitlab.WithContext(ctx))
+	}
+
+	c.requestsRemaining(resp)
+
+	if err != nil {
+		return
+	}
+
+	hook.ID = gh.ID
+	hook.AlertStatus = gh.AlertStatus
+	c.setHookToken(hook.Scope, groupName, gh.ID, cfg.SecretToken)
+
+	return
+}
+
+func projectHookMatches(h *goGitlab.ProjectHook, cfg HookConfig) bool {
+	return h.URL == cfg.URL &&
+		h.EnableSSLVerification == cfg.EnableSSLVerification &&
+		h.DeploymentEvents &&
+		h.JobEvents &&
+		h.MergeRequestsEvents &&
+		h.PipelineEvents &&
+		h.PushEvents &&
+		h.TagPushEvents
+}
+
+func groupHookMatches(h *goGitlab.GroupHook, cfg HookConfig) bool {
+	return h.URL == cfg.URL &&
+		h.EnableSSLVerification == cfg.EnableSSLVerification &&
+		h.DeploymentEvents &&
+		h.JobEvents &&
+		h.MergeRequestsEvents &&
+		h.PipelineEvents &&
+		h.ProjectEvents &&
+		h.PushEvents &&
+		h.TagPushEvents
+}
+
+func hookTokenKey(scope HookScope, name string, id int64) string {
+	return fmt.Sprintf("%s/%s/%d", scope, name, id)
+}
+
+// isHookTokenUpToDate returns whether we already set the secret token onto the hook. GitLab does not
+// return the tokens of the hooks, hence the hooks get updated once after each restart of the exporter.
+func (c *Client) isHookTokenUpToDate(scope HookScope, name string, id int64, token string) bool {
+	digest, ok := c.hookTokens.Load(hookTokenKey(scope, name, id))
+
+	return ok && digest == sha256.Sum256([]byte(token))
+}
+
+func (c *Client) setHookToken(scope HookScope, name string, id int64, token string) {
+	c.hookTokens.Store(hookTokenKey(scope, name, id), sha256.Sum256([]byte(token)))
+}
+
+func (c *Client) findProjectHook(ctx context.Context, projectName string, url string) (*goGitlab.ProjectHook, error) {
+	options := &goGitlab.ListProjectHooksOptions{
+		ListOptions: goGitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	for {
+		c.rateLimit(ctx)
+
+		hooks, resp, err := c.Projects.ListProjectHooks(projectName, options, goGitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		c.requestsRemaining(resp)
+
+		for _, h := range hooks {
+			if h.URL == url {
+				return h, nil
+			}
+		}
+
+		if resp.CurrentPage >= resp.NextPage {
+			return nil, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
+
+func (c *Client) findGroupHook(ctx context.Context, groupName string, url string) (*goGitlab.GroupHook, error) {
+	options := &goGitlab.ListGroupHooksOptions{
+		ListOptions: goGitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	for {
+		c.rateLimit(ctx)
+
+		hooks, resp, err := c.Groups.ListGroupHooks(groupName, options, goGitlab.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		c.requestsRemaining(resp)
+
+		for _, h := range hooks {
+			if h.URL == url {
+				return h, nil
+			}
+		}
+
+		if resp.CurrentPage >= resp.NextPage {
+			return nil, nil
+		}
+
+		options.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,180 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterProjectHookCreate(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				_, _ = fmt.Fprint(w, `[{"id":1,"url":"https://other.example.net/webhook"}]`)
+			case http.MethodPost:
+				body := make(map[string]interface{})
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, "https://gcpe.example.net/webhook", body["url"])
+				assert.Equal(t, "secret", body["token"])
+				assert.Equal(t, true, body["pipeline_events"])
+				assert.Equal(t, true, body["job_events"])
+				assert.Equal(t, false, body["enable_ssl_verification"])
+				_, _ = fmt.Fprint(w, `{"id":2,"url":"https://gcpe.example.net/webhook","alert_status":"executable"}`)
+			default:
+				t.Errorf("unexpected method %s", r.Method)
+			}
+		})
+
+	hook, err := c.RegisterProjectHook(ctx, "foo/bar", HookConfig{
+		URL:         "https://gcpe.example.net/webhook",
+		SecretToken: "secret",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Hook{
+		ID:          2,
+		Scope:       HookScopeProject,
+		AlertStatus: "executable",
+	}, hook)
+}
+
+func TestRegisterProjectHookUpdate(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			_, _ = fmt.Fprint(w, `[{"id":1,"url":"https://gcpe.example.net/webhook"}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			_, _ = fmt.Fprint(w, `{"id":1,"url":"https://gcpe.example.net/webhook","alert_status":"temporarily_disabled","disabled_until":"2026-01-01T00:00:00Z"}`)
+		})
+
+	hook, err := c.RegisterProjectHook(ctx, "foo/bar", HookConfig{
+		URL: "https://gcpe.example.net/webhook",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), hook.ID)
+	assert.Equal(t, "temporarily_disabled", hook.AlertStatus)
+	require.NotNil(t, hook.DisabledUntil)
+	assert.Equal(t, int64(1767225600), hook.DisabledUntil.Unix())
+}
+
+func TestUnregisterProjectHook(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	var deleted bool
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[{"id":1,"url":"https://other.example.net/webhook"},{"id":2,"url":"https://gcpe.example.net/webhook"}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks/2",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodDelete, r.Method)
+			deleted = true
+		})
+
+	assert.NoError(t, c.UnregisterProjectHook(ctx, "foo/bar", "https://gcpe.example.net/webhook"))
+	assert.True(t, deleted)
+
+	// No matching hook
+	deleted = false
+	assert.NoError(t, c.UnregisterProjectHook(ctx, "foo/bar", "https://unknown.example.net/webhook"))
+	assert.False(t, deleted)
+}
+
+func TestRegisterGroupHook(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	mux.HandleFunc("/api/v4/groups/foo/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				_, _ = fmt.Fprint(w, `[]`)
+			case http.MethodPost:
+				body := make(map[string]interface{})
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, true, body["project_events"])
+				_, _ = fmt.Fprint(w, `{"id":3,"url":"https://gcpe.example.net/webhook","alert_status":"executable"}`)
+			default:
+				t.Errorf("unexpected method %s", r.Method)
+			}
+		})
+
+	hook, err := c.RegisterGroupHook(ctx, "foo", HookConfig{
+		URL: "https://gcpe.example.net/webhook",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Hook{
+		ID:          3,
+		Scope:       HookScopeGroup,
+		AlertStatus: "executable",
+	}, hook)
+}
+
+func TestRegisterProjectHookUpToDate(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	var edits int
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			_, _ = fmt.Fprint(w, `[{"id":1,"url":"https://gcpe.example.net/webhook","alert_status":"executable",`+
+				`"deployment_events":true,"job_events":true,"merge_requests_events":true,`+
+				`"pipeline_events":true,"push_events":true,"tag_push_events":true}]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/foo%2Fbar/hooks/1",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			edits++
+			_, _ = fmt.Fprint(w, `{"id":1,"url":"https://gcpe.example.net/webhook","alert_status":"executable"}`)
+		})
+
+	cfg := HookConfig{
+		URL:         "https://gcpe.example.net/webhook",
+		SecretToken: "secret",
+	}
+
+	// The token cannot be read back from GitLab, the hook gets updated once
+	_, err := c.RegisterProjectHook(ctx, "foo/bar", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, edits)
+
+	hook, err := c.RegisterProjectHook(ctx, "foo/bar", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, edits)
+	assert.Equal(t, Hook{
+		ID:          1,
+		Scope:       HookScopeProject,
+		AlertStatus: "executable",
+	}, hook)
+
+	// Changing the token or the settings of the hook must update it
+	cfg.SecretToken = "other"
+	_, err = c.RegisterProjectHook(ctx, "foo/bar", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, edits)
+
+	cfg.EnableSSLVerification = true
+	_, err = c.RegisterProjectHook(ctx, "foo/bar", cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, edits)
+}
@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -151,6 +152,67 @@ func (c *Client) ListPipelineBridges(ctx context.Context, projectNameOrID string
 	return
 }
 
+// ListRefPipelineBridges returns the bridges of the most recent pipeline of the ref along with their downstream pipelines.
+func (c *Client) ListRefPipelineBridges(ctx context.Context, ref schemas.Ref) (bridges []schemas.Bridge, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:ListRefPipelineBridges")
+	defer span.End()
+	span.SetAttributes(attribute.String("project_name", ref.Project.Name))
+	span.SetAttributes(attribute.String("ref_name", ref.Name))
+
+	if reflect.DeepEqual(ref.LatestPipeline, (schemas.Pipeline{})) {
+		log.WithFields(
+			log.Fields{
+				"project-name": ref.Project.Name,
+				"ref":          ref.Name,
+			},
+		).Debug("most recent pipeline not defined, exiting..")
+
+		return
+	}
+
+	foundBridges, err := c.ListPipelineBridges(ctx, ref.Project.Name, ref.LatestPipeline.ID)
+	if err != nil {
+		return
+	}
+
+	for _, foundBridge := range foundBridges {
+		bridge := schemas.NewBridge(*foundBridge)
+
+		if bridge.Downstream != nil {
+			bridge.Downstream.ProjectName = ref.Project.Name
+
+			if bridge.Downstream.CrossProject {
+				if bridge.Downstream.ProjectName, err = c.GetProjectPath(ctx, bridge.Downstream.ProjectID); err != nil {
+					return
+				}
+			}
+
+			var (
+				gp   *goGitlab.Pipeline
+				resp *goGitlab.Response
+			)
+
+			c.rateLimit(ctx)
+
+			gp, resp, err = c.Pipelines.GetPipeline(bridge.Downstream.ProjectID, bridge.Downstream.Pipeline.ID, goGitlab.WithContext(ctx))
+			if err != nil {
+				return bridges, fmt.Errorf("could not read content of downstream pipeline %d of bridge %s: %w", bridge.Downstream.Pipeline.ID, bridge.Name, err)
+			}
+
+			c.requestsRemaining(resp)
+
+			bridge.Downstream.Pipeline = schemas.NewPipeline(ctx, *gp)
+			if gp.Tag {
+				bridge.Downstream.RefKind = schemas.RefKindTag
+			}
+		}
+
+		bridges = append(bridges, bridge)
+	}
+
+	return
+}
+
 // ListPipelineChildJobs ..
 func (c *Client) ListPipelineChildJobs(ctx context.Context, projectNameOrID string, parentPipelineID int64) (jobs []schemas.Job, err error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:ListPipelineChildJobs")
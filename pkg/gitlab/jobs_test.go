@@ -131,6 +131,102 @@ func TestListPipelineBridges(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestListRefPipelineBridges(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	ref := schemas.Ref{
+		Project: schemas.NewProject("foo"),
+		Name:    "yay",
+	}
+
+	// Test with no most recent pipeline defined
+	bridges, err := c.ListRefPipelineBridges(ctx, ref)
+	assert.NoError(t, err)
+	assert.Len(t, bridges, 0)
+
+	mux.HandleFunc("/api/v4/projects/foo/pipelines/1/bridges",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `[
+				{"id":10,"name":"child","stage":"test","status":"success","pipeline":{"id":1,"project_id":1},"downstream_pipeline":{"id":2,"project_id":1,"ref":"yay"}},
+				{"id":11,"name":"release","stage":"deploy","status":"failed","pipeline":{"id":1,"project_id":1},"downstream_pipeline":{"id":3,"project_id":20,"ref":"v1.0.0"}},
+				{"id":12,"name":"pending","stage":"deploy","status":"created","pipeline":{"id":1,"project_id":1}}
+			]`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":20,"path_with_namespace":"group/release"}`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/1/pipelines/2",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":2,"status":"success","duration":30,"source":"parent_pipeline"}`)
+		})
+
+	mux.HandleFunc("/api/v4/projects/20/pipelines/3",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":3,"status":"failed","duration":60,"source":"pipeline","tag":true}`)
+		})
+
+	ref.LatestPipeline = schemas.Pipeline{
+		ID: 1,
+	}
+
+	bridges, err = c.ListRefPipelineBridges(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, []schemas.Bridge{
+		{
+			ID:     10,
+			Name:   "child",
+			Stage:  "test",
+			Status: "success",
+			Downstream: &schemas.BridgeDownstream{
+				ProjectID:   1,
+				ProjectName: "foo",
+				RefKind:     schemas.RefKindBranch,
+				RefName:     "yay",
+				Pipeline: schemas.Pipeline{
+					ID:              2,
+					Status:          "success",
+					DurationSeconds: 30,
+					Source:          "parent_pipeline",
+				},
+			},
+		},
+		{
+			ID:     11,
+			Name:   "release",
+			Stage:  "deploy",
+			Status: "failed",
+			Downstream: &schemas.BridgeDownstream{
+				ProjectID:    20,
+				ProjectName:  "group/release",
+				CrossProject: true,
+				RefKind:      schemas.RefKindTag,
+				RefName:      "v1.0.0",
+				Pipeline: schemas.Pipeline{
+					ID:              3,
+					Status:          "failed",
+					DurationSeconds: 60,
+					Source:          "pipeline",
+				},
+			},
+		},
+		{
+			ID:     12,
+			Name:   "pending",
+			Stage:  "deploy",
+			Status: "created",
+		},
+	}, bridges)
+
+	// Test invalid project id
+	ref.Project.Name = "bar"
+	_, err = c.ListRefPipelineBridges(ctx, ref)
+	assert.Error(t, err)
+}
+
 func TestListRefMostRecentJobs(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 	goGitlab "gitlab.com/gitlab-org/api/client-go"
@@ -32,6 +33,22 @@ func (c *Client) GetProject(ctx context.Context, name string) (*goGitlab.Project
 	return p, err
 }
 
+// GetProjectPath returns the path with namespace of the project, caching it.
+func (c *Client) GetProjectPath(ctx context.Context, id int64) (string, error) {
+	if path, ok := c.projectPaths.Load(id); ok {
+		return path.(string), nil
+	}
+
+	p, err := c.GetProject(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		return "", err
+	}
+
+	c.projectPaths.Store(id, p.PathWithNamespace)
+
+	return p.PathWithNamespace, nil
+}
+
 // ListProjects ..
 func (c *Client) ListProjects(ctx context.Context, w config.Wildcard) ([]schemas.Project, error) {
 	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:ListProjects")
@@ -135,6 +152,7 @@ func (c *Client) ListProjects(ctx context.Context, w config.Wildcard) ([]schemas
 			}
 
 			p := schemas.NewProject(gp.PathWithNamespace)
+			p.ID = gp.ID
 			p.ProjectParameters = w.ProjectParameters
 			projects = append(projects, p)
 		}
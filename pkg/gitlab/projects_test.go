@@ -27,6 +27,31 @@ func TestGetProject(t *testing.T) {
 	assert.Equal(t, int64(1), p.ID)
 }
 
+func TestGetProjectPath(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	calls := 0
+
+	mux.HandleFunc("/api/v4/projects/20",
+		func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			_, _ = fmt.Fprint(w, `{"id":20,"path_with_namespace":"group/release"}`)
+		})
+
+	for range 2 {
+		path, err := c.GetProjectPath(ctx, 20)
+		assert.NoError(t, err)
+		assert.Equal(t, "group/release", path)
+	}
+
+	// The path gets looked up only once
+	assert.Equal(t, 1, calls)
+
+	_, err := c.GetProjectPath(ctx, 21)
+	assert.Error(t, err)
+}
+
 func TestListUserProjects(t *testing.T) {
 	ctx, mux, server, c := getMockedClient()
 	defer server.Close()
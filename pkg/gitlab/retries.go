@@ -0,0 +1,166 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// The REST API does not tell whether a job got retried, we have to use GraphQL for it
+const pipelineJobAttemptsQuery = `query($fullPath: ID!, $pipelineID: CiPipelineID!, $after: String) {
+  project(fullPath: $fullPath) {
+    pipeline(id: $pipelineID) {
+      jobs(after: $after) {
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+        nodes {
+          id
+          name
+          status
+          retried
+          createdAt
+          finishedAt
+          stage {
+            name
+          }
+          downstreamPipeline {
+            id
+            project {
+              fullPath
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type pipelineJobAttempts struct {
+	Jobs struct {
+		PageInfo graphQLPageInfo `json:"pageInfo"`
+		Nodes    []struct {
+			ID         string     `json:"id"`
+			Name       string     `json:"name"`
+			Status     string     `json:"status"`
+			Retried    bool       `json:"retried"`
+			CreatedAt  *time.Time `json:"createdAt"`
+			FinishedAt *time.Time `json:"finishedAt"`
+			Stage      *struct {
+				Name string `json:"name"`
+			} `json:"stage"`
+			DownstreamPipeline *struct {
+				ID      string `json:"id"`
+				Project *struct {
+					FullPath string `json:"fullPath"`
+				} `json:"project"`
+			} `json:"downstreamPipeline"`
+		} `json:"nodes"`
+	} `json:"jobs"`
+}
+
+// parseGlobalID returns the ID of a GraphQL global ID (eg: gid://gitlab/Ci::Build/1).
+func parseGlobalID(gid string) (int64, error) {
+	return strconv.ParseInt(gid[strings.LastIndex(gid, "/")+1:], 10, 64)
+}
+
+// ListRefPipelineJobAttempts returns all the attempts of the jobs of the most recent pipeline of the ref,
+// retried ones included.
+func (c *Client) ListRefPipelineJobAttempts(ctx context.Context, ref schemas.Ref) (schemas.JobAttempts, error) {
+	projectFullPath, err := c.projectFullPath(ctx, ref.Project.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.ListPipelineJobAttempts(
+		ctx,
+		projectFullPath,
+		ref.LatestPipeline.ID,
+		ref.Project.Pull.Pipeline.Jobs.FromChildPipelines.Enabled,
+	)
+}
+
+// ListPipelineJobAttempts returns all the attempts of the jobs of the pipeline, retried ones included. It recurses
+// into the downstream pipelines of the bridges which have not been retried when fromChildPipelines is set.
+func (c *Client) ListPipelineJobAttempts(ctx context.Context, projectFullPath string, pipelineID int64, fromChildPipelines bool) (attempts schemas.JobAttempts, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "gitlab:ListPipelineJobAttempts")
+	defer span.End()
+	span.SetAttributes(attribute.String("project_full_path", projectFullPath))
+	span.SetAttributes(attribute.Int64("pipeline_id", pipelineID))
+
+	type pipelineDef struct {
+		projectFullPath string
+		pipelineID      int64
+	}
+
+	pipelines := []pipelineDef{{projectFullPath, pipelineID}}
+
+	for len(pipelines) > 0 {
+		pipeline := pipelines[len(pipelines)-1]
+		pipelines = pipelines[:len(pipelines)-1]
+
+		err = queryPipelineJobs(ctx, c, pipelineJobAttemptsQuery, pipeline.projectFullPath, pipeline.pipelineID, func(p *pipelineJobAttempts) (graphQLPageInfo, error) {
+			for _, job := range p.Jobs.Nodes {
+				attempt := schemas.JobAttempt{
+					PipelineID: pipeline.pipelineID,
+					Name:       job.Name,
+					Status:     strings.ToLower(job.Status),
+					Retried:    job.Retried,
+				}
+
+				var err error
+
+				if attempt.ID, err = parseGlobalID(job.ID); err != nil {
+					return p.Jobs.PageInfo, fmt.Errorf("parsing the ID of job %s: %w", job.Name, err)
+				}
+
+				if job.Stage != nil {
+					attempt.Stage = job.Stage.Name
+				}
+
+				if job.CreatedAt != nil {
+					attempt.CreatedTimestamp = float64(job.CreatedAt.Unix())
+				}
+
+				if job.FinishedAt != nil {
+					attempt.FinishedTimestamp = float64(job.FinishedAt.Unix())
+				}
+
+				attempts = append(attempts, attempt)
+
+				if fromChildPipelines && !job.Retried && job.DownstreamPipeline != nil && job.DownstreamPipeline.Project != nil {
+					downstreamPipelineID, err := parseGlobalID(job.DownstreamPipeline.ID)
+					if err != nil {
+						return p.Jobs.PageInfo, fmt.Errorf("parsing the ID of the downstream pipeline of bridge %s: %w", job.Name, err)
+					}
+
+					pipelines = append(pipelines, pipelineDef{job.DownstreamPipeline.Project.FullPath, downstreamPipelineID})
+				}
+			}
+
+			return p.Jobs.PageInfo, nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	log.WithFields(
+		log.Fields{
+			"project-full-path": projectFullPath,
+			"pipeline-id":       pipelineID,
+			"attempts-count":    len(attempts),
+		},
+	).Debug("found pipeline jobs attempts")
+
+	return
+}
@@ -0,0 +1,100 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func TestListRefPipelineJobAttempts(t *testing.T) {
+	ctx, mux, server, c := getMockedClient()
+	defer server.Close()
+
+	mux.HandleFunc("/api/graphql",
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+
+			var query goGitlab.GraphQLQuery
+
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+
+			assert.Equal(t, "foo", query.Variables["fullPath"])
+
+			switch query.Variables["pipelineID"] {
+			case "gid://gitlab/Ci::Pipeline/1":
+				if query.Variables["after"] == nil {
+					_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{"jobs":{
+						"pageInfo":{"hasNextPage":true,"endCursor":"abc"},
+						"nodes":[
+							{"id":"gid://gitlab/Ci::Build/10","name":"unit","status":"FAILED","retried":true,"createdAt":"2016-08-11T11:28:34Z","finishedAt":"2016-08-11T11:29:34Z","stage":{"name":"test"}},
+							{"id":"gid://gitlab/Ci::Build/11","name":"unit","status":"SUCCESS","retried":false,"createdAt":"2016-08-11T11:29:35Z","stage":{"name":"test"}}
+						]
+					}}}}}`)
+
+					return
+				}
+
+				assert.Equal(t, "abc", query.Variables["after"])
+				_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{"jobs":{
+					"pageInfo":{"hasNextPage":false},
+					"nodes":[
+						{"id":"gid://gitlab/Ci::Bridge/12","name":"trigger","status":"SUCCESS","retried":false,"stage":{"name":"deploy"},"downstreamPipeline":{"id":"gid://gitlab/Ci::Pipeline/2","project":{"fullPath":"foo"}}}
+					]
+				}}}}}`)
+			case "gid://gitlab/Ci::Pipeline/2":
+				_, _ = fmt.Fprint(w, `{"data":{"project":{"pipeline":{"jobs":{
+					"pageInfo":{"hasNextPage":false},
+					"nodes":[{"id":"gid://gitlab/Ci::Build/20","name":"unit","status":"SUCCESS","retried":false,"stage":{"name":"test"}}]
+				}}}}}`)
+			default:
+				_, _ = fmt.Fprint(w, `{"data":null,"errors":[{"message":"boom"}]}`)
+			}
+		})
+
+	p := schemas.NewProject("foo")
+	p.Pull.Pipeline.Jobs.FromChildPipelines.Enabled = false
+
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	ref.LatestPipeline.ID = 1
+
+	expectedAttempts := schemas.JobAttempts{
+		{ID: 10, PipelineID: 1, Name: "unit", Stage: "test", Status: "failed", Retried: true, CreatedTimestamp: 1470914914, FinishedTimestamp: 1470914974},
+		{ID: 11, PipelineID: 1, Name: "unit", Stage: "test", Status: "success", CreatedTimestamp: 1470914975},
+		{ID: 12, PipelineID: 1, Name: "trigger", Stage: "deploy", Status: "success"},
+	}
+
+	attempts, err := c.ListRefPipelineJobAttempts(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedAttempts, attempts)
+
+	// Including the child pipelines
+	ref.Project.Pull.Pipeline.Jobs.FromChildPipelines.Enabled = true
+
+	attempts, err = c.ListRefPipelineJobAttempts(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, append(expectedAttempts, schemas.JobAttempt{ID: 20, PipelineID: 2, Name: "unit", Stage: "test", Status: "success"}), attempts)
+
+	// Projects configured by ID get looked up by their full path
+	mux.HandleFunc("/api/v4/projects/42",
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"id":42,"path_with_namespace":"foo"}`)
+		})
+
+	ref.Project.Name = "42"
+	ref.Project.Pull.Pipeline.Jobs.FromChildPipelines.Enabled = false
+
+	attempts, err = c.ListRefPipelineJobAttempts(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedAttempts, attempts)
+
+	// Test errored query
+	ref.LatestPipeline.ID = 3
+	_, err = c.ListRefPipelineJobAttempts(ctx, ref)
+	assert.ErrorContains(t, err, "boom")
+}
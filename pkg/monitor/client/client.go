@@ -1,12 +1,16 @@
 package client
 
 import (
+	"context"
 	"net/url"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor"
 	pb "github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor/protobuf"
 )
 
@@ -15,19 +19,85 @@ type Client struct {
 	pb.MonitorClient
 }
 
+// ClientConfig allows to configure how the client connects onto the server.
+type ClientConfig struct {
+	TLS   config.InternalMonitoringListenerTLS
+	Token string
+}
+
+// ClientOptions ..
+type ClientOptions func(cfg *ClientConfig)
+
+// WithTLS configures the client to connect using TLS.
+func WithTLS(tls config.InternalMonitoringListenerTLS) ClientOptions {
+	return func(cfg *ClientConfig) {
+		cfg.TLS = tls
+	}
+}
+
+// WithToken configures the client to authenticate using a token.
+func WithToken(token string) ClientOptions {
+	return func(cfg *ClientConfig) {
+		cfg.Token = token
+	}
+}
+
+// tokenCredentials implements credentials.PerRPCCredentials.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+// GetRequestMetadata ..
+func (t tokenCredentials) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + t.token,
+	}, nil
+}
+
+// RequireTransportSecurity ..
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
 // NewClient ..
-func NewClient(endpoint *url.URL) *Client {
+func NewClient(endpoint *url.URL, opts ...ClientOptions) *Client {
 	log.WithField("endpoint", endpoint.String()).Debug("establishing gRPC connection to the server..")
 
+	cfg := &ClientConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	targetAddress := endpoint.String()
 	if endpoint.Scheme != "unix" {
 		// Drop the schema and just use "host:port" if we're dealing with local addresses
 		targetAddress = endpoint.Host
 	}
 
+	dialOptions := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+
+	if cfg.TLS.IsClientEnabled() {
+		tlsConfig, err := monitor.ClientTLSConfig(cfg.TLS)
+		if err != nil {
+			log.WithField("endpoint", endpoint.String()).WithField("error", err).Fatal("could not configure TLS")
+		}
+
+		dialOptions[0] = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+
+	if cfg.Token != "" {
+		dialOptions = append(dialOptions, grpc.WithPerRPCCredentials(tokenCredentials{
+			token:  cfg.Token,
+			secure: cfg.TLS.IsClientEnabled(),
+		}))
+	}
+
 	conn, err := grpc.NewClient(
 		targetAddress,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		dialOptions...,
 	)
 	if err != nil {
 		log.WithField("endpoint", endpoint.String()).WithField("error", err).Fatal("could not connect to the server")
@@ -1,9 +1,109 @@
 package monitor
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
 
 // TaskSchedulingStatus reports the status of a scheduled job.
 type TaskSchedulingStatus struct {
 	Last time.Time
 	Next time.Time
 }
+
+// TaskExecutionStats reports the executions of a type of task by the process.
+type TaskExecutionStats struct {
+	SuccessCount        uint64
+	FailureCount        uint64
+	SkippedCount        uint64
+	DurationSecondsSum  float64
+	QueueWaitSecondsSum float64
+}
+
+// TaskExecutionMonitoring keeps track of the executions of the tasks, per type.
+type TaskExecutionMonitoring struct {
+	mutex sync.RWMutex
+	stats map[schemas.TaskType]TaskExecutionStats
+}
+
+// NewTaskExecutionMonitoring ..
+func NewTaskExecutionMonitoring() *TaskExecutionMonitoring {
+	return &TaskExecutionMonitoring{
+		stats: make(map[schemas.TaskType]TaskExecutionStats),
+	}
+}
+
+// RecordExecution ..
+func (m *TaskExecutionMonitoring) RecordExecution(tt schemas.TaskType, queueWait, duration time.Duration, success bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.stats[tt]
+	if success {
+		s.SuccessCount++
+	} else {
+		s.FailureCount++
+	}
+
+	s.DurationSecondsSum += duration.Seconds()
+	s.QueueWaitSecondsSum += queueWait.Seconds()
+	m.stats[tt] = s
+}
+
+// RecordSkip ..
+func (m *TaskExecutionMonitoring) RecordSkip(tt schemas.TaskType) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s := m.stats[tt]
+	s.SkippedCount++
+	m.stats[tt] = s
+}
+
+// Stats returns a copy of the current statistics.
+func (m *TaskExecutionMonitoring) Stats() map[schemas.TaskType]TaskExecutionStats {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	stats := make(map[schemas.TaskType]TaskExecutionStats, len(m.stats))
+	for tt, s := range m.stats {
+		stats[tt] = s
+	}
+
+	return stats
+}
+
+// LeaderElectionStatus reports whether the process is the leader amongst
+// the ones sharing the same Redis, and which one is if not.
+type LeaderElectionStatus struct {
+	mutex    sync.RWMutex
+	isLeader bool
+	leader   string
+}
+
+// Set ..
+func (s *LeaderElectionStatus) Set(isLeader bool, leader string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.isLeader = isLeader
+	s.leader = leader
+}
+
+// IsLeader ..
+func (s *LeaderElectionStatus) IsLeader() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.isLeader
+}
+
+// Leader returns the UUID of the leader process, empty if there is currently none.
+func (s *LeaderElectionStatus) Leader() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.leader
+}
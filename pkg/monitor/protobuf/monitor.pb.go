@@ -1,18 +1,18 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.0
+// 	protoc-gen-go v1.36.11
 // 	protoc        v3.21.0
 // source: pkg/monitor/protobuf/monitor.proto
 
 package protobuf
 
 import (
-	reflect "reflect"
-	sync "sync"
-
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -23,18 +23,16 @@ const (
 )
 
 type Empty struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Empty) Reset() {
 	*x = Empty{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Empty) String() string {
@@ -45,7 +43,7 @@ func (*Empty) ProtoMessage() {}
 
 func (x *Empty) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -61,20 +59,17 @@ func (*Empty) Descriptor() ([]byte, []int) {
 }
 
 type Config struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	Content string `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Config) Reset() {
 	*x = Config{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Config) String() string {
@@ -85,7 +80,7 @@ func (*Config) ProtoMessage() {}
 
 func (x *Config) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -108,29 +103,29 @@ func (x *Config) GetContent() string {
 }
 
 type Telemetry struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	GitlabApiUsage          float64 `protobuf:"fixed64,1,opt,name=gitlab_api_usage,json=gitlabApiUsage,proto3" json:"gitlab_api_usage,omitempty"`
-	GitlabApiRequestsCount  uint64  `protobuf:"varint,2,opt,name=gitlab_api_requests_count,json=gitlabApiRequestsCount,proto3" json:"gitlab_api_requests_count,omitempty"`
-	GitlabApiRateLimit      float64 `protobuf:"fixed64,3,opt,name=gitlab_api_rate_limit,json=gitlabApiRateLimit,proto3" json:"gitlab_api_rate_limit,omitempty"`
-	GitlabApiLimitRemaining uint64  `protobuf:"varint,4,opt,name=gitlab_api_limit_remaining,json=gitlabApiLimitRemaining,proto3" json:"gitlab_api_limit_remaining,omitempty"`
-	TasksBufferUsage        float64 `protobuf:"fixed64,5,opt,name=tasks_buffer_usage,json=tasksBufferUsage,proto3" json:"tasks_buffer_usage,omitempty"`
-	TasksExecutedCount      uint64  `protobuf:"varint,6,opt,name=tasks_executed_count,json=tasksExecutedCount,proto3" json:"tasks_executed_count,omitempty"`
-	Projects                *Entity `protobuf:"bytes,7,opt,name=projects,proto3" json:"projects,omitempty"`
-	Refs                    *Entity `protobuf:"bytes,8,opt,name=refs,proto3" json:"refs,omitempty"`
-	Envs                    *Entity `protobuf:"bytes,9,opt,name=envs,proto3" json:"envs,omitempty"`
-	Metrics                 *Entity `protobuf:"bytes,10,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	GitlabApiUsage          float64                `protobuf:"fixed64,1,opt,name=gitlab_api_usage,json=gitlabApiUsage,proto3" json:"gitlab_api_usage,omitempty"`
+	GitlabApiRequestsCount  uint64                 `protobuf:"varint,2,opt,name=gitlab_api_requests_count,json=gitlabApiRequestsCount,proto3" json:"gitlab_api_requests_count,omitempty"`
+	GitlabApiRateLimit      float64                `protobuf:"fixed64,3,opt,name=gitlab_api_rate_limit,json=gitlabApiRateLimit,proto3" json:"gitlab_api_rate_limit,omitempty"`
+	GitlabApiLimitRemaining uint64                 `protobuf:"varint,4,opt,name=gitlab_api_limit_remaining,json=gitlabApiLimitRemaining,proto3" json:"gitlab_api_limit_remaining,omitempty"`
+	TasksBufferUsage        float64                `protobuf:"fixed64,5,opt,name=tasks_buffer_usage,json=tasksBufferUsage,proto3" json:"tasks_buffer_usage,omitempty"`
+	TasksExecutedCount      uint64                 `protobuf:"varint,6,opt,name=tasks_executed_count,json=tasksExecutedCount,proto3" json:"tasks_executed_count,omitempty"`
+	Projects                *Entity                `protobuf:"bytes,7,opt,name=projects,proto3" json:"projects,omitempty"`
+	Refs                    *Entity                `protobuf:"bytes,8,opt,name=refs,proto3" json:"refs,omitempty"`
+	Envs                    *Entity                `protobuf:"bytes,9,opt,name=envs,proto3" json:"envs,omitempty"`
+	Metrics                 *Entity                `protobuf:"bytes,10,opt,name=metrics,proto3" json:"metrics,omitempty"`
+	Tasks                   []*TaskTelemetry       `protobuf:"bytes,11,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	IsLeader                bool                   `protobuf:"varint,12,opt,name=is_leader,json=isLeader,proto3" json:"is_leader,omitempty"`
+	Leader                  string                 `protobuf:"bytes,13,opt,name=leader,proto3" json:"leader,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
 }
 
 func (x *Telemetry) Reset() {
 	*x = Telemetry{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[2]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Telemetry) String() string {
@@ -141,7 +136,7 @@ func (*Telemetry) ProtoMessage() {}
 
 func (x *Telemetry) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[2]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -226,25 +221,127 @@ func (x *Telemetry) GetMetrics() *Entity {
 	return nil
 }
 
+func (x *Telemetry) GetTasks() []*TaskTelemetry {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+func (x *Telemetry) GetIsLeader() bool {
+	if x != nil {
+		return x.IsLeader
+	}
+	return false
+}
+
+func (x *Telemetry) GetLeader() string {
+	if x != nil {
+		return x.Leader
+	}
+	return ""
+}
+
+type TaskTelemetry struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Type                    string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	SuccessCount            uint64                 `protobuf:"varint,2,opt,name=success_count,json=successCount,proto3" json:"success_count,omitempty"`
+	FailureCount            uint64                 `protobuf:"varint,3,opt,name=failure_count,json=failureCount,proto3" json:"failure_count,omitempty"`
+	SkippedCount            uint64                 `protobuf:"varint,4,opt,name=skipped_count,json=skippedCount,proto3" json:"skipped_count,omitempty"`
+	AverageDurationSeconds  float64                `protobuf:"fixed64,5,opt,name=average_duration_seconds,json=averageDurationSeconds,proto3" json:"average_duration_seconds,omitempty"`
+	AverageQueueWaitSeconds float64                `protobuf:"fixed64,6,opt,name=average_queue_wait_seconds,json=averageQueueWaitSeconds,proto3" json:"average_queue_wait_seconds,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *TaskTelemetry) Reset() {
+	*x = TaskTelemetry{}
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskTelemetry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskTelemetry) ProtoMessage() {}
+
+func (x *TaskTelemetry) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskTelemetry.ProtoReflect.Descriptor instead.
+func (*TaskTelemetry) Descriptor() ([]byte, []int) {
+	return file_pkg_monitor_protobuf_monitor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TaskTelemetry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TaskTelemetry) GetSuccessCount() uint64 {
+	if x != nil {
+		return x.SuccessCount
+	}
+	return 0
+}
+
+func (x *TaskTelemetry) GetFailureCount() uint64 {
+	if x != nil {
+		return x.FailureCount
+	}
+	return 0
+}
+
+func (x *TaskTelemetry) GetSkippedCount() uint64 {
+	if x != nil {
+		return x.SkippedCount
+	}
+	return 0
+}
+
+func (x *TaskTelemetry) GetAverageDurationSeconds() float64 {
+	if x != nil {
+		return x.AverageDurationSeconds
+	}
+	return 0
+}
+
+func (x *TaskTelemetry) GetAverageQueueWaitSeconds() float64 {
+	if x != nil {
+		return x.AverageQueueWaitSeconds
+	}
+	return 0
+}
+
 type Entity struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	LastGc        *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=last_gc,json=lastGc,proto3" json:"last_gc,omitempty"`
+	LastPull      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_pull,json=lastPull,proto3" json:"last_pull,omitempty"`
+	NextGc        *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=next_gc,json=nextGc,proto3" json:"next_gc,omitempty"`
+	NextPull      *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_pull,json=nextPull,proto3" json:"next_pull,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	Count    int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
-	LastGc   *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=last_gc,json=lastGc,proto3" json:"last_gc,omitempty"`
-	LastPull *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=last_pull,json=lastPull,proto3" json:"last_pull,omitempty"`
-	NextGc   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=next_gc,json=nextGc,proto3" json:"next_gc,omitempty"`
-	NextPull *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_pull,json=nextPull,proto3" json:"next_pull,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Entity) Reset() {
 	*x = Entity{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[3]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Entity) String() string {
@@ -254,8 +351,8 @@ func (x *Entity) String() string {
 func (*Entity) ProtoMessage() {}
 
 func (x *Entity) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[3]
-	if protoimpl.UnsafeEnabled && x != nil {
+	mi := &file_pkg_monitor_protobuf_monitor_proto_msgTypes[4]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -267,7 +364,7 @@ func (x *Entity) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Entity.ProtoReflect.Descriptor instead.
 func (*Entity) Descriptor() ([]byte, []int) {
-	return file_pkg_monitor_protobuf_monitor_proto_rawDescGZIP(), []int{3}
+	return file_pkg_monitor_protobuf_monitor_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *Entity) GetCount() int64 {
@@ -307,117 +404,84 @@ func (x *Entity) GetNextPull() *timestamppb.Timestamp {
 
 var File_pkg_monitor_protobuf_monitor_proto protoreflect.FileDescriptor
 
-var file_pkg_monitor_protobuf_monitor_proto_rawDesc = []byte{
-	0x0a, 0x22, 0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2f, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x1a, 0x1f, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x07,
-	0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x22, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x22, 0xe2, 0x03, 0x0a, 0x09,
-	0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x28, 0x0a, 0x10, 0x67, 0x69, 0x74,
-	0x6c, 0x61, 0x62, 0x5f, 0x61, 0x70, 0x69, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x01, 0x52, 0x0e, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x41, 0x70, 0x69, 0x55, 0x73,
-	0x61, 0x67, 0x65, 0x12, 0x39, 0x0a, 0x19, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x5f, 0x61, 0x70,
-	0x69, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x16, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x41, 0x70,
-	0x69, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x31,
-	0x0a, 0x15, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x5f, 0x61, 0x70, 0x69, 0x5f, 0x72, 0x61, 0x74,
-	0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x12, 0x67,
-	0x69, 0x74, 0x6c, 0x61, 0x62, 0x41, 0x70, 0x69, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69,
-	0x74, 0x12, 0x3b, 0x0a, 0x1a, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x5f, 0x61, 0x70, 0x69, 0x5f,
-	0x6c, 0x69, 0x6d, 0x69, 0x74, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x17, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62, 0x41, 0x70, 0x69,
-	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x2c,
-	0x0a, 0x12, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x5f, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x5f, 0x75,
-	0x73, 0x61, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x10, 0x74, 0x61, 0x73, 0x6b,
-	0x73, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x30, 0x0a, 0x14,
-	0x74, 0x61, 0x73, 0x6b, 0x73, 0x5f, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x64, 0x5f, 0x63,
-	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x12, 0x74, 0x61, 0x73, 0x6b,
-	0x73, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2b,
-	0x0a, 0x08, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0f, 0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74,
-	0x79, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x23, 0x0a, 0x04, 0x72,
-	0x65, 0x66, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6d, 0x6f, 0x6e, 0x69,
-	0x74, 0x6f, 0x72, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x52, 0x04, 0x72, 0x65, 0x66, 0x73,
-	0x12, 0x23, 0x0a, 0x04, 0x65, 0x6e, 0x76, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
-	0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x52,
-	0x04, 0x65, 0x6e, 0x76, 0x73, 0x12, 0x29, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72,
-	0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x22, 0xfa, 0x01, 0x0a, 0x06, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x63,
-	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e,
-	0x74, 0x12, 0x33, 0x0a, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x67, 0x63, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06,
-	0x6c, 0x61, 0x73, 0x74, 0x47, 0x63, 0x12, 0x37, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x70,
-	0x75, 0x6c, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
-	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
-	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x50, 0x75, 0x6c, 0x6c, 0x12,
-	0x33, 0x0a, 0x07, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x67, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x06, 0x6e, 0x65,
-	0x78, 0x74, 0x47, 0x63, 0x12, 0x37, 0x0a, 0x09, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x75, 0x6c,
-	0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
-	0x61, 0x6d, 0x70, 0x52, 0x08, 0x6e, 0x65, 0x78, 0x74, 0x50, 0x75, 0x6c, 0x6c, 0x32, 0x71, 0x0a,
-	0x07, 0x4d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x12, 0x2e, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x43,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x0e, 0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2e,
-	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0f, 0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74, 0x6f, 0x72, 0x2e,
-	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x00, 0x12, 0x36, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x54,
-	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x0e, 0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74,
-	0x6f, 0x72, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x6d, 0x6f, 0x6e, 0x69, 0x74,
-	0x6f, 0x72, 0x2e, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x22, 0x00, 0x30, 0x01,
-	0x42, 0x49, 0x5a, 0x47, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d,
-	0x76, 0x69, 0x73, 0x6f, 0x6e, 0x6e, 0x65, 0x61, 0x75, 0x2f, 0x67, 0x69, 0x74, 0x6c, 0x61, 0x62,
-	0x2d, 0x63, 0x69, 0x2d, 0x70, 0x69, 0x70, 0x65, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x2d, 0x65, 0x78,
-	0x70, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x6f, 0x6e, 0x69, 0x74,
-	0x6f, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x33,
-}
+const file_pkg_monitor_protobuf_monitor_proto_rawDesc = "" +
+	"\n" +
+	"\"pkg/monitor/protobuf/monitor.proto\x12\amonitor\x1a\x1fgoogle/protobuf/timestamp.proto\"\a\n" +
+	"\x05Empty\"\"\n" +
+	"\x06Config\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\"\xc5\x04\n" +
+	"\tTelemetry\x12(\n" +
+	"\x10gitlab_api_usage\x18\x01 \x01(\x01R\x0egitlabApiUsage\x129\n" +
+	"\x19gitlab_api_requests_count\x18\x02 \x01(\x04R\x16gitlabApiRequestsCount\x121\n" +
+	"\x15gitlab_api_rate_limit\x18\x03 \x01(\x01R\x12gitlabApiRateLimit\x12;\n" +
+	"\x1agitlab_api_limit_remaining\x18\x04 \x01(\x04R\x17gitlabApiLimitRemaining\x12,\n" +
+	"\x12tasks_buffer_usage\x18\x05 \x01(\x01R\x10tasksBufferUsage\x120\n" +
+	"\x14tasks_executed_count\x18\x06 \x01(\x04R\x12tasksExecutedCount\x12+\n" +
+	"\bprojects\x18\a \x01(\v2\x0f.monitor.EntityR\bprojects\x12#\n" +
+	"\x04refs\x18\b \x01(\v2\x0f.monitor.EntityR\x04refs\x12#\n" +
+	"\x04envs\x18\t \x01(\v2\x0f.monitor.EntityR\x04envs\x12)\n" +
+	"\ametrics\x18\n" +
+	" \x01(\v2\x0f.monitor.EntityR\ametrics\x12,\n" +
+	"\x05tasks\x18\v \x03(\v2\x16.monitor.TaskTelemetryR\x05tasks\x12\x1b\n" +
+	"\tis_leader\x18\f \x01(\bR\bisLeader\x12\x16\n" +
+	"\x06leader\x18\r \x01(\tR\x06leader\"\x89\x02\n" +
+	"\rTaskTelemetry\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12#\n" +
+	"\rsuccess_count\x18\x02 \x01(\x04R\fsuccessCount\x12#\n" +
+	"\rfailure_count\x18\x03 \x01(\x04R\ffailureCount\x12#\n" +
+	"\rskipped_count\x18\x04 \x01(\x04R\fskippedCount\x128\n" +
+	"\x18average_duration_seconds\x18\x05 \x01(\x01R\x16averageDurationSeconds\x12;\n" +
+	"\x1aaverage_queue_wait_seconds\x18\x06 \x01(\x01R\x17averageQueueWaitSeconds\"\xfa\x01\n" +
+	"\x06Entity\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x123\n" +
+	"\alast_gc\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x06lastGc\x127\n" +
+	"\tlast_pull\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\blastPull\x123\n" +
+	"\anext_gc\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06nextGc\x127\n" +
+	"\tnext_pull\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bnextPull2q\n" +
+	"\aMonitor\x12.\n" +
+	"\tGetConfig\x12\x0e.monitor.Empty\x1a\x0f.monitor.Config\"\x00\x126\n" +
+	"\fGetTelemetry\x12\x0e.monitor.Empty\x1a\x12.monitor.Telemetry\"\x000\x01BIZGgithub.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor/protobufb\x06proto3"
 
 var (
 	file_pkg_monitor_protobuf_monitor_proto_rawDescOnce sync.Once
-	file_pkg_monitor_protobuf_monitor_proto_rawDescData = file_pkg_monitor_protobuf_monitor_proto_rawDesc
+	file_pkg_monitor_protobuf_monitor_proto_rawDescData []byte
 )
 
 func file_pkg_monitor_protobuf_monitor_proto_rawDescGZIP() []byte {
 	file_pkg_monitor_protobuf_monitor_proto_rawDescOnce.Do(func() {
-		file_pkg_monitor_protobuf_monitor_proto_rawDescData = protoimpl.X.CompressGZIP(file_pkg_monitor_protobuf_monitor_proto_rawDescData)
+		file_pkg_monitor_protobuf_monitor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_monitor_protobuf_monitor_proto_rawDesc), len(file_pkg_monitor_protobuf_monitor_proto_rawDesc)))
 	})
 	return file_pkg_monitor_protobuf_monitor_proto_rawDescData
 }
 
-var (
-	file_pkg_monitor_protobuf_monitor_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
-	file_pkg_monitor_protobuf_monitor_proto_goTypes  = []interface{}{
-		(*Empty)(nil),                 // 0: monitor.Empty
-		(*Config)(nil),                // 1: monitor.Config
-		(*Telemetry)(nil),             // 2: monitor.Telemetry
-		(*Entity)(nil),                // 3: monitor.Entity
-		(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
-	}
-)
-
+var file_pkg_monitor_protobuf_monitor_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_pkg_monitor_protobuf_monitor_proto_goTypes = []any{
+	(*Empty)(nil),                 // 0: monitor.Empty
+	(*Config)(nil),                // 1: monitor.Config
+	(*Telemetry)(nil),             // 2: monitor.Telemetry
+	(*TaskTelemetry)(nil),         // 3: monitor.TaskTelemetry
+	(*Entity)(nil),                // 4: monitor.Entity
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
 var file_pkg_monitor_protobuf_monitor_proto_depIdxs = []int32{
-	3,  // 0: monitor.Telemetry.projects:type_name -> monitor.Entity
-	3,  // 1: monitor.Telemetry.refs:type_name -> monitor.Entity
-	3,  // 2: monitor.Telemetry.envs:type_name -> monitor.Entity
-	3,  // 3: monitor.Telemetry.metrics:type_name -> monitor.Entity
-	4,  // 4: monitor.Entity.last_gc:type_name -> google.protobuf.Timestamp
-	4,  // 5: monitor.Entity.last_pull:type_name -> google.protobuf.Timestamp
-	4,  // 6: monitor.Entity.next_gc:type_name -> google.protobuf.Timestamp
-	4,  // 7: monitor.Entity.next_pull:type_name -> google.protobuf.Timestamp
-	0,  // 8: monitor.Monitor.GetConfig:input_type -> monitor.Empty
-	0,  // 9: monitor.Monitor.GetTelemetry:input_type -> monitor.Empty
-	1,  // 10: monitor.Monitor.GetConfig:output_type -> monitor.Config
-	2,  // 11: monitor.Monitor.GetTelemetry:output_type -> monitor.Telemetry
-	10, // [10:12] is the sub-list for method output_type
-	8,  // [8:10] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	4,  // 0: monitor.Telemetry.projects:type_name -> monitor.Entity
+	4,  // 1: monitor.Telemetry.refs:type_name -> monitor.Entity
+	4,  // 2: monitor.Telemetry.envs:type_name -> monitor.Entity
+	4,  // 3: monitor.Telemetry.metrics:type_name -> monitor.Entity
+	3,  // 4: monitor.Telemetry.tasks:type_name -> monitor.TaskTelemetry
+	5,  // 5: monitor.Entity.last_gc:type_name -> google.protobuf.Timestamp
+	5,  // 6: monitor.Entity.last_pull:type_name -> google.protobuf.Timestamp
+	5,  // 7: monitor.Entity.next_gc:type_name -> google.protobuf.Timestamp
+	5,  // 8: monitor.Entity.next_pull:type_name -> google.protobuf.Timestamp
+	0,  // 9: monitor.Monitor.GetConfig:input_type -> monitor.Empty
+	0,  // 10: monitor.Monitor.GetTelemetry:input_type -> monitor.Empty
+	1,  // 11: monitor.Monitor.GetConfig:output_type -> monitor.Config
+	2,  // 12: monitor.Monitor.GetTelemetry:output_type -> monitor.Telemetry
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_pkg_monitor_protobuf_monitor_proto_init() }
@@ -425,63 +489,13 @@ func file_pkg_monitor_protobuf_monitor_proto_init() {
 	if File_pkg_monitor_protobuf_monitor_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_pkg_monitor_protobuf_monitor_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Empty); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_monitor_protobuf_monitor_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Config); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_monitor_protobuf_monitor_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Telemetry); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_pkg_monitor_protobuf_monitor_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Entity); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_pkg_monitor_protobuf_monitor_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_monitor_protobuf_monitor_proto_rawDesc), len(file_pkg_monitor_protobuf_monitor_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -490,7 +504,6 @@ func file_pkg_monitor_protobuf_monitor_proto_init() {
 		MessageInfos:      file_pkg_monitor_protobuf_monitor_proto_msgTypes,
 	}.Build()
 	File_pkg_monitor_protobuf_monitor_proto = out.File
-	file_pkg_monitor_protobuf_monitor_proto_rawDesc = nil
 	file_pkg_monitor_protobuf_monitor_proto_goTypes = nil
 	file_pkg_monitor_protobuf_monitor_proto_depIdxs = nil
 }
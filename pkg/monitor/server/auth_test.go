@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestTokenAuthenticatorUnaryInterceptor(t *testing.T) {
+	a := tokenAuthenticator{token: "secret"}
+
+	handler := func(_ context.Context, _ any) (any, error) {
+		return "ok", nil
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"missing metadata": context.Background(),
+		"missing token":    metadata.NewIncomingContext(context.Background(), metadata.Pairs("foo", "bar")),
+		"wrong token":      metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer wrong")),
+		"wrong scheme":     metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic secret")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := a.unaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			assert.Nil(t, resp)
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+
+	resp, err := a.unaryInterceptor(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret")),
+		nil,
+		&grpc.UnaryServerInfo{},
+		handler,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestTokenAuthenticatorStreamInterceptor(t *testing.T) {
+	a := tokenAuthenticator{token: "secret"}
+
+	called := false
+	handler := func(_ any, _ grpc.ServerStream) error {
+		called = true
+
+		return nil
+	}
+
+	err := a.streamInterceptor(
+		nil,
+		testServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer wrong"))},
+		&grpc.StreamServerInfo{},
+		handler,
+	)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.False(t, called)
+
+	assert.NoError(t, a.streamInterceptor(
+		nil,
+		testServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer secret"))},
+		&grpc.StreamServerInfo{},
+		handler,
+	))
+	assert.True(t, called)
+}
@@ -2,12 +2,15 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os"
+	"sort"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
@@ -26,6 +29,8 @@ type Server struct {
 	cfg                      config.Config
 	store                    store.Store
 	taskSchedulingMonitoring map[schemas.TaskType]*monitor.TaskSchedulingStatus
+	taskExecutionMonitoring  *monitor.TaskExecutionMonitoring
+	leaderElection           *monitor.LeaderElectionStatus
 }
 
 // NewServer ..
@@ -34,12 +39,16 @@ func NewServer(
 	c config.Config,
 	st store.Store,
 	tsm map[schemas.TaskType]*monitor.TaskSchedulingStatus,
+	tem *monitor.TaskExecutionMonitoring,
+	les *monitor.LeaderElectionStatus,
 ) (s *Server) {
 	s = &Server{
 		gitlabClient:             gitlabClient,
 		cfg:                      c,
 		store:                    st,
 		taskSchedulingMonitoring: tsm,
+		taskExecutionMonitoring:  tem,
+		leaderElection:           les,
 	}
 
 	return
@@ -59,13 +68,15 @@ func (s *Server) Serve() {
 		"path":   s.cfg.Global.InternalMonitoringListenerAddress.Path,
 	}).Info("internal monitoring listener set")
 
-	grpcServer := grpc.NewServer()
+	opts, err := s.serverOptions()
+	if err != nil {
+		log.WithError(err).Fatal("configuring internal monitoring listener")
+	}
+
+	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterMonitorServer(grpcServer, s)
 
-	var (
-		l   net.Listener
-		err error
-	)
+	var l net.Listener
 
 	switch s.cfg.Global.InternalMonitoringListenerAddress.Scheme {
 	case "unix":
@@ -103,6 +114,38 @@ func (s *Server) Serve() {
 	}
 }
 
+func (s *Server) serverOptions() (opts []grpc.ServerOption, err error) {
+	if s.cfg.Global.InternalMonitoringListenerTLS.IsEnabled() {
+		tlsConfig, err := monitor.ServerTLSConfig(s.cfg.Global.InternalMonitoringListenerTLS)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+
+		log.WithField("mtls", tlsConfig.ClientCAs != nil).Info("internal monitoring listener TLS enabled")
+	}
+
+	if s.cfg.Global.InternalMonitoringListenerToken != "" {
+		// The token would otherwise be sent in plaintext over the network
+		if s.cfg.Global.InternalMonitoringListenerAddress.Scheme != "unix" && !s.cfg.Global.InternalMonitoringListenerTLS.IsEnabled() {
+			return nil, fmt.Errorf("the internal monitoring listener token requires the listener to be served over TLS or a unix socket")
+		}
+
+		a := tokenAuthenticator{token: s.cfg.Global.InternalMonitoringListenerToken}
+		opts = append(opts,
+			grpc.UnaryInterceptor(a.unaryInterceptor),
+			grpc.StreamInterceptor(a.streamInterceptor),
+		)
+
+		log.Info("internal monitoring listener token authentication enabled")
+	} else if s.cfg.Global.InternalMonitoringListenerAddress.Scheme != "unix" {
+		log.Warn("internal monitoring listener is exposed over the network without token authentication")
+	}
+
+	return
+}
+
 // GetConfig ..
 func (s *Server) GetConfig(ctx context.Context, _ *pb.Empty) (*pb.Config, error) {
 	return &pb.Config{
@@ -144,7 +187,7 @@ func (s *Server) GetTelemetry(_ *pb.Empty, ts pb.Monitor_GetTelemetryServer) (er
 			return
 		}
 
-		telemetry.TasksBufferUsage = float64(queuedTasks) / float64(s.cfg.Gitlab.MaximumJobsQueueSize)
+		telemetry.TasksBufferUsage = float64(queuedTasks) / float64(s.cfg.Scheduler.Queues.BufferSize(s.cfg.Gitlab.MaximumJobsQueueSize))
 
 		telemetry.TasksExecutedCount, err = s.store.ExecutedTasksCount(ctx)
 		if err != nil {
@@ -211,6 +254,13 @@ func (s *Server) GetTelemetry(_ *pb.Empty, ts pb.Monitor_GetTelemetryServer) (er
 			telemetry.Metrics.NextGc = timestamppb.New(s.taskSchedulingMonitoring[schemas.TaskTypeGarbageCollectMetrics].Next)
 		}
 
+		telemetry.Tasks = s.tasksTelemetry()
+
+		if s.leaderElection != nil {
+			telemetry.IsLeader = s.leaderElection.IsLeader()
+			telemetry.Leader = s.leaderElection.Leader()
+		}
+
 		_ = ts.Send(telemetry)
 
 		select {
@@ -221,3 +271,31 @@ func (s *Server) GetTelemetry(_ *pb.Empty, ts pb.Monitor_GetTelemetryServer) (er
 		}
 	}
 }
+
+func (s *Server) tasksTelemetry() (tasks []*pb.TaskTelemetry) {
+	if s.taskExecutionMonitoring == nil {
+		return
+	}
+
+	for tt, stats := range s.taskExecutionMonitoring.Stats() {
+		t := &pb.TaskTelemetry{
+			Type:         string(tt),
+			SuccessCount: stats.SuccessCount,
+			FailureCount: stats.FailureCount,
+			SkippedCount: stats.SkippedCount,
+		}
+
+		if executions := stats.SuccessCount + stats.FailureCount; executions > 0 {
+			t.AverageDurationSeconds = stats.DurationSecondsSum / float64(executions)
+			t.AverageQueueWaitSeconds = stats.QueueWaitSecondsSum / float64(executions)
+		}
+
+		tasks = append(tasks, t)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Type < tasks[j].Type
+	})
+
+	return
+}
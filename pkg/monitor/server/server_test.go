@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+)
+
+func TestServerOptionsToken(t *testing.T) {
+	s := &Server{}
+	s.cfg.Global.InternalMonitoringListenerToken = "secret"
+
+	// The token must not be sent in plaintext over the network
+	s.cfg.Global.InternalMonitoringListenerAddress = &url.URL{Scheme: "tcp", Host: "127.0.0.1:9000"}
+	_, err := s.serverOptions()
+	assert.Error(t, err)
+
+	// Only skipping the verification of the server certificate does not enable TLS on the server
+	s.cfg.Global.InternalMonitoringListenerTLS = config.InternalMonitoringListenerTLS{InsecureSkipVerify: true}
+	_, err = s.serverOptions()
+	assert.Error(t, err)
+
+	s.cfg.Global.InternalMonitoringListenerTLS = config.InternalMonitoringListenerTLS{}
+	s.cfg.Global.InternalMonitoringListenerAddress = &url.URL{Scheme: "unix", Path: "/tmp/gcpe-monitor.sock"}
+	opts, err := s.serverOptions()
+	assert.NoError(t, err)
+	assert.Len(t, opts, 2)
+}
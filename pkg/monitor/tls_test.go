@@ -0,0 +1,175 @@
+package monitor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+)
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCertificate(t *testing.T, dir, name string, parent *testCertificate, usage x509.ExtKeyUsage) (c testCertificate, certFile, keyFile string) {
+	var err error
+
+	c.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+
+	signer, signerKey := template, c.key
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		template.DNSNames = []string{name}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &c.key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	c.cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+
+	return
+}
+
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (serverErr, clientErr error) {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+
+	defer func() { _ = l.Close() }()
+
+	done := make(chan error)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			done <- err
+
+			return
+		}
+
+		defer func() { _ = conn.Close() }()
+
+		done <- conn.(*tls.Conn).Handshake()
+	}()
+
+	conn, clientErr := tls.Dial("tcp", l.Addr().String(), clientConfig)
+	if clientErr == nil {
+		// With TLS 1.3, the client only gets notified of its certificate being rejected on the next read
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, _ = conn.Read(make([]byte, 1))
+		_ = conn.Close()
+	}
+
+	return <-done, clientErr
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	_, err := ServerTLSConfig(config.InternalMonitoringListenerTLS{Enabled: true})
+	assert.Error(t, err)
+
+	_, err = ServerTLSConfig(config.InternalMonitoringListenerTLS{CertFile: "/not/found.crt", KeyFile: "/not/found.key"})
+	assert.Error(t, err)
+}
+
+func TestMutualTLSHandshake(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, caFile, _ := newTestCertificate(t, dir, "ca", nil, 0)
+	_, serverCertFile, serverKeyFile := newTestCertificate(t, dir, "gcpe", &ca, x509.ExtKeyUsageServerAuth)
+	_, clientCertFile, clientKeyFile := newTestCertificate(t, dir, "client", &ca, x509.ExtKeyUsageClientAuth)
+	otherCA, _, _ := newTestCertificate(t, dir, "other-ca", nil, 0)
+	_, otherClientCertFile, otherClientKeyFile := newTestCertificate(t, dir, "other-client", &otherCA, x509.ExtKeyUsageClientAuth)
+
+	serverConfig, err := ServerTLSConfig(config.InternalMonitoringListenerTLS{
+		CertFile: serverCertFile,
+		KeyFile:  serverKeyFile,
+		CAFile:   caFile,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+
+	t.Run("valid client certificate", func(t *testing.T) {
+		clientConfig, err := ClientTLSConfig(config.InternalMonitoringListenerTLS{
+			CertFile: clientCertFile,
+			KeyFile:  clientKeyFile,
+			CAFile:   caFile,
+		})
+		require.NoError(t, err)
+		clientConfig.ServerName = "gcpe"
+
+		serverErr, clientErr := handshake(t, serverConfig, clientConfig)
+		assert.NoError(t, serverErr)
+		assert.NoError(t, clientErr)
+	})
+
+	t.Run("missing client certificate", func(t *testing.T) {
+		clientConfig, err := ClientTLSConfig(config.InternalMonitoringListenerTLS{CAFile: caFile})
+		require.NoError(t, err)
+		clientConfig.ServerName = "gcpe"
+
+		serverErr, _ := handshake(t, serverConfig, clientConfig)
+		assert.Error(t, serverErr)
+	})
+
+	t.Run("client certificate signed by another CA", func(t *testing.T) {
+		clientConfig, err := ClientTLSConfig(config.InternalMonitoringListenerTLS{
+			CertFile: otherClientCertFile,
+			KeyFile:  otherClientKeyFile,
+			CAFile:   caFile,
+		})
+		require.NoError(t, err)
+		clientConfig.ServerName = "gcpe"
+
+		serverErr, _ := handshake(t, serverConfig, clientConfig)
+		assert.Error(t, serverErr)
+	})
+
+	t.Run("untrusted server certificate", func(t *testing.T) {
+		clientConfig, err := ClientTLSConfig(config.InternalMonitoringListenerTLS{
+			CertFile: clientCertFile,
+			KeyFile:  clientKeyFile,
+		})
+		require.NoError(t, err)
+		clientConfig.ServerName = "gcpe"
+
+		_, clientErr := handshake(t, serverConfig, clientConfig)
+		assert.Error(t, clientErr)
+	})
+}
@@ -3,7 +3,6 @@ package ui
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -16,6 +15,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/xeonx/timeago"
 
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor/client"
 	pb "github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/monitor/protobuf"
 )
@@ -177,8 +177,23 @@ func (m *model) renderTelemetryViewport() string {
 		"\n",
 	)
 
+	leader := m.telemetry.GetLeader()
+	if m.telemetry.GetIsLeader() {
+		leader = "this process"
+	} else if len(leader) == 0 {
+		leader = "N/A"
+	}
+
+	leaderElection := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		" Leader                 ",
+		dataStyle.SetString(leader).String(),
+		"\n",
+	)
+
 	return strings.Join([]string{
 		"",
+		leaderElection,
 		gitlabAPIUsage,
 		gitlabAPIRequestsCount,
 		gitlabAPIRateLimit,
@@ -216,7 +231,7 @@ func prettyTimeago(t time.Time) string {
 	return timeago.English.Format(t)
 }
 
-func newModel(version string, endpoint *url.URL) (m *model) {
+func newModel(version string, cfg config.Global) (m *model) {
 	p := progress.New(progress.WithScaledGradient("#80c904", "#ff9d5c"))
 
 	m = &model{
@@ -224,7 +239,11 @@ func newModel(version string, endpoint *url.URL) (m *model) {
 		vp:              viewport.Model{},
 		telemetryStream: make(chan *pb.Telemetry),
 		progress:        &p,
-		client:          client.NewClient(endpoint),
+		client: client.NewClient(
+			cfg.InternalMonitoringListenerAddress,
+			client.WithTLS(cfg.InternalMonitoringListenerTLS),
+			client.WithToken(cfg.InternalMonitoringListenerToken),
+		),
 	}
 
 	return
@@ -351,9 +370,9 @@ func waitForTelemetryUpdate(t chan *pb.Telemetry) tea.Cmd {
 }
 
 // Start ..
-func Start(version string, listenerAddress *url.URL) {
+func Start(version string, cfg config.Global) {
 	if _, err := tea.NewProgram(
-		newModel(version, listenerAddress),
+		newModel(version, cfg),
 		tea.WithAltScreen(),
 	).Run(); err != nil {
 		fmt.Println("Error running program:", err)
@@ -38,6 +38,7 @@ func TestRedisTake(t *testing.T) {
 
 	l := NewRedisLimiter(
 		redis.NewClient(&redis.Options{Addr: s.Addr()}),
+		"",
 		1,
 	)
 
@@ -49,6 +50,7 @@ func TestRedisTakeError(t *testing.T) {
 	if os.Getenv("SHOULD_ERROR") == "1" {
 		l := NewRedisLimiter(
 			redis.NewClient(&redis.Options{Addr: "doesnotexist"}),
+			"",
 			1,
 		)
 
@@ -15,13 +15,20 @@ const redisKey string = `gcpe:gitlab:api`
 type Redis struct {
 	*redis_rate.Limiter
 	MaxRPS int
+	Key    string
 }
 
-// NewRedisLimiter ..
-func NewRedisLimiter(redisClient *redis.Client, maxRPS int) Limiter {
+// NewRedisLimiter returns a limiter shared by all the instances using the same namespace.
+func NewRedisLimiter(redisClient redis.UniversalClient, namespace string, maxRPS int) Limiter {
+	key := redisKey
+	if namespace != "" {
+		key = namespace + ":" + redisKey
+	}
+
 	return Redis{
 		Limiter: redis_rate.NewLimiter(redisClient),
 		MaxRPS:  maxRPS,
+		Key:     key,
 	}
 }
 
@@ -30,7 +37,7 @@ func (r Redis) Take(ctx context.Context) time.Duration {
 	start := time.Now()
 
 	for {
-		res, err := r.Allow(ctx, redisKey, redis_rate.PerSecond(r.MaxRPS))
+		res, err := r.Allow(ctx, r.Key, redis_rate.PerSecond(r.MaxRPS))
 		if err != nil {
 			log.WithContext(ctx).
 				WithError(err).
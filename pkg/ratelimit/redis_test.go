@@ -12,13 +12,18 @@ func TestNewRedisLimiter(t *testing.T) {
 	redisClient := redis.NewClient(&redis.Options{})
 	l := NewRedisLimiter(
 		redisClient,
+		"",
 		10,
 	)
 
 	expectedValue := Redis{
 		Limiter: redis_rate.NewLimiter(redisClient),
 		MaxRPS:  10,
+		Key:     "gcpe:gitlab:api",
 	}
 
 	assert.Equal(t, expectedValue, l)
+
+	l = NewRedisLimiter(redisClient, "foo", 10)
+	assert.Equal(t, "foo:gcpe:gitlab:api", l.(Redis).Key)
 }
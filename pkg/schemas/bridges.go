@@ -0,0 +1,71 @@
+package schemas
+
+import (
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// Bridge is a job triggering a downstream pipeline.
+type Bridge struct {
+	ID              int64
+	Name            string
+	Stage           string
+	Status          string
+	AllowFailure    bool
+	FailureReason   string
+	DurationSeconds float64
+
+	// Not set as long as the downstream pipeline has not been created
+	Downstream *BridgeDownstream
+}
+
+// BridgeDownstream holds the pipeline triggered by a bridge.
+type BridgeDownstream struct {
+	ProjectID   int64
+	ProjectName string
+
+	// Whether the pipeline belongs to another project than the bridge (multi-project pipeline)
+	CrossProject bool
+
+	RefKind  RefKind
+	RefName  string
+	Pipeline Pipeline
+}
+
+// NewBridge ..
+func NewBridge(gb goGitlab.Bridge) Bridge {
+	b := Bridge{
+		ID:              gb.ID,
+		Name:            gb.Name,
+		Stage:           gb.Stage,
+		Status:          gb.Status,
+		AllowFailure:    gb.AllowFailure,
+		FailureReason:   gb.FailureReason,
+		DurationSeconds: gb.Duration,
+	}
+
+	if gb.DownstreamPipeline != nil {
+		b.Downstream = &BridgeDownstream{
+			ProjectID:    gb.DownstreamPipeline.ProjectID,
+			CrossProject: gb.DownstreamPipeline.ProjectID != gb.Pipeline.ProjectID,
+			RefKind:      RefKindBranch,
+			RefName:      gb.DownstreamPipeline.Ref,
+			Pipeline: Pipeline{
+				ID:     gb.DownstreamPipeline.ID,
+				Status: gb.DownstreamPipeline.Status,
+				Source: gb.DownstreamPipeline.Source,
+			},
+		}
+	}
+
+	return b
+}
+
+// CausedFailure returns whether the bridge made the pipeline it belongs to fail
+// because its downstream pipeline failed.
+func (b Bridge) CausedFailure(pipelineStatus string) bool {
+	return pipelineStatus == "failed" &&
+		b.Status == "failed" &&
+		!b.AllowFailure &&
+		b.Downstream != nil &&
+		b.Downstream.Pipeline.Status == "failed"
+}
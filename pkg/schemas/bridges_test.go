@@ -0,0 +1,79 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	goGitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestNewBridge(t *testing.T) {
+	gb := goGitlab.Bridge{
+		ID:            10,
+		Name:          "trigger",
+		Stage:         "deploy",
+		Status:        "failed",
+		FailureReason: "unknown_failure",
+		Duration:      30,
+		Pipeline: goGitlab.PipelineInfo{
+			ID:        1,
+			ProjectID: 100,
+		},
+	}
+
+	// Downstream pipeline not created yet
+	assert.Equal(t, Bridge{
+		ID:              10,
+		Name:            "trigger",
+		Stage:           "deploy",
+		Status:          "failed",
+		FailureReason:   "unknown_failure",
+		DurationSeconds: 30,
+	}, NewBridge(gb))
+
+	gb.DownstreamPipeline = &goGitlab.PipelineInfo{
+		ID:        2,
+		ProjectID: 200,
+		Ref:       "main",
+		Status:    "failed",
+		Source:    "pipeline",
+	}
+
+	assert.Equal(t, &BridgeDownstream{
+		ProjectID:    200,
+		CrossProject: true,
+		RefKind:      RefKindBranch,
+		RefName:      "main",
+		Pipeline: Pipeline{
+			ID:     2,
+			Status: "failed",
+			Source: "pipeline",
+		},
+	}, NewBridge(gb).Downstream)
+
+	// Child pipeline
+	gb.DownstreamPipeline.ProjectID = 100
+	assert.False(t, NewBridge(gb).Downstream.CrossProject)
+}
+
+func TestBridgeCausedFailure(t *testing.T) {
+	b := Bridge{
+		Status: "failed",
+		Downstream: &BridgeDownstream{
+			Pipeline: Pipeline{Status: "failed"},
+		},
+	}
+
+	assert.True(t, b.CausedFailure("failed"))
+	assert.False(t, b.CausedFailure("success"))
+
+	b.AllowFailure = true
+	assert.False(t, b.CausedFailure("failed"))
+
+	b.AllowFailure = false
+	b.Downstream.Pipeline.Status = "canceled"
+	assert.False(t, b.CausedFailure("failed"))
+
+	b.Downstream = nil
+	assert.False(t, b.CausedFailure("failed"))
+}
@@ -0,0 +1,180 @@
+package schemas
+
+import (
+	"slices"
+)
+
+// PipelineGraph holds the jobs of a pipeline along with their dependencies.
+type PipelineGraph struct {
+	// Stages of the pipeline, in their order of execution
+	Stages []string
+	Jobs   []PipelineGraphJob
+}
+
+// PipelineGraphJob ..
+type PipelineGraphJob struct {
+	Name            string
+	Stage           string
+	DurationSeconds float64
+
+	// Whether the job starts as soon as its needs are completed rather than
+	// waiting for all the jobs of the previous stages (`needs:` keyword)
+	DAG   bool
+	Needs []string
+
+	// Whether the job is a bridge, triggering a downstream pipeline
+	Bridge bool
+
+	// Graph of the pipeline triggered by the bridge, when it got fetched
+	Downstream *PipelineGraph
+}
+
+// CriticalPath holds the result of the critical path analysis of a pipeline.
+type CriticalPath struct {
+	// Sum of the durations of the jobs of the longest chain of dependent jobs
+	DurationSeconds float64
+
+	// Cumulative duration of all the jobs, including the downstream ones. The bridges
+	// are not accounted for as they only wait for their downstream pipelines.
+	JobsDurationSeconds float64
+
+	// Jobs of the pipeline and of its downstream pipelines
+	Jobs []CriticalPathJob
+}
+
+// CriticalPathJob ..
+type CriticalPathJob struct {
+	Name           string
+	Stage          string
+	OnCriticalPath bool
+}
+
+// AverageParallelism returns the average number of jobs which ran at the
+// same time over the given duration, defaulting to the critical path one.
+func (cp CriticalPath) AverageParallelism(durationSeconds float64) float64 {
+	if durationSeconds <= 0 {
+		durationSeconds = cp.DurationSeconds
+	}
+
+	if durationSeconds <= 0 {
+		return 0
+	}
+
+	return cp.JobsDurationSeconds / durationSeconds
+}
+
+// CriticalPath computes the longest chain of dependent jobs of the pipeline, weighted
+// by their durations. When a bridge is on it, so is the critical path of its downstream pipeline.
+func (g PipelineGraph) CriticalPath() (cp CriticalPath) {
+	var (
+		finishes    = make([]float64, len(g.Jobs))
+		computed    = make([]bool, len(g.Jobs))
+		predecessor = make([]int, len(g.Jobs))
+		deps        = g.dependencies()
+		finish      func(i int) float64
+	)
+
+	finish = func(i int) float64 {
+		if computed[i] {
+			return finishes[i]
+		}
+
+		// Prevents from looping forever on circular needs, which GitLab does not allow anyway
+		computed[i] = true
+		predecessor[i] = -1
+
+		var start float64
+
+		for _, d := range deps[i] {
+			if f := finish(d); f > start || predecessor[i] == -1 {
+				start = f
+				predecessor[i] = d
+			}
+		}
+
+		finishes[i] = start + g.Jobs[i].DurationSeconds
+
+		return finishes[i]
+	}
+
+	last := -1
+
+	for i := range g.Jobs {
+		if f := finish(i); last == -1 || f > finishes[last] {
+			last = i
+		}
+	}
+
+	onCriticalPath := make([]bool, len(g.Jobs))
+
+	if last != -1 {
+		cp.DurationSeconds = finishes[last]
+
+		for i := last; i != -1; i = predecessor[i] {
+			onCriticalPath[i] = true
+		}
+	}
+
+	for i, job := range g.Jobs {
+		cp.Jobs = append(cp.Jobs, CriticalPathJob{
+			Name:           job.Name,
+			Stage:          job.Stage,
+			OnCriticalPath: onCriticalPath[i],
+		})
+
+		if !job.Bridge {
+			cp.JobsDurationSeconds += job.DurationSeconds
+		}
+
+		if job.Downstream != nil {
+			downstream := job.Downstream.CriticalPath()
+			cp.JobsDurationSeconds += downstream.JobsDurationSeconds
+
+			for _, dj := range downstream.Jobs {
+				dj.OnCriticalPath = dj.OnCriticalPath && onCriticalPath[i]
+				cp.Jobs = append(cp.Jobs, dj)
+			}
+		}
+	}
+
+	return
+}
+
+// dependencies returns, for each job, the indexes of the jobs it waits for.
+func (g PipelineGraph) dependencies() [][]int {
+	stagePosition := func(stage string) int {
+		if i := slices.Index(g.Stages, stage); i >= 0 {
+			return i
+		}
+
+		return len(g.Stages)
+	}
+
+	jobIndexes := make(map[string]int, len(g.Jobs))
+	for i, job := range g.Jobs {
+		jobIndexes[job.Name] = i
+	}
+
+	deps := make([][]int, len(g.Jobs))
+
+	for i, job := range g.Jobs {
+		if job.DAG {
+			for _, need := range job.Needs {
+				// Optional needs or needs from other pipelines are not part of the graph
+				if d, ok := jobIndexes[need]; ok && d != i {
+					deps[i] = append(deps[i], d)
+				}
+			}
+
+			continue
+		}
+
+		for d, other := range g.Jobs {
+			if stagePosition(other.Stage) < stagePosition(job.Stage) {
+				deps[i] = append(deps[i], d)
+			}
+		}
+	}
+
+	return deps
+}
@@ -0,0 +1,73 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineGraphCriticalPath(t *testing.T) {
+	g := PipelineGraph{
+		Stages: []string{"build", "test", "deploy"},
+		Jobs: []PipelineGraphJob{
+			{Name: "build-front", Stage: "build", DurationSeconds: 30},
+			{Name: "build-back", Stage: "build", DurationSeconds: 60},
+			{Name: "test-front", Stage: "test", DurationSeconds: 50, DAG: true, Needs: []string{"build-front"}},
+			{Name: "test-back", Stage: "test", DurationSeconds: 10, DAG: true, Needs: []string{"build-back", "optional"}},
+			{Name: "lint", Stage: "test", DurationSeconds: 5, DAG: true},
+			{Name: "deploy", Stage: "deploy", DurationSeconds: 20},
+		},
+	}
+
+	cp := g.CriticalPath()
+	assert.Equal(t, float64(100), cp.DurationSeconds)
+	assert.Equal(t, float64(175), cp.JobsDurationSeconds)
+	assert.Equal(t, []CriticalPathJob{
+		{Name: "build-front", Stage: "build", OnCriticalPath: true},
+		{Name: "build-back", Stage: "build"},
+		{Name: "test-front", Stage: "test", OnCriticalPath: true},
+		{Name: "test-back", Stage: "test"},
+		{Name: "lint", Stage: "test"},
+		{Name: "deploy", Stage: "deploy", OnCriticalPath: true},
+	}, cp.Jobs)
+
+	assert.Equal(t, 1.75, cp.AverageParallelism(0))
+	assert.Equal(t, 0.875, cp.AverageParallelism(200))
+	assert.Equal(t, float64(0), PipelineGraph{}.CriticalPath().AverageParallelism(0))
+}
+
+func TestPipelineGraphCriticalPathDownstream(t *testing.T) {
+	child := &PipelineGraph{
+		Stages: []string{"test"},
+		Jobs: []PipelineGraphJob{
+			{Name: "child-a", Stage: "test", DurationSeconds: 40},
+			{Name: "child-b", Stage: "test", DurationSeconds: 20},
+		},
+	}
+
+	g := PipelineGraph{
+		Stages: []string{"build", "trigger"},
+		Jobs: []PipelineGraphJob{
+			{Name: "build", Stage: "build", DurationSeconds: 10},
+			{Name: "trigger", Stage: "trigger", DurationSeconds: 45, Bridge: true, Downstream: child},
+		},
+	}
+
+	cp := g.CriticalPath()
+	assert.Equal(t, float64(55), cp.DurationSeconds)
+	assert.Equal(t, float64(70), cp.JobsDurationSeconds)
+	assert.Equal(t, []CriticalPathJob{
+		{Name: "build", Stage: "build", OnCriticalPath: true},
+		{Name: "trigger", Stage: "trigger", OnCriticalPath: true},
+		{Name: "child-a", Stage: "test", OnCriticalPath: true},
+		{Name: "child-b", Stage: "test"},
+	}, cp.Jobs)
+
+	// A downstream pipeline which does not bound the parent one is not on the critical path
+	g.Jobs[1].DurationSeconds = 0
+	g.Jobs = append(g.Jobs, PipelineGraphJob{Name: "package", Stage: "trigger", DurationSeconds: 30})
+
+	cp = g.CriticalPath()
+	assert.Equal(t, float64(40), cp.DurationSeconds)
+	assert.False(t, cp.Jobs[2].OnCriticalPath)
+}
@@ -0,0 +1,22 @@
+package schemas
+
+// GarbageCollectionCursor tracks the progress of a garbage collection spread over
+// several time slices.
+type GarbageCollectionCursor struct {
+	// Where to resume scanning the entities from, 0 when starting over
+	Position uint64
+
+	// Number of entities stored when the garbage collection started
+	Total int64
+
+	// Number of entities to delete found so far by the pass checking
+	// them against the safety threshold
+	Found int64
+
+	// Whether the pass checking the deletions against the safety threshold
+	// is over, the entities then getting deleted
+	Checked bool
+
+	// Number of entities deleted by the previous slices
+	Deleted int64
+}
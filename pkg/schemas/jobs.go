@@ -2,6 +2,7 @@ package schemas
 
 import (
 	"strings"
+	"time"
 
 	goGitlab "gitlab.com/gitlab-org/api/client-go"
 )
@@ -9,9 +10,12 @@ import (
 // Job ..
 type Job struct {
 	ID                    int64
+	PipelineID            int64
 	Name                  string
 	Stage                 string
 	Timestamp             float64
+	StartedTimestamp      float64
+	FinishedTimestamp     float64
 	DurationSeconds       float64
 	QueuedDurationSeconds float64
 	Status                string
@@ -22,6 +26,16 @@ type Job struct {
 	Runner                Runner
 }
 
+// PlayedTimestamp returns when the job got queued, which is when it got played in the case of a manual job.
+func (j Job) PlayedTimestamp(now time.Time) float64 {
+	if j.StartedTimestamp > 0 {
+		return j.StartedTimestamp - j.QueuedDurationSeconds
+	}
+
+	// The queued duration of a pending job is the one up to now
+	return float64(now.Unix()) - j.QueuedDurationSeconds
+}
+
 // Runner ..
 type Runner struct {
 	Description string
@@ -33,8 +47,10 @@ type Jobs map[string]Job
 // NewJob ..
 func NewJob(gj goGitlab.Job) Job {
 	var (
-		artifactSize float64
-		timestamp    float64
+		artifactSize      float64
+		timestamp         float64
+		startedTimestamp  float64
+		finishedTimestamp float64
 	)
 
 	for _, artifact := range gj.Artifacts {
@@ -45,11 +61,22 @@ func NewJob(gj goGitlab.Job) Job {
 		timestamp = float64(gj.CreatedAt.Unix())
 	}
 
+	if gj.StartedAt != nil {
+		startedTimestamp = float64(gj.StartedAt.Unix())
+	}
+
+	if gj.FinishedAt != nil {
+		finishedTimestamp = float64(gj.FinishedAt.Unix())
+	}
+
 	return Job{
 		ID:                    gj.ID,
+		PipelineID:            gj.Pipeline.ID,
 		Name:                  gj.Name,
 		Stage:                 gj.Stage,
 		Timestamp:             timestamp,
+		StartedTimestamp:      startedTimestamp,
+		FinishedTimestamp:     finishedTimestamp,
 		DurationSeconds:       gj.Duration,
 		QueuedDurationSeconds: gj.QueuedDuration,
 		Status:                gj.Status,
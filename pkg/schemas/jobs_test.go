@@ -15,6 +15,7 @@ func TestNewJob(t *testing.T) {
 	gitlabJob := goGitlab.Job{
 		ID:             2,
 		Name:           "foo",
+		Pipeline:       goGitlab.JobPipeline{ID: 1},
 		CreatedAt:      &createdAt,
 		StartedAt:      &startedAt,
 		Duration:       15,
@@ -44,9 +45,11 @@ func TestNewJob(t *testing.T) {
 
 	expectedJob := Job{
 		ID:                    2,
+		PipelineID:            1,
 		Name:                  "foo",
 		Stage:                 "🚀",
 		Timestamp:             1.601557505e+09,
+		StartedTimestamp:      1.601557535e+09,
 		DurationSeconds:       15,
 		QueuedDurationSeconds: 10,
 		Status:                "failed",
@@ -61,3 +64,15 @@ func TestNewJob(t *testing.T) {
 
 	assert.Equal(t, expectedJob, NewJob(gitlabJob))
 }
+
+func TestJobPlayedTimestamp(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	// Pending job
+	j := Job{Timestamp: 100, QueuedDurationSeconds: 30}
+	assert.Equal(t, float64(970), j.PlayedTimestamp(now))
+
+	// Started job
+	j.StartedTimestamp = 500
+	assert.Equal(t, float64(470), j.PlayedTimestamp(now))
+}
@@ -116,6 +116,66 @@ const (
 
 	// MetricKindTestCaseStatus ..
 	MetricKindTestCaseStatus
+
+	// MetricKindWebhookDisabledUntilTimestamp ..
+	MetricKindWebhookDisabledUntilTimestamp
+
+	// MetricKindWebhookStatus ..
+	MetricKindWebhookStatus
+
+	// MetricKindStageDurationSeconds ..
+	MetricKindStageDurationSeconds
+
+	// MetricKindStageJobCount ..
+	MetricKindStageJobCount
+
+	// MetricKindStageJobsDurationSeconds ..
+	MetricKindStageJobsDurationSeconds
+
+	// MetricKindStageStatus ..
+	MetricKindStageStatus
+
+	// MetricKindAverageParallelism ..
+	MetricKindAverageParallelism
+
+	// MetricKindCriticalPathDurationSeconds ..
+	MetricKindCriticalPathDurationSeconds
+
+	// MetricKindJobCriticalPath ..
+	MetricKindJobCriticalPath
+
+	// MetricKindBridgeCausedFailure ..
+	MetricKindBridgeCausedFailure
+
+	// MetricKindBridgeDownstreamDurationSeconds ..
+	MetricKindBridgeDownstreamDurationSeconds
+
+	// MetricKindBridgeDownstreamPipelineID ..
+	MetricKindBridgeDownstreamPipelineID
+
+	// MetricKindBridgeDownstreamStatus ..
+	MetricKindBridgeDownstreamStatus
+
+	// MetricKindJobFailureCount ..
+	MetricKindJobFailureCount
+
+	// MetricKindJobAttemptsToSucceed ..
+	MetricKindJobAttemptsToSucceed
+
+	// MetricKindJobRetryCount ..
+	MetricKindJobRetryCount
+
+	// MetricKindBlockedDurationSeconds ..
+	MetricKindBlockedDurationSeconds
+
+	// MetricKindJobManualPlayDelaySeconds ..
+	MetricKindJobManualPlayDelaySeconds
+
+	// MetricKindJobManualWaiting ..
+	MetricKindJobManualWaiting
+
+	// MetricKindJobWaitingForResourceDurationSeconds ..
+	MetricKindJobWaitingForResourceDurationSeconds
 )
 
 // MetricKind ..
@@ -139,7 +199,7 @@ func (m Metric) Key() MetricKey {
 	key := strconv.Itoa(int(m.Kind))
 
 	switch m.Kind {
-	case MetricKindCoverage, MetricKindDurationSeconds, MetricKindID, MetricKindQueuedDurationSeconds, MetricKindRunCount, MetricKindStatus, MetricKindTimestamp, MetricKindTestReportTotalCount, MetricKindTestReportErrorCount, MetricKindTestReportFailedCount, MetricKindTestReportSkippedCount, MetricKindTestReportSuccessCount, MetricKindTestReportTotalTime:
+	case MetricKindCoverage, MetricKindDurationSeconds, MetricKindID, MetricKindQueuedDurationSeconds, MetricKindRunCount, MetricKindStatus, MetricKindTimestamp, MetricKindTestReportTotalCount, MetricKindTestReportErrorCount, MetricKindTestReportFailedCount, MetricKindTestReportSkippedCount, MetricKindTestReportSuccessCount, MetricKindTestReportTotalTime, MetricKindAverageParallelism, MetricKindCriticalPathDurationSeconds, MetricKindBlockedDurationSeconds:
 		key += fmt.Sprintf("%v", []string{
 			m.Labels["project"],
 			m.Labels["kind"],
@@ -158,6 +218,52 @@ func (m Metric) Key() MetricKey {
 			m.Labels["job_name"],
 		})
 
+	case MetricKindJobAttemptsToSucceed, MetricKindJobCriticalPath, MetricKindJobManualPlayDelaySeconds, MetricKindJobManualWaiting, MetricKindJobWaitingForResourceDurationSeconds:
+		key += fmt.Sprintf("%v", []string{
+			m.Labels["project"],
+			m.Labels["kind"],
+			m.Labels["ref"],
+			m.Labels["stage"],
+			m.Labels["job_name"],
+		})
+
+	case MetricKindBridgeCausedFailure, MetricKindBridgeDownstreamDurationSeconds, MetricKindBridgeDownstreamPipelineID, MetricKindBridgeDownstreamStatus:
+		key += fmt.Sprintf("%v", []string{
+			m.Labels["project"],
+			m.Labels["kind"],
+			m.Labels["ref"],
+			m.Labels["stage"],
+			m.Labels["bridge_name"],
+		})
+
+	case MetricKindJobRetryCount:
+		key += fmt.Sprintf("%v", []string{
+			m.Labels["project"],
+			m.Labels["kind"],
+			m.Labels["ref"],
+			m.Labels["stage"],
+			m.Labels["job_name"],
+			m.Labels["retry_kind"],
+		})
+
+	case MetricKindJobFailureCount:
+		key += fmt.Sprintf("%v", []string{
+			m.Labels["project"],
+			m.Labels["kind"],
+			m.Labels["ref"],
+			m.Labels["stage"],
+			m.Labels["runner_description"],
+			m.Labels["failure_reason"],
+		})
+
+	case MetricKindStageDurationSeconds, MetricKindStageJobCount, MetricKindStageJobsDurationSeconds, MetricKindStageStatus:
+		key += fmt.Sprintf("%v", []string{
+			m.Labels["project"],
+			m.Labels["kind"],
+			m.Labels["ref"],
+			m.Labels["stage"],
+		})
+
 	case MetricKindEnvironmentBehindCommitsCount, MetricKindEnvironmentBehindDurationSeconds, MetricKindEnvironmentDeploymentCount, MetricKindEnvironmentDeploymentDurationSeconds, MetricKindEnvironmentDeploymentJobID, MetricKindEnvironmentDeploymentStatus, MetricKindEnvironmentDeploymentTimestamp, MetricKindEnvironmentInformation:
 		key += fmt.Sprintf("%v", []string{
 			m.Labels["project"],
@@ -181,11 +287,16 @@ func (m Metric) Key() MetricKey {
 			m.Labels["test_case_name"],
 			m.Labels["test_case_classname"],
 		})
+
+	case MetricKindWebhookDisabledUntilTimestamp, MetricKindWebhookStatus:
+		key += fmt.Sprintf("%v", []string{
+			m.Labels["project"],
+		})
 	}
 
 	// If the metric is a "status" one, add the status label
 	switch m.Kind {
-	case MetricKindJobStatus, MetricKindEnvironmentDeploymentStatus, MetricKindStatus, MetricKindTestCaseStatus:
+	case MetricKindJobStatus, MetricKindBridgeDownstreamStatus, MetricKindEnvironmentDeploymentStatus, MetricKindStageStatus, MetricKindStatus, MetricKindTestCaseStatus, MetricKindWebhookStatus:
 		key += m.Labels["status"]
 	}
 
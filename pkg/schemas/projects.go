@@ -11,6 +11,10 @@ import (
 type Project struct {
 	config.Project
 
+	// Numeric ID of the project on GitLab, which remains the same
+	// when the project gets renamed or transferred
+	ID int64
+
 	Topics string
 }
 
@@ -20,7 +24,10 @@ type ProjectKey string
 // Projects ..
 type Projects map[ProjectKey]Project
 
-// Key ..
+// Key identifies the project by its name rather than its ID, as the configured projects
+// are named and their ID is unknown until they get pulled. The metrics being identified by
+// their labels, the project name included, they would have to be rewritten on renames anyway.
+// The renamed projects get migrated instead, see Controller.trackProject.
 func (p Project) Key() ProjectKey {
 	return ProjectKey(strconv.Itoa(int(crc32.ChecksumIEEE([]byte(p.Name)))))
 }
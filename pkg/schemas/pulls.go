@@ -0,0 +1,49 @@
+package schemas
+
+import (
+	"strings"
+	"time"
+)
+
+// MetricsPullKey identifies a ref or an environment within the queue of the
+// metrics pulls.
+type MetricsPullKey string
+
+// MetricsPull holds when the metrics of a ref or an environment are next due to be
+// pulled, and the interval they have been scheduled with.
+type MetricsPull struct {
+	DueTime         time.Time
+	IntervalSeconds int
+}
+
+// MetricsPulls ..
+type MetricsPulls map[MetricsPullKey]MetricsPull
+
+const (
+	refMetricsPullKeyPrefix         = "ref:"
+	environmentMetricsPullKeyPrefix = "environment:"
+)
+
+// MetricsPullKey ..
+func (k RefKey) MetricsPullKey() MetricsPullKey {
+	return MetricsPullKey(refMetricsPullKeyPrefix + string(k))
+}
+
+// MetricsPullKey ..
+func (k EnvironmentKey) MetricsPullKey() MetricsPullKey {
+	return MetricsPullKey(environmentMetricsPullKeyPrefix + string(k))
+}
+
+// RefKey returns the key of the ref the pull belongs to, if it belongs to a ref.
+func (k MetricsPullKey) RefKey() (RefKey, bool) {
+	rk, ok := strings.CutPrefix(string(k), refMetricsPullKeyPrefix)
+
+	return RefKey(rk), ok
+}
+
+// EnvironmentKey returns the key of the environment the pull belongs to, if it belongs to an environment.
+func (k MetricsPullKey) EnvironmentKey() (EnvironmentKey, bool) {
+	ek, ok := strings.CutPrefix(string(k), environmentMetricsPullKeyPrefix)
+
+	return EnvironmentKey(ek), ok
+}
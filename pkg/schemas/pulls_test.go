@@ -0,0 +1,23 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsPullKeyEntityKeys(t *testing.T) {
+	rk, isRef := RefKey("1").MetricsPullKey().RefKey()
+	assert.True(t, isRef)
+	assert.Equal(t, RefKey("1"), rk)
+
+	_, isEnv := RefKey("1").MetricsPullKey().EnvironmentKey()
+	assert.False(t, isEnv)
+
+	ek, isEnv := EnvironmentKey("2").MetricsPullKey().EnvironmentKey()
+	assert.True(t, isEnv)
+	assert.Equal(t, EnvironmentKey("2"), ek)
+
+	_, isRef = EnvironmentKey("2").MetricsPullKey().RefKey()
+	assert.False(t, isRef)
+}
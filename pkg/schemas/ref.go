@@ -33,6 +33,12 @@ type Ref struct {
 	Project        Project
 	LatestPipeline Pipeline
 	LatestJobs     Jobs
+
+	// Retried jobs of the most recent pipelines which have already been counted
+	LatestRetriedJobs RetriedJobs
+
+	// Set when the ref is only tracked as it got triggered by a bridge of another project
+	UpstreamRefKey RefKey
 }
 
 // RefKey ..
@@ -0,0 +1,150 @@
+package schemas
+
+import (
+	"maps"
+	"slices"
+	"sort"
+)
+
+const (
+	// JobRetryKindManual refers to a job retried by a user.
+	JobRetryKindManual JobRetryKind = "manual"
+
+	// JobRetryKindAutomatic refers to a job retried by GitLab using the `retry:` keyword.
+	JobRetryKindAutomatic JobRetryKind = "automatic"
+
+	// GitLab creates the automatic retries of a job as soon as it fails, manual ones
+	// are expected to come later on as someone has to notice the failure first
+	automaticRetryMaxDelaySeconds float64 = 30
+)
+
+// JobRetryKind is used to determine how a job got retried.
+type JobRetryKind string
+
+// JobAttempt is an execution of a job, retried ones included.
+type JobAttempt struct {
+	ID                int64
+	PipelineID        int64
+	Name              string
+	Stage             string
+	Status            string
+	Retried           bool
+	CreatedTimestamp  float64
+	FinishedTimestamp float64
+}
+
+// JobAttempts ..
+type JobAttempts []JobAttempt
+
+// JobRetry refers to an attempt of a job which got retried.
+type JobRetry struct {
+	ID    int64
+	Name  string
+	Stage string
+	Kind  JobRetryKind
+}
+
+// RetriedJobs keeps track, by pipeline ID, of the retried jobs which have already been accounted for.
+type RetriedJobs map[int64][]int64
+
+// Contains returns whether the ID of the retried job of the pipeline has already been accounted for.
+func (rj RetriedJobs) Contains(pipelineID, id int64) bool {
+	return slices.Contains(rj[pipelineID], id)
+}
+
+// With returns a copy of the retried jobs in which the ones of the pipeline are replaced by ids. Only
+// the maxPipelines most recent pipelines are kept track of, the older ones not being pulled anymore.
+func (rj RetriedJobs) With(pipelineID int64, ids []int64, maxPipelines int) RetriedJobs {
+	result := make(RetriedJobs, len(rj)+1)
+	for id, jobIDs := range rj {
+		result[id] = jobIDs
+	}
+
+	result[pipelineID] = ids
+
+	pipelineIDs := slices.Sorted(maps.Keys(result))
+	for len(pipelineIDs) > max(maxPipelines, 1) {
+		delete(result, pipelineIDs[0])
+		pipelineIDs = pipelineIDs[1:]
+	}
+
+	return result
+}
+
+type jobAttemptsKey struct {
+	PipelineID int64
+	Name       string
+}
+
+// byJob returns the attempts of the jobs, sorted by ID. Child pipelines can have jobs
+// named after the ones of their parent, hence the attempts are grouped by pipeline too.
+func (attempts JobAttempts) byJob() map[jobAttemptsKey]JobAttempts {
+	jobs := make(map[jobAttemptsKey]JobAttempts)
+
+	sorted := make(JobAttempts, len(attempts))
+	copy(sorted, attempts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for _, a := range sorted {
+		key := jobAttemptsKey{PipelineID: a.PipelineID, Name: a.Name}
+		jobs[key] = append(jobs[key], a)
+	}
+
+	return jobs
+}
+
+// Retries returns the attempts which got retried along with the way they got retried.
+// As the API does not tell them apart, a retry is considered automatic when the attempt
+// failed and the next one got created right after.
+func (attempts JobAttempts) Retries() (retries []JobRetry) {
+	for _, jobAttempts := range attempts.byJob() {
+		for i, a := range jobAttempts {
+			if !a.Retried || i+1 >= len(jobAttempts) {
+				continue
+			}
+
+			retry := JobRetry{
+				ID:    a.ID,
+				Name:  a.Name,
+				Stage: a.Stage,
+				Kind:  JobRetryKindManual,
+			}
+
+			if a.Status == "failed" &&
+				a.FinishedTimestamp > 0 &&
+				jobAttempts[i+1].CreatedTimestamp-a.FinishedTimestamp <= automaticRetryMaxDelaySeconds {
+				retry.Kind = JobRetryKindAutomatic
+			}
+
+			retries = append(retries, retry)
+		}
+	}
+
+	sort.Slice(retries, func(i, j int) bool {
+		return retries[i].ID < retries[j].ID
+	})
+
+	return
+}
+
+// AttemptsToSucceed returns, by stage and job name, the number of attempts it took
+// for the jobs to succeed. The jobs which did not succeed are left out.
+func (attempts JobAttempts) AttemptsToSucceed() map[[2]string]int {
+	result := make(map[[2]string]int)
+
+	for _, jobAttempts := range attempts.byJob() {
+		latest := jobAttempts[len(jobAttempts)-1]
+		if latest.Retried || latest.Status != "success" {
+			continue
+		}
+
+		key := [2]string{latest.Stage, latest.Name}
+		if len(jobAttempts) > result[key] {
+			result[key] = len(jobAttempts)
+		}
+	}
+
+	return result
+}
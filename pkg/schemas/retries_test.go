@@ -0,0 +1,51 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobAttemptsRetries(t *testing.T) {
+	attempts := JobAttempts{
+		{ID: 3, PipelineID: 1, Name: "unit", Stage: "test", Status: "failed", Retried: true, CreatedTimestamp: 20, FinishedTimestamp: 100},
+		// Automatic retry, created as soon as the former attempt failed
+		{ID: 4, PipelineID: 1, Name: "unit", Stage: "test", Status: "failed", Retried: true, CreatedTimestamp: 105, FinishedTimestamp: 200},
+		// Manual retry
+		{ID: 9, PipelineID: 1, Name: "unit", Stage: "test", Status: "success", CreatedTimestamp: 600, FinishedTimestamp: 700},
+		// Successful jobs only get retried manually
+		{ID: 1, PipelineID: 1, Name: "build", Stage: "build", Status: "success", Retried: true, CreatedTimestamp: 0, FinishedTimestamp: 10},
+		{ID: 2, PipelineID: 1, Name: "build", Stage: "build", Status: "success", CreatedTimestamp: 11, FinishedTimestamp: 20},
+		// Job of a child pipeline named after one of its parent
+		{ID: 5, PipelineID: 2, Name: "unit", Stage: "test", Status: "failed", CreatedTimestamp: 30, FinishedTimestamp: 50},
+	}
+
+	assert.Equal(t, []JobRetry{
+		{ID: 1, Name: "build", Stage: "build", Kind: JobRetryKindManual},
+		{ID: 3, Name: "unit", Stage: "test", Kind: JobRetryKindAutomatic},
+		{ID: 4, Name: "unit", Stage: "test", Kind: JobRetryKindManual},
+	}, attempts.Retries())
+
+	assert.Equal(t, map[[2]string]int{
+		{"build", "build"}: 2,
+		{"test", "unit"}:   3,
+	}, attempts.AttemptsToSucceed())
+}
+
+func TestRetriedJobsContains(t *testing.T) {
+	rj := RetriedJobs{1: {3, 4}}
+	assert.True(t, rj.Contains(1, 4))
+	assert.False(t, rj.Contains(1, 5))
+	assert.False(t, rj.Contains(2, 4))
+}
+
+func TestRetriedJobsWith(t *testing.T) {
+	rj := RetriedJobs{1: {3}, 2: {5}}
+
+	assert.Equal(t, RetriedJobs{1: {3}, 2: {5, 6}}, rj.With(2, []int64{5, 6}, 2))
+	assert.Equal(t, RetriedJobs{2: {5}, 3: nil}, rj.With(3, nil, 2))
+	assert.Equal(t, RetriedJobs{3: {7}}, rj.With(3, []int64{7}, 0))
+
+	// The original retried jobs are left untouched
+	assert.Equal(t, RetriedJobs{1: {3}, 2: {5}}, rj)
+}
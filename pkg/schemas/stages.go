@@ -0,0 +1,86 @@
+package schemas
+
+import "slices"
+
+// stageStatusesSeverity lists the job statuses from the worst to the best one,
+// the status of a stage being the worst one of its jobs.
+var stageStatusesSeverity = []string{
+	"failed",
+	"canceled",
+	"running",
+	"pending",
+	"preparing",
+	"waiting_for_resource",
+	"created",
+	"scheduled",
+	"success_with_warnings",
+	"success",
+	"manual",
+	"skipped",
+}
+
+// Stage holds the state of a pipeline stage, aggregated out of its jobs.
+type Stage struct {
+	Name                string
+	Status              string
+	StartedTimestamp    float64
+	FinishedTimestamp   float64
+	JobsDurationSeconds float64
+	JobCount            int
+}
+
+// Stages ..
+type Stages map[string]Stage
+
+// DurationSeconds returns the wall-clock duration of the stage, from the
+// start of its first job to the end of its last one.
+func (s Stage) DurationSeconds() float64 {
+	return s.FinishedTimestamp - s.StartedTimestamp
+}
+
+// NewStages aggregates the jobs by stage.
+func NewStages(jobs []Job) Stages {
+	stages := make(Stages)
+
+	for _, job := range jobs {
+		s, exists := stages[job.Stage]
+		s.Name = job.Stage
+		s.JobCount++
+		s.JobsDurationSeconds += job.DurationSeconds
+
+		status := job.Status
+		if status == "failed" && job.AllowFailure {
+			status = "success_with_warnings"
+		}
+
+		if !exists || stageStatusSeverity(status) < stageStatusSeverity(s.Status) {
+			s.Status = status
+		}
+
+		if job.StartedTimestamp > 0 {
+			// Jobs which are still running have been going on for their duration so far
+			finishedTimestamp := job.FinishedTimestamp
+			if finishedTimestamp == 0 {
+				finishedTimestamp = job.StartedTimestamp + job.DurationSeconds
+			}
+
+			if s.StartedTimestamp == 0 || job.StartedTimestamp < s.StartedTimestamp {
+				s.StartedTimestamp = job.StartedTimestamp
+			}
+
+			s.FinishedTimestamp = max(s.FinishedTimestamp, finishedTimestamp)
+		}
+
+		stages[job.Stage] = s
+	}
+
+	return stages
+}
+
+func stageStatusSeverity(status string) int {
+	if i := slices.Index(stageStatusesSeverity, status); i >= 0 {
+		return i
+	}
+
+	return len(stageStatusesSeverity)
+}
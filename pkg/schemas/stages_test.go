@@ -0,0 +1,51 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStages(t *testing.T) {
+	jobs := []Job{
+		{Name: "build", Stage: "build", Status: "success", StartedTimestamp: 100, FinishedTimestamp: 130, DurationSeconds: 30},
+		{Name: "lint", Stage: "test", Status: "failed", AllowFailure: true, StartedTimestamp: 140, FinishedTimestamp: 150, DurationSeconds: 10},
+		{Name: "unit", Stage: "test", Status: "success", StartedTimestamp: 135, FinishedTimestamp: 200, DurationSeconds: 65},
+		{Name: "e2e", Stage: "test", Status: "running", StartedTimestamp: 145, DurationSeconds: 20},
+		{Name: "deploy", Stage: "deploy", Status: "manual"},
+		{Name: "notify", Stage: "deploy", Status: "skipped"},
+	}
+
+	assert.Equal(t, Stages{
+		"build": {
+			Name:                "build",
+			Status:              "success",
+			StartedTimestamp:    100,
+			FinishedTimestamp:   130,
+			JobsDurationSeconds: 30,
+			JobCount:            1,
+		},
+		"test": {
+			Name:                "test",
+			Status:              "running",
+			StartedTimestamp:    135,
+			FinishedTimestamp:   200,
+			JobsDurationSeconds: 95,
+			JobCount:            3,
+		},
+		"deploy": {
+			Name:     "deploy",
+			Status:   "manual",
+			JobCount: 2,
+		},
+	}, NewStages(jobs))
+
+	assert.Equal(t, float64(65), NewStages(jobs)["test"].DurationSeconds())
+
+	// The worst status wins, regardless of the order of the jobs
+	assert.Equal(t, "failed", NewStages([]Job{
+		{Stage: "test", Status: "success"},
+		{Stage: "test", Status: "failed"},
+		{Stage: "test", Status: "canceled"},
+	})["test"].Status)
+}
@@ -25,6 +25,12 @@ const (
 	// TaskTypePullMetrics ..
 	TaskTypePullMetrics TaskType = "PullMetrics"
 
+	// TaskTypeProcessWebhookEvent ..
+	TaskTypeProcessWebhookEvent TaskType = "ProcessWebhookEvent"
+
+	// TaskTypeReconcileMetrics ..
+	TaskTypeReconcileMetrics TaskType = "ReconcileMetrics"
+
 	// TaskTypePullRefsFromProject ..
 	TaskTypePullRefsFromProject TaskType = "PullRefsFromProject"
 
@@ -49,3 +55,49 @@ const (
 
 // Tasks can be used to keep track of tasks.
 type Tasks map[TaskType]map[string]interface{}
+
+// TaskLock represents the claim of a process over a queued task.
+type TaskLock struct {
+	Queue       TaskQueue
+	Type        TaskType
+	UniqueID    string
+	ProcessUUID string
+}
+
+// TaskQueue represents a queue onto which tasks get processed.
+type TaskQueue string
+
+const (
+	// TaskQueuePriority holds the tasks triggered by webhooks, processed ahead of the scheduled ones
+	TaskQueuePriority TaskQueue = "priority"
+
+	// TaskQueueDiscovery holds the discovery of the projects, environments and refs
+	TaskQueueDiscovery TaskQueue = "discovery"
+
+	// TaskQueueMetrics holds the pulls of the metrics
+	TaskQueueMetrics TaskQueue = "metrics"
+
+	// TaskQueueGarbageCollect holds the garbage collection tasks
+	TaskQueueGarbageCollect TaskQueue = "garbage_collect"
+)
+
+// Queue returns the queue onto which the scheduled tasks of this type get processed.
+func (tt TaskType) Queue() TaskQueue {
+	switch tt {
+	case TaskTypePullProject,
+		TaskTypePullProjectsFromWildcard,
+		TaskTypePullProjectsFromWildcards,
+		TaskTypePullEnvironmentsFromProject,
+		TaskTypePullEnvironmentsFromProjects,
+		TaskTypePullRefsFromProject,
+		TaskTypePullRefsFromProjects:
+		return TaskQueueDiscovery
+	case TaskTypeGarbageCollectProjects,
+		TaskTypeGarbageCollectEnvironments,
+		TaskTypeGarbageCollectRefs,
+		TaskTypeGarbageCollectMetrics:
+		return TaskQueueGarbageCollect
+	default:
+		return TaskQueueMetrics
+	}
+}
@@ -1 +1,15 @@
 package schemas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTypeQueue(t *testing.T) {
+	assert.Equal(t, TaskQueueDiscovery, TaskTypePullProjectsFromWildcards.Queue())
+	assert.Equal(t, TaskQueueDiscovery, TaskTypePullRefsFromProject.Queue())
+	assert.Equal(t, TaskQueueGarbageCollect, TaskTypeGarbageCollectMetrics.Queue())
+	assert.Equal(t, TaskQueueMetrics, TaskTypePullRefMetrics.Queue())
+	assert.Equal(t, TaskQueueMetrics, TaskTypeReconcileMetrics.Queue())
+}
@@ -0,0 +1,62 @@
+package schemas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// WebhookEventStatus ..
+type WebhookEventStatus string
+
+const (
+	// WebhookEventStatusReceived ..
+	WebhookEventStatusReceived WebhookEventStatus = "received"
+
+	// WebhookEventStatusProcessed ..
+	WebhookEventStatusProcessed WebhookEventStatus = "processed"
+
+	// WebhookEventStatusFailed ..
+	WebhookEventStatusFailed WebhookEventStatus = "failed"
+
+	// WebhookEventStatusDropped ..
+	WebhookEventStatusDropped WebhookEventStatus = "dropped"
+)
+
+// WebhookEvent holds a webhook payload received from GitLab.
+type WebhookEvent struct {
+	// Idempotency key of the event, identical across the delivery attempts of the same event
+	Key string
+
+	// Value of the X-Gitlab-Event header
+	Type string
+
+	Payload []byte
+
+	// Unix timestamp at which the event has been received
+	ReceivedAt int64
+
+	// Last processing error, if any
+	Error string
+}
+
+// WebhookEvents ..
+type WebhookEvents map[string]WebhookEvent
+
+// WebhookEventsCountKey ..
+type WebhookEventsCountKey struct {
+	Type   string
+	Status WebhookEventStatus
+}
+
+// WebhookEventsCount ..
+type WebhookEventsCount map[WebhookEventsCountKey]uint64
+
+// NewWebhookEventKey returns a key computed out of the content of the
+// event, to be used when GitLab did not provide one.
+func NewWebhookEventKey(eventType string, payload []byte) string {
+	h := sha256.New()
+	h.Write([]byte(eventType))
+	h.Write(payload)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+)
+
+// Middleware ..
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies the middlewares onto the handler, the first one being the outermost.
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
+	return h
+}
+
+// AllowCIDRs returns a middleware rejecting the requests
+// which are not originating from one of the given CIDRs.
+// If no CIDR is provided, all requests are allowed.
+func AllowCIDRs(cidrs []string) (Middleware, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR '%s': %w", cidr, err)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(prefixes) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isAddrAllowed(r.RemoteAddr, prefixes) {
+				log.WithFields(log.Fields{
+					"remote-addr": r.RemoteAddr,
+					"path":        r.URL.Path,
+				}).Debug("request rejected, remote address not allowed")
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func isAddrAllowed(remoteAddr string, prefixes []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	addr = addr.Unmap()
+
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireClientCertificate returns a middleware rejecting the requests which
+// were not made using a verified client certificate, if required.
+func RequireClientCertificate(required bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !required {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				log.WithFields(log.Fields{
+					"remote-addr": r.RemoteAddr,
+					"path":        r.URL.Path,
+				}).Debug("request rejected, no verified client certificate")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authenticate returns a middleware which requires the requests to be
+// authenticated using either basic auth or a bearer token, if configured.
+func Authenticate(cfg config.ServerAuth) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isAuthenticated(r, cfg) {
+				if cfg.Basic.Username != "" {
+					w.Header().Set("WWW-Authenticate", `Basic realm="gitlab-ci-pipelines-exporter"`)
+				}
+
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAuthenticated(r *http.Request, cfg config.ServerAuth) bool {
+	if cfg.Basic.Username != "" {
+		if username, password, ok := r.BasicAuth(); ok &&
+			secureCompare(username, cfg.Basic.Username) &&
+			secureCompare(password, cfg.Basic.Password) {
+			return true
+		}
+	}
+
+	if cfg.BearerToken != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok &&
+			secureCompare(token, cfg.BearerToken) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func secureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
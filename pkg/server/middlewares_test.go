@@ -0,0 +1,132 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestAllowCIDRs(t *testing.T) {
+	_, err := AllowCIDRs([]string{"foo"})
+	assert.Error(t, err)
+
+	m, err := AllowCIDRs([]string{"10.0.0.0/8", "2001:db8::/32"})
+	assert.NoError(t, err)
+
+	h := Chain(okHandler, m)
+
+	for remoteAddr, expectedStatus := range map[string]int{
+		"10.1.2.3:1234":             http.StatusOK,
+		"[::ffff:10.1.2.3]:1234":    http.StatusOK,
+		"[2001:db8::1]:1234":        http.StatusOK,
+		"192.168.0.1:1234":          http.StatusForbidden,
+		"[2001:db9::1]:1234":        http.StatusForbidden,
+		"not-an-ip":                 http.StatusForbidden,
+		"127.0.0.1":                 http.StatusForbidden,
+		"[fe80::1%eth0]:1234":       http.StatusForbidden,
+		"[::ffff:192.168.1.1]:1234": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, expectedStatus, w.Result().StatusCode, remoteAddr)
+	}
+
+	// No CIDRs, everything should be allowed
+	m, err = AllowCIDRs(nil)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.RemoteAddr = "192.168.0.1:1234"
+	w := httptest.NewRecorder()
+	Chain(okHandler, m).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestAuthenticate(t *testing.T) {
+	h := Chain(okHandler, Authenticate(config.ServerAuth{
+		Basic: config.ServerAuthBasic{
+			Username: "foo",
+			Password: "bar",
+		},
+		BearerToken: "secret",
+	}))
+
+	type testCase struct {
+		setAuth        func(r *http.Request)
+		expectedStatus int
+	}
+
+	for name, tc := range map[string]testCase{
+		"no credentials": {
+			setAuth:        func(_ *http.Request) {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"valid basic auth": {
+			setAuth:        func(r *http.Request) { r.SetBasicAuth("foo", "bar") },
+			expectedStatus: http.StatusOK,
+		},
+		"invalid basic auth": {
+			setAuth:        func(r *http.Request) { r.SetBasicAuth("foo", "baz") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"valid bearer token": {
+			setAuth:        func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") },
+			expectedStatus: http.StatusOK,
+		},
+		"invalid bearer token": {
+			setAuth:        func(r *http.Request) { r.Header.Set("Authorization", "Bearer foo") },
+			expectedStatus: http.StatusUnauthorized,
+		},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		tc.setAuth(req)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedStatus, w.Result().StatusCode, name)
+	}
+
+	// No auth configured, everything should be allowed
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	Chain(okHandler, Authenticate(config.ServerAuth{})).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
+
+func TestRequireClientCertificate(t *testing.T) {
+	h := Chain(okHandler, RequireClientCertificate(true))
+
+	for name, tc := range map[string]struct {
+		state          *tls.ConnectionState
+		expectedStatus int
+	}{
+		"plain http":                  {nil, http.StatusUnauthorized},
+		"no client certificate":       {&tls.ConnectionState{}, http.StatusUnauthorized},
+		"verified client certificate": {&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.TLS = tc.state
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedStatus, w.Result().StatusCode, name)
+	}
+
+	// Not required, everything should be allowed
+	req := httptest.NewRequest(http.MethodGet, "/webhook", nil)
+	w := httptest.NewRecorder()
+	Chain(okHandler, RequireClientCertificate(false)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+)
+
+// CertificateReloader serves TLS certificates and reloads them
+// from the disk whenever the underlying files are updated.
+type CertificateReloader struct {
+	cfg            config.ServerTLS
+	reloadInterval time.Duration
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+	modTimes    map[string]time.Time
+	lastCheck   time.Time
+}
+
+// NewCertificateReloader loads the certificates and returns a reloader.
+func NewCertificateReloader(cfg config.ServerTLS) (*CertificateReloader, error) {
+	cr := &CertificateReloader{
+		cfg:            cfg,
+		reloadInterval: time.Duration(cfg.ReloadIntervalSeconds) * time.Second,
+		modTimes:       make(map[string]time.Time),
+	}
+
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// TLSConfig returns a TLS configuration leveraging the reloader.
+func (cr *CertificateReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cr.reloadIfChanged()
+
+			cr.mutex.RLock()
+			defer cr.mutex.RUnlock()
+
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*cr.certificate},
+			}
+
+			// GitLab does not present any certificate when delivering webhooks, neither do most probes
+			// hitting the health endpoints: the certificate is verified when given and then required
+			// per endpoint using the RequireClientCertificate middleware
+			if cr.clientCAs != nil {
+				cfg.ClientCAs = cr.clientCAs
+				cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+
+			return cfg, nil
+		},
+	}
+}
+
+func (cr *CertificateReloader) files() (files []string) {
+	files = []string{cr.cfg.CertFile, cr.cfg.KeyFile}
+	if cr.cfg.ClientCAFile != "" {
+		files = append(files, cr.cfg.ClientCAFile)
+	}
+
+	return
+}
+
+// reloadIfChanged reloads the files if one of them got modified since the last load.
+// To avoid stat'ing the files on every handshake, checks are done at most once per reload interval.
+func (cr *CertificateReloader) reloadIfChanged() {
+	cr.mutex.Lock()
+	if time.Since(cr.lastCheck) < cr.reloadInterval {
+		cr.mutex.Unlock()
+
+		return
+	}
+
+	cr.lastCheck = time.Now()
+
+	changed := false
+
+	for _, f := range cr.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			log.WithError(err).WithField("file", f).Warn("unable to stat TLS file, keeping the currently loaded certificates")
+			cr.mutex.Unlock()
+
+			return
+		}
+
+		if !fi.ModTime().Equal(cr.modTimes[f]) {
+			changed = true
+		}
+	}
+	cr.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := cr.load(); err != nil {
+		log.WithError(err).Warn("unable to reload TLS certificates, keeping the currently loaded ones")
+
+		return
+	}
+
+	log.Info("reloaded TLS certificates")
+}
+
+func (cr *CertificateReloader) load() error {
+	modTimes := make(map[string]time.Time)
+
+	for _, f := range cr.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+
+		modTimes[f] = fi.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(cr.cfg.CertFile, cr.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+
+	if cr.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cr.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading client CA file: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificate found in client CA file '%s'", cr.cfg.ClientCAFile)
+		}
+	}
+
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+
+	cr.certificate = &cert
+	cr.clientCAs = clientCAs
+	cr.modTimes = modTimes
+	cr.lastCheck = time.Now()
+
+	return nil
+}
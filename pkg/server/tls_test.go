@@ -0,0 +1,157 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/config"
+)
+
+func writeTestCertificate(t *testing.T, dir, commonName string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return
+}
+
+func leafCommonName(t *testing.T, cr *CertificateReloader) string {
+	cfg, err := cr.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Len(t, cfg.Certificates, 1)
+
+	leaf, err := x509.ParseCertificate(cfg.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+
+	return leaf.Subject.CommonName
+}
+
+func TestNewCertificateReloader(t *testing.T) {
+	_, err := NewCertificateReloader(config.ServerTLS{
+		CertFile: "/does/not/exist",
+		KeyFile:  "/does/not/exist",
+	})
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "foo")
+
+	cr, err := NewCertificateReloader(config.ServerTLS{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: certFile,
+	})
+	require.NoError(t, err)
+
+	cfg, err := cr.TLSConfig().GetConfigForClient(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, cfg.ClientAuth)
+	assert.NotNil(t, cfg.ClientCAs)
+}
+
+func TestCertificateReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "foo")
+
+	cr, err := NewCertificateReloader(config.ServerTLS{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", leafCommonName(t, cr))
+
+	// Rotate the certificate
+	writeTestCertificate(t, dir, "bar")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	// The reload interval not being reached, we should still serve the previous one
+	cr.reloadInterval = time.Hour
+	assert.Equal(t, "foo", leafCommonName(t, cr))
+
+	cr.reloadInterval = 0
+	assert.Equal(t, "bar", leafCommonName(t, cr))
+}
+
+func TestCertificateReloaderClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "foo")
+
+	cr, err := NewCertificateReloader(config.ServerTLS{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: certFile,
+	})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Chain(okHandler, RequireClientCertificate(true)))
+	mux.Handle("/webhook", okHandler)
+
+	srv := httptest.NewUnstartedServer(mux)
+	srv.TLS = cr.TLSConfig()
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		certificates   []tls.Certificate
+		path           string
+		expectedStatus int
+	}{
+		"webhook without client certificate": {nil, "/webhook", http.StatusOK},
+		"metrics without client certificate": {nil, "/metrics", http.StatusUnauthorized},
+		"metrics with client certificate":    {[]tls.Certificate{cert}, "/metrics", http.StatusOK},
+	} {
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					MinVersion:         tls.VersionTLS12,
+					Certificates:       tc.certificates,
+					InsecureSkipVerify: true, //nolint:gosec
+				},
+			},
+		}
+
+		resp, err := client.Get(srv.URL + tc.path)
+		require.NoError(t, err, name)
+		_ = resp.Body.Close()
+		assert.Equal(t, tc.expectedStatus, resp.StatusCode, name)
+	}
+}
@@ -1,8 +1,12 @@
 package store
 
 import (
+	"cmp"
 	"context"
+	"hash/fnv"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 )
@@ -16,9 +20,11 @@ type Local struct {
 	environmentsMutex sync.RWMutex
 
 	refs      schemas.Refs
+	refsIndex hashIndex[schemas.RefKey]
 	refsMutex sync.RWMutex
 
 	metrics      schemas.Metrics
+	metricsIndex hashIndex[schemas.MetricKey]
 	metricsMutex sync.RWMutex
 
 	pipelines      schemas.Pipelines
@@ -27,9 +33,109 @@ type Local struct {
 	pipelineVariables      map[schemas.PipelineKey]string
 	pipelineVariablesMutex sync.RWMutex
 
+	webhookEvents      map[schemas.ProjectKey]time.Time
+	webhookEventsMutex sync.RWMutex
+
+	failedWebhookEvents      schemas.WebhookEvents
+	failedWebhookEventsMutex sync.RWMutex
+
+	webhookEventsCount      schemas.WebhookEventsCount
+	webhookEventsCountMutex sync.Mutex
+
+	metricsPulls      schemas.MetricsPulls
+	metricsPullsMutex sync.RWMutex
+
 	tasks              schemas.Tasks
 	tasksMutex         sync.RWMutex
 	executedTasksCount uint64
+
+	gcCursors      map[string]schemas.GarbageCollectionCursor
+	gcCursorsMutex sync.RWMutex
+}
+
+// hashIndexEntry ..
+type hashIndexEntry[K ~string] struct {
+	hash uint64
+	key  K
+}
+
+// hashIndex keeps the keys of a map ordered by their hash in order to scan them in batches. As with
+// Redis, the entries which are present throughout a whole scan get returned regardless of the others
+// being added or deleted in the meantime.
+type hashIndex[K ~string] struct {
+	entries []hashIndexEntry[K]
+}
+
+func keyHash[K ~string](k K) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(k))
+
+	return h.Sum64()
+}
+
+func compareHashIndexEntries[K ~string](a, b hashIndexEntry[K]) int {
+	if c := cmp.Compare(a.hash, b.hash); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(a.key, b.key)
+}
+
+// add indexes the key, if it is not already.
+func (idx *hashIndex[K]) add(k K) {
+	e := hashIndexEntry[K]{hash: keyHash(k), key: k}
+
+	if i, found := slices.BinarySearchFunc(idx.entries, e, compareHashIndexEntries[K]); !found {
+		idx.entries = slices.Insert(idx.entries, i, e)
+	}
+}
+
+// remove drops the key from the index, if it is indexed.
+func (idx *hashIndex[K]) remove(k K) {
+	e := hashIndexEntry[K]{hash: keyHash(k), key: k}
+
+	if i, found := slices.BinarySearchFunc(idx.entries, e, compareHashIndexEntries[K]); found {
+		idx.entries = slices.Delete(idx.entries, i, i+1)
+	}
+}
+
+// scan returns up to count keys along with the cursor to provide in order to get the next ones,
+// 0 once they have all been returned.
+func (idx *hashIndex[K]) scan(cursor uint64, count int64) (keys []K, next uint64) {
+	count = max(count, 1)
+
+	i, _ := slices.BinarySearchFunc(idx.entries, cursor, func(e hashIndexEntry[K], cursor uint64) int {
+		return cmp.Compare(e.hash, cursor)
+	})
+
+	for ; i < len(idx.entries); i++ {
+		// Entries sharing the same hash are returned together
+		if int64(len(keys)) >= count && idx.entries[i].hash != idx.entries[i-1].hash {
+			return keys, idx.entries[i].hash
+		}
+
+		keys = append(keys, idx.entries[i].key)
+	}
+
+	return keys, 0
+}
+
+// SetGarbageCollectionCursor ..
+func (l *Local) SetGarbageCollectionCursor(_ context.Context, entity string, cursor schemas.GarbageCollectionCursor) error {
+	l.gcCursorsMutex.Lock()
+	defer l.gcCursorsMutex.Unlock()
+
+	l.gcCursors[entity] = cursor
+
+	return nil
+}
+
+// GarbageCollectionCursor ..
+func (l *Local) GarbageCollectionCursor(_ context.Context, entity string) (schemas.GarbageCollectionCursor, error) {
+	l.gcCursorsMutex.RLock()
+	defer l.gcCursorsMutex.RUnlock()
+
+	return l.gcCursors[entity], nil
 }
 
 // HasProjectExpired ..
@@ -64,6 +170,11 @@ func (l *Local) DelProject(_ context.Context, k schemas.ProjectKey) error {
 
 	delete(l.projects, k)
 
+	l.webhookEventsMutex.Lock()
+	defer l.webhookEventsMutex.Unlock()
+
+	delete(l.webhookEvents, k)
+
 	return nil
 }
 
@@ -112,6 +223,22 @@ func (l *Local) ProjectsCount(_ context.Context) (int64, error) {
 	return int64(len(l.projects)), nil
 }
 
+// ProjectsByKeys returns the stored projects among the given ones.
+func (l *Local) ProjectsByKeys(_ context.Context, keys []schemas.ProjectKey) (projects schemas.Projects, err error) {
+	projects = make(schemas.Projects, len(keys))
+
+	l.projectsMutex.RLock()
+	defer l.projectsMutex.RUnlock()
+
+	for _, k := range keys {
+		if p, ok := l.projects[k]; ok {
+			projects[k] = p
+		}
+	}
+
+	return
+}
+
 // SetEnvironment ..
 func (l *Local) SetEnvironment(_ context.Context, environment schemas.Environment) error {
 	l.environmentsMutex.Lock()
@@ -123,13 +250,13 @@ func (l *Local) SetEnvironment(_ context.Context, environment schemas.Environmen
 }
 
 // DelEnvironment ..
-func (l *Local) DelEnvironment(_ context.Context, k schemas.EnvironmentKey) error {
+func (l *Local) DelEnvironment(ctx context.Context, k schemas.EnvironmentKey) error {
 	l.environmentsMutex.Lock()
-	defer l.environmentsMutex.Unlock()
 
 	delete(l.environments, k)
+	l.environmentsMutex.Unlock()
 
-	return nil
+	return l.DelMetricsPull(ctx, k.MetricsPullKey())
 }
 
 // GetEnvironment ..
@@ -177,22 +304,65 @@ func (l *Local) EnvironmentsCount(_ context.Context) (int64, error) {
 	return int64(len(l.environments)), nil
 }
 
+// EnvironmentsByKeys returns the stored environments among the given ones.
+func (l *Local) EnvironmentsByKeys(_ context.Context, keys []schemas.EnvironmentKey) (environments schemas.Environments, err error) {
+	environments = make(schemas.Environments, len(keys))
+
+	l.environmentsMutex.RLock()
+	defer l.environmentsMutex.RUnlock()
+
+	for _, k := range keys {
+		if env, ok := l.environments[k]; ok {
+			environments[k] = env
+		}
+	}
+
+	return
+}
+
 // SetRef ..
 func (l *Local) SetRef(_ context.Context, ref schemas.Ref) error {
 	l.refsMutex.Lock()
 	defer l.refsMutex.Unlock()
 
 	l.refs[ref.Key()] = ref
+	l.refsIndex.add(ref.Key())
 
 	return nil
 }
 
 // DelRef ..
-func (l *Local) DelRef(_ context.Context, k schemas.RefKey) error {
+func (l *Local) DelRef(ctx context.Context, k schemas.RefKey) error {
 	l.refsMutex.Lock()
-	defer l.refsMutex.Unlock()
-
 	delete(l.refs, k)
+	l.refsIndex.remove(k)
+	l.refsMutex.Unlock()
+
+	return l.DelMetricsPull(ctx, k.MetricsPullKey())
+}
+
+// ScanRefs ..
+func (l *Local) ScanRefs(_ context.Context, cursor uint64, count int64) (schemas.Refs, uint64, error) {
+	l.refsMutex.RLock()
+	defer l.refsMutex.RUnlock()
+
+	keys, next := l.refsIndex.scan(cursor, count)
+
+	refs := make(schemas.Refs, len(keys))
+	for _, k := range keys {
+		refs[k] = l.refs[k]
+	}
+
+	return refs, next, nil
+}
+
+// DelRefs ..
+func (l *Local) DelRefs(ctx context.Context, keys []schemas.RefKey) error {
+	for _, k := range keys {
+		if err := l.DelRef(ctx, k); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -242,12 +412,29 @@ func (l *Local) RefsCount(_ context.Context) (int64, error) {
 	return int64(len(l.refs)), nil
 }
 
+// RefsByKeys returns the stored refs among the given ones.
+func (l *Local) RefsByKeys(_ context.Context, keys []schemas.RefKey) (refs schemas.Refs, err error) {
+	refs = make(schemas.Refs, len(keys))
+
+	l.refsMutex.RLock()
+	defer l.refsMutex.RUnlock()
+
+	for _, k := range keys {
+		if ref, ok := l.refs[k]; ok {
+			refs[k] = ref
+		}
+	}
+
+	return
+}
+
 // SetMetric ..
 func (l *Local) SetMetric(_ context.Context, m schemas.Metric) error {
 	l.metricsMutex.Lock()
 	defer l.metricsMutex.Unlock()
 
 	l.metrics[m.Key()] = m
+	l.metricsIndex.add(m.Key())
 
 	return nil
 }
@@ -258,6 +445,35 @@ func (l *Local) DelMetric(_ context.Context, k schemas.MetricKey) error {
 	defer l.metricsMutex.Unlock()
 
 	delete(l.metrics, k)
+	l.metricsIndex.remove(k)
+
+	return nil
+}
+
+// ScanMetrics ..
+func (l *Local) ScanMetrics(_ context.Context, cursor uint64, count int64) (schemas.Metrics, uint64, error) {
+	l.metricsMutex.RLock()
+	defer l.metricsMutex.RUnlock()
+
+	keys, next := l.metricsIndex.scan(cursor, count)
+
+	metrics := make(schemas.Metrics, len(keys))
+	for _, k := range keys {
+		metrics[k] = l.metrics[k]
+	}
+
+	return metrics, next, nil
+}
+
+// DelMetrics ..
+func (l *Local) DelMetrics(_ context.Context, keys []schemas.MetricKey) error {
+	l.metricsMutex.Lock()
+	defer l.metricsMutex.Unlock()
+
+	for _, k := range keys {
+		delete(l.metrics, k)
+		l.metricsIndex.remove(k)
+	}
 
 	return nil
 }
@@ -337,6 +553,20 @@ func (l *Local) PipelineExists(_ context.Context, key schemas.PipelineKey) (bool
 	return ok, nil
 }
 
+// Pipelines ..
+func (l *Local) Pipelines(_ context.Context) (pipelines schemas.Pipelines, err error) {
+	pipelines = make(schemas.Pipelines)
+
+	l.pipelinesMutex.RLock()
+	defer l.pipelinesMutex.RUnlock()
+
+	for k, v := range l.pipelines {
+		pipelines[k] = v
+	}
+
+	return
+}
+
 func (l *Local) SetPipelineVariables(_ context.Context, pipeline schemas.Pipeline, variables string) error {
 	l.pipelineVariablesMutex.Lock()
 	defer l.pipelineVariablesMutex.Unlock()
@@ -370,8 +600,175 @@ func (l *Local) PipelineVariablesExists(_ context.Context, pipeline schemas.Pipe
 	return ok, nil
 }
 
+// PipelinesVariables ..
+func (l *Local) PipelinesVariables(_ context.Context) (variables map[schemas.PipelineKey]string, err error) {
+	variables = make(map[schemas.PipelineKey]string)
+
+	l.pipelineVariablesMutex.RLock()
+	defer l.pipelineVariablesMutex.RUnlock()
+
+	for k, v := range l.pipelineVariables {
+		variables[k] = v
+	}
+
+	return
+}
+
+// SetProjectLastWebhookEvent ..
+func (l *Local) SetProjectLastWebhookEvent(_ context.Context, k schemas.ProjectKey, t time.Time) error {
+	l.webhookEventsMutex.Lock()
+	defer l.webhookEventsMutex.Unlock()
+
+	l.webhookEvents[k] = t
+
+	return nil
+}
+
+// GetProjectLastWebhookEvent returns when the last webhook event has been received for
+// the project, zero if none.
+func (l *Local) GetProjectLastWebhookEvent(_ context.Context, k schemas.ProjectKey) (time.Time, error) {
+	l.webhookEventsMutex.RLock()
+	defer l.webhookEventsMutex.RUnlock()
+
+	return l.webhookEvents[k], nil
+}
+
+// SetFailedWebhookEvent ..
+func (l *Local) SetFailedWebhookEvent(_ context.Context, e schemas.WebhookEvent) error {
+	l.failedWebhookEventsMutex.Lock()
+	defer l.failedWebhookEventsMutex.Unlock()
+
+	l.failedWebhookEvents[e.Key] = e
+
+	return nil
+}
+
+// DelFailedWebhookEvent ..
+func (l *Local) DelFailedWebhookEvent(_ context.Context, key string) error {
+	l.failedWebhookEventsMutex.Lock()
+	defer l.failedWebhookEventsMutex.Unlock()
+
+	delete(l.failedWebhookEvents, key)
+
+	return nil
+}
+
+// FailedWebhookEvents ..
+func (l *Local) FailedWebhookEvents(_ context.Context) (events schemas.WebhookEvents, err error) {
+	events = make(schemas.WebhookEvents)
+
+	l.failedWebhookEventsMutex.RLock()
+	defer l.failedWebhookEventsMutex.RUnlock()
+
+	for k, v := range l.failedWebhookEvents {
+		events[k] = v
+	}
+
+	return
+}
+
+// FailedWebhookEventsCount ..
+func (l *Local) FailedWebhookEventsCount(_ context.Context) (int64, error) {
+	l.failedWebhookEventsMutex.RLock()
+	defer l.failedWebhookEventsMutex.RUnlock()
+
+	return int64(len(l.failedWebhookEvents)), nil
+}
+
+// IncrWebhookEventsCount ..
+func (l *Local) IncrWebhookEventsCount(_ context.Context, eventType string, status schemas.WebhookEventStatus) error {
+	l.webhookEventsCountMutex.Lock()
+	defer l.webhookEventsCountMutex.Unlock()
+
+	l.webhookEventsCount[schemas.WebhookEventsCountKey{Type: eventType, Status: status}]++
+
+	return nil
+}
+
+// WebhookEventsCount ..
+func (l *Local) WebhookEventsCount(_ context.Context) (count schemas.WebhookEventsCount, err error) {
+	count = make(schemas.WebhookEventsCount)
+
+	l.webhookEventsCountMutex.Lock()
+	defer l.webhookEventsCountMutex.Unlock()
+
+	for k, v := range l.webhookEventsCount {
+		count[k] = v
+	}
+
+	return
+}
+
+// SetMetricsPull ..
+func (l *Local) SetMetricsPull(_ context.Context, k schemas.MetricsPullKey, mp schemas.MetricsPull) error {
+	l.metricsPullsMutex.Lock()
+	defer l.metricsPullsMutex.Unlock()
+
+	l.metricsPulls[k] = mp
+
+	return nil
+}
+
+// DelMetricsPull ..
+func (l *Local) DelMetricsPull(_ context.Context, k schemas.MetricsPullKey) error {
+	l.metricsPullsMutex.Lock()
+	defer l.metricsPullsMutex.Unlock()
+
+	delete(l.metricsPulls, k)
+
+	return nil
+}
+
+// MetricsPulls ..
+func (l *Local) MetricsPulls(_ context.Context) (mps schemas.MetricsPulls, err error) {
+	mps = make(schemas.MetricsPulls)
+
+	l.metricsPullsMutex.RLock()
+	defer l.metricsPullsMutex.RUnlock()
+
+	for k, v := range l.metricsPulls {
+		mps[k] = v
+	}
+
+	return
+}
+
+// MetricsPullsCount ..
+func (l *Local) MetricsPullsCount(_ context.Context) (int64, error) {
+	l.metricsPullsMutex.RLock()
+	defer l.metricsPullsMutex.RUnlock()
+
+	return int64(len(l.metricsPulls)), nil
+}
+
+// DueMetricsPulls returns the pulls which are due at the given time.
+func (l *Local) DueMetricsPulls(_ context.Context, now time.Time) (due schemas.MetricsPulls, err error) {
+	due = make(schemas.MetricsPulls)
+
+	l.metricsPullsMutex.RLock()
+	defer l.metricsPullsMutex.RUnlock()
+
+	for k, v := range l.metricsPulls {
+		if !v.DueTime.After(now) {
+			due[k] = v
+		}
+	}
+
+	return
+}
+
+// localTaskID returns the ID of the task within the ones of its type, prefixed by the
+// queue when it is not the default one of the task type.
+func localTaskID(q schemas.TaskQueue, tt schemas.TaskType, uniqueID string) string {
+	if q != tt.Queue() {
+		return string(q) + ":" + uniqueID
+	}
+
+	return uniqueID
+}
+
 // isTaskAlreadyQueued assess if a task is already queued or not.
-func (l *Local) isTaskAlreadyQueued(tt schemas.TaskType, uniqueID string) bool {
+func (l *Local) isTaskAlreadyQueued(tt schemas.TaskType, taskID string) bool {
 	l.tasksMutex.Lock()
 	defer l.tasksMutex.Unlock()
 
@@ -386,7 +783,7 @@ func (l *Local) isTaskAlreadyQueued(tt schemas.TaskType, uniqueID string) bool {
 		return false
 	}
 
-	if _, alreadyQueued := taskTypeQueue[uniqueID]; alreadyQueued {
+	if _, alreadyQueued := taskTypeQueue[taskID]; alreadyQueued {
 		return true
 	}
 
@@ -395,12 +792,14 @@ func (l *Local) isTaskAlreadyQueued(tt schemas.TaskType, uniqueID string) bool {
 
 // QueueTask registers that we are queueing the task.
 // It returns true if it managed to schedule it, false if it was already scheduled.
-func (l *Local) QueueTask(_ context.Context, tt schemas.TaskType, uniqueID, _ string) (bool, error) {
-	if !l.isTaskAlreadyQueued(tt, uniqueID) {
+func (l *Local) QueueTask(_ context.Context, q schemas.TaskQueue, tt schemas.TaskType, uniqueID, _ string) (bool, error) {
+	taskID := localTaskID(q, tt, uniqueID)
+
+	if !l.isTaskAlreadyQueued(tt, taskID) {
 		l.tasksMutex.Lock()
 		defer l.tasksMutex.Unlock()
 
-		l.tasks[tt][uniqueID] = nil
+		l.tasks[tt][taskID] = nil
 
 		return true, nil
 	}
@@ -409,12 +808,14 @@ func (l *Local) QueueTask(_ context.Context, tt schemas.TaskType, uniqueID, _ st
 }
 
 // UnqueueTask removes the task from the tracker.
-func (l *Local) UnqueueTask(_ context.Context, tt schemas.TaskType, uniqueID string) error {
-	if l.isTaskAlreadyQueued(tt, uniqueID) {
+func (l *Local) UnqueueTask(_ context.Context, q schemas.TaskQueue, tt schemas.TaskType, uniqueID string) error {
+	taskID := localTaskID(q, tt, uniqueID)
+
+	if l.isTaskAlreadyQueued(tt, taskID) {
 		l.tasksMutex.Lock()
 		defer l.tasksMutex.Unlock()
 
-		delete(l.tasks[tt], uniqueID)
+		delete(l.tasks[tt], taskID)
 
 		l.executedTasksCount++
 	}
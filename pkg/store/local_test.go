@@ -1,7 +1,10 @@
 package store
 
 import (
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
@@ -9,6 +12,47 @@ import (
 	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
 )
 
+func TestHashIndex(t *testing.T) {
+	idx := hashIndex[schemas.RefKey]{}
+
+	for i := range 25 {
+		idx.add(schemas.RefKey(fmt.Sprintf("ref-%d", i)))
+	}
+
+	// Adding a key twice does not index it twice
+	idx.add("ref-0")
+	assert.Len(t, idx.entries, 25)
+	assert.True(t, slices.IsSortedFunc(idx.entries, compareHashIndexEntries[schemas.RefKey]))
+
+	idx.remove("ref-0")
+	idx.remove("ref-0")
+	assert.Len(t, idx.entries, 24)
+
+	var (
+		cursor  uint64
+		scanned []schemas.RefKey
+		batches int
+	)
+
+	for {
+		keys, next := idx.scan(cursor, 10)
+		scanned = append(scanned, keys...)
+		batches++
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	assert.Equal(t, 3, batches)
+	assert.Len(t, scanned, 24)
+	assert.NotContains(t, scanned, schemas.RefKey("ref-0"))
+
+	keys, next := (&hashIndex[schemas.RefKey]{}).scan(0, 10)
+	assert.Empty(t, keys)
+	assert.Equal(t, uint64(0), next)
+}
+
 func TestLocalProjectFunctions(t *testing.T) {
 	p := schemas.NewProject("foo/bar")
 	p.OutputSparseStatusMetrics = false
@@ -224,48 +268,165 @@ func TestLocalMetricFunctions(t *testing.T) {
 
 func TestLocalQueueTask(t *testing.T) {
 	l := NewLocalStore()
-	ok, err := l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
+	ok, err := l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
 	assert.True(t, ok)
 	assert.NoError(t, err)
 
-	ok, err = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
+	ok, err = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
 	assert.False(t, ok)
 	assert.NoError(t, err)
 
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "bar", "")
-	ok, err = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "bar", "")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "bar", "")
+	ok, err = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "bar", "")
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	// The tasks are deduplicated per queue
+	ok, err = l.QueueTask(testCtx, schemas.TaskQueuePriority, schemas.TaskTypePullMetrics, "foo", "")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	assert.NoError(t, l.UnqueueTask(testCtx, schemas.TaskQueuePriority, schemas.TaskTypePullMetrics, "foo"))
+
+	ok, err = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
 	assert.False(t, ok)
 	assert.NoError(t, err)
 }
 
 func TestLocalUnqueueTask(t *testing.T) {
 	l := NewLocalStore()
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
 	assert.Equal(t, uint64(0), l.(*Local).executedTasksCount)
-	assert.NoError(t, l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo"))
+	assert.NoError(t, l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo"))
 	assert.Equal(t, uint64(1), l.(*Local).executedTasksCount)
 }
 
 func TestLocalCurrentlyQueuedTasksCount(t *testing.T) {
 	l := NewLocalStore()
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "bar", "")
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "baz", "")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "bar", "")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "baz", "")
 
 	count, _ := l.CurrentlyQueuedTasksCount(testCtx)
 	assert.Equal(t, uint64(3), count)
-	assert.NoError(t, l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo"))
+	assert.NoError(t, l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo"))
 	count, _ = l.CurrentlyQueuedTasksCount(testCtx)
 	assert.Equal(t, uint64(2), count)
 }
 
 func TestLocalExecutedTasksCount(t *testing.T) {
 	l := NewLocalStore()
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
-	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics, "bar", "")
-	_ = l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo")
-	_ = l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
+	_, _ = l.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "bar", "")
+	_ = l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo")
+	_ = l.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo")
 
 	count, _ := l.ExecutedTasksCount(testCtx)
 	assert.Equal(t, uint64(1), count)
 }
+
+func TestLocalProjectLastWebhookEvent(t *testing.T) {
+	l := NewLocalStore()
+	p := schemas.NewProject("foo/bar")
+
+	ts, err := l.GetProjectLastWebhookEvent(testCtx, p.Key())
+	assert.NoError(t, err)
+	assert.True(t, ts.IsZero())
+
+	now := time.Unix(time.Now().Unix(), 0)
+	assert.NoError(t, l.SetProjectLastWebhookEvent(testCtx, p.Key(), now))
+
+	ts, err = l.GetProjectLastWebhookEvent(testCtx, p.Key())
+	assert.NoError(t, err)
+	assert.Equal(t, now, ts)
+
+	// Deleting the project should also remove the webhook event tracking
+	assert.NoError(t, l.DelProject(testCtx, p.Key()))
+
+	ts, err = l.GetProjectLastWebhookEvent(testCtx, p.Key())
+	assert.NoError(t, err)
+	assert.True(t, ts.IsZero())
+}
+
+func TestLocalFailedWebhookEvents(t *testing.T) {
+	l := NewLocalStore()
+
+	e := schemas.WebhookEvent{
+		Key:     "foo",
+		Type:    "Pipeline Hook",
+		Payload: []byte(`{"object_kind":"pipeline"}`),
+		Error:   "boom",
+	}
+	assert.NoError(t, l.SetFailedWebhookEvent(testCtx, e))
+
+	events, err := l.FailedWebhookEvents(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.WebhookEvents{"foo": e}, events)
+
+	count, err := l.FailedWebhookEventsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, l.DelFailedWebhookEvent(testCtx, "foo"))
+
+	count, err = l.FailedWebhookEventsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestLocalWebhookEventsCount(t *testing.T) {
+	l := NewLocalStore()
+
+	assert.NoError(t, l.IncrWebhookEventsCount(testCtx, "Pipeline Hook", schemas.WebhookEventStatusReceived))
+	assert.NoError(t, l.IncrWebhookEventsCount(testCtx, "Pipeline Hook", schemas.WebhookEventStatusReceived))
+	assert.NoError(t, l.IncrWebhookEventsCount(testCtx, "Job Hook", schemas.WebhookEventStatusDropped))
+
+	count, err := l.WebhookEventsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.WebhookEventsCount{
+		{Type: "Pipeline Hook", Status: schemas.WebhookEventStatusReceived}: 2,
+		{Type: "Job Hook", Status: schemas.WebhookEventStatusDropped}:       1,
+	}, count)
+}
+
+func TestLocalMetricsPulls(t *testing.T) {
+	l := NewLocalStore()
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	env := schemas.Environment{ProjectName: "foo/bar", Name: "prod"}
+
+	now := time.Unix(time.Now().Unix(), 0)
+	refPull := schemas.MetricsPull{DueTime: now, IntervalSeconds: 30}
+	envPull := schemas.MetricsPull{DueTime: now.Add(time.Minute), IntervalSeconds: 60}
+
+	assert.NoError(t, l.SetMetricsPull(testCtx, ref.Key().MetricsPullKey(), refPull))
+	assert.NoError(t, l.SetMetricsPull(testCtx, env.Key().MetricsPullKey(), envPull))
+
+	mps, err := l.MetricsPulls(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.MetricsPulls{
+		ref.Key().MetricsPullKey(): refPull,
+		env.Key().MetricsPullKey(): envPull,
+	}, mps)
+
+	count, err := l.MetricsPullsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// Only the pull of the ref is due, the one of the environment is due later on
+	due, err := l.DueMetricsPulls(testCtx, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.MetricsPulls{ref.Key().MetricsPullKey(): refPull}, due)
+
+	due, err = l.DueMetricsPulls(testCtx, now.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Deleting the ref or the environment should also remove them from the queue
+	assert.NoError(t, l.DelRef(testCtx, ref.Key()))
+	assert.NoError(t, l.DelEnvironment(testCtx, env.Key()))
+
+	mps, err = l.MetricsPulls(testCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, mps)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,26 +14,59 @@ import (
 )
 
 const (
-	redisProjectsKey           string = `projects`
-	redisEnvironmentsKey       string = `environments`
-	redisRefsKey               string = `refs`
-	redisMetricsKey            string = `metrics`
-	redisPipelinesKey          string = `pipelines`
-	redisPipelineVariablesKey  string = `pipelineVariables`
-	redisTaskKey               string = `task`
-	redisTasksExecutedCountKey string = `tasksExecutedCount`
-	redisKeepaliveKey          string = `keepalive`
+	redisProjectsKey             string = `projects`
+	redisEnvironmentsKey         string = `environments`
+	redisRefsKey                 string = `refs`
+	redisMetricsKey              string = `metrics`
+	redisPipelinesKey            string = `pipelines`
+	redisPipelineVariablesKey    string = `pipelineVariables`
+	redisTaskKey                 string = `task`
+	redisTasksExecutedCountKey   string = `tasksExecutedCount`
+	redisKeepaliveKey            string = `keepalive`
+	redisLeaderKey               string = `leader`
+	redisWebhookEventsKey        string = `projectsWebhookEvents`
+	redisFailedWebhookEventsKey  string = `failedWebhookEvents`
+	redisWebhookEventsCountKey   string = `webhookEventsCount`
+	redisMetricsPullDueTimesKey  string = `metricsPullDueTimes`
+	redisMetricsPullIntervalsKey string = `metricsPullIntervals`
+	redisGCCursorsKey            string = `garbageCollectionCursors`
+)
+
+var (
+	// Renews the lease only if it is still owned by the provided process UUID
+	redisRenewLeaderLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+	// Deletes the key only if it is still owned by the provided process UUID
+	redisCompareAndDeleteScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
 )
 
 // Redis ..
 type Redis struct {
-	*redis.Client
+	redis.UniversalClient
 	StoreConfig *RedisStoreConfig
 }
 
 // RedisStoreConfig allows to fine tune the store behaviour.
 type RedisStoreConfig struct {
 	TTLConfig *RedisTTLConfig
+
+	// HashTag gets prepended to all the keys in order to get them
+	// assigned to the same slot when running against a Redis Cluster.
+	HashTag string
+
+	// Namespace prefixes all the keys in order to get
+	// several deployments to share the same Redis.
+	Namespace string
 }
 
 // RedisTTLConfig allows to set the TTL values for the various fields tracked.
@@ -48,8 +82,52 @@ func WithTTLConfig(opt *RedisTTLConfig) func(*RedisStoreConfig) {
 	}
 }
 
+// WithHashTag ..
+func WithHashTag(tag string) func(*RedisStoreConfig) {
+	return func(cfg *RedisStoreConfig) {
+		cfg.HashTag = tag
+	}
+}
+
+// WithNamespace ..
+func WithNamespace(namespace string) func(*RedisStoreConfig) {
+	return func(cfg *RedisStoreConfig) {
+		cfg.Namespace = namespace
+	}
+}
+
 type RedisStoreOptions func(opts *RedisStoreConfig)
 
+// key returns the name under which the key is stored, prefixed with the namespace and wrapped
+// into the hash tag if any. Sharing the same slot, the keys can be used together in transactions
+// and scripts.
+func (r *Redis) key(name string) string {
+	if r.StoreConfig.Namespace != "" {
+		name = fmt.Sprintf("%s:%s", r.StoreConfig.Namespace, name)
+	}
+
+	if r.StoreConfig.HashTag == "" {
+		return name
+	}
+
+	return fmt.Sprintf("{%s}:%s", r.StoreConfig.HashTag, name)
+}
+
+// scan iterates over the keys matching the pattern. Against a Redis Cluster, SCAN only
+// covers a single node, we hence target the master owning the slot of the hash tag.
+func (r *Redis) scan(ctx context.Context, pattern string) (*redis.ScanIterator, error) {
+	if cc, ok := r.UniversalClient.(*redis.ClusterClient); ok {
+		node, err := cc.MasterForKey(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return node.Scan(ctx, 0, pattern, 0).Iterator(), nil
+	}
+
+	return r.Scan(ctx, 0, pattern, 0).Iterator(), nil
+}
+
 // SetProject ..
 func (r *Redis) SetProject(ctx context.Context, p schemas.Project) error {
 	marshalledProject, err := msgpack.Marshal(p)
@@ -57,13 +135,13 @@ func (r *Redis) SetProject(ctx context.Context, p schemas.Project) error {
 		return err
 	}
 
-	_, err = r.HSet(ctx, redisProjectsKey, string(p.Key()), marshalledProject).Result()
+	_, err = r.HSet(ctx, r.key(redisProjectsKey), string(p.Key()), marshalledProject).Result()
 	if err != nil {
 		return err
 	}
 
 	if r.StoreConfig.TTLConfig != nil {
-		_, err = r.Set(ctx, getTTLProjectKey(p.Key()), true, r.StoreConfig.TTLConfig.Project).Result()
+		_, err = r.Set(ctx, r.key(getTTLProjectKey(p.Key())), true, r.StoreConfig.TTLConfig.Project).Result()
 	}
 
 	return err
@@ -71,7 +149,11 @@ func (r *Redis) SetProject(ctx context.Context, p schemas.Project) error {
 
 // DelProject ..
 func (r *Redis) DelProject(ctx context.Context, k schemas.ProjectKey) error {
-	_, err := r.HDel(ctx, redisProjectsKey, string(k)).Result()
+	if _, err := r.HDel(ctx, r.key(redisProjectsKey), string(k)).Result(); err != nil {
+		return err
+	}
+
+	_, err := r.HDel(ctx, r.key(redisWebhookEventsKey), string(k)).Result()
 
 	return err
 }
@@ -86,7 +168,7 @@ func (r *Redis) GetProject(ctx context.Context, p *schemas.Project) error {
 	if exists {
 		k := p.Key()
 
-		marshalledProject, err := r.HGet(ctx, redisProjectsKey, string(k)).Result()
+		marshalledProject, err := r.HGet(ctx, r.key(redisProjectsKey), string(k)).Result()
 		if err != nil {
 			return err
 		}
@@ -101,14 +183,14 @@ func (r *Redis) GetProject(ctx context.Context, p *schemas.Project) error {
 
 // ProjectExists ..
 func (r *Redis) ProjectExists(ctx context.Context, k schemas.ProjectKey) (bool, error) {
-	return r.HExists(ctx, redisProjectsKey, string(k)).Result()
+	return r.HExists(ctx, r.key(redisProjectsKey), string(k)).Result()
 }
 
 // Projects ..
 func (r *Redis) Projects(ctx context.Context) (schemas.Projects, error) {
 	projects := schemas.Projects{}
 
-	marshalledProjects, err := r.HGetAll(ctx, redisProjectsKey).Result()
+	marshalledProjects, err := r.HGetAll(ctx, r.key(redisProjectsKey)).Result()
 	if err != nil {
 		return projects, err
 	}
@@ -128,7 +210,43 @@ func (r *Redis) Projects(ctx context.Context) (schemas.Projects, error) {
 
 // ProjectsCount ..
 func (r *Redis) ProjectsCount(ctx context.Context) (int64, error) {
-	return r.HLen(ctx, redisProjectsKey).Result()
+	return r.HLen(ctx, r.key(redisProjectsKey)).Result()
+}
+
+// ProjectsByKeys returns the stored projects among the given ones.
+func (r *Redis) ProjectsByKeys(ctx context.Context, keys []schemas.ProjectKey) (schemas.Projects, error) {
+	projects := schemas.Projects{}
+
+	if len(keys) == 0 {
+		return projects, nil
+	}
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = string(k)
+	}
+
+	values, err := r.HMGet(ctx, r.key(redisProjectsKey), fields...).Result()
+	if err != nil {
+		return projects, err
+	}
+
+	for i, v := range values {
+		marshalledProject, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		p := schemas.Project{}
+
+		if err = msgpack.Unmarshal([]byte(marshalledProject), &p); err != nil {
+			return projects, err
+		}
+
+		projects[keys[i]] = p
+	}
+
+	return projects, nil
 }
 
 // SetEnvironment ..
@@ -138,16 +256,18 @@ func (r *Redis) SetEnvironment(ctx context.Context, e schemas.Environment) error
 		return err
 	}
 
-	_, err = r.HSet(ctx, redisEnvironmentsKey, string(e.Key()), marshalledEnvironment).Result()
+	_, err = r.HSet(ctx, r.key(redisEnvironmentsKey), string(e.Key()), marshalledEnvironment).Result()
 
 	return err
 }
 
 // DelEnvironment ..
 func (r *Redis) DelEnvironment(ctx context.Context, k schemas.EnvironmentKey) error {
-	_, err := r.HDel(ctx, redisEnvironmentsKey, string(k)).Result()
+	if _, err := r.HDel(ctx, r.key(redisEnvironmentsKey), string(k)).Result(); err != nil {
+		return err
+	}
 
-	return err
+	return r.DelMetricsPull(ctx, k.MetricsPullKey())
 }
 
 // GetEnvironment ..
@@ -160,7 +280,7 @@ func (r *Redis) GetEnvironment(ctx context.Context, e *schemas.Environment) erro
 	if exists {
 		k := e.Key()
 
-		marshalledEnvironment, err := r.HGet(ctx, redisEnvironmentsKey, string(k)).Result()
+		marshalledEnvironment, err := r.HGet(ctx, r.key(redisEnvironmentsKey), string(k)).Result()
 		if err != nil {
 			return err
 		}
@@ -175,14 +295,14 @@ func (r *Redis) GetEnvironment(ctx context.Context, e *schemas.Environment) erro
 
 // EnvironmentExists ..
 func (r *Redis) EnvironmentExists(ctx context.Context, k schemas.EnvironmentKey) (bool, error) {
-	return r.HExists(ctx, redisEnvironmentsKey, string(k)).Result()
+	return r.HExists(ctx, r.key(redisEnvironmentsKey), string(k)).Result()
 }
 
 // Environments ..
 func (r *Redis) Environments(ctx context.Context) (schemas.Environments, error) {
 	environments := schemas.Environments{}
 
-	marshalledProjects, err := r.HGetAll(ctx, redisEnvironmentsKey).Result()
+	marshalledProjects, err := r.HGetAll(ctx, r.key(redisEnvironmentsKey)).Result()
 	if err != nil {
 		return environments, err
 	}
@@ -202,7 +322,43 @@ func (r *Redis) Environments(ctx context.Context) (schemas.Environments, error)
 
 // EnvironmentsCount ..
 func (r *Redis) EnvironmentsCount(ctx context.Context) (int64, error) {
-	return r.HLen(ctx, redisEnvironmentsKey).Result()
+	return r.HLen(ctx, r.key(redisEnvironmentsKey)).Result()
+}
+
+// EnvironmentsByKeys returns the stored environments among the given ones.
+func (r *Redis) EnvironmentsByKeys(ctx context.Context, keys []schemas.EnvironmentKey) (schemas.Environments, error) {
+	environments := schemas.Environments{}
+
+	if len(keys) == 0 {
+		return environments, nil
+	}
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = string(k)
+	}
+
+	values, err := r.HMGet(ctx, r.key(redisEnvironmentsKey), fields...).Result()
+	if err != nil {
+		return environments, err
+	}
+
+	for i, v := range values {
+		marshalledEnvironment, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		env := schemas.Environment{}
+
+		if err = msgpack.Unmarshal([]byte(marshalledEnvironment), &env); err != nil {
+			return environments, err
+		}
+
+		environments[keys[i]] = env
+	}
+
+	return environments, nil
 }
 
 // SetRef ..
@@ -212,13 +368,13 @@ func (r *Redis) SetRef(ctx context.Context, ref schemas.Ref) error {
 		return err
 	}
 
-	_, err = r.HSet(ctx, redisRefsKey, string(ref.Key()), marshalledRef).Result()
+	_, err = r.HSet(ctx, r.key(redisRefsKey), string(ref.Key()), marshalledRef).Result()
 	if err != nil {
 		return err
 	}
 
 	if r.StoreConfig.TTLConfig != nil {
-		_, err = r.Set(ctx, getTTLRefKey(ref.Key()), true, r.StoreConfig.TTLConfig.Ref).Result()
+		_, err = r.Set(ctx, r.key(getTTLRefKey(ref.Key())), true, r.StoreConfig.TTLConfig.Ref).Result()
 	}
 
 	return err
@@ -226,9 +382,11 @@ func (r *Redis) SetRef(ctx context.Context, ref schemas.Ref) error {
 
 // DelRef ..
 func (r *Redis) DelRef(ctx context.Context, k schemas.RefKey) error {
-	_, err := r.HDel(ctx, redisRefsKey, string(k)).Result()
+	if _, err := r.HDel(ctx, r.key(redisRefsKey), string(k)).Result(); err != nil {
+		return err
+	}
 
-	return err
+	return r.DelMetricsPull(ctx, k.MetricsPullKey())
 }
 
 // GetRef ..
@@ -241,7 +399,7 @@ func (r *Redis) GetRef(ctx context.Context, ref *schemas.Ref) error {
 	if exists {
 		k := ref.Key()
 
-		marshalledRef, err := r.HGet(ctx, redisRefsKey, string(k)).Result()
+		marshalledRef, err := r.HGet(ctx, r.key(redisRefsKey), string(k)).Result()
 		if err != nil {
 			return err
 		}
@@ -256,14 +414,14 @@ func (r *Redis) GetRef(ctx context.Context, ref *schemas.Ref) error {
 
 // RefExists ..
 func (r *Redis) RefExists(ctx context.Context, k schemas.RefKey) (bool, error) {
-	return r.HExists(ctx, redisRefsKey, string(k)).Result()
+	return r.HExists(ctx, r.key(redisRefsKey), string(k)).Result()
 }
 
 // Refs ..
 func (r *Redis) Refs(ctx context.Context) (schemas.Refs, error) {
 	refs := schemas.Refs{}
 
-	marshalledProjects, err := r.HGetAll(ctx, redisRefsKey).Result()
+	marshalledProjects, err := r.HGetAll(ctx, r.key(redisRefsKey)).Result()
 	if err != nil {
 		return refs, err
 	}
@@ -281,9 +439,93 @@ func (r *Redis) Refs(ctx context.Context) (schemas.Refs, error) {
 	return refs, nil
 }
 
+// ScanRefs ..
+func (r *Redis) ScanRefs(ctx context.Context, cursor uint64, count int64) (schemas.Refs, uint64, error) {
+	refs := schemas.Refs{}
+
+	values, next, err := r.HScan(ctx, r.key(redisRefsKey), cursor, "", count).Result()
+	if err != nil {
+		return refs, 0, err
+	}
+
+	// Fields and values are interleaved
+	for i := 0; i+1 < len(values); i += 2 {
+		ref := schemas.Ref{}
+
+		if err = msgpack.Unmarshal([]byte(values[i+1]), &ref); err != nil {
+			return refs, 0, err
+		}
+
+		refs[schemas.RefKey(values[i])] = ref
+	}
+
+	return refs, next, nil
+}
+
+// DelRefs ..
+func (r *Redis) DelRefs(ctx context.Context, keys []schemas.RefKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = string(k)
+	}
+
+	_, err := r.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, r.key(redisRefsKey), fields...)
+
+		for _, k := range keys {
+			pipe.ZRem(ctx, r.key(redisMetricsPullDueTimesKey), string(k.MetricsPullKey()))
+			pipe.HDel(ctx, r.key(redisMetricsPullIntervalsKey), string(k.MetricsPullKey()))
+		}
+
+		return nil
+	})
+
+	return err
+}
+
 // RefsCount ..
 func (r *Redis) RefsCount(ctx context.Context) (int64, error) {
-	return r.HLen(ctx, redisRefsKey).Result()
+	return r.HLen(ctx, r.key(redisRefsKey)).Result()
+}
+
+// RefsByKeys returns the stored refs among the given ones.
+func (r *Redis) RefsByKeys(ctx context.Context, keys []schemas.RefKey) (schemas.Refs, error) {
+	refs := schemas.Refs{}
+
+	if len(keys) == 0 {
+		return refs, nil
+	}
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = string(k)
+	}
+
+	values, err := r.HMGet(ctx, r.key(redisRefsKey), fields...).Result()
+	if err != nil {
+		return refs, err
+	}
+
+	for i, v := range values {
+		marshalledRef, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		ref := schemas.Ref{}
+
+		if err = msgpack.Unmarshal([]byte(marshalledRef), &ref); err != nil {
+			return refs, err
+		}
+
+		refs[keys[i]] = ref
+	}
+
+	return refs, nil
 }
 
 // SetMetric ..
@@ -293,13 +535,13 @@ func (r *Redis) SetMetric(ctx context.Context, m schemas.Metric) error {
 		return err
 	}
 
-	_, err = r.HSet(ctx, redisMetricsKey, string(m.Key()), marshalledMetric).Result()
+	_, err = r.HSet(ctx, r.key(redisMetricsKey), string(m.Key()), marshalledMetric).Result()
 	if err != nil {
 		return err
 	}
 
 	if r.StoreConfig.TTLConfig != nil {
-		_, err = r.Set(ctx, getTTLMetricKey(m.Key()), true, r.StoreConfig.TTLConfig.Metric).Result()
+		_, err = r.Set(ctx, r.key(getTTLMetricKey(m.Key())), true, r.StoreConfig.TTLConfig.Metric).Result()
 	}
 
 	return err
@@ -307,14 +549,51 @@ func (r *Redis) SetMetric(ctx context.Context, m schemas.Metric) error {
 
 // DelMetric ..
 func (r *Redis) DelMetric(ctx context.Context, k schemas.MetricKey) error {
-	_, err := r.HDel(ctx, redisMetricsKey, string(k)).Result()
+	_, err := r.HDel(ctx, r.key(redisMetricsKey), string(k)).Result()
 
 	return err
 }
 
+// ScanMetrics ..
+func (r *Redis) ScanMetrics(ctx context.Context, cursor uint64, count int64) (schemas.Metrics, uint64, error) {
+	metrics := schemas.Metrics{}
+
+	values, next, err := r.HScan(ctx, r.key(redisMetricsKey), cursor, "", count).Result()
+	if err != nil {
+		return metrics, 0, err
+	}
+
+	// Fields and values are interleaved
+	for i := 0; i+1 < len(values); i += 2 {
+		m := schemas.Metric{}
+
+		if err = msgpack.Unmarshal([]byte(values[i+1]), &m); err != nil {
+			return metrics, 0, err
+		}
+
+		metrics[schemas.MetricKey(values[i])] = m
+	}
+
+	return metrics, next, nil
+}
+
+// DelMetrics ..
+func (r *Redis) DelMetrics(ctx context.Context, keys []schemas.MetricKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = string(k)
+	}
+
+	return r.HDel(ctx, r.key(redisMetricsKey), fields...).Err()
+}
+
 // MetricExists ..
 func (r *Redis) MetricExists(ctx context.Context, k schemas.MetricKey) (bool, error) {
-	return r.HExists(ctx, redisMetricsKey, string(k)).Result()
+	return r.HExists(ctx, r.key(redisMetricsKey), string(k)).Result()
 }
 
 // GetMetric ..
@@ -327,7 +606,7 @@ func (r *Redis) GetMetric(ctx context.Context, m *schemas.Metric) error {
 	if exists {
 		k := m.Key()
 
-		marshalledMetric, err := r.HGet(ctx, redisMetricsKey, string(k)).Result()
+		marshalledMetric, err := r.HGet(ctx, r.key(redisMetricsKey), string(k)).Result()
 		if err != nil {
 			return err
 		}
@@ -344,7 +623,7 @@ func (r *Redis) GetMetric(ctx context.Context, m *schemas.Metric) error {
 func (r *Redis) Metrics(ctx context.Context) (schemas.Metrics, error) {
 	metrics := schemas.Metrics{}
 
-	marshalledMetrics, err := r.HGetAll(ctx, redisMetricsKey).Result()
+	marshalledMetrics, err := r.HGetAll(ctx, r.key(redisMetricsKey)).Result()
 	if err != nil {
 		return metrics, err
 	}
@@ -364,7 +643,7 @@ func (r *Redis) Metrics(ctx context.Context) (schemas.Metrics, error) {
 
 // MetricsCount ..
 func (r *Redis) MetricsCount(ctx context.Context) (int64, error) {
-	return r.HLen(ctx, redisMetricsKey).Result()
+	return r.HLen(ctx, r.key(redisMetricsKey)).Result()
 }
 
 func (r *Redis) SetPipeline(ctx context.Context, pipeline schemas.Pipeline) error {
@@ -373,7 +652,7 @@ func (r *Redis) SetPipeline(ctx context.Context, pipeline schemas.Pipeline) erro
 		return err
 	}
 
-	_, err = r.HSet(ctx, redisPipelinesKey, fmt.Sprintf("%d", pipeline.Key()), marshalledPipeline).Result()
+	_, err = r.HSet(ctx, r.key(redisPipelinesKey), fmt.Sprintf("%d", pipeline.Key()), marshalledPipeline).Result()
 
 	return err
 }
@@ -387,7 +666,7 @@ func (r *Redis) GetPipeline(ctx context.Context, pipeline *schemas.Pipeline) err
 	if exists {
 		k := pipeline.Key()
 
-		marshalledPipeline, err := r.HGet(ctx, redisPipelinesKey, fmt.Sprintf("%d", k)).Result()
+		marshalledPipeline, err := r.HGet(ctx, r.key(redisPipelinesKey), fmt.Sprintf("%d", k)).Result()
 		if err != nil {
 			return err
 		}
@@ -401,7 +680,34 @@ func (r *Redis) GetPipeline(ctx context.Context, pipeline *schemas.Pipeline) err
 }
 
 func (r *Redis) PipelineExists(ctx context.Context, key schemas.PipelineKey) (bool, error) {
-	return r.HExists(ctx, redisPipelinesKey, fmt.Sprintf("%d", key)).Result()
+	return r.HExists(ctx, r.key(redisPipelinesKey), fmt.Sprintf("%d", key)).Result()
+}
+
+// Pipelines ..
+func (r *Redis) Pipelines(ctx context.Context) (schemas.Pipelines, error) {
+	pipelines := schemas.Pipelines{}
+
+	marshalledPipelines, err := r.HGetAll(ctx, r.key(redisPipelinesKey)).Result()
+	if err != nil {
+		return pipelines, err
+	}
+
+	for stringPipelineKey, marshalledPipeline := range marshalledPipelines {
+		k, err := strconv.Atoi(stringPipelineKey)
+		if err != nil {
+			return pipelines, err
+		}
+
+		p := schemas.Pipeline{}
+
+		if err = msgpack.Unmarshal([]byte(marshalledPipeline), &p); err != nil {
+			return pipelines, err
+		}
+
+		pipelines[schemas.PipelineKey(k)] = p
+	}
+
+	return pipelines, nil
 }
 
 func (r *Redis) SetPipelineVariables(ctx context.Context, pipeline schemas.Pipeline, variables string) error {
@@ -410,7 +716,7 @@ func (r *Redis) SetPipelineVariables(ctx context.Context, pipeline schemas.Pipel
 		return err
 	}
 
-	_, err = r.HSet(ctx, redisPipelineVariablesKey, fmt.Sprintf("%d", pipeline.ID), marshalledVariables).Result()
+	_, err = r.HSet(ctx, r.key(redisPipelineVariablesKey), fmt.Sprintf("%d", pipeline.ID), marshalledVariables).Result()
 
 	return err
 }
@@ -424,44 +730,255 @@ func (r *Redis) GetPipelineVariables(ctx context.Context, pipeline schemas.Pipel
 	if exists {
 		k := fmt.Sprintf("%d", pipeline.ID)
 
-		marshalledVariables, err := r.HGet(ctx, redisPipelineVariablesKey, string(k)).Result()
+		marshalledVariables, err := r.HGet(ctx, r.key(redisPipelineVariablesKey), string(k)).Result()
 		if err != nil {
 			return "", err
 		}
+
 		var variables string
 
-		if err = msgpack.Unmarshal([]byte(marshalledVariables), variables); err != nil {
-			return variables, err
-		}
+		err = msgpack.Unmarshal([]byte(marshalledVariables), &variables)
+
+		return variables, err
 	}
 
 	return "", err
 }
 
 func (r *Redis) PipelineVariablesExists(ctx context.Context, pipeline schemas.Pipeline) (bool, error) {
-	return r.HExists(ctx, redisPipelineVariablesKey, fmt.Sprintf("%d", pipeline.ID)).Result()
+	return r.HExists(ctx, r.key(redisPipelineVariablesKey), fmt.Sprintf("%d", pipeline.ID)).Result()
+}
+
+// PipelinesVariables ..
+func (r *Redis) PipelinesVariables(ctx context.Context) (map[schemas.PipelineKey]string, error) {
+	variables := make(map[schemas.PipelineKey]string)
+
+	marshalledVariables, err := r.HGetAll(ctx, r.key(redisPipelineVariablesKey)).Result()
+	if err != nil {
+		return variables, err
+	}
+
+	for stringPipelineKey, marshalledValue := range marshalledVariables {
+		k, err := strconv.Atoi(stringPipelineKey)
+		if err != nil {
+			return variables, err
+		}
+
+		var v string
+
+		if err = msgpack.Unmarshal([]byte(marshalledValue), &v); err != nil {
+			return variables, err
+		}
+
+		variables[schemas.PipelineKey(k)] = v
+	}
+
+	return variables, nil
+}
+
+// SetProjectLastWebhookEvent ..
+func (r *Redis) SetProjectLastWebhookEvent(ctx context.Context, k schemas.ProjectKey, t time.Time) error {
+	_, err := r.HSet(ctx, r.key(redisWebhookEventsKey), string(k), t.Unix()).Result()
+
+	return err
+}
+
+// GetProjectLastWebhookEvent returns when the last webhook event has been received for
+// the project, zero if none.
+func (r *Redis) GetProjectLastWebhookEvent(ctx context.Context, k schemas.ProjectKey) (time.Time, error) {
+	ts, err := r.HGet(ctx, r.key(redisWebhookEventsKey), string(k)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(ts, 0), nil
+}
+
+// SetFailedWebhookEvent ..
+func (r *Redis) SetFailedWebhookEvent(ctx context.Context, e schemas.WebhookEvent) error {
+	marshalledEvent, err := msgpack.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.HSet(ctx, r.key(redisFailedWebhookEventsKey), e.Key, marshalledEvent).Result()
+
+	return err
+}
+
+// DelFailedWebhookEvent ..
+func (r *Redis) DelFailedWebhookEvent(ctx context.Context, key string) error {
+	_, err := r.HDel(ctx, r.key(redisFailedWebhookEventsKey), key).Result()
+
+	return err
+}
+
+// FailedWebhookEvents ..
+func (r *Redis) FailedWebhookEvents(ctx context.Context) (schemas.WebhookEvents, error) {
+	events := make(schemas.WebhookEvents)
+
+	marshalledEvents, err := r.HGetAll(ctx, r.key(redisFailedWebhookEventsKey)).Result()
+	if err != nil {
+		return events, err
+	}
+
+	for key, marshalledEvent := range marshalledEvents {
+		e := schemas.WebhookEvent{}
+
+		if err = msgpack.Unmarshal([]byte(marshalledEvent), &e); err != nil {
+			return events, err
+		}
+
+		events[key] = e
+	}
+
+	return events, nil
 }
 
-// SetKeepalive sets a key with an UUID corresponding to the currently running process.
+// FailedWebhookEventsCount ..
+func (r *Redis) FailedWebhookEventsCount(ctx context.Context) (int64, error) {
+	return r.HLen(ctx, r.key(redisFailedWebhookEventsKey)).Result()
+}
+
+// IncrWebhookEventsCount ..
+func (r *Redis) IncrWebhookEventsCount(ctx context.Context, eventType string, status schemas.WebhookEventStatus) error {
+	_, err := r.HIncrBy(ctx, r.key(redisWebhookEventsCountKey), fmt.Sprintf("%s:%s", status, eventType), 1).Result()
+
+	return err
+}
+
+// WebhookEventsCount ..
+func (r *Redis) WebhookEventsCount(ctx context.Context) (schemas.WebhookEventsCount, error) {
+	count := make(schemas.WebhookEventsCount)
+
+	values, err := r.HGetAll(ctx, r.key(redisWebhookEventsCountKey)).Result()
+	if err != nil {
+		return count, err
+	}
+
+	for field, value := range values {
+		// Statuses do not contain any colon whereas event types could
+		status, eventType, found := strings.Cut(field, ":")
+		if !found {
+			continue
+		}
+
+		c, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return count, err
+		}
+
+		count[schemas.WebhookEventsCountKey{Type: eventType, Status: schemas.WebhookEventStatus(status)}] = c
+	}
+
+	return count, nil
+}
+
+// SetKeepalive sets or refreshes a key with an UUID corresponding to the currently running process.
 func (r *Redis) SetKeepalive(ctx context.Context, uuid string, ttl time.Duration) (bool, error) {
-	return r.SetNX(ctx, fmt.Sprintf("%s:%s", redisKeepaliveKey, uuid), nil, ttl).Result()
+	if err := r.Set(ctx, r.key(fmt.Sprintf("%s:%s", redisKeepaliveKey, uuid)), nil, ttl).Err(); err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
 // KeepaliveExists returns whether a keepalive exists or not for a particular UUID.
 func (r *Redis) KeepaliveExists(ctx context.Context, uuid string) (bool, error) {
-	exists, err := r.Exists(ctx, fmt.Sprintf("%s:%s", redisKeepaliveKey, uuid)).Result()
+	exists, err := r.Exists(ctx, r.key(fmt.Sprintf("%s:%s", redisKeepaliveKey, uuid))).Result()
 
 	return exists == 1, err
 }
 
-func getRedisQueueKey(tt schemas.TaskType, taskUUID string) string {
+// AliveProcesses returns the UUIDs of the processes which currently have a keepalive.
+func (r *Redis) AliveProcesses(ctx context.Context) (uuids []string, err error) {
+	prefix := r.key(redisKeepaliveKey + ":")
+
+	iter, err := r.scan(ctx, prefix+"*")
+	if err != nil {
+		return
+	}
+
+	for iter.Next(ctx) {
+		uuids = append(uuids, strings.TrimPrefix(iter.Val(), prefix))
+	}
+
+	err = iter.Err()
+
+	return
+}
+
+// AcquireLeaderLease attempts to acquire or renew the leadership lease for the process UUID.
+// It returns true if the process holds the lease for the provided TTL.
+func (r *Redis) AcquireLeaderLease(ctx context.Context, uuid string, ttl time.Duration) (bool, error) {
+	acquired, err := r.SetNX(ctx, r.key(redisLeaderKey), uuid, ttl).Result()
+	if err != nil || acquired {
+		return acquired, err
+	}
+
+	renewed, err := redisRenewLeaderLeaseScript.Run(ctx, r, []string{r.key(redisLeaderKey)}, uuid, ttl.Milliseconds()).Int()
+
+	return renewed == 1, err
+}
+
+// ReleaseLeaderLease releases the leadership lease if it is held by the process UUID.
+func (r *Redis) ReleaseLeaderLease(ctx context.Context, uuid string) error {
+	return redisCompareAndDeleteScript.Run(ctx, r, []string{r.key(redisLeaderKey)}, uuid).Err()
+}
+
+// LeaderLease returns the UUID of the process holding the leadership lease, if any.
+func (r *Redis) LeaderLease(ctx context.Context) (string, error) {
+	uuid, err := r.Get(ctx, r.key(redisLeaderKey)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return uuid, err
+}
+
+// getRedisQueueKey returns the key of the lock of the task onto the queue, the queue only gets
+// mentioned when it is not the default one of the task type as it used to be the only one.
+func getRedisQueueKey(q schemas.TaskQueue, tt schemas.TaskType, taskUUID string) string {
+	if q != tt.Queue() {
+		return fmt.Sprintf("%s:%s:%v:%s", redisTaskKey, q, tt, taskUUID)
+	}
+
 	return fmt.Sprintf("%s:%v:%s", redisTaskKey, tt, taskUUID)
 }
 
+// parseRedisQueueKey returns the lock of the task out of its key, without its prefix.
+func parseRedisQueueKey(key string) (l schemas.TaskLock, ok bool) {
+	// The unique ID of the task may contain colons
+	parts := strings.SplitN(key, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	switch q := schemas.TaskQueue(parts[0]); q {
+	case schemas.TaskQueuePriority, schemas.TaskQueueDiscovery, schemas.TaskQueueMetrics, schemas.TaskQueueGarbageCollect:
+		if parts = strings.SplitN(parts[1], ":", 2); len(parts) != 2 {
+			return
+		}
+
+		l.Queue = q
+	default:
+		l.Queue = schemas.TaskType(parts[0]).Queue()
+	}
+
+	l.Type = schemas.TaskType(parts[0])
+	l.UniqueID = parts[1]
+
+	return l, true
+}
+
 // QueueTask registers that we are queueing the task.
 // It returns true if it managed to schedule it, false if it was already scheduled.
-func (r *Redis) QueueTask(ctx context.Context, tt schemas.TaskType, taskUUID, processUUID string) (set bool, err error) {
-	k := getRedisQueueKey(tt, taskUUID)
+func (r *Redis) QueueTask(ctx context.Context, q schemas.TaskQueue, tt schemas.TaskType, taskUUID, processUUID string) (set bool, err error) {
+	k := r.key(getRedisQueueKey(q, tt, taskUUID))
 
 	// We attempt to set the key, if it already exists, we do not overwrite it
 	set, err = r.SetNX(ctx, k, processUUID, 0).Result()
@@ -498,17 +1015,59 @@ func (r *Redis) QueueTask(ctx context.Context, tt schemas.TaskType, taskUUID, pr
 	return
 }
 
+// TaskLocks returns the tasks currently queued along with the process which queued them.
+func (r *Redis) TaskLocks(ctx context.Context) (locks []schemas.TaskLock, err error) {
+	prefix := r.key(redisTaskKey + ":")
+
+	iter, err := r.scan(ctx, prefix+"*")
+	if err != nil {
+		return
+	}
+
+	for iter.Next(ctx) {
+		l, ok := parseRedisQueueKey(strings.TrimPrefix(iter.Val(), prefix))
+		if !ok {
+			continue
+		}
+
+		processUUID, err := r.Get(ctx, iter.Val()).Result()
+		if err == redis.Nil {
+			// The task got unqueued in the meantime
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		l.ProcessUUID = processUUID
+		locks = append(locks, l)
+	}
+
+	err = iter.Err()
+
+	return
+}
+
+// ReleaseTaskLock removes the task from the tracker, only if it is still claimed by the provided process UUID.
+// It returns true if the lock has been released.
+func (r *Redis) ReleaseTaskLock(ctx context.Context, q schemas.TaskQueue, tt schemas.TaskType, taskUUID, processUUID string) (bool, error) {
+	released, err := redisCompareAndDeleteScript.Run(ctx, r, []string{r.key(getRedisQueueKey(q, tt, taskUUID))}, processUUID).Int()
+
+	return released == 1, err
+}
+
 // UnqueueTask removes the task from the tracker.
-func (r *Redis) UnqueueTask(ctx context.Context, tt schemas.TaskType, taskUUID string) (err error) {
+func (r *Redis) UnqueueTask(ctx context.Context, q schemas.TaskQueue, tt schemas.TaskType, taskUUID string) (err error) {
 	var matched int64
 
-	matched, err = r.Del(ctx, getRedisQueueKey(tt, taskUUID)).Result()
+	matched, err = r.Del(ctx, r.key(getRedisQueueKey(q, tt, taskUUID))).Result()
 	if err != nil {
 		return
 	}
 
 	if matched > 0 {
-		_, err = r.Incr(ctx, redisTasksExecutedCountKey).Result()
+		_, err = r.Incr(ctx, r.key(redisTasksExecutedCountKey)).Result()
 	}
 
 	return
@@ -516,7 +1075,11 @@ func (r *Redis) UnqueueTask(ctx context.Context, tt schemas.TaskType, taskUUID s
 
 // CurrentlyQueuedTasksCount ..
 func (r *Redis) CurrentlyQueuedTasksCount(ctx context.Context) (count uint64, err error) {
-	iter := r.Scan(ctx, 0, fmt.Sprintf("%s:*", redisTaskKey), 0).Iterator()
+	iter, err := r.scan(ctx, r.key(redisTaskKey+":*"))
+	if err != nil {
+		return
+	}
+
 	for iter.Next(ctx) {
 		count++
 	}
@@ -528,7 +1091,7 @@ func (r *Redis) CurrentlyQueuedTasksCount(ctx context.Context) (count uint64, er
 
 // ExecutedTasksCount ..
 func (r *Redis) ExecutedTasksCount(ctx context.Context) (uint64, error) {
-	countString, err := r.Get(ctx, redisTasksExecutedCountKey).Result()
+	countString, err := r.Get(ctx, r.key(redisTasksExecutedCountKey)).Result()
 	if err != nil {
 		return 0, err
 	}
@@ -538,9 +1101,65 @@ func (r *Redis) ExecutedTasksCount(ctx context.Context) (uint64, error) {
 	return uint64(c), err
 }
 
+// SetGarbageCollectionCursor ..
+func (r *Redis) SetGarbageCollectionCursor(ctx context.Context, entity string, cursor schemas.GarbageCollectionCursor) error {
+	return r.HSet(
+		ctx,
+		r.key(redisGCCursorsKey),
+		entity, cursor.Position,
+		gcCursorField(entity, "total"), cursor.Total,
+		gcCursorField(entity, "found"), cursor.Found,
+		gcCursorField(entity, "checked"), cursor.Checked,
+		gcCursorField(entity, "deleted"), cursor.Deleted,
+	).Err()
+}
+
+// GarbageCollectionCursor ..
+func (r *Redis) GarbageCollectionCursor(ctx context.Context, entity string) (cursor schemas.GarbageCollectionCursor, err error) {
+	values, err := r.HMGet(
+		ctx,
+		r.key(redisGCCursorsKey),
+		entity,
+		gcCursorField(entity, "total"),
+		gcCursorField(entity, "found"),
+		gcCursorField(entity, "checked"),
+		gcCursorField(entity, "deleted"),
+	).Result()
+	if err != nil {
+		return
+	}
+
+	// The cursors stored by the previous releases only hold the position
+	for i, dest := range []any{&cursor.Position, &cursor.Total, &cursor.Found, &cursor.Checked, &cursor.Deleted} {
+		v, ok := values[i].(string)
+		if !ok {
+			continue
+		}
+
+		switch d := dest.(type) {
+		case *uint64:
+			*d, err = strconv.ParseUint(v, 10, 64)
+		case *int64:
+			*d, err = strconv.ParseInt(v, 10, 64)
+		case *bool:
+			*d, err = strconv.ParseBool(v)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func gcCursorField(entity, name string) string {
+	return entity + ":" + name
+}
+
 // HasProjectExpired ..
 func (r *Redis) HasProjectExpired(ctx context.Context, key schemas.ProjectKey) bool {
-	reply, err := r.Exists(ctx, getTTLProjectKey(key)).Result()
+	reply, err := r.Exists(ctx, r.key(getTTLProjectKey(key))).Result()
 	if err != nil {
 		return false
 	}
@@ -554,7 +1173,7 @@ func getTTLProjectKey(key schemas.ProjectKey) string {
 
 // HasRefExpired ..
 func (r *Redis) HasRefExpired(ctx context.Context, key schemas.RefKey) bool {
-	reply, err := r.Exists(ctx, getTTLRefKey(key)).Result()
+	reply, err := r.Exists(ctx, r.key(getTTLRefKey(key))).Result()
 	if err != nil {
 		return false
 	}
@@ -568,7 +1187,7 @@ func getTTLRefKey(key schemas.RefKey) string {
 
 // HasMetricExpired ..
 func (r *Redis) HasMetricExpired(ctx context.Context, key schemas.MetricKey) bool {
-	reply, err := r.Exists(ctx, getTTLMetricKey(key)).Result()
+	reply, err := r.Exists(ctx, r.key(getTTLMetricKey(key))).Result()
 	if err != nil {
 		return false
 	}
@@ -579,3 +1198,178 @@ func (r *Redis) HasMetricExpired(ctx context.Context, key schemas.MetricKey) boo
 func getTTLMetricKey(key schemas.MetricKey) string {
 	return fmt.Sprintf("%s:%s", redisMetricsKey, key)
 }
+
+// SetMetricsPull ..
+func (r *Redis) SetMetricsPull(ctx context.Context, k schemas.MetricsPullKey, mp schemas.MetricsPull) error {
+	_, err := r.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, r.key(redisMetricsPullDueTimesKey), redis.Z{
+			Score:  float64(mp.DueTime.Unix()),
+			Member: string(k),
+		})
+		pipe.HSet(ctx, r.key(redisMetricsPullIntervalsKey), string(k), mp.IntervalSeconds)
+
+		return nil
+	})
+
+	return err
+}
+
+// DelMetricsPull ..
+func (r *Redis) DelMetricsPull(ctx context.Context, k schemas.MetricsPullKey) error {
+	_, err := r.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, r.key(redisMetricsPullDueTimesKey), string(k))
+		pipe.HDel(ctx, r.key(redisMetricsPullIntervalsKey), string(k))
+
+		return nil
+	})
+
+	return err
+}
+
+// MetricsPulls ..
+func (r *Redis) MetricsPulls(ctx context.Context) (schemas.MetricsPulls, error) {
+	mps := make(schemas.MetricsPulls)
+
+	members, err := r.ZRangeWithScores(ctx, r.key(redisMetricsPullDueTimesKey), 0, -1).Result()
+	if err != nil {
+		return mps, err
+	}
+
+	intervals, err := r.HGetAll(ctx, r.key(redisMetricsPullIntervalsKey)).Result()
+	if err != nil {
+		return mps, err
+	}
+
+	for _, m := range members {
+		k := m.Member.(string)
+		mp := schemas.MetricsPull{
+			DueTime: time.Unix(int64(m.Score), 0),
+		}
+
+		if v, ok := intervals[k]; ok {
+			if mp.IntervalSeconds, err = strconv.Atoi(v); err != nil {
+				return mps, err
+			}
+		}
+
+		mps[schemas.MetricsPullKey(k)] = mp
+	}
+
+	return mps, nil
+}
+
+// MetricsPullsCount ..
+func (r *Redis) MetricsPullsCount(ctx context.Context) (int64, error) {
+	return r.ZCard(ctx, r.key(redisMetricsPullDueTimesKey)).Result()
+}
+
+// DueMetricsPulls returns the pulls which are due at the given time.
+func (r *Redis) DueMetricsPulls(ctx context.Context, now time.Time) (due schemas.MetricsPulls, err error) {
+	due = make(schemas.MetricsPulls)
+
+	// Due times are stored with a precision of a second
+	members, err := r.ZRangeByScoreWithScores(ctx, r.key(redisMetricsPullDueTimesKey), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.Unix(), 10),
+	}).Result()
+	if err != nil || len(members) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(members))
+	for _, m := range members {
+		keys = append(keys, m.Member.(string))
+	}
+
+	intervals, err := r.HMGet(ctx, r.key(redisMetricsPullIntervalsKey), keys...).Result()
+	if err != nil {
+		return
+	}
+
+	for i, m := range members {
+		mp := schemas.MetricsPull{
+			DueTime: time.Unix(int64(m.Score), 0),
+		}
+
+		if v, ok := intervals[i].(string); ok {
+			if mp.IntervalSeconds, err = strconv.Atoi(v); err != nil {
+				return
+			}
+		}
+
+		due[schemas.MetricsPullKey(keys[i])] = mp
+	}
+
+	return
+}
+
+// MigrateToNamespace moves the data stored outside of any namespace, as done by the previous versions,
+// into the configured one. The keys which already exist within the namespace are left untouched, as
+// well as the task locks, keepalives and leadership lease which are recreated by the running processes.
+// It returns the number of keys which got moved.
+func (r *Redis) MigrateToNamespace(ctx context.Context) (migrated int, err error) {
+	if r.StoreConfig.Namespace == "" {
+		return
+	}
+
+	legacy := &Redis{
+		UniversalClient: r.UniversalClient,
+		StoreConfig:     &RedisStoreConfig{HashTag: r.StoreConfig.HashTag},
+	}
+
+	names := []string{
+		redisProjectsKey,
+		redisEnvironmentsKey,
+		redisRefsKey,
+		redisMetricsKey,
+		redisPipelinesKey,
+		redisPipelineVariablesKey,
+		redisTasksExecutedCountKey,
+		redisWebhookEventsKey,
+		redisFailedWebhookEventsKey,
+		redisWebhookEventsCountKey,
+		redisMetricsPullDueTimesKey,
+		redisMetricsPullIntervalsKey,
+	}
+
+	// TTL keys
+	for _, name := range []string{redisProjectsKey, redisRefsKey, redisMetricsKey} {
+		prefix := legacy.key(name + ":")
+
+		iter, err := legacy.scan(ctx, prefix+"*")
+		if err != nil {
+			return migrated, err
+		}
+
+		for iter.Next(ctx) {
+			// The namespace may itself match the pattern
+			if strings.HasPrefix(iter.Val(), r.key("")) {
+				continue
+			}
+
+			names = append(names, name+":"+strings.TrimPrefix(iter.Val(), prefix))
+		}
+
+		if err = iter.Err(); err != nil {
+			return migrated, err
+		}
+	}
+
+	for _, name := range names {
+		renamed, err := r.RenameNX(ctx, legacy.key(name), r.key(name)).Result()
+		if err != nil {
+			// The key does not exist or got migrated concurrently
+			if strings.Contains(err.Error(), "no such key") {
+				continue
+			}
+
+			return migrated, err
+		}
+
+		if renamed {
+			migrated++
+		}
+	}
+
+	return
+}
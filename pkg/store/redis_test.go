@@ -259,8 +259,194 @@ func TestRedisKeepalive(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRedisAliveProcesses(t *testing.T) {
+	mr, r := newTestRedisStore(t)
+
+	_, _ = r.(*Redis).SetKeepalive(testCtx, "controller1", time.Second)
+	_, _ = r.(*Redis).SetKeepalive(testCtx, "controller2", 10*time.Second)
+
+	uuids, err := r.(*Redis).AliveProcesses(testCtx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"controller1", "controller2"}, uuids)
+
+	// Refreshing the keepalive extends it
+	_, _ = r.(*Redis).SetKeepalive(testCtx, "controller1", 10*time.Second)
+	mr.FastForward(2 * time.Second)
+
+	uuids, err = r.(*Redis).AliveProcesses(testCtx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"controller1", "controller2"}, uuids)
+}
+
+func TestRedisTaskLocks(t *testing.T) {
+	_, r := newTestRedisStore(t)
+
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "_", "controller1")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullRefMetrics.Queue(), schemas.TaskTypePullRefMetrics, "foo:bar", "controller2")
+	_, _ = r.QueueTask(testCtx, schemas.TaskQueuePriority, schemas.TaskTypePullRefMetrics, "foo:bar", "controller2")
+
+	locks, err := r.(*Redis).TaskLocks(testCtx)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []schemas.TaskLock{
+		{Queue: schemas.TaskQueueMetrics, Type: schemas.TaskTypePullMetrics, UniqueID: "_", ProcessUUID: "controller1"},
+		{Queue: schemas.TaskQueueMetrics, Type: schemas.TaskTypePullRefMetrics, UniqueID: "foo:bar", ProcessUUID: "controller2"},
+		{Queue: schemas.TaskQueuePriority, Type: schemas.TaskTypePullRefMetrics, UniqueID: "foo:bar", ProcessUUID: "controller2"},
+	}, locks)
+
+	// The lock is not released if claimed by another process
+	released, err := r.(*Redis).ReleaseTaskLock(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "_", "controller2")
+	assert.NoError(t, err)
+	assert.False(t, released)
+
+	released, err = r.(*Redis).ReleaseTaskLock(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "_", "controller1")
+	assert.NoError(t, err)
+	assert.True(t, released)
+
+	count, err := r.CurrentlyQueuedTasksCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+}
+
+func TestRedisLeaderLease(t *testing.T) {
+	mr, r := newTestRedisStore(t)
+
+	leader, err := r.(*Redis).LeaderLease(testCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, leader)
+
+	ok, err := r.(*Redis).AcquireLeaderLease(testCtx, "controller1", 10*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// The lease being held, it cannot be acquired by another process
+	ok, err = r.(*Redis).AcquireLeaderLease(testCtx, "controller2", 10*time.Second)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// But it can be renewed by its owner
+	mr.FastForward(8 * time.Second)
+	ok, err = r.(*Redis).AcquireLeaderLease(testCtx, "controller1", 10*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	mr.FastForward(8 * time.Second)
+	leader, err = r.(*Redis).LeaderLease(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, "controller1", leader)
+
+	// Releasing a lease held by another process is a no-op
+	assert.NoError(t, r.(*Redis).ReleaseLeaderLease(testCtx, "controller2"))
+	leader, _ = r.(*Redis).LeaderLease(testCtx)
+	assert.Equal(t, "controller1", leader)
+
+	assert.NoError(t, r.(*Redis).ReleaseLeaderLease(testCtx, "controller1"))
+	ok, err = r.(*Redis).AcquireLeaderLease(testCtx, "controller2", 10*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// The lease expires if it is not renewed
+	mr.FastForward(11 * time.Second)
+	ok, err = r.(*Redis).AcquireLeaderLease(testCtx, "controller1", 10*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRedisHashTag(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer mr.Close()
+
+	r := NewRedisStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}), WithHashTag("gcpe"))
+	assert.Equal(t, "{gcpe}:projects", r.key(redisProjectsKey))
+
+	p := schemas.NewProject("foo/bar")
+	assert.NoError(t, r.SetProject(testCtx, p))
+	assert.True(t, mr.Exists("{gcpe}:projects"))
+	assert.False(t, mr.Exists("projects"))
+
+	assert.NoError(t, r.SetMetricsPull(testCtx, "foo", schemas.MetricsPull{IntervalSeconds: 30}))
+	assert.True(t, mr.Exists("{gcpe}:metricsPullDueTimes"))
+	assert.True(t, mr.Exists("{gcpe}:metricsPullIntervals"))
+
+	// Scans only return the keys of the store, without their hash tag
+	_, _ = r.SetKeepalive(testCtx, "controller1", 10*time.Second)
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullRefMetrics.Queue(), schemas.TaskTypePullRefMetrics, "foo:bar", "controller1")
+	assert.NoError(t, mr.Set("task:PullMetrics:_", "controller2"))
+
+	uuids, err := r.AliveProcesses(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"controller1"}, uuids)
+
+	locks, err := r.TaskLocks(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, []schemas.TaskLock{{Queue: schemas.TaskQueueMetrics, Type: schemas.TaskTypePullRefMetrics, UniqueID: "foo:bar", ProcessUUID: "controller1"}}, locks)
+
+	count, err := r.CurrentlyQueuedTasksCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+}
+
+func TestRedisMigrateToNamespace(t *testing.T) {
+	mr, r := newTestRedisStore(t)
+
+	p := schemas.NewProject("foo/bar")
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	_ = r.SetProject(testCtx, p)
+	_ = r.SetRef(testCtx, ref)
+	assert.NoError(t, mr.Set(getTTLRefKey(ref.Key()), "1"))
+
+	ns := NewRedisStore(r.(*Redis).UniversalClient, WithNamespace("foo"))
+	assert.Equal(t, "foo:projects", ns.key(redisProjectsKey))
+
+	// Nothing to migrate without namespace
+	migrated, err := r.(*Redis).MigrateToNamespace(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+
+	migrated, err = ns.MigrateToNamespace(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, migrated)
+	assert.False(t, mr.Exists("projects"))
+	assert.True(t, mr.Exists("foo:projects"))
+	assert.True(t, mr.Exists("foo:"+getTTLRefKey(ref.Key())))
+
+	refs, err := ns.Refs(testCtx)
+	assert.NoError(t, err)
+	assert.Contains(t, refs, ref.Key())
+	assert.True(t, ns.HasRefExpired(testCtx, ref.Key()))
+
+	// The data of the namespace is not overwritten
+	_ = r.SetProject(testCtx, schemas.NewProject("baz"))
+	migrated, err = ns.MigrateToNamespace(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+
+	projects, err := ns.Projects(testCtx)
+	assert.NoError(t, err)
+	assert.Len(t, projects, 1)
+}
+
 func TestGetRedisQueueKey(t *testing.T) {
-	assert.Equal(t, "task:GarbageCollectEnvironments:foo", getRedisQueueKey(schemas.TaskTypeGarbageCollectEnvironments, "foo"))
+	assert.Equal(t, "task:GarbageCollectEnvironments:foo", getRedisQueueKey(schemas.TaskQueueGarbageCollect, schemas.TaskTypeGarbageCollectEnvironments, "foo"))
+	assert.Equal(t, "task:priority:PullRefMetrics:foo:bar", getRedisQueueKey(schemas.TaskQueuePriority, schemas.TaskTypePullRefMetrics, "foo:bar"))
+}
+
+func TestParseRedisQueueKey(t *testing.T) {
+	l, ok := parseRedisQueueKey("GarbageCollectEnvironments:foo")
+	assert.True(t, ok)
+	assert.Equal(t, schemas.TaskLock{Queue: schemas.TaskQueueGarbageCollect, Type: schemas.TaskTypeGarbageCollectEnvironments, UniqueID: "foo"}, l)
+
+	l, ok = parseRedisQueueKey("priority:PullRefMetrics:foo:bar")
+	assert.True(t, ok)
+	assert.Equal(t, schemas.TaskLock{Queue: schemas.TaskQueuePriority, Type: schemas.TaskTypePullRefMetrics, UniqueID: "foo:bar"}, l)
+
+	_, ok = parseRedisQueueKey("priority:PullRefMetrics")
+	assert.False(t, ok)
+
+	_, ok = parseRedisQueueKey("foo")
+	assert.False(t, ok)
 }
 
 func TestRedisQueueTask(t *testing.T) {
@@ -268,19 +454,24 @@ func TestRedisQueueTask(t *testing.T) {
 
 	_, _ = r.(*Redis).SetKeepalive(testCtx, "controller1", time.Second)
 
-	ok, err := r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "controller1")
+	ok, err := r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "controller1")
 	assert.True(t, ok)
 	assert.NoError(t, err)
 
 	// The keepalive of controller1 not being expired, we should not requeue the task
-	ok, err = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "controller2")
+	ok, err = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "controller2")
 	assert.False(t, ok)
 	assert.NoError(t, err)
 
+	// The tasks are deduplicated per queue
+	ok, err = r.QueueTask(testCtx, schemas.TaskQueuePriority, schemas.TaskTypePullMetrics, "foo", "controller2")
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
 	// The keepalive of controller1 being expired, we should requeue the task
 	mr.FastForward(2 * time.Second)
 
-	ok, err = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "controller2")
+	ok, err = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "controller2")
 	assert.True(t, ok)
 	assert.NoError(t, err)
 }
@@ -288,11 +479,11 @@ func TestRedisQueueTask(t *testing.T) {
 func TestRedisUnqueueTask(t *testing.T) {
 	_, r := newTestRedisStore(t)
 
-	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
 	count, _ := r.ExecutedTasksCount(testCtx)
 	assert.Equal(t, uint64(0), count)
 
-	assert.NoError(t, r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo"))
+	assert.NoError(t, r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo"))
 	count, _ = r.ExecutedTasksCount(testCtx)
 	assert.Equal(t, uint64(1), count)
 }
@@ -300,13 +491,13 @@ func TestRedisUnqueueTask(t *testing.T) {
 func TestRedisCurrentlyQueuedTasksCount(t *testing.T) {
 	_, r := newTestRedisStore(t)
 
-	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
-	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "bar", "")
-	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "baz", "")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "bar", "")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "baz", "")
 
 	count, _ := r.CurrentlyQueuedTasksCount(testCtx)
 	assert.Equal(t, uint64(3), count)
-	_ = r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo")
+	_ = r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo")
 	count, _ = r.CurrentlyQueuedTasksCount(testCtx)
 	assert.Equal(t, uint64(2), count)
 }
@@ -314,11 +505,128 @@ func TestRedisCurrentlyQueuedTasksCount(t *testing.T) {
 func TestRedisExecutedTasksCount(t *testing.T) {
 	_, r := newTestRedisStore(t)
 
-	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "foo", "")
-	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics, "bar", "")
-	_ = r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo")
-	_ = r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics, "foo")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo", "")
+	_, _ = r.QueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "bar", "")
+	_ = r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo")
+	_ = r.UnqueueTask(testCtx, schemas.TaskTypePullMetrics.Queue(), schemas.TaskTypePullMetrics, "foo")
 
 	count, _ := r.ExecutedTasksCount(testCtx)
 	assert.Equal(t, uint64(1), count)
 }
+
+func TestRedisLegacyGarbageCollectionCursor(t *testing.T) {
+	mr, r := newTestRedisStore(t)
+
+	// Only the position got stored by the previous releases
+	mr.HSet(redisGCCursorsKey, "metrics", "42")
+
+	cursor, err := r.GarbageCollectionCursor(testCtx, "metrics")
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.GarbageCollectionCursor{Position: 42}, cursor)
+}
+
+func TestRedisProjectLastWebhookEvent(t *testing.T) {
+	_, r := newTestRedisStore(t)
+	p := schemas.NewProject("foo/bar")
+
+	ts, err := r.GetProjectLastWebhookEvent(testCtx, p.Key())
+	assert.NoError(t, err)
+	assert.True(t, ts.IsZero())
+
+	now := time.Unix(time.Now().Unix(), 0)
+	assert.NoError(t, r.SetProjectLastWebhookEvent(testCtx, p.Key(), now))
+
+	ts, err = r.GetProjectLastWebhookEvent(testCtx, p.Key())
+	assert.NoError(t, err)
+	assert.Equal(t, now, ts)
+
+	// Deleting the project should also remove the webhook event tracking
+	assert.NoError(t, r.DelProject(testCtx, p.Key()))
+
+	ts, err = r.GetProjectLastWebhookEvent(testCtx, p.Key())
+	assert.NoError(t, err)
+	assert.True(t, ts.IsZero())
+}
+
+func TestRedisFailedWebhookEvents(t *testing.T) {
+	_, r := newTestRedisStore(t)
+
+	e := schemas.WebhookEvent{
+		Key:     "foo",
+		Type:    "Pipeline Hook",
+		Payload: []byte(`{"object_kind":"pipeline"}`),
+		Error:   "boom",
+	}
+	assert.NoError(t, r.SetFailedWebhookEvent(testCtx, e))
+
+	events, err := r.FailedWebhookEvents(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.WebhookEvents{"foo": e}, events)
+
+	count, err := r.FailedWebhookEventsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, r.DelFailedWebhookEvent(testCtx, "foo"))
+
+	count, err = r.FailedWebhookEventsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRedisWebhookEventsCount(t *testing.T) {
+	_, r := newTestRedisStore(t)
+
+	assert.NoError(t, r.IncrWebhookEventsCount(testCtx, "Pipeline Hook", schemas.WebhookEventStatusReceived))
+	assert.NoError(t, r.IncrWebhookEventsCount(testCtx, "Pipeline Hook", schemas.WebhookEventStatusReceived))
+	assert.NoError(t, r.IncrWebhookEventsCount(testCtx, "Job Hook", schemas.WebhookEventStatusDropped))
+
+	count, err := r.WebhookEventsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.WebhookEventsCount{
+		{Type: "Pipeline Hook", Status: schemas.WebhookEventStatusReceived}: 2,
+		{Type: "Job Hook", Status: schemas.WebhookEventStatusDropped}:       1,
+	}, count)
+}
+
+func TestRedisMetricsPulls(t *testing.T) {
+	_, r := newTestRedisStore(t)
+
+	ref := schemas.NewRef(schemas.NewProject("foo/bar"), schemas.RefKindBranch, "main")
+	env := schemas.Environment{ProjectName: "foo/bar", Name: "prod"}
+
+	now := time.Unix(time.Now().Unix(), 0)
+	refPull := schemas.MetricsPull{DueTime: now, IntervalSeconds: 30}
+	envPull := schemas.MetricsPull{DueTime: now.Add(time.Minute), IntervalSeconds: 60}
+
+	assert.NoError(t, r.SetMetricsPull(testCtx, ref.Key().MetricsPullKey(), refPull))
+	assert.NoError(t, r.SetMetricsPull(testCtx, env.Key().MetricsPullKey(), envPull))
+
+	mps, err := r.MetricsPulls(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.MetricsPulls{
+		ref.Key().MetricsPullKey(): refPull,
+		env.Key().MetricsPullKey(): envPull,
+	}, mps)
+
+	count, err := r.MetricsPullsCount(testCtx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// Only the pull of the ref is due, the one of the environment is due later on
+	due, err := r.DueMetricsPulls(testCtx, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, schemas.MetricsPulls{ref.Key().MetricsPullKey(): refPull}, due)
+
+	due, err = r.DueMetricsPulls(testCtx, now.Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Deleting the ref or the environment should also remove them from the queue
+	assert.NoError(t, r.DelRef(testCtx, ref.Key()))
+	assert.NoError(t, r.DelEnvironment(testCtx, env.Key()))
+
+	mps, err = r.MetricsPulls(testCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, mps)
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+// SnapshotFormat ..
+type SnapshotFormat string
+
+const (
+	// SnapshotFormatJSON ..
+	SnapshotFormatJSON SnapshotFormat = "json"
+
+	// SnapshotFormatMsgpack ..
+	SnapshotFormatMsgpack SnapshotFormat = "msgpack"
+)
+
+// Snapshot holds the state of a store, in order to back it up, inspect it
+// or restore it onto another store.
+type Snapshot struct {
+	Projects          schemas.Projects               `json:"projects"           msgpack:"projects"`
+	Environments      schemas.Environments           `json:"environments"       msgpack:"environments"`
+	Refs              schemas.Refs                   `json:"refs"               msgpack:"refs"`
+	Metrics           schemas.Metrics                `json:"metrics"            msgpack:"metrics"`
+	Pipelines         schemas.Pipelines              `json:"pipelines"          msgpack:"pipelines"`
+	PipelineVariables map[schemas.PipelineKey]string `json:"pipeline_variables" msgpack:"pipeline_variables"`
+}
+
+// Export returns a snapshot of the content of the store.
+func Export(ctx context.Context, s Store) (snap Snapshot, err error) {
+	if snap.Projects, err = s.Projects(ctx); err != nil {
+		return snap, fmt.Errorf("exporting projects: %w", err)
+	}
+
+	if snap.Environments, err = s.Environments(ctx); err != nil {
+		return snap, fmt.Errorf("exporting environments: %w", err)
+	}
+
+	if snap.Refs, err = s.Refs(ctx); err != nil {
+		return snap, fmt.Errorf("exporting refs: %w", err)
+	}
+
+	if snap.Metrics, err = s.Metrics(ctx); err != nil {
+		return snap, fmt.Errorf("exporting metrics: %w", err)
+	}
+
+	if snap.Pipelines, err = s.Pipelines(ctx); err != nil {
+		return snap, fmt.Errorf("exporting pipelines: %w", err)
+	}
+
+	if snap.PipelineVariables, err = s.PipelinesVariables(ctx); err != nil {
+		return snap, fmt.Errorf("exporting pipeline variables: %w", err)
+	}
+
+	return
+}
+
+// Import restores the content of the snapshot onto the store. Entries already present
+// in the store get overwritten by the ones of the snapshot, the other ones are left untouched.
+func Import(ctx context.Context, s Store, snap Snapshot) error {
+	for _, p := range snap.Projects {
+		if err := s.SetProject(ctx, p); err != nil {
+			return fmt.Errorf("importing project '%s': %w", p.Name, err)
+		}
+	}
+
+	for _, e := range snap.Environments {
+		if err := s.SetEnvironment(ctx, e); err != nil {
+			return fmt.Errorf("importing environment '%s': %w", e.Name, err)
+		}
+	}
+
+	for _, r := range snap.Refs {
+		if err := s.SetRef(ctx, r); err != nil {
+			return fmt.Errorf("importing ref '%s': %w", r.Name, err)
+		}
+	}
+
+	for _, m := range snap.Metrics {
+		if err := s.SetMetric(ctx, m); err != nil {
+			return fmt.Errorf("importing metric '%s': %w", m.Key(), err)
+		}
+	}
+
+	for _, p := range snap.Pipelines {
+		if err := s.SetPipeline(ctx, p); err != nil {
+			return fmt.Errorf("importing pipeline '%d': %w", p.ID, err)
+		}
+	}
+
+	for k, v := range snap.PipelineVariables {
+		if err := s.SetPipelineVariables(ctx, schemas.Pipeline{ID: int64(k)}, v); err != nil {
+			return fmt.Errorf("importing variables of pipeline '%d': %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+// Encode writes the snapshot onto w using the provided format.
+func (snap Snapshot) Encode(w io.Writer, format SnapshotFormat) error {
+	switch format {
+	case SnapshotFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(snap)
+	case SnapshotFormatMsgpack:
+		return msgpack.NewEncoder(w).Encode(snap)
+	default:
+		return fmt.Errorf("invalid snapshot format '%s', must be either '%s' or '%s'", format, SnapshotFormatJSON, SnapshotFormatMsgpack)
+	}
+}
+
+// DecodeSnapshot reads a snapshot encoded using the provided format out of r.
+func DecodeSnapshot(r io.Reader, format SnapshotFormat) (snap Snapshot, err error) {
+	switch format {
+	case SnapshotFormatJSON:
+		err = json.NewDecoder(r).Decode(&snap)
+	case SnapshotFormatMsgpack:
+		err = msgpack.NewDecoder(r).Decode(&snap)
+	default:
+		err = fmt.Errorf("invalid snapshot format '%s', must be either '%s' or '%s'", format, SnapshotFormatJSON, SnapshotFormatMsgpack)
+	}
+
+	return
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mvisonneau/gitlab-ci-pipelines-exporter/pkg/schemas"
+)
+
+func newTestSnapshot() Snapshot {
+	p := schemas.NewProject("foo/bar")
+	env := schemas.Environment{ProjectName: "foo/bar", ID: 1, Name: "prod"}
+	ref := schemas.NewRef(p, schemas.RefKindBranch, "main")
+	ref.LatestPipeline = schemas.Pipeline{ID: 10, Status: "success", Variables: "foo:bar"}
+	m := schemas.Metric{
+		Kind:   schemas.MetricKindRunCount,
+		Labels: ref.DefaultLabelsValues(),
+		Value:  5,
+	}
+
+	return Snapshot{
+		Projects:          schemas.Projects{p.Key(): p},
+		Environments:      schemas.Environments{env.Key(): env},
+		Refs:              schemas.Refs{ref.Key(): ref},
+		Metrics:           schemas.Metrics{m.Key(): m},
+		Pipelines:         schemas.Pipelines{ref.LatestPipeline.Key(): ref.LatestPipeline},
+		PipelineVariables: map[schemas.PipelineKey]string{ref.LatestPipeline.Key(): "foo:bar"},
+	}
+}
+
+func TestSnapshotExportImport(t *testing.T) {
+	snap := newTestSnapshot()
+
+	// From the local store onto Redis and back
+	l := NewLocalStore()
+	assert.NoError(t, Import(testCtx, l, snap))
+
+	exported, err := Export(testCtx, l)
+	assert.NoError(t, err)
+	assert.Equal(t, snap, exported)
+
+	_, r := newTestRedisStore(t)
+	assert.NoError(t, Import(testCtx, r, exported))
+
+	exported, err = Export(testCtx, r)
+	assert.NoError(t, err)
+	assert.Equal(t, snap, exported)
+
+	variables, err := r.GetPipelineVariables(testCtx, schemas.Pipeline{ID: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo:bar", variables)
+}
+
+func TestSnapshotEncodeDecode(t *testing.T) {
+	snap := newTestSnapshot()
+
+	for _, format := range []SnapshotFormat{SnapshotFormatJSON, SnapshotFormatMsgpack} {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, snap.Encode(buf, format))
+
+		decoded, err := DecodeSnapshot(buf, format)
+		assert.NoError(t, err)
+		assert.Equal(t, snap, decoded)
+	}
+
+	assert.Error(t, snap.Encode(&bytes.Buffer{}, "yaml"))
+
+	_, err := DecodeSnapshot(&bytes.Buffer{}, "yaml")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	log "github.com/sirupsen/logrus"
@@ -19,40 +20,70 @@ type Store interface {
 	ProjectExists(ctx context.Context, pk schemas.ProjectKey) (bool, error)
 	Projects(ctx context.Context) (schemas.Projects, error)
 	ProjectsCount(ctx context.Context) (int64, error)
+	ProjectsByKeys(ctx context.Context, pks []schemas.ProjectKey) (schemas.Projects, error)
 	SetEnvironment(ctx context.Context, e schemas.Environment) error
 	DelEnvironment(ctx context.Context, ek schemas.EnvironmentKey) error
 	GetEnvironment(ctx context.Context, e *schemas.Environment) error
 	EnvironmentExists(ctx context.Context, ek schemas.EnvironmentKey) (bool, error)
 	Environments(ctx context.Context) (schemas.Environments, error)
 	EnvironmentsCount(ctx context.Context) (int64, error)
+	EnvironmentsByKeys(ctx context.Context, eks []schemas.EnvironmentKey) (schemas.Environments, error)
 	SetRef(ctx context.Context, r schemas.Ref) error
 	DelRef(ctx context.Context, rk schemas.RefKey) error
 	GetRef(ctx context.Context, r *schemas.Ref) error
 	RefExists(ctx context.Context, rk schemas.RefKey) (bool, error)
 	Refs(ctx context.Context) (schemas.Refs, error)
 	RefsCount(ctx context.Context) (int64, error)
+	RefsByKeys(ctx context.Context, rks []schemas.RefKey) (schemas.Refs, error)
+	ScanRefs(ctx context.Context, cursor uint64, count int64) (schemas.Refs, uint64, error)
+	DelRefs(ctx context.Context, rks []schemas.RefKey) error
 	SetMetric(ctx context.Context, m schemas.Metric) error
 	DelMetric(ctx context.Context, mk schemas.MetricKey) error
 	GetMetric(ctx context.Context, m *schemas.Metric) error
 	MetricExists(ctx context.Context, mk schemas.MetricKey) (bool, error)
 	Metrics(ctx context.Context) (schemas.Metrics, error)
 	MetricsCount(ctx context.Context) (int64, error)
+	ScanMetrics(ctx context.Context, cursor uint64, count int64) (schemas.Metrics, uint64, error)
+	DelMetrics(ctx context.Context, mks []schemas.MetricKey) error
 
 	SetPipeline(ctx context.Context, pipeline schemas.Pipeline) error
 	GetPipeline(ctx context.Context, pipeline *schemas.Pipeline) error
 	PipelineExists(ctx context.Context, key schemas.PipelineKey) (bool, error)
+	Pipelines(ctx context.Context) (schemas.Pipelines, error)
 	SetPipelineVariables(ctx context.Context, pipeline schemas.Pipeline, variables string) error
 	GetPipelineVariables(ctx context.Context, pipeline schemas.Pipeline) (string, error)
 	PipelineVariablesExists(ctx context.Context, pipeline schemas.Pipeline) (bool, error)
-
-	// Helpers to keep track of currently queued tasks and avoid scheduling them
-	// twice at the risk of ending up with loads of dangling goroutines being locked
-	QueueTask(ctx context.Context, tt schemas.TaskType, taskUUID string, processUUID string) (bool, error)
-	UnqueueTask(ctx context.Context, tt schemas.TaskType, processUUID string) error
+	PipelinesVariables(ctx context.Context) (map[schemas.PipelineKey]string, error)
+
+	// Keep track of the webhook events received for each project
+	SetProjectLastWebhookEvent(ctx context.Context, pk schemas.ProjectKey, t time.Time) error
+	GetProjectLastWebhookEvent(ctx context.Context, pk schemas.ProjectKey) (time.Time, error)
+
+	// Keep track of the webhook events which could not be processed, for them to be replayed
+	SetFailedWebhookEvent(ctx context.Context, e schemas.WebhookEvent) error
+	DelFailedWebhookEvent(ctx context.Context, key string) error
+	FailedWebhookEvents(ctx context.Context) (schemas.WebhookEvents, error)
+	FailedWebhookEventsCount(ctx context.Context) (int64, error)
+	IncrWebhookEventsCount(ctx context.Context, eventType string, status schemas.WebhookEventStatus) error
+	WebhookEventsCount(ctx context.Context) (schemas.WebhookEventsCount, error)
+
+	// Queue of the metrics pulls of the refs and environments, ordered by due time
+	SetMetricsPull(ctx context.Context, k schemas.MetricsPullKey, mp schemas.MetricsPull) error
+	DelMetricsPull(ctx context.Context, k schemas.MetricsPullKey) error
+	MetricsPulls(ctx context.Context) (schemas.MetricsPulls, error)
+	MetricsPullsCount(ctx context.Context) (int64, error)
+	DueMetricsPulls(ctx context.Context, now time.Time) (schemas.MetricsPulls, error)
+
+	// Helpers to keep track of currently queued tasks and avoid scheduling them twice onto
+	// the same queue at the risk of ending up with loads of dangling goroutines being locked
+	QueueTask(ctx context.Context, q schemas.TaskQueue, tt schemas.TaskType, taskUUID string, processUUID string) (bool, error)
+	UnqueueTask(ctx context.Context, q schemas.TaskQueue, tt schemas.TaskType, taskUUID string) error
 	CurrentlyQueuedTasksCount(ctx context.Context) (uint64, error)
 	ExecutedTasksCount(ctx context.Context) (uint64, error)
 
 	// Garbage collections
+	SetGarbageCollectionCursor(ctx context.Context, entity string, cursor schemas.GarbageCollectionCursor) error
+	GarbageCollectionCursor(ctx context.Context, entity string) (schemas.GarbageCollectionCursor, error)
 	HasProjectExpired(ctx context.Context, projectKey schemas.ProjectKey) bool
 	HasRefExpired(ctx context.Context, refKey schemas.RefKey) bool
 	HasMetricExpired(ctx context.Context, metricKey schemas.MetricKey) bool
@@ -61,20 +92,25 @@ type Store interface {
 // NewLocalStore ..
 func NewLocalStore() Store {
 	return &Local{
-		projects:          make(schemas.Projects),
-		environments:      make(schemas.Environments),
-		refs:              make(schemas.Refs),
-		metrics:           make(schemas.Metrics),
-		pipelines:         make(schemas.Pipelines),
-		pipelineVariables: make(map[schemas.PipelineKey]string),
+		projects:            make(schemas.Projects),
+		environments:        make(schemas.Environments),
+		refs:                make(schemas.Refs),
+		metrics:             make(schemas.Metrics),
+		pipelines:           make(schemas.Pipelines),
+		pipelineVariables:   make(map[schemas.PipelineKey]string),
+		webhookEvents:       make(map[schemas.ProjectKey]time.Time),
+		failedWebhookEvents: make(schemas.WebhookEvents),
+		webhookEventsCount:  make(schemas.WebhookEventsCount),
+		metricsPulls:        make(schemas.MetricsPulls),
+		gcCursors:           make(map[string]schemas.GarbageCollectionCursor),
 	}
 }
 
 // NewRedisStore ..
-func NewRedisStore(client *redis.Client, opts ...RedisStoreOptions) *Redis {
+func NewRedisStore(client redis.UniversalClient, opts ...RedisStoreOptions) *Redis {
 	r := &Redis{
-		Client:      client,
-		StoreConfig: &RedisStoreConfig{},
+		UniversalClient: client,
+		StoreConfig:     &RedisStoreConfig{},
 	}
 
 	for _, opt := range opts {
@@ -2,7 +2,9 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -15,12 +17,17 @@ var testCtx = context.Background()
 
 func TestNewLocalStore(t *testing.T) {
 	expectedValue := &Local{
-		projects:          make(schemas.Projects),
-		environments:      make(schemas.Environments),
-		refs:              make(schemas.Refs),
-		metrics:           make(schemas.Metrics),
-		pipelines:         make(schemas.Pipelines),
-		pipelineVariables: make(map[schemas.PipelineKey]string),
+		projects:            make(schemas.Projects),
+		environments:        make(schemas.Environments),
+		refs:                make(schemas.Refs),
+		metrics:             make(schemas.Metrics),
+		pipelines:           make(schemas.Pipelines),
+		pipelineVariables:   make(map[schemas.PipelineKey]string),
+		webhookEvents:       make(map[schemas.ProjectKey]time.Time),
+		failedWebhookEvents: make(schemas.WebhookEvents),
+		webhookEventsCount:  make(schemas.WebhookEventsCount),
+		metricsPulls:        make(schemas.MetricsPulls),
+		gcCursors:           make(map[string]schemas.GarbageCollectionCursor),
 	}
 	assert.Equal(t, expectedValue, NewLocalStore())
 }
@@ -28,8 +35,8 @@ func TestNewLocalStore(t *testing.T) {
 func TestNewRedisStore(t *testing.T) {
 	redisClient := redis.NewClient(&redis.Options{})
 	expectedValue := &Redis{
-		Client:      redisClient,
-		StoreConfig: &RedisStoreConfig{},
+		UniversalClient: redisClient,
+		StoreConfig:     &RedisStoreConfig{},
 	}
 
 	assert.Equal(t, expectedValue, NewRedisStore(redisClient))
@@ -58,3 +65,118 @@ func TestNew(t *testing.T) {
 	count, _ := localStore.ProjectsCount(testCtx)
 	assert.Equal(t, int64(2), count)
 }
+
+func TestScanAndBulkDeletes(t *testing.T) {
+	_, r := newTestRedisStore(t)
+
+	for name, s := range map[string]Store{
+		"local": NewLocalStore(),
+		"redis": r,
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := schemas.NewProject("foo")
+
+			for i := range 50 {
+				ref := schemas.NewRef(p, schemas.RefKindBranch, fmt.Sprintf("ref-%d", i))
+				assert.NoError(t, s.SetRef(testCtx, ref))
+				assert.NoError(t, s.SetMetric(testCtx, schemas.Metric{
+					Kind:   schemas.MetricKindRunCount,
+					Labels: ref.DefaultLabelsValues(),
+				}))
+			}
+
+			// Deleting the entries along the way does not prevent the other ones from being returned
+			var (
+				cursor  uint64
+				scanned = make(schemas.Metrics)
+			)
+
+			for {
+				metrics, next, err := s.ScanMetrics(testCtx, cursor, 10)
+				assert.NoError(t, err)
+
+				keys := []schemas.MetricKey{}
+				for k, m := range metrics {
+					scanned[k] = m
+					keys = append(keys, k)
+				}
+
+				assert.NoError(t, s.DelMetrics(testCtx, keys))
+
+				if cursor = next; cursor == 0 {
+					break
+				}
+			}
+
+			assert.Len(t, scanned, 50)
+
+			count, err := s.MetricsCount(testCtx)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(0), count)
+
+			refs, _, err := s.ScanRefs(testCtx, 0, 100)
+			assert.NoError(t, err)
+			assert.Len(t, refs, 50)
+
+			keys := []schemas.RefKey{}
+			for k := range refs {
+				keys = append(keys, k)
+			}
+
+			assert.NoError(t, s.DelRefs(testCtx, keys))
+
+			count, err = s.RefsCount(testCtx)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(0), count)
+
+			// Garbage collection cursors
+			gcCursor, err := s.GarbageCollectionCursor(testCtx, "metrics")
+			assert.NoError(t, err)
+			assert.Equal(t, schemas.GarbageCollectionCursor{}, gcCursor)
+
+			expectedCursor := schemas.GarbageCollectionCursor{Position: 42, Total: 10, Found: 4, Checked: true, Deleted: 3}
+			assert.NoError(t, s.SetGarbageCollectionCursor(testCtx, "metrics", expectedCursor))
+			gcCursor, err = s.GarbageCollectionCursor(testCtx, "metrics")
+			assert.NoError(t, err)
+			assert.Equal(t, expectedCursor, gcCursor)
+		})
+	}
+}
+
+func TestByKeys(t *testing.T) {
+	_, r := newTestRedisStore(t)
+
+	for name, s := range map[string]Store{
+		"local": NewLocalStore(),
+		"redis": r,
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := schemas.NewProject("foo")
+			assert.NoError(t, s.SetProject(testCtx, p))
+
+			projects, err := s.ProjectsByKeys(testCtx, []schemas.ProjectKey{p.Key(), schemas.NewProject("bar").Key()})
+			assert.NoError(t, err)
+			assert.Equal(t, schemas.Projects{p.Key(): p}, projects)
+
+			ref := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "main")
+			missingRef := schemas.NewRef(schemas.NewProject("foo"), schemas.RefKindBranch, "dev")
+			env := schemas.Environment{ProjectName: "foo", Name: "prod"}
+			missingEnv := schemas.Environment{ProjectName: "foo", Name: "dev"}
+
+			assert.NoError(t, s.SetRef(testCtx, ref))
+			assert.NoError(t, s.SetEnvironment(testCtx, env))
+
+			refs, err := s.RefsByKeys(testCtx, []schemas.RefKey{ref.Key(), missingRef.Key()})
+			assert.NoError(t, err)
+			assert.Equal(t, schemas.Refs{ref.Key(): ref}, refs)
+
+			envs, err := s.EnvironmentsByKeys(testCtx, []schemas.EnvironmentKey{env.Key(), missingEnv.Key()})
+			assert.NoError(t, err)
+			assert.Equal(t, schemas.Environments{env.Key(): env}, envs)
+
+			refs, err = s.RefsByKeys(testCtx, nil)
+			assert.NoError(t, err)
+			assert.Empty(t, refs)
+		})
+	}
+}